@@ -3,14 +3,20 @@ package main
 import (
 	"context"
 	"log"
+	"net/http"
 
 	"github.com/sayeed1999/share-a-ride/internal/app/http/handlers"
 	"github.com/sayeed1999/share-a-ride/internal/app/http/middleware"
 	"github.com/sayeed1999/share-a-ride/internal/app/http/router"
+	"github.com/sayeed1999/share-a-ride/internal/app/jobs"
 	"github.com/sayeed1999/share-a-ride/internal/app/services"
 	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
 	"github.com/sayeed1999/share-a-ride/internal/provider/database"
+	"github.com/sayeed1999/share-a-ride/internal/provider/email"
+	"github.com/sayeed1999/share-a-ride/internal/provider/payment"
 	"github.com/sayeed1999/share-a-ride/internal/provider/repository"
+	"github.com/sayeed1999/share-a-ride/internal/provider/storage"
 	"github.com/sayeed1999/share-a-ride/internal/provider/token"
 )
 
@@ -38,23 +44,144 @@ func main() {
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db.DB())
 	driverRepo := repository.NewDriverRepository(db.DB())
+	rideRepo := repository.NewRideRepository(db.DB())
+	rideOfferRepo := repository.NewRideOfferRepository(db.DB())
+	locationHistoryRepo := repository.NewLocationHistoryRepository(db.DB())
+	promoCodeRepo := repository.NewPromoCodeRepository(db.DB())
+	paymentRepo := repository.NewPaymentRepository(db.DB())
+	ledgerRepo := repository.NewLedgerRepository(db.DB())
+	earningsRepo := repository.NewEarningsRepository(db.DB())
+	ratingRepo := repository.NewRatingRepository(db.DB())
+	poolRepo := repository.NewPoolRepository(db.DB())
 
 	// Initialize token provider
 	tokenProvider := token.NewJWTProvider(cfg.JWT)
 
+	// Initialize payment gateway
+	if cfg.Payment.Gateway != "fake" {
+		log.Fatalf("Unsupported payment gateway: %s", cfg.Payment.Gateway)
+	}
+	paymentGateway := payment.NewFake()
+
+	// Initialize email service
+	emailService := email.NewEmailService(cfg)
+
+	// Initialize clock
+	clk := clock.New()
+
+	// Initialize document storage
+	var objectStore storage.ObjectStore
+	var fileServer http.Handler
+	switch cfg.Storage.Backend {
+	case "local":
+		local, err := storage.NewLocal(cfg.Storage.LocalDir, cfg.App.BaseURL+"/files", []byte(cfg.Storage.SigningKey), clk)
+		if err != nil {
+			log.Fatalf("Failed to initialize local storage: %v", err)
+		}
+		objectStore, fileServer = local, local.Handler()
+	case "s3":
+		objectStore, err = storage.NewS3(storage.S3Config{
+			Endpoint:        cfg.Storage.S3Endpoint,
+			Region:          cfg.Storage.S3Region,
+			Bucket:          cfg.Storage.S3Bucket,
+			AccessKeyID:     cfg.Storage.S3AccessKeyID,
+			SecretAccessKey: cfg.Storage.S3SecretAccessKey,
+			PathStyle:       cfg.Storage.S3PathStyle,
+		}, http.DefaultClient, clk)
+		if err != nil {
+			log.Fatalf("Failed to initialize S3 storage: %v", err)
+		}
+	default:
+		log.Fatalf("Unsupported storage backend: %s", cfg.Storage.Backend)
+	}
+
+	// Load online drivers into the in-memory location index
+	driverIndex := services.NewDriverIndex()
+	if err := services.WarmDriverIndex(context.Background(), driverRepo, driverIndex); err != nil {
+		log.Fatalf("Failed to load driver location index: %v", err)
+	}
+
 	// Initialize services
 	authService := services.NewAuthService(userRepo, tokenProvider)
-	driverService := services.NewDriverService(driverRepo, userRepo)
+	documentFileService := services.NewDocumentFileService(objectStore, driverRepo, clk, cfg.Document)
+	driverService := services.NewDriverService(driverRepo, userRepo, documentFileService, driverIndex, clk, cfg.Location)
+	surgeService := services.NewSurgeService(rideRepo, driverRepo, clk, cfg.Surge)
+	if err := surgeService.Recompute(context.Background()); err != nil {
+		log.Fatalf("Failed to compute surge multipliers: %v", err)
+	}
+	pricingService := services.NewPricingService(surgeService, cfg.Pricing)
+	promoService := services.NewPromoService(promoCodeRepo, pricingService, clk)
+	paymentService := services.NewPaymentService(paymentRepo, paymentGateway, cfg.Pricing.Currency, cfg.Payment)
+	walletService := services.NewWalletService(ledgerRepo, paymentGateway, cfg.Pricing.Currency)
+	earningsService := services.NewEarningsService(earningsRepo, clk, cfg.Earnings)
+	receiptService := services.NewReceiptService(rideRepo, driverRepo, userRepo, emailService, cfg.Pricing.Currency)
+	poolService := services.NewPoolService(poolRepo, rideRepo, clk, cfg.Pool)
+	verificationService := services.NewVerificationService(driverRepo, userRepo, documentFileService, emailService, driverIndex, clk, cfg.Document)
+	rideService := services.NewRideService(rideRepo, driverRepo, userRepo, locationHistoryRepo,
+		surgeService, pricingService, promoService, paymentService, walletService, earningsService, receiptService,
+		poolService, clk, cfg.Scheduling, cfg.Pool, cfg.Waypoint)
+	ratingService := services.NewRatingService(ratingRepo, rideRepo, driverRepo, cfg.Rating)
+	matchingService := services.NewMatchingService(rideRepo, driverService, rideOfferRepo, ratingRepo, rideService, poolService, clk, cfg.Matching)
+	if _, err := matchingService.ResumeDispatch(context.Background()); err != nil {
+		log.Fatalf("Failed to resume dispatch of open ride requests: %v", err)
+	}
+	trackingService := services.NewTrackingService(driverService, rideRepo, locationHistoryRepo, services.NewPositionHub(),
+		clk, cfg.Location, cfg.Realtime.SubscriberBuffer)
+	schedulingService := services.NewSchedulingService(rideRepo, userRepo, matchingService, emailService, clk, cfg.Scheduling)
+
+	// Start background jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go jobs.Every(jobsCtx, clk, cfg.Location.StaleCheckInterval, "expire-stale-locations", func(ctx context.Context) error {
+		_, err := driverService.ExpireStaleLocations(ctx)
+		return err
+	})
+	go jobs.Every(jobsCtx, clk, cfg.Surge.RecomputeInterval, "recompute-surge", surgeService.Recompute)
+	go jobs.Every(jobsCtx, clk, cfg.Location.HistoryPruneInterval, "prune-location-history", func(ctx context.Context) error {
+		_, err := trackingService.PruneLocationHistory(ctx)
+		return err
+	})
+	go jobs.Every(jobsCtx, clk, cfg.Scheduling.PollInterval, "dispatch-scheduled-rides", func(ctx context.Context) error {
+		_, err := schedulingService.ReleaseDueRides(ctx)
+		return err
+	})
+	go jobs.Every(jobsCtx, clk, cfg.Scheduling.PollInterval, "remind-scheduled-rides", func(ctx context.Context) error {
+		_, err := schedulingService.SendReminders(ctx)
+		return err
+	})
+	go jobs.Every(jobsCtx, clk, cfg.Payment.ChargeRetryInterval, "retry-ride-charges", func(ctx context.Context) error {
+		_, err := rideService.RetryCharges(ctx, clk.Now().Add(-cfg.Payment.ChargeRetryInterval))
+		return err
+	})
+	go jobs.Every(jobsCtx, clk, cfg.Document.UploadSweepInterval, "sweep-document-uploads", func(ctx context.Context) error {
+		_, err := documentFileService.SweepUploads(ctx)
+		return err
+	})
+	go jobs.Every(jobsCtx, clk, cfg.Document.ExpiryCheckInterval, "check-document-expiry", func(ctx context.Context) error {
+		if _, err := verificationService.RemindExpiringDocuments(ctx); err != nil {
+			return err
+		}
+		_, err := verificationService.SuspendLapsedDrivers(ctx)
+		return err
+	})
 
 	// Initialize middleware
 	authMiddleware := middleware.NewAuthMiddleware(authService)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
-	driverHandler := handlers.NewDriverHandler(driverService)
+	driverHandler := handlers.NewDriverHandler(driverService, rideService, matchingService, trackingService, poolService,
+		documentFileService, cfg.Document)
+	rideHandler := handlers.NewRideHandler(rideService, matchingService, trackingService, pricingService, promoService)
+	streamHandler := handlers.NewLocationStreamHandler(driverService, trackingService, cfg.Realtime)
+	adminHandler := handlers.NewAdminHandler(surgeService, promoService, paymentService, verificationService)
+	walletHandler := handlers.NewWalletHandler(walletService)
+	earningsHandler := handlers.NewEarningsHandler(driverService, earningsService)
+	ratingHandler := handlers.NewRatingHandler(driverService, ratingService)
 
 	// Setup router
-	r := router.New(authHandler, driverHandler, authMiddleware)
+	r := router.New(authHandler, driverHandler, rideHandler, streamHandler, adminHandler, walletHandler,
+		earningsHandler, ratingHandler, authMiddleware, fileServer)
 	r.SetupRoutes()
 
 	// Start Gin server on port 8000
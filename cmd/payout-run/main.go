@@ -0,0 +1,59 @@
+// Command payout-run creates the weekly payout statements of all drivers.
+//
+// Usage:
+//
+//	payout-run [-week YYYY-MM-DD]
+//
+// The week defaults to the last completed one. Running a week again only
+// creates statements for drivers that had none.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/sayeed1999/share-a-ride/internal/app/services"
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/dateutil"
+	"github.com/sayeed1999/share-a-ride/internal/provider/database"
+	"github.com/sayeed1999/share-a-ride/internal/provider/repository"
+)
+
+func main() {
+	week := flag.String("week", "", "any date (YYYY-MM-DD) in the week to pay out; defaults to last week")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	db, err := database.New(database.Config{
+		DSN: cfg.Database.GetDSN(),
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	clk := clock.New()
+	weekOf := clk.Now().AddDate(0, 0, -7)
+	if *week != "" {
+		weekOf, err = dateutil.ParseDate(*week)
+		if err != nil {
+			log.Fatalf("Invalid week %q, expected YYYY-MM-DD", *week)
+		}
+	}
+
+	earningsService := services.NewEarningsService(repository.NewEarningsRepository(db.DB()), clk, cfg.Earnings)
+	statements, err := earningsService.RunPayouts(context.Background(), weekOf)
+	if err != nil {
+		log.Fatalf("Payout run failed: %v", err)
+	}
+
+	for _, s := range statements {
+		log.Printf("driver %s: %d rides, earnings %.2f %s", s.DriverID, s.RideCount, s.Earnings, cfg.Pricing.Currency)
+	}
+}
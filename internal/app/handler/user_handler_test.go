@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -15,6 +16,7 @@ import (
 	"github.com/sayeed1999/share-a-ride/internal/domain/entity"
 	"github.com/sayeed1999/share-a-ride/internal/domain/errs"
 	"github.com/sayeed1999/share-a-ride/internal/domain/usecase"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/receipt"
 	"github.com/sayeed1999/share-a-ride/internal/provider/email"
 )
 
@@ -35,6 +37,26 @@ func (m *MockEmailService) SendPasswordResetEmail(email, token string) error {
 	return args.Error(0)
 }
 
+func (m *MockEmailService) SendRideReceipt(email string, r *receipt.Receipt) error {
+	args := m.Called(email, r)
+	return args.Error(0)
+}
+
+func (m *MockEmailService) SendRideReminder(email string, scheduledAt time.Time) error {
+	args := m.Called(email, scheduledAt)
+	return args.Error(0)
+}
+
+func (m *MockEmailService) SendDriverVerificationDecision(email, status, reason string) error {
+	args := m.Called(email, status, reason)
+	return args.Error(0)
+}
+
+func (m *MockEmailService) SendDocumentExpiryReminder(email, documentType string, expiresOn time.Time) error {
+	args := m.Called(email, documentType, expiresOn)
+	return args.Error(0)
+}
+
 func setupTestRouter(userUseCase usecase.UserUseCase, emailService email.EmailServiceInterface) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
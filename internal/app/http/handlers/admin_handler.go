@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+)
+
+type createPromoCodeRequest struct {
+	Code          string              `json:"code" binding:"required,alphanum,max=32"`
+	DiscountType  models.DiscountType `json:"discount_type" binding:"required,oneof=percentage flat"`
+	DiscountValue float64             `json:"discount_value" binding:"required,gt=0"`
+	MaxDiscount   float64             `json:"max_discount" binding:"min=0"`
+	MaxUses       int                 `json:"max_uses" binding:"min=0"`
+	PerUserLimit  int                 `json:"per_user_limit" binding:"min=0"`
+	VehicleType   models.VehicleType  `json:"vehicle_type" binding:"omitempty,oneof=car bike"`
+	StartsAt      *time.Time          `json:"starts_at"`
+	EndsAt        *time.Time          `json:"ends_at"`
+}
+
+type refundRideRequest struct {
+	Amount float64 `json:"amount" binding:"required,gt=0"`
+}
+
+type listVerificationsQuery struct {
+	Status models.VerificationStatus `form:"status" binding:"omitempty,oneof=pending approved rejected resubmission_required"`
+	Page   int                       `form:"page" binding:"omitempty,min=1"`
+	Limit  int                       `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type reviewDriverRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+type AdminHandler struct {
+	surgeService        services.SurgeService
+	promoService        services.PromoService
+	paymentService      services.PaymentService
+	verificationService services.VerificationService
+}
+
+func NewAdminHandler(
+	surgeService services.SurgeService,
+	promoService services.PromoService,
+	paymentService services.PaymentService,
+	verificationService services.VerificationService,
+) *AdminHandler {
+	return &AdminHandler{
+		surgeService:        surgeService,
+		promoService:        promoService,
+		paymentService:      paymentService,
+		verificationService: verificationService,
+	}
+}
+
+// SurgeHeatmap returns the supply, demand and multiplier of every busy
+// surge cell as of the last computation
+func (h *AdminHandler) SurgeHeatmap(c *gin.Context) {
+	snapshot := h.surgeService.Heatmap()
+	if snapshot == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "surge has not been computed yet"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    snapshot,
+	})
+}
+
+func (h *AdminHandler) CreatePromoCode(c *gin.Context) {
+	var req createPromoCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.DiscountType == models.DiscountTypePercentage && req.DiscountValue > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "percentage discount cannot exceed 100"})
+		return
+	}
+	if req.StartsAt != nil && req.EndsAt != nil && !req.EndsAt.After(*req.StartsAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ends_at must be after starts_at"})
+		return
+	}
+
+	promo, err := h.promoService.CreatePromoCode(c.Request.Context(), services.CreatePromoCodeInput{
+		Code:          req.Code,
+		DiscountType:  req.DiscountType,
+		DiscountValue: req.DiscountValue,
+		MaxDiscount:   req.MaxDiscount,
+		MaxUses:       req.MaxUses,
+		PerUserLimit:  req.PerUserLimit,
+		VehicleType:   req.VehicleType,
+		StartsAt:      req.StartsAt,
+		EndsAt:        req.EndsAt,
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == errors.ErrPromoCodeExists {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    promo,
+	})
+}
+
+func (h *AdminHandler) ListPromoCodes(c *gin.Context) {
+	promos, err := h.promoService.ListPromoCodes(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    promos,
+	})
+}
+
+// RefundRide returns part or all of a ride's captured fare to the rider
+func (h *AdminHandler) RefundRide(c *gin.Context) {
+	var req refundRideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payment, err := h.paymentService.RefundRide(c.Request.Context(), c.Param("id"), req.Amount)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err {
+		case errors.ErrPaymentNotFound:
+			status = http.StatusNotFound
+		case errors.ErrPaymentNotRefundable, errors.ErrRefundTooLarge:
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    payment,
+	})
+}
+
+// ListDriverVerifications returns the review queue: drivers in one
+// verification status, pending by default, with their documents
+func (h *AdminHandler) ListDriverVerifications(c *gin.Context) {
+	var query listVerificationsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := h.verificationService.ListVerifications(c.Request.Context(), services.ListVerificationsInput{
+		Status: query.Status,
+		Page:   query.Page,
+		Limit:  query.Limit,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"drivers": list.Drivers,
+		},
+		"metadata": gin.H{
+			"total":    list.Total,
+			"page":     list.Page,
+			"limit":    list.Limit,
+			"has_more": list.HasMore,
+		},
+	})
+}
+
+func (h *AdminHandler) ApproveDriver(c *gin.Context) {
+	h.reviewDriver(c, models.VerificationStatusApproved)
+}
+
+func (h *AdminHandler) RejectDriver(c *gin.Context) {
+	h.reviewDriver(c, models.VerificationStatusRejected)
+}
+
+func (h *AdminHandler) RequestDriverResubmission(c *gin.Context) {
+	h.reviewDriver(c, models.VerificationStatusResubmissionRequired)
+}
+
+// reviewDriver records the admin's decision on the driver in the path
+func (h *AdminHandler) reviewDriver(c *gin.Context, status models.VerificationStatus) {
+	var req reviewDriverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	admin := c.MustGet("user").(*models.User)
+
+	driver, err := h.verificationService.ReviewDriver(c.Request.Context(), c.Param("id"), admin.ID, services.ReviewDriverInput{
+		Status: status,
+		Reason: req.Reason,
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err {
+		case errors.ErrDriverNotFound:
+			status = http.StatusNotFound
+		case errors.ErrInvalidVerificationTransition, errors.ErrVerificationConflict:
+			status = http.StatusConflict
+		case errors.ErrReviewReasonRequired:
+			status = http.StatusUnprocessableEntity
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    driver,
+	})
+}
@@ -1,12 +1,17 @@
 package handlers
 
 import (
+	"context"
+	stderrors "errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sayeed1999/share-a-ride/internal/config"
 	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
 	"github.com/sayeed1999/share-a-ride/internal/domain/models"
 	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/dateutil"
 )
 
 type verifyDriverRequest struct {
@@ -19,29 +24,143 @@ type vehicleRequest struct {
 	Type        models.VehicleType `json:"type" binding:"required,oneof=car bike"`
 	Model       string             `json:"model" binding:"required"`
 	PlateNumber string             `json:"plate_number" binding:"required"`
+	Seats       int                `json:"seats" binding:"omitempty,min=1,max=8"`
+	Colour      string             `json:"colour" binding:"omitempty,max=30"`
+	Year        int                `json:"year" binding:"omitempty,min=1950"`
+}
+
+func (r vehicleRequest) toVehicle() models.Vehicle {
+	return models.Vehicle{
+		Type:        r.Type,
+		Model:       r.Model,
+		PlateNumber: r.PlateNumber,
+		Seats:       r.Seats,
+		Colour:      r.Colour,
+		Year:        r.Year,
+	}
 }
 
 type documentRequest struct {
-	Type    models.DocumentType `json:"type" binding:"required,oneof=license registration insurance"`
-	FileURL string              `json:"file_url" binding:"required,url"`
+	Type      models.DocumentType `json:"type" binding:"required,oneof=license registration insurance"`
+	FileKey   string              `json:"file_key" binding:"required"`
+	IssuedOn  string              `json:"issued_on"`
+	ExpiresOn string              `json:"expires_on"`
+	// VehicleID names the vehicle a registration or insurance document
+	// belongs to; it defaults to the active vehicle
+	VehicleID string `json:"vehicle_id" binding:"omitempty,uuid"`
+}
+
+// replaceDocumentRequest replaces a document's file and dates; its type
+// stays the same
+type replaceDocumentRequest struct {
+	FileKey   string `json:"file_key" binding:"required"`
+	IssuedOn  string `json:"issued_on"`
+	ExpiresOn string `json:"expires_on"`
 }
 
-type updateLocationRequest struct {
-	Latitude  float64 `json:"latitude" binding:"required"`
-	Longitude float64 `json:"longitude" binding:"required"`
+func (r documentRequest) toInput() (services.DocumentInput, error) {
+	input := services.DocumentInput{
+		Type:      r.Type,
+		FileKey:   r.FileKey,
+		VehicleID: r.VehicleID,
+	}
+	if r.IssuedOn != "" {
+		issuedOn, err := dateutil.ParseDate(r.IssuedOn)
+		if err != nil {
+			return services.DocumentInput{}, stderrors.New("issued_on must be in YYYY-MM-DD format")
+		}
+		input.IssuedOn = &issuedOn
+	}
+	if r.ExpiresOn != "" {
+		expiresOn, err := dateutil.ParseDate(r.ExpiresOn)
+		if err != nil {
+			return services.DocumentInput{}, stderrors.New("expires_on must be in YYYY-MM-DD format")
+		}
+		input.ExpiresOn = &expiresOn
+	}
+	return input, nil
+}
+
+// multipartOverheadBytes allows for the form fields and boundaries around
+// an uploaded file
+const multipartOverheadBytes = 64 << 10
+
+type respondToOfferRequest struct {
+	Accept *bool `json:"accept" binding:"required"`
 }
 
 type updateAvailabilityRequest struct {
-	IsAvailable bool `json:"is_available" binding:"required"`
+	IsAvailable *bool `json:"is_available" binding:"required"`
+}
+
+type rideRiderResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type driverRideResponse struct {
+	ID              string             `json:"id"`
+	Rider           *rideRiderResponse `json:"rider"`
+	PickupLocation  models.Location    `json:"pickup_location"`
+	DropoffLocation models.Location    `json:"dropoff_location"`
+	Status          models.RideStatus  `json:"status"`
+	Pooled          bool               `json:"pooled"`
+	Seats           int                `json:"seats"`
+	PoolID          *string            `json:"pool_id"`
+	Fare            float64            `json:"fare"`
+	CreatedAt       time.Time          `json:"created_at"`
+	CompletedAt     *time.Time         `json:"completed_at"`
+}
+
+func newDriverRideResponse(ride models.Ride) driverRideResponse {
+	resp := driverRideResponse{
+		ID:              ride.ID,
+		PickupLocation:  ride.PickupLocation,
+		DropoffLocation: ride.DropoffLocation,
+		Status:          ride.Status,
+		Pooled:          ride.Pooled,
+		Seats:           ride.Seats,
+		PoolID:          ride.PoolID,
+		Fare:            ride.Fare,
+		CreatedAt:       ride.CreatedAt,
+		CompletedAt:     ride.CompletedAt,
+	}
+	if ride.Rider != nil {
+		resp.Rider = &rideRiderResponse{
+			ID:   ride.Rider.ID,
+			Name: ride.Rider.Name,
+		}
+	}
+	return resp
 }
 
 type DriverHandler struct {
-	driverService services.DriverService
+	driverService       services.DriverService
+	rideService         services.RideService
+	matchingService     services.MatchingService
+	trackingService     services.TrackingService
+	poolService         services.PoolService
+	documentFileService services.DocumentFileService
+	documentConfig      config.DocumentConfig
 }
 
-func NewDriverHandler(driverService services.DriverService) *DriverHandler {
+func NewDriverHandler(
+	driverService services.DriverService,
+	rideService services.RideService,
+	matchingService services.MatchingService,
+	trackingService services.TrackingService,
+	poolService services.PoolService,
+	documentFileService services.DocumentFileService,
+	documentConfig config.DocumentConfig,
+) *DriverHandler {
 	return &DriverHandler{
-		driverService: driverService,
+		driverService:       driverService,
+		rideService:         rideService,
+		matchingService:     matchingService,
+		trackingService:     trackingService,
+		poolService:         poolService,
+		documentFileService: documentFileService,
+		documentConfig:      documentConfig,
 	}
 }
 
@@ -57,29 +176,29 @@ func (h *DriverHandler) VerifyDriver(c *gin.Context) {
 	// Convert request to service input
 	documents := make([]services.DocumentInput, len(req.Documents))
 	for i, doc := range req.Documents {
-		documents[i] = services.DocumentInput{
-			Type:    doc.Type,
-			FileURL: doc.FileURL,
+		input, err := doc.toInput()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
 		}
+		documents[i] = input
 	}
 
 	driver, err := h.driverService.VerifyDriver(c.Request.Context(), user.ID, services.VerifyDriverInput{
 		LicenseNumber: req.LicenseNumber,
-		Vehicle: models.Vehicle{
-			Type:        req.Vehicle.Type,
-			Model:       req.Vehicle.Model,
-			PlateNumber: req.Vehicle.PlateNumber,
-		},
-		Documents: documents,
+		Vehicle:       req.Vehicle.toVehicle(),
+		Documents:     documents,
 	})
 
 	if err != nil {
 		status := http.StatusInternalServerError
 		switch err {
-		case errors.ErrDriverExists, errors.ErrLicenseExists:
+		case errors.ErrDriverExists, errors.ErrLicenseExists, errors.ErrPlateNumberExists, errors.ErrVerificationConflict:
 			status = http.StatusConflict
 		case errors.ErrUnauthorizedAccess:
 			status = http.StatusForbidden
+		case errors.ErrUploadNotFound, errors.ErrDocumentExpiryRequired, errors.ErrDocumentExpired, errors.ErrInvalidDocumentDates:
+			status = http.StatusUnprocessableEntity
 		}
 		c.JSON(status, gin.H{"error": err.Error()})
 		return
@@ -89,14 +208,29 @@ func (h *DriverHandler) VerifyDriver(c *gin.Context) {
 		"success": true,
 		"data": gin.H{
 			"verification_id": driver.ID,
-			"status":          "pending",
-			"submitted_at":    driver.CreatedAt,
+			"status":          driver.VerificationStatus,
+			"submitted_at":    driver.SubmittedAt,
 		},
 	})
 }
 
+// locationErrorStatus maps the errors of a location update to HTTP statuses
+func locationErrorStatus(err error) int {
+	switch err {
+	case errors.ErrInvalidLocation:
+		return http.StatusBadRequest
+	case errors.ErrDriverNotFound:
+		return http.StatusNotFound
+	case errors.ErrImplausibleLocation:
+		return http.StatusUnprocessableEntity
+	case errors.ErrLocationThrottled:
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
 func (h *DriverHandler) UpdateLocation(c *gin.Context) {
-	var req updateLocationRequest
+	var req locationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -109,13 +243,14 @@ func (h *DriverHandler) UpdateLocation(c *gin.Context) {
 		return
 	}
 
-	err = h.driverService.UpdateLocation(c.Request.Context(), driver.ID, services.UpdateLocationInput{
-		Latitude:  req.Latitude,
-		Longitude: req.Longitude,
+	location := req.toModel()
+	err = h.trackingService.PublishLocation(c.Request.Context(), driver.ID, services.UpdateLocationInput{
+		Latitude:  location.Latitude,
+		Longitude: location.Longitude,
 	})
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(locationErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -123,7 +258,7 @@ func (h *DriverHandler) UpdateLocation(c *gin.Context) {
 		"success": true,
 		"data": gin.H{
 			"driver_id":        driver.ID,
-			"current_location": req,
+			"current_location": location,
 			"updated_at":       driver.UpdatedAt,
 		},
 	})
@@ -143,11 +278,14 @@ func (h *DriverHandler) UpdateAvailability(c *gin.Context) {
 		return
 	}
 
-	err = h.driverService.UpdateAvailability(c.Request.Context(), driver.ID, req.IsAvailable)
+	err = h.driverService.UpdateAvailability(c.Request.Context(), driver.ID, *req.IsAvailable)
 	if err != nil {
 		status := http.StatusInternalServerError
-		if err == errors.ErrDriverNotVerified {
+		switch err {
+		case errors.ErrDriverNotVerified:
 			status = http.StatusForbidden
+		case errors.ErrVehicleNotFound:
+			status = http.StatusConflict
 		}
 		c.JSON(status, gin.H{"error": err.Error()})
 		return
@@ -157,7 +295,7 @@ func (h *DriverHandler) UpdateAvailability(c *gin.Context) {
 		"success": true,
 		"data": gin.H{
 			"driver_id":    driver.ID,
-			"is_available": req.IsAvailable,
+			"is_available": *req.IsAvailable,
 			"updated_at":   driver.UpdatedAt,
 		},
 	})
@@ -177,6 +315,57 @@ func (h *DriverHandler) GetProfile(c *gin.Context) {
 	})
 }
 
+// UploadDocument stores a document file sent as the "file" field of a
+// multipart form. The returned file_key is then used to submit the
+// document.
+func (h *DriverHandler) UploadDocument(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.documentConfig.MaxUploadBytes+multipartOverheadBytes)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if stderrors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": errors.ErrDocumentTooLarge.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	user := c.MustGet("user").(*models.User)
+
+	uploaded, err := h.documentFileService.Upload(c.Request.Context(), user.ID, file)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err {
+		case errors.ErrDocumentTooLarge:
+			status = http.StatusRequestEntityTooLarge
+		case errors.ErrUnsupportedDocumentFormat:
+			status = http.StatusUnsupportedMediaType
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data": gin.H{
+			"file_key":       uploaded.FileKey,
+			"content_type":   uploaded.ContentType,
+			"size_bytes":     uploaded.SizeBytes,
+			"checksum":       uploaded.Checksum,
+			"url":            uploaded.URL,
+			"url_expires_at": uploaded.URLExpiresAt,
+		},
+	})
+}
+
 func (h *DriverHandler) GetDocuments(c *gin.Context) {
 	user := c.MustGet("user").(*models.User)
 	driver, err := h.driverService.GetDriverByUserID(c.Request.Context(), user.ID)
@@ -196,3 +385,325 @@ func (h *DriverHandler) GetDocuments(c *gin.Context) {
 		"data":    documents,
 	})
 }
+
+func (h *DriverHandler) AddDocument(c *gin.Context) {
+	var req documentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	input, err := req.toInput()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	driver, err := h.driverService.GetDriverByUserID(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "driver not found"})
+		return
+	}
+
+	document, err := h.driverService.AddDocument(c.Request.Context(), driver.ID, input)
+	if err != nil {
+		c.JSON(documentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    document,
+	})
+}
+
+func (h *DriverHandler) ReplaceDocument(c *gin.Context) {
+	var req replaceDocumentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	input, err := documentRequest{FileKey: req.FileKey, IssuedOn: req.IssuedOn, ExpiresOn: req.ExpiresOn}.toInput()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	driver, err := h.driverService.GetDriverByUserID(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "driver not found"})
+		return
+	}
+
+	document, err := h.driverService.ReplaceDocument(c.Request.Context(), driver.ID, c.Param("id"), input)
+	if err != nil {
+		c.JSON(documentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    document,
+	})
+}
+
+func (h *DriverHandler) DeleteDocument(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	driver, err := h.driverService.GetDriverByUserID(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "driver not found"})
+		return
+	}
+
+	if err := h.driverService.DeleteDocument(c.Request.Context(), driver.ID, c.Param("id")); err != nil {
+		c.JSON(documentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"document_id": c.Param("id"),
+		},
+	})
+}
+
+func (h *DriverHandler) ListVehicles(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	driver, err := h.driverService.GetDriverByUserID(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "driver not found"})
+		return
+	}
+
+	vehicles, err := h.driverService.ListVehicles(c.Request.Context(), driver.ID)
+	if err != nil {
+		c.JSON(vehicleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    vehicles,
+	})
+}
+
+func (h *DriverHandler) AddVehicle(c *gin.Context) {
+	var req vehicleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	driver, err := h.driverService.GetDriverByUserID(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "driver not found"})
+		return
+	}
+
+	vehicle, err := h.driverService.AddVehicle(c.Request.Context(), driver.ID, req.toVehicle())
+	if err != nil {
+		c.JSON(vehicleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    vehicle,
+	})
+}
+
+func (h *DriverHandler) ActivateVehicle(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	driver, err := h.driverService.GetDriverByUserID(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "driver not found"})
+		return
+	}
+
+	vehicle, err := h.driverService.ActivateVehicle(c.Request.Context(), driver.ID, c.Param("id"))
+	if err != nil {
+		c.JSON(vehicleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    vehicle,
+	})
+}
+
+// vehicleErrorStatus maps the errors of vehicle management to HTTP statuses
+func vehicleErrorStatus(err error) int {
+	switch err {
+	case errors.ErrDriverNotFound, errors.ErrVehicleNotFound:
+		return http.StatusNotFound
+	case errors.ErrPlateNumberExists, errors.ErrVehicleSwitchOnline:
+		return http.StatusConflict
+	case errors.ErrVehicleDocumentsMissing:
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+func documentErrorStatus(err error) int {
+	switch err {
+	case errors.ErrDriverNotFound, errors.ErrDocumentNotFound, errors.ErrVehicleNotFound:
+		return http.StatusNotFound
+	case errors.ErrRequiredDocument, errors.ErrVerificationConflict:
+		return http.StatusConflict
+	case errors.ErrUploadNotFound, errors.ErrDocumentExpiryRequired, errors.ErrDocumentExpired, errors.ErrInvalidDocumentDates:
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *DriverHandler) GetRides(c *gin.Context) {
+	input, err := bindListRidesQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	driver, err := h.driverService.GetDriverByUserID(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "driver not found"})
+		return
+	}
+
+	list, err := h.rideService.ListDriverRides(c.Request.Context(), driver.ID, input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	rides := make([]driverRideResponse, len(list.Rides))
+	for i, ride := range list.Rides {
+		rides[i] = newDriverRideResponse(ride)
+	}
+
+	c.JSON(http.StatusOK, rideListResponse(rides, list))
+}
+
+// ArriveAtPickup, StartRide and CompleteRide move the driver's ride through
+// its lifecycle. Completing a ride prices it and charges the rider.
+
+func (h *DriverHandler) ArriveAtPickup(c *gin.Context) {
+	h.advanceRide(c, h.rideService.MarkDriverArrived)
+}
+
+func (h *DriverHandler) StartRide(c *gin.Context) {
+	h.advanceRide(c, h.rideService.StartRide)
+}
+
+func (h *DriverHandler) CompleteRide(c *gin.Context) {
+	h.advanceRide(c, h.rideService.CompleteRide)
+}
+
+func (h *DriverHandler) advanceRide(c *gin.Context, step func(ctx context.Context, rideID string, driverID string) (*models.Ride, error)) {
+	user := c.MustGet("user").(*models.User)
+	driver, err := h.driverService.GetDriverByUserID(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "driver not found"})
+		return
+	}
+
+	ride, err := step(c.Request.Context(), c.Param("id"), driver.ID)
+	if err != nil {
+		c.JSON(rideErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newDriverRideResponse(*ride),
+	})
+}
+
+func (h *DriverHandler) GetCurrentOffer(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	driver, err := h.driverService.GetDriverByUserID(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "driver not found"})
+		return
+	}
+
+	offer, err := h.matchingService.GetPendingOffer(c.Request.Context(), driver.ID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == errors.ErrOfferNotFound {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    offer,
+	})
+}
+
+func (h *DriverHandler) RespondToOffer(c *gin.Context) {
+	var req respondToOfferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	driver, err := h.driverService.GetDriverByUserID(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "driver not found"})
+		return
+	}
+
+	err = h.matchingService.RespondToOffer(c.Request.Context(), driver.ID, c.Param("id"), *req.Accept)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err {
+		case errors.ErrOfferNotFound:
+			status = http.StatusNotFound
+		case errors.ErrOfferExpired, errors.ErrOfferNotPending:
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"offer_id": c.Param("id"),
+			"accepted": *req.Accept,
+		},
+	})
+}
+
+// GetPool returns the stops ahead of the driver in their open pool
+func (h *DriverHandler) GetPool(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	driver, err := h.driverService.GetDriverByUserID(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "driver not found"})
+		return
+	}
+
+	pool, err := h.poolService.GetDriverPool(c.Request.Context(), driver.ID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == errors.ErrPoolNotFound {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    pool,
+	})
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/dateutil"
+)
+
+type earningsQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=json csv"`
+	Page   int    `form:"page" binding:"omitempty,min=1"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=52"`
+}
+
+type EarningsHandler struct {
+	driverService   services.DriverService
+	earningsService services.EarningsService
+}
+
+func NewEarningsHandler(driverService services.DriverService, earningsService services.EarningsService) *EarningsHandler {
+	return &EarningsHandler{
+		driverService:   driverService,
+		earningsService: earningsService,
+	}
+}
+
+// GetEarnings shows the driver's earnings totals and payout statements.
+// With format=csv the statements of the page are exported instead.
+func (h *EarningsHandler) GetEarnings(c *gin.Context) {
+	var query earningsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	driver, ok := h.currentDriver(c)
+	if !ok {
+		return
+	}
+
+	earnings, err := h.earningsService.GetDriverEarnings(c.Request.Context(), driver.ID, services.ListPayoutStatementsInput{
+		Page:  query.Page,
+		Limit: query.Limit,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if query.Format == "csv" {
+		rows := [][]string{{"statement_id", "period_start", "period_end", "ride_count", "gross_fares", "commission", "earnings"}}
+		for _, s := range earnings.Statements {
+			rows = append(rows, []string{
+				s.ID,
+				dateutil.FormatDate(s.PeriodStart),
+				dateutil.FormatDate(s.PeriodEnd.AddDate(0, 0, -1)),
+				strconv.Itoa(s.RideCount),
+				formatAmount(s.GrossFares),
+				formatAmount(s.Commission),
+				formatAmount(s.Earnings),
+			})
+		}
+		writeCSV(c, "payout-statements.csv", rows)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"commission_rate": earnings.CommissionRate,
+			"current_week":    earnings.CurrentWeek,
+			"unpaid":          earnings.Unpaid,
+			"lifetime":        earnings.Lifetime,
+			"statements":      earnings.Statements,
+		},
+		"metadata": gin.H{
+			"total":    earnings.Total,
+			"page":     earnings.Page,
+			"limit":    earnings.Limit,
+			"has_more": earnings.HasMore,
+		},
+	})
+}
+
+// GetStatement shows one payout statement with its rides, as JSON or with
+// format=csv as a spreadsheet.
+func (h *EarningsHandler) GetStatement(c *gin.Context) {
+	var query earningsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	driver, ok := h.currentDriver(c)
+	if !ok {
+		return
+	}
+
+	statement, err := h.earningsService.GetStatement(c.Request.Context(), driver.ID, c.Param("id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == errors.ErrPayoutStatementNotFound {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	if query.Format == "csv" {
+		rows := [][]string{{"ride_id", "earned_at", "gross_fare", "commission", "earnings"}}
+		for _, item := range statement.Items {
+			rows = append(rows, []string{
+				item.RideID,
+				dateutil.FormatDateTime(item.EarnedAt),
+				formatAmount(item.GrossFare),
+				formatAmount(item.Commission),
+				formatAmount(item.Earnings),
+			})
+		}
+		rows = append(rows, []string{
+			"total",
+			"",
+			formatAmount(statement.GrossFares),
+			formatAmount(statement.Commission),
+			formatAmount(statement.Earnings),
+		})
+		writeCSV(c, fmt.Sprintf("payout-statement-%s.csv", dateutil.FormatDate(statement.PeriodStart)), rows)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    statement,
+	})
+}
+
+func (h *EarningsHandler) currentDriver(c *gin.Context) (*models.Driver, bool) {
+	user := c.MustGet("user").(*models.User)
+	driver, err := h.driverService.GetDriverByUserID(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "driver not found"})
+		return nil, false
+	}
+	return driver, true
+}
+
+// writeCSV sends rows as a CSV attachment named filename
+func writeCSV(c *gin.Context, filename string, rows [][]string) {
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.WriteAll(rows)
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
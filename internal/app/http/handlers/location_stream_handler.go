@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gorilla/websocket"
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+)
+
+// streamFrame is the envelope of every message the server sends on a
+// location stream
+type streamFrame struct {
+	Type  string      `json:"type"`
+	Data  interface{} `json:"data,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+const (
+	frameTypePosition = "position"
+	frameTypeError    = "error"
+)
+
+type LocationStreamHandler struct {
+	driverService   services.DriverService
+	trackingService services.TrackingService
+	upgrader        websocket.Upgrader
+	config          config.RealtimeConfig
+}
+
+func NewLocationStreamHandler(driverService services.DriverService, trackingService services.TrackingService, cfg config.RealtimeConfig) *LocationStreamHandler {
+	return &LocationStreamHandler{
+		driverService:   driverService,
+		trackingService: trackingService,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+		},
+		config: cfg,
+	}
+}
+
+// Stream upgrades the request to a WebSocket. Drivers push location frames
+// on it; riders receive the position of the driver on their active ride.
+func (h *LocationStreamHandler) Stream(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	switch {
+	case user.IsDriver():
+		h.streamDriver(c, user)
+	case user.IsRider():
+		h.streamRider(c, user)
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": "driver or rider access required"})
+	}
+}
+
+func (h *LocationStreamHandler) streamDriver(c *gin.Context, user *models.User) {
+	driver, err := h.driverService.GetDriverByUserID(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "driver not found"})
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already replied to the client
+		return
+	}
+
+	// Only errors are sent back; a driver that stops reading them loses
+	// the surplus rather than stalling its own updates
+	replies := make(chan streamFrame, h.config.SubscriberBuffer)
+	reply := func(frame streamFrame) {
+		select {
+		case replies <- frame:
+		default:
+		}
+	}
+
+	runConnection(conn, h.config, replies, func(f streamFrame) streamFrame { return f }, func(data []byte) {
+		var req locationRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			reply(streamFrame{Type: frameTypeError, Error: "invalid location frame"})
+			return
+		}
+		if err := binding.Validator.ValidateStruct(&req); err != nil {
+			reply(streamFrame{Type: frameTypeError, Error: err.Error()})
+			return
+		}
+
+		err := h.trackingService.PublishLocation(c.Request.Context(), driver.ID, services.UpdateLocationInput{
+			Latitude:  *req.Latitude,
+			Longitude: *req.Longitude,
+		})
+		if err != nil {
+			reply(streamFrame{Type: frameTypeError, Error: err.Error()})
+		}
+	})
+}
+
+func (h *LocationStreamHandler) streamRider(c *gin.Context, user *models.User) {
+	sub, err := h.trackingService.SubscribeToCurrentRide(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(rideErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer sub.Close()
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+
+	runConnection(conn, h.config, sub.Positions, func(p services.DriverPosition) streamFrame {
+		return streamFrame{Type: frameTypePosition, Data: p}
+	}, nil)
+}
+
+// runConnection serves conn until the client goes away, stops answering
+// pings, or outgoing is closed. Values from outgoing are written as frames;
+// messages from the client are passed to handle, which may be nil.
+func runConnection[T any](conn *websocket.Conn, cfg config.RealtimeConfig, outgoing <-chan T, frame func(T) streamFrame, handle func(data []byte)) {
+	done := make(chan struct{})
+	defer func() {
+		conn.Close()
+		<-done
+	}()
+
+	go func() {
+		defer close(done)
+
+		conn.SetReadLimit(cfg.MaxMessageBytes)
+		alive := func() error {
+			return conn.SetReadDeadline(time.Now().Add(cfg.PongTimeout))
+		}
+		alive()
+		conn.SetPongHandler(func(string) error { return alive() })
+
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+					log.Printf("location stream closed: %v", err)
+				}
+				return
+			}
+			alive()
+			if handle != nil {
+				handle(data)
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(cfg.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg, ok := <-outgoing:
+			conn.SetWriteDeadline(time.Now().Add(cfg.WriteTimeout))
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			if err := conn.WriteJSON(frame(msg)); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(cfg.WriteTimeout)); err != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
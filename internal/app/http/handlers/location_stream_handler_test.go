@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+)
+
+type fakeDriverService struct {
+	services.DriverService
+}
+
+func (s *fakeDriverService) GetDriverByUserID(ctx context.Context, userID string) (*models.Driver, error) {
+	return &models.Driver{ID: "driver-" + userID, UserID: userID}, nil
+}
+
+type fakeTrackingService struct {
+	services.TrackingService
+	published chan services.UpdateLocationInput
+	positions chan services.DriverPosition
+}
+
+func (s *fakeTrackingService) PublishLocation(ctx context.Context, driverID string, input services.UpdateLocationInput) error {
+	s.published <- input
+	return nil
+}
+
+func (s *fakeTrackingService) SubscribeToCurrentRide(ctx context.Context, riderID string) (*services.RideSubscription, error) {
+	return &services.RideSubscription{Ride: &models.Ride{ID: "ride-1"}, Positions: s.positions, Close: func() {}}, nil
+}
+
+var testRealtimeConfig = config.RealtimeConfig{
+	SubscriberBuffer: 4,
+	PingInterval:     20 * time.Millisecond,
+	PongTimeout:      time.Second,
+	WriteTimeout:     time.Second,
+	MaxMessageBytes:  512,
+}
+
+func dialLocationStream(t *testing.T, tracking *fakeTrackingService, userType models.UserType) *websocket.Conn {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	handler := NewLocationStreamHandler(&fakeDriverService{}, tracking, testRealtimeConfig)
+	engine.GET("/ws/location", func(c *gin.Context) {
+		c.Set("user", &models.User{ID: "user-1", UserType: userType})
+	}, handler.Stream)
+
+	server := httptest.NewServer(engine)
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/location", nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestLocationStreamDriverFrames(t *testing.T) {
+	tracking := &fakeTrackingService{published: make(chan services.UpdateLocationInput, 1)}
+	conn := dialLocationStream(t, tracking, models.UserTypeDriver)
+
+	require.NoError(t, conn.WriteJSON(gin.H{"latitude": 0, "longitude": 90.4125}))
+	assert.Equal(t, services.UpdateLocationInput{Latitude: 0, Longitude: 90.4125}, <-tracking.published)
+
+	// Invalid frames are answered with an error and keep the stream open
+	require.NoError(t, conn.WriteJSON(gin.H{"latitude": 91, "longitude": 0}))
+	var frame streamFrame
+	require.NoError(t, conn.ReadJSON(&frame))
+	assert.Equal(t, frameTypeError, frame.Type)
+}
+
+func TestLocationStreamRiderReceivesPositions(t *testing.T) {
+	tracking := &fakeTrackingService{positions: make(chan services.DriverPosition, 1)}
+	conn := dialLocationStream(t, tracking, models.UserTypeRider)
+
+	pings := make(chan struct{}, 1)
+	conn.SetPingHandler(func(string) error {
+		select {
+		case pings <- struct{}{}:
+		default:
+		}
+		return conn.WriteControl(websocket.PongMessage, nil, time.Now().Add(time.Second))
+	})
+
+	tracking.positions <- services.DriverPosition{RideID: "ride-1", DriverID: "driver-1",
+		Location: models.Location{Latitude: 23.8103, Longitude: 90.4125}}
+
+	var frame struct {
+		Type string                  `json:"type"`
+		Data services.DriverPosition `json:"data"`
+	}
+	require.NoError(t, conn.ReadJSON(&frame))
+	assert.Equal(t, frameTypePosition, frame.Type)
+	assert.Equal(t, "driver-1", frame.Data.DriverID)
+
+	// The server keeps the connection alive with pings; control frames are
+	// only processed while reading
+	go conn.ReadMessage()
+	select {
+	case <-pings:
+	case <-time.After(time.Second):
+		t.Fatal("no heartbeat received")
+	}
+}
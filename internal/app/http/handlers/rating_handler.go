@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+)
+
+type rateRideRequest struct {
+	Stars   int      `json:"stars" binding:"required,min=1,max=5"`
+	Comment string   `json:"comment" binding:"max=500"`
+	Tags    []string `json:"tags" binding:"max=5,dive,max=32"`
+}
+
+type RatingHandler struct {
+	driverService services.DriverService
+	ratingService services.RatingService
+}
+
+func NewRatingHandler(driverService services.DriverService, ratingService services.RatingService) *RatingHandler {
+	return &RatingHandler{
+		driverService: driverService,
+		ratingService: ratingService,
+	}
+}
+
+// RateDriver lets the rider of a completed ride rate its driver
+func (h *RatingHandler) RateDriver(c *gin.Context) {
+	var req rateRideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+
+	rating, err := h.ratingService.RateDriver(c.Request.Context(), c.Param("id"), user.ID, req.toInput())
+	if err != nil {
+		c.JSON(ratingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    rating,
+	})
+}
+
+// RateRider lets the driver of a completed ride rate its rider
+func (h *RatingHandler) RateRider(c *gin.Context) {
+	var req rateRideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	driver, err := h.driverService.GetDriverByUserID(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "driver not found"})
+		return
+	}
+
+	rating, err := h.ratingService.RateRider(c.Request.Context(), c.Param("id"), driver.ID, req.toInput())
+	if err != nil {
+		c.JSON(ratingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    rating,
+	})
+}
+
+func (r rateRideRequest) toInput() services.RateRideInput {
+	return services.RateRideInput{
+		Stars:   r.Stars,
+		Comment: r.Comment,
+		Tags:    r.Tags,
+	}
+}
+
+func ratingErrorStatus(err error) int {
+	switch err {
+	case errors.ErrInvalidRating:
+		return http.StatusUnprocessableEntity
+	case errors.ErrRatingExists, errors.ErrRideNotCompleted:
+		return http.StatusConflict
+	}
+	return rideErrorStatus(err)
+}
@@ -0,0 +1,413 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/dateutil"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/geojson"
+)
+
+type locationRequest struct {
+	Latitude  *float64 `json:"latitude" binding:"required,min=-90,max=90"`
+	Longitude *float64 `json:"longitude" binding:"required,min=-180,max=180"`
+}
+
+func (l locationRequest) toModel() models.Location {
+	return models.Location{
+		Latitude:  *l.Latitude,
+		Longitude: *l.Longitude,
+	}
+}
+
+func toLocations(requests []locationRequest) []models.Location {
+	locations := make([]models.Location, len(requests))
+	for i, l := range requests {
+		locations[i] = l.toModel()
+	}
+	return locations
+}
+
+type createRideRequest struct {
+	PickupLocation  locationRequest      `json:"pickup_location" binding:"required"`
+	DropoffLocation locationRequest      `json:"dropoff_location" binding:"required"`
+	VehicleType     models.VehicleType   `json:"vehicle_type" binding:"omitempty,oneof=car bike"`
+	PromoCode       string               `json:"promo_code" binding:"max=32"`
+	PaymentMethod   models.PaymentMethod `json:"payment_method" binding:"omitempty,oneof=card wallet"`
+	ScheduledAt     *time.Time           `json:"scheduled_at"`
+	Pooled          bool                 `json:"pooled"`
+	Seats           int                  `json:"seats" binding:"omitempty,min=1"`
+	Waypoints       []locationRequest    `json:"waypoints" binding:"omitempty,dive"`
+}
+
+type addWaypointRequest struct {
+	Location locationRequest `json:"location" binding:"required"`
+	Position *int            `json:"position" binding:"omitempty,min=0"`
+}
+
+type estimateFareRequest struct {
+	PickupLocation  locationRequest    `json:"pickup_location" binding:"required"`
+	DropoffLocation locationRequest    `json:"dropoff_location" binding:"required"`
+	Waypoints       []locationRequest  `json:"waypoints" binding:"omitempty,dive"`
+	VehicleType     models.VehicleType `json:"vehicle_type" binding:"omitempty,oneof=car bike"`
+}
+
+type applyPromoCodeRequest struct {
+	estimateFareRequest
+	PromoCode string `json:"promo_code" binding:"required,max=32"`
+}
+
+type cancelRideRequest struct {
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+type listRidesQuery struct {
+	Status   string `form:"status" binding:"omitempty,oneof=scheduled requested accepted driver_arrived in_progress completed cancelled ongoing"`
+	FromDate string `form:"from_date"`
+	ToDate   string `form:"to_date"`
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	Limit    int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// bindListRidesQuery parses the shared ride history query parameters. The
+// "ongoing" status expands to every active ride status, and dates are
+// widened to cover the whole day.
+func bindListRidesQuery(c *gin.Context) (services.ListRidesInput, error) {
+	var query listRidesQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		return services.ListRidesInput{}, err
+	}
+
+	input := services.ListRidesInput{
+		Page:  query.Page,
+		Limit: query.Limit,
+	}
+
+	switch query.Status {
+	case "":
+	case "ongoing":
+		input.Statuses = models.ActiveRideStatuses
+	default:
+		input.Statuses = []models.RideStatus{models.RideStatus(query.Status)}
+	}
+
+	if query.FromDate != "" {
+		from, err := dateutil.ParseDate(query.FromDate)
+		if err != nil {
+			return services.ListRidesInput{}, stderrors.New("from_date must be in YYYY-MM-DD format")
+		}
+		from = dateutil.StartOfDay(from)
+		input.From = &from
+	}
+	if query.ToDate != "" {
+		to, err := dateutil.ParseDate(query.ToDate)
+		if err != nil {
+			return services.ListRidesInput{}, stderrors.New("to_date must be in YYYY-MM-DD format")
+		}
+		to = dateutil.EndOfDay(to)
+		input.To = &to
+	}
+	if input.From != nil && input.To != nil && input.From.After(*input.To) {
+		return services.ListRidesInput{}, stderrors.New("from_date must not be after to_date")
+	}
+
+	return input, nil
+}
+
+// rideListResponse wraps rides, which may be a shaped view of list.Rides,
+// with the pagination metadata of list.
+func rideListResponse(rides interface{}, list *services.RideList) gin.H {
+	return gin.H{
+		"success": true,
+		"data": gin.H{
+			"rides": rides,
+		},
+		"metadata": gin.H{
+			"total":    list.Total,
+			"page":     list.Page,
+			"limit":    list.Limit,
+			"has_more": list.HasMore,
+		},
+	}
+}
+
+// rideErrorStatus maps ride service errors to HTTP status codes.
+func rideErrorStatus(err error) int {
+	switch {
+	case err == errors.ErrRideNotFound, err == errors.ErrWaypointNotFound:
+		return http.StatusNotFound
+	case err == errors.ErrNotRideParticipant, err == errors.ErrUnauthorizedAccess:
+		return http.StatusForbidden
+	case err == errors.ErrActiveRideExists, err == errors.ErrRideStatusConflict,
+		err == errors.ErrRideDoesNotFit, err == errors.ErrPoolConflict, err == errors.ErrWaypointsLocked,
+		stderrors.Is(err, errors.ErrInvalidRideTransition):
+		return http.StatusConflict
+	case err == errors.ErrTariffNotFound, err == errors.ErrPromoCodeNotValid, err == errors.ErrPromoCodeNotApplicable,
+		err == errors.ErrInvalidScheduledTime, err == errors.ErrTooManyPoolSeats,
+		err == errors.ErrTooManyWaypoints, err == errors.ErrPooledRideWaypoints:
+		return http.StatusUnprocessableEntity
+	case err == errors.ErrPromoCodeNotFound:
+		return http.StatusNotFound
+	case err == errors.ErrPromoCodeExhausted, err == errors.ErrPromoCodeUserLimit:
+		return http.StatusConflict
+	case err == errors.ErrPaymentDeclined, err == errors.ErrInsufficientBalance:
+		return http.StatusPaymentRequired
+	}
+	return http.StatusInternalServerError
+}
+
+// ridePathFeature renders the recorded positions of a ride as a GeoJSON
+// LineString feature. The timestamps property lines up with the coordinates.
+func ridePathFeature(rideID string, pings []models.LocationPing) geojson.Feature {
+	positions := make([]geojson.Position, len(pings))
+	timestamps := make([]time.Time, len(pings))
+	for i, ping := range pings {
+		positions[i] = geojson.Position{ping.Location.Longitude, ping.Location.Latitude}
+		timestamps[i] = ping.RecordedAt
+	}
+
+	return geojson.NewFeature(geojson.NewLineString(positions), map[string]interface{}{
+		"ride_id":    rideID,
+		"timestamps": timestamps,
+	})
+}
+
+type RideHandler struct {
+	rideService     services.RideService
+	matchingService services.MatchingService
+	trackingService services.TrackingService
+	pricingService  services.PricingService
+	promoService    services.PromoService
+}
+
+func NewRideHandler(
+	rideService services.RideService,
+	matchingService services.MatchingService,
+	trackingService services.TrackingService,
+	pricingService services.PricingService,
+	promoService services.PromoService,
+) *RideHandler {
+	return &RideHandler{
+		rideService:     rideService,
+		matchingService: matchingService,
+		trackingService: trackingService,
+		pricingService:  pricingService,
+		promoService:    promoService,
+	}
+}
+
+// EstimateFare prices a trip before the rider books it. Without a vehicle
+// type every vehicle type is priced.
+func (h *RideHandler) EstimateFare(c *gin.Context) {
+	var req estimateFareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	estimates, err := h.pricingService.EstimateFare(c.Request.Context(), services.EstimateFareInput{
+		Pickup:      req.PickupLocation.toModel(),
+		Dropoff:     req.DropoffLocation.toModel(),
+		Waypoints:   toLocations(req.Waypoints),
+		VehicleType: req.VehicleType,
+	})
+	if err != nil {
+		c.JSON(rideErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    estimates,
+	})
+}
+
+// ApplyPromoCode prices a trip like EstimateFare with a promo code's
+// discount taken off. The code is not used up until a ride booked with it
+// completes.
+func (h *RideHandler) ApplyPromoCode(c *gin.Context) {
+	var req applyPromoCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+
+	estimates, err := h.promoService.ApplyToEstimate(c.Request.Context(), user.ID, services.ApplyPromoCodeInput{
+		Code: req.PromoCode,
+		Estimate: services.EstimateFareInput{
+			Pickup:      req.PickupLocation.toModel(),
+			Dropoff:     req.DropoffLocation.toModel(),
+			Waypoints:   toLocations(req.Waypoints),
+			VehicleType: req.VehicleType,
+		},
+	})
+	if err != nil {
+		c.JSON(rideErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    estimates,
+	})
+}
+
+func (h *RideHandler) CreateRide(c *gin.Context) {
+	var req createRideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+
+	ride, err := h.rideService.RequestRide(c.Request.Context(), user.ID, services.RequestRideInput{
+		Pickup:        req.PickupLocation.toModel(),
+		Dropoff:       req.DropoffLocation.toModel(),
+		VehicleType:   req.VehicleType,
+		PromoCode:     req.PromoCode,
+		PaymentMethod: req.PaymentMethod,
+		ScheduledAt:   req.ScheduledAt,
+		Pooled:        req.Pooled,
+		Seats:         req.Seats,
+		Waypoints:     toLocations(req.Waypoints),
+	})
+	if err != nil {
+		c.JSON(rideErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// Look for a driver in the background; the rider polls the current ride.
+	// Scheduled rides are dispatched by the scheduler shortly before pickup.
+	if ride.Status == models.RideStatusRequested {
+		h.matchingService.StartDispatch(ride)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    ride,
+	})
+}
+
+func (h *RideHandler) GetCurrentRide(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	ride, err := h.rideService.GetCurrentRide(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(rideErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    ride,
+	})
+}
+
+func (h *RideHandler) CancelRide(c *gin.Context) {
+	// The body is optional; a cancellation without a reason is allowed
+	var req cancelRideRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+
+	ride, err := h.rideService.CancelRide(c.Request.Context(), c.Param("id"), user.ID, services.CancelRideInput{
+		Reason: req.Reason,
+	})
+	if err != nil {
+		c.JSON(rideErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.matchingService.StopDispatch(ride.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    ride,
+	})
+}
+
+func (h *RideHandler) AddWaypoint(c *gin.Context) {
+	var req addWaypointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+
+	route, err := h.rideService.AddWaypoint(c.Request.Context(), c.Param("id"), user.ID, services.AddWaypointInput{
+		Location: req.Location.toModel(),
+		Position: req.Position,
+	})
+	if err != nil {
+		c.JSON(rideErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    route,
+	})
+}
+
+func (h *RideHandler) RemoveWaypoint(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	route, err := h.rideService.RemoveWaypoint(c.Request.Context(), c.Param("id"), user.ID, c.Param("waypointId"))
+	if err != nil {
+		c.JSON(rideErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    route,
+	})
+}
+
+func (h *RideHandler) ListRides(c *gin.Context) {
+	input, err := bindListRidesQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+
+	list, err := h.rideService.ListRiderRides(c.Request.Context(), user.ID, input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rideListResponse(list.Rides, list))
+}
+
+// GetRidePath returns the route the driver took during a ride. It is open
+// to both the rider and the driver of the ride.
+func (h *RideHandler) GetRidePath(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	pings, err := h.trackingService.GetRidePath(c.Request.Context(), c.Param("id"), user.ID)
+	if err != nil {
+		c.JSON(rideErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    ridePathFeature(c.Param("id"), pings),
+	})
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/geojson"
+)
+
+func TestBindListRidesQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		query        string
+		wantErr      bool
+		wantStatuses []models.RideStatus
+	}{
+		{name: "No filters", query: ""},
+		{name: "Single status", query: "status=completed", wantStatuses: []models.RideStatus{models.RideStatusCompleted}},
+		{name: "Ongoing expands to active statuses", query: "status=ongoing", wantStatuses: models.ActiveRideStatuses},
+		{name: "Unknown status", query: "status=lost", wantErr: true},
+		{name: "Date range", query: "from_date=2024-02-01&to_date=2024-02-29"},
+		{name: "Malformed date", query: "from_date=01-02-2024", wantErr: true},
+		{name: "Inverted date range", query: "from_date=2024-03-01&to_date=2024-02-01", wantErr: true},
+		{name: "Limit above maximum", query: "limit=500", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/rides?"+tt.query, nil)
+
+			input, err := bindListRidesQuery(c)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatuses, input.Statuses)
+		})
+	}
+
+	t.Run("Dates cover whole days", func(t *testing.T) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/rides?from_date=2024-02-01&to_date=2024-02-01", nil)
+
+		input, err := bindListRidesQuery(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, input.From.Hour())
+		assert.Equal(t, 23, input.To.Hour())
+		assert.Equal(t, 59, input.To.Minute())
+	})
+}
+
+func TestRidePathFeature(t *testing.T) {
+	recordedAt := time.Date(2024, 2, 20, 12, 0, 0, 0, time.UTC)
+	pings := []models.LocationPing{
+		{Location: models.Location{Latitude: 23.8103, Longitude: 90.4125}, RecordedAt: recordedAt},
+		{Location: models.Location{Latitude: 23.8150, Longitude: 90.4130}, RecordedAt: recordedAt.Add(time.Minute)},
+	}
+
+	feature := ridePathFeature("ride-1", pings)
+
+	if assert.NotNil(t, feature.Geometry) {
+		assert.Equal(t, "LineString", feature.Geometry.Type)
+		// GeoJSON positions are longitude first
+		assert.Equal(t, []geojson.Position{{90.4125, 23.8103}, {90.4130, 23.8150}}, feature.Geometry.Coordinates)
+	}
+	assert.Equal(t, "ride-1", feature.Properties["ride_id"])
+
+	assert.Nil(t, ridePathFeature("ride-1", pings[:1]).Geometry)
+}
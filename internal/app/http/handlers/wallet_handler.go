@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+)
+
+// idempotencyKeyHeader carries the client's key for retrying a top-up safely
+const idempotencyKeyHeader = "Idempotency-Key"
+
+type topUpRequest struct {
+	Amount float64 `json:"amount" binding:"required,gt=0"`
+}
+
+type listWalletTransactionsQuery struct {
+	Page  int `form:"page" binding:"omitempty,min=1"`
+	Limit int `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type WalletHandler struct {
+	walletService services.WalletService
+}
+
+func NewWalletHandler(walletService services.WalletService) *WalletHandler {
+	return &WalletHandler{
+		walletService: walletService,
+	}
+}
+
+func (h *WalletHandler) GetWallet(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	wallet, err := h.walletService.GetWallet(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    wallet,
+	})
+}
+
+func (h *WalletHandler) TopUp(c *gin.Context) {
+	key := c.GetHeader(idempotencyKeyHeader)
+	if key == "" || len(key) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": idempotencyKeyHeader + " header must be 1 to 100 characters"})
+		return
+	}
+
+	var req topUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+
+	transaction, err := h.walletService.TopUp(c.Request.Context(), user.ID, services.TopUpInput{
+		Amount:         req.Amount,
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == errors.ErrPaymentDeclined {
+			status = http.StatusPaymentRequired
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    transaction,
+	})
+}
+
+func (h *WalletHandler) ListTransactions(c *gin.Context) {
+	var query listWalletTransactionsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+
+	list, err := h.walletService.ListTransactions(c.Request.Context(), user.ID, services.ListWalletTransactionsInput{
+		Page:  query.Page,
+		Limit: query.Limit,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"transactions": list.Transactions,
+		},
+		"metadata": gin.H{
+			"total":    list.Total,
+			"page":     list.Page,
+			"limit":    list.Limit,
+			"has_more": list.HasMore,
+		},
+	})
+}
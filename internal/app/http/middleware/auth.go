@@ -47,6 +47,21 @@ func (m *AuthMiddleware) Authenticate() gin.HandlerFunc {
 	}
 }
 
+// AllowQueryToken accepts the access token from the access_token query
+// parameter when no Authorization header is sent, for clients such as
+// browser WebSockets that cannot set headers. It must run before
+// Authenticate.
+func (m *AuthMiddleware) AllowQueryToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if token := c.Query("access_token"); token != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		c.Next()
+	}
+}
+
 func (m *AuthMiddleware) RequireDriver() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		user, exists := c.Get("user")
@@ -84,3 +99,22 @@ func (m *AuthMiddleware) RequireRider() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+func (m *AuthMiddleware) RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, exists := c.Get("user")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found in context"})
+			c.Abort()
+			return
+		}
+
+		if u, ok := user.(*models.User); !ok || !u.IsAdmin() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -1,24 +1,53 @@
 package router
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	"github.com/sayeed1999/share-a-ride/internal/app/http/handlers"
 	"github.com/sayeed1999/share-a-ride/internal/app/http/middleware"
 )
 
 type Router struct {
-	engine         *gin.Engine
-	authHandler    *handlers.AuthHandler
-	driverHandler  *handlers.DriverHandler
-	authMiddleware *middleware.AuthMiddleware
+	engine          *gin.Engine
+	authHandler     *handlers.AuthHandler
+	driverHandler   *handlers.DriverHandler
+	rideHandler     *handlers.RideHandler
+	streamHandler   *handlers.LocationStreamHandler
+	adminHandler    *handlers.AdminHandler
+	walletHandler   *handlers.WalletHandler
+	earningsHandler *handlers.EarningsHandler
+	ratingHandler   *handlers.RatingHandler
+	authMiddleware  *middleware.AuthMiddleware
+	// fileServer serves signed downloads of stored files; nil when the
+	// object store serves them itself
+	fileServer http.Handler
 }
 
-func New(authHandler *handlers.AuthHandler, driverHandler *handlers.DriverHandler, authMiddleware *middleware.AuthMiddleware) *Router {
+func New(
+	authHandler *handlers.AuthHandler,
+	driverHandler *handlers.DriverHandler,
+	rideHandler *handlers.RideHandler,
+	streamHandler *handlers.LocationStreamHandler,
+	adminHandler *handlers.AdminHandler,
+	walletHandler *handlers.WalletHandler,
+	earningsHandler *handlers.EarningsHandler,
+	ratingHandler *handlers.RatingHandler,
+	authMiddleware *middleware.AuthMiddleware,
+	fileServer http.Handler,
+) *Router {
 	r := &Router{
-		engine:         gin.Default(),
-		authHandler:    authHandler,
-		driverHandler:  driverHandler,
-		authMiddleware: authMiddleware,
+		engine:          gin.Default(),
+		authHandler:     authHandler,
+		driverHandler:   driverHandler,
+		rideHandler:     rideHandler,
+		streamHandler:   streamHandler,
+		adminHandler:    adminHandler,
+		walletHandler:   walletHandler,
+		earningsHandler: earningsHandler,
+		ratingHandler:   ratingHandler,
+		authMiddleware:  authMiddleware,
+		fileServer:      fileServer,
 	}
 	return r
 }
@@ -52,5 +81,76 @@ func (r *Router) SetupRoutes() {
 		drivers.PUT("/availability", r.authMiddleware.RequireDriver(), r.driverHandler.UpdateAvailability)
 		drivers.GET("/profile", r.authMiddleware.RequireDriver(), r.driverHandler.GetProfile)
 		drivers.GET("/documents", r.authMiddleware.RequireDriver(), r.driverHandler.GetDocuments)
+		drivers.POST("/documents", r.authMiddleware.RequireDriver(), r.driverHandler.AddDocument)
+		drivers.PUT("/documents/:id", r.authMiddleware.RequireDriver(), r.driverHandler.ReplaceDocument)
+		drivers.DELETE("/documents/:id", r.authMiddleware.RequireDriver(), r.driverHandler.DeleteDocument)
+		drivers.POST("/documents/uploads", r.authMiddleware.RequireDriver(), r.driverHandler.UploadDocument)
+		drivers.GET("/vehicles", r.authMiddleware.RequireDriver(), r.driverHandler.ListVehicles)
+		drivers.POST("/vehicles", r.authMiddleware.RequireDriver(), r.driverHandler.AddVehicle)
+		drivers.POST("/vehicles/:id/activate", r.authMiddleware.RequireDriver(), r.driverHandler.ActivateVehicle)
+		drivers.GET("/rides", r.authMiddleware.RequireDriver(), r.driverHandler.GetRides)
+		drivers.POST("/rides/:id/arrive", r.authMiddleware.RequireDriver(), r.driverHandler.ArriveAtPickup)
+		drivers.POST("/rides/:id/start", r.authMiddleware.RequireDriver(), r.driverHandler.StartRide)
+		drivers.POST("/rides/:id/complete", r.authMiddleware.RequireDriver(), r.driverHandler.CompleteRide)
+		drivers.POST("/rides/:id/rating", r.authMiddleware.RequireDriver(), r.ratingHandler.RateRider)
+		drivers.GET("/earnings", r.authMiddleware.RequireDriver(), r.earningsHandler.GetEarnings)
+		drivers.GET("/earnings/statements/:id", r.authMiddleware.RequireDriver(), r.earningsHandler.GetStatement)
+		drivers.GET("/offers/current", r.authMiddleware.RequireDriver(), r.driverHandler.GetCurrentOffer)
+		drivers.POST("/offers/:id/respond", r.authMiddleware.RequireDriver(), r.driverHandler.RespondToOffer)
+		drivers.GET("/pool", r.authMiddleware.RequireDriver(), r.driverHandler.GetPool)
+	}
+
+	// Rider ride routes
+	rides := r.engine.Group("/rides")
+	rides.Use(r.authMiddleware.Authenticate(), r.authMiddleware.RequireRider())
+	{
+		rides.POST("", r.rideHandler.CreateRide)
+		rides.POST("/estimate", r.rideHandler.EstimateFare)
+		rides.POST("/estimate/promo", r.rideHandler.ApplyPromoCode)
+		rides.GET("", r.rideHandler.ListRides)
+		rides.GET("/current", r.rideHandler.GetCurrentRide)
+		rides.POST("/:id/cancel", r.rideHandler.CancelRide)
+		rides.POST("/:id/waypoints", r.rideHandler.AddWaypoint)
+		rides.DELETE("/:id/waypoints/:waypointId", r.rideHandler.RemoveWaypoint)
+		rides.POST("/:id/rating", r.ratingHandler.RateDriver)
+	}
+
+	// Rider wallet routes
+	wallet := r.engine.Group("/wallet")
+	wallet.Use(r.authMiddleware.Authenticate(), r.authMiddleware.RequireRider())
+	{
+		wallet.GET("", r.walletHandler.GetWallet)
+		wallet.POST("/top-ups", r.walletHandler.TopUp)
+		wallet.GET("/transactions", r.walletHandler.ListTransactions)
+	}
+
+	// Ride routes open to both participants of a ride
+	r.engine.GET("/rides/:id/path", r.authMiddleware.Authenticate(), r.rideHandler.GetRidePath)
+
+	// Live location stream for drivers and riders
+	r.engine.GET("/ws/location",
+		r.authMiddleware.AllowQueryToken(),
+		r.authMiddleware.Authenticate(),
+		r.streamHandler.Stream,
+	)
+
+	// Signed file downloads; the signature in the URL stands in for
+	// authentication
+	if r.fileServer != nil {
+		r.engine.GET("/files/*key", gin.WrapH(http.StripPrefix("/files", r.fileServer)))
+	}
+
+	// Operations dashboard routes
+	admin := r.engine.Group("/admin")
+	admin.Use(r.authMiddleware.Authenticate(), r.authMiddleware.RequireAdmin())
+	{
+		admin.GET("/surge/heatmap", r.adminHandler.SurgeHeatmap)
+		admin.POST("/promos", r.adminHandler.CreatePromoCode)
+		admin.GET("/promos", r.adminHandler.ListPromoCodes)
+		admin.POST("/rides/:id/refund", r.adminHandler.RefundRide)
+		admin.GET("/drivers/verifications", r.adminHandler.ListDriverVerifications)
+		admin.POST("/drivers/:id/approve", r.adminHandler.ApproveDriver)
+		admin.POST("/drivers/:id/reject", r.adminHandler.RejectDriver)
+		admin.POST("/drivers/:id/request-resubmission", r.adminHandler.RequestDriverResubmission)
 	}
 }
@@ -0,0 +1,27 @@
+// Package jobs runs the application's periodic background work.
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+)
+
+// Every calls fn once per interval until ctx is cancelled. A failing run is
+// logged and does not stop later runs. Every blocks, so it is usually
+// started in its own goroutine.
+func Every(ctx context.Context, clk clock.Clock, interval time.Duration, name string, fn func(ctx context.Context) error) {
+	for {
+		select {
+		case <-clk.After(interval):
+		case <-ctx.Done():
+			return
+		}
+
+		if err := fn(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("job %s failed: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+)
+
+func TestEveryRunsUntilCancelled(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 2, 20, 12, 0, 0, 0, time.UTC))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	runs := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Every(ctx, clk, time.Minute, "test", func(ctx context.Context) error {
+			runs <- struct{}{}
+			return errors.New("failing runs do not stop the job")
+		})
+	}()
+
+	for i := 0; i < 2; i++ {
+		clk.BlockUntil(1)
+		clk.Advance(time.Minute)
+		<-runs
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("job did not stop after cancel")
+	}
+	assert.Empty(t, runs)
+}
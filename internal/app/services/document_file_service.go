@@ -0,0 +1,185 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+	"github.com/sayeed1999/share-a-ride/internal/provider/storage"
+)
+
+// documentExtensions lists the accepted document formats by the content
+// type sniffed from their first bytes
+var documentExtensions = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+}
+
+// documentKeyRoot is the prefix of the keys of all document files
+const documentKeyRoot = "documents"
+
+// documentFileName matches the checksum and extension that name a stored
+// document file
+var documentFileName = regexp.MustCompile(`^[0-9a-f]{64}\.(pdf|jpg|png)$`)
+
+type documentFileService struct {
+	store      storage.ObjectStore
+	driverRepo repositories.DriverRepository
+	clock      clock.Clock
+	config     config.DocumentConfig
+}
+
+func NewDocumentFileService(store storage.ObjectStore, driverRepo repositories.DriverRepository, clk clock.Clock, cfg config.DocumentConfig) services.DocumentFileService {
+	return &documentFileService{
+		store:      store,
+		driverRepo: driverRepo,
+		clock:      clk,
+		config:     cfg,
+	}
+}
+
+// Upload keys files by their owner and checksum, so uploading the same
+// file twice stores it once.
+func (s *documentFileService) Upload(ctx context.Context, userID string, file io.Reader) (*services.UploadedFile, error) {
+	data, err := io.ReadAll(io.LimitReader(file, s.config.MaxUploadBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.config.MaxUploadBytes {
+		return nil, errors.ErrDocumentTooLarge
+	}
+
+	// The client's filename and content type are not trusted
+	contentType := http.DetectContentType(data)
+	ext, ok := documentExtensions[contentType]
+	if len(data) == 0 || !ok {
+		return nil, errors.ErrUnsupportedDocumentFormat
+	}
+
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+	key := path.Join(documentKeyPrefix(userID), checksum+ext)
+	if err := s.store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return nil, err
+	}
+
+	url, err := s.store.SignedURL(ctx, key, s.config.URLExpiry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &services.UploadedFile{
+		FileKey:      key,
+		ContentType:  contentType,
+		SizeBytes:    int64(len(data)),
+		Checksum:     checksum,
+		URL:          url,
+		URLExpiresAt: s.clock.Now().Add(s.config.URLExpiry),
+	}, nil
+}
+
+func (s *documentFileService) Resolve(ctx context.Context, userID string, fileKey string) (*models.DocumentFile, error) {
+	// Drivers may only submit files they uploaded themselves
+	name := strings.TrimPrefix(fileKey, documentKeyPrefix(userID)+"/")
+	if name == fileKey || !documentFileName.MatchString(name) {
+		return nil, errors.ErrUploadNotFound
+	}
+
+	info, err := s.store.Stat(ctx, fileKey)
+	if err == storage.ErrNotFound {
+		return nil, errors.ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.DocumentFile{
+		StorageKey:  fileKey,
+		ContentType: info.ContentType,
+		SizeBytes:   info.Size,
+		Checksum:    strings.TrimSuffix(name, path.Ext(name)),
+	}, nil
+}
+
+func (s *documentFileService) SignURLs(ctx context.Context, documents []models.Document) error {
+	for i := range documents {
+		if !documents[i].IsStored() {
+			continue
+		}
+		url, err := s.store.SignedURL(ctx, documents[i].StorageKey, s.config.URLExpiry)
+		if err != nil {
+			return err
+		}
+		documents[i].FileURL = url
+	}
+	return nil
+}
+
+func (s *documentFileService) Discard(ctx context.Context, keys []string) error {
+	_, err := s.deleteUnused(ctx, keys)
+	return err
+}
+
+// SweepUploads removes the files of uploads that were never submitted, and
+// of documents whose removal was not followed by Discard. Uploading a file
+// again renews it.
+func (s *documentFileService) SweepUploads(ctx context.Context) (int, error) {
+	objects, err := s.store.List(ctx, documentKeyRoot+"/")
+	if err != nil {
+		return 0, err
+	}
+	cutoff := s.clock.Now().Add(-s.config.URLExpiry)
+	var keys []string
+	for _, object := range objects {
+		if object.ModifiedAt.Before(cutoff) {
+			keys = append(keys, object.Key)
+		}
+	}
+	return s.deleteUnused(ctx, keys)
+}
+
+// deleteUnused deletes the files under keys that no document refers to
+// and returns how many it deleted
+func (s *documentFileService) deleteUnused(ctx context.Context, keys []string) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	inUse, err := s.driverRepo.FindStorageKeysInUse(ctx, keys)
+	if err != nil {
+		return 0, err
+	}
+	used := make(map[string]bool, len(inUse))
+	for _, key := range inUse {
+		used[key] = true
+	}
+
+	deleted := 0
+	for _, key := range keys {
+		if used[key] {
+			continue
+		}
+		if err := s.store.Delete(ctx, key); err != nil {
+			return deleted, err
+		}
+		used[key] = true
+		deleted++
+	}
+	return deleted, nil
+}
+
+func documentKeyPrefix(userID string) string {
+	return documentKeyRoot + "/" + userID
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+	"github.com/sayeed1999/share-a-ride/internal/provider/storage"
+)
+
+func TestDocumentFiles(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(now)
+	store, err := storage.NewLocal(t.TempDir(), "http://localhost:8080/files", []byte("secret"), clk)
+	require.NoError(t, err)
+	svc := NewDocumentFileService(store, new(MockDriverRepository), clk, config.DocumentConfig{MaxUploadBytes: 64, URLExpiry: time.Minute})
+
+	pdf := []byte("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n1 0 obj\n<<>>\nendobj\n")
+
+	t.Run("stores a pdf under its checksum", func(t *testing.T) {
+		uploaded, err := svc.Upload(ctx, "user-1", bytes.NewReader(pdf))
+
+		require.NoError(t, err)
+		assert.Equal(t, "application/pdf", uploaded.ContentType)
+		assert.Equal(t, int64(len(pdf)), uploaded.SizeBytes)
+		assert.Equal(t, "documents/user-1/"+uploaded.Checksum+".pdf", uploaded.FileKey)
+		assert.Equal(t, now.Add(time.Minute), uploaded.URLExpiresAt)
+
+		file, err := svc.Resolve(ctx, "user-1", uploaded.FileKey)
+		require.NoError(t, err)
+		assert.Equal(t, models.DocumentFile{
+			StorageKey:  uploaded.FileKey,
+			ContentType: "application/pdf",
+			SizeBytes:   int64(len(pdf)),
+			Checksum:    uploaded.Checksum,
+		}, *file)
+	})
+
+	t.Run("only the uploader can submit the file", func(t *testing.T) {
+		uploaded, err := svc.Upload(ctx, "user-1", bytes.NewReader(pdf))
+		require.NoError(t, err)
+
+		_, err = svc.Resolve(ctx, "user-2", uploaded.FileKey)
+		assert.Equal(t, errors.ErrUploadNotFound, err)
+
+		_, err = svc.Resolve(ctx, "user-1", "documents/user-1/../user-2/"+uploaded.Checksum+".pdf")
+		assert.Equal(t, errors.ErrUploadNotFound, err)
+	})
+
+	t.Run("rejects other formats", func(t *testing.T) {
+		_, err := svc.Upload(ctx, "user-1", strings.NewReader("<html><body>not a licence</body></html>"))
+		assert.Equal(t, errors.ErrUnsupportedDocumentFormat, err)
+
+		_, err = svc.Upload(ctx, "user-1", strings.NewReader(""))
+		assert.Equal(t, errors.ErrUnsupportedDocumentFormat, err)
+	})
+
+	t.Run("rejects files over the limit", func(t *testing.T) {
+		_, err := svc.Upload(ctx, "user-1", bytes.NewReader(append(pdf, make([]byte, 64)...)))
+		assert.Equal(t, errors.ErrDocumentTooLarge, err)
+	})
+
+	t.Run("signs urls of stored documents only", func(t *testing.T) {
+		uploaded, err := svc.Upload(ctx, "user-1", bytes.NewReader(pdf))
+		require.NoError(t, err)
+		documents := []models.Document{
+			{DocumentFile: models.DocumentFile{StorageKey: uploaded.FileKey}},
+			{FileURL: "https://example.com/license.jpg"},
+		}
+
+		require.NoError(t, svc.SignURLs(ctx, documents))
+
+		assert.True(t, strings.HasPrefix(documents[0].FileURL, "http://localhost:8080/files/"+uploaded.FileKey+"?"))
+		assert.Equal(t, "https://example.com/license.jpg", documents[1].FileURL)
+	})
+}
+
+func TestDocumentFileCleanup(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	pdf := []byte("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n1 0 obj\n<<>>\nendobj\n")
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	newTestService := func(t *testing.T) (services.DocumentFileService, *MockDriverRepository, *clock.Fake) {
+		clk := clock.NewFake(now)
+		store, err := storage.NewLocal(t.TempDir(), "http://localhost:8080/files", []byte("secret"), clk)
+		require.NoError(t, err)
+		driverRepo := new(MockDriverRepository)
+		return NewDocumentFileService(store, driverRepo, clk, config.DocumentConfig{MaxUploadBytes: 64, URLExpiry: time.Minute}), driverRepo, clk
+	}
+
+	t.Run("discard keeps files still referred to", func(t *testing.T) {
+		svc, driverRepo, _ := newTestService(t)
+		kept, err := svc.Upload(ctx, "user-1", bytes.NewReader(pdf))
+		require.NoError(t, err)
+		dropped, err := svc.Upload(ctx, "user-1", bytes.NewReader(png))
+		require.NoError(t, err)
+		keys := []string{kept.FileKey, dropped.FileKey}
+		driverRepo.On("FindStorageKeysInUse", ctx, keys).Return([]string{kept.FileKey}, nil)
+
+		require.NoError(t, svc.Discard(ctx, keys))
+
+		_, err = svc.Resolve(ctx, "user-1", kept.FileKey)
+		assert.NoError(t, err)
+		_, err = svc.Resolve(ctx, "user-1", dropped.FileKey)
+		assert.Equal(t, errors.ErrUploadNotFound, err)
+	})
+
+	t.Run("sweep deletes unsubmitted uploads once their url expired", func(t *testing.T) {
+		svc, driverRepo, clk := newTestService(t)
+		submitted, err := svc.Upload(ctx, "user-1", bytes.NewReader(pdf))
+		require.NoError(t, err)
+		abandoned, err := svc.Upload(ctx, "user-2", bytes.NewReader(pdf))
+		require.NoError(t, err)
+		clk.Advance(2 * time.Minute)
+		fresh, err := svc.Upload(ctx, "user-1", bytes.NewReader(png))
+		require.NoError(t, err)
+		driverRepo.On("FindStorageKeysInUse", ctx, mock.MatchedBy(func(keys []string) bool {
+			return assert.ElementsMatch(t, []string{submitted.FileKey, abandoned.FileKey}, keys)
+		})).Return([]string{submitted.FileKey}, nil)
+
+		deleted, err := svc.SweepUploads(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, deleted)
+		_, err = svc.Resolve(ctx, "user-2", abandoned.FileKey)
+		assert.Equal(t, errors.ErrUploadNotFound, err)
+		_, err = svc.Resolve(ctx, "user-1", submitted.FileKey)
+		assert.NoError(t, err)
+		_, err = svc.Resolve(ctx, "user-1", fresh.FileKey)
+		assert.NoError(t, err)
+	})
+}
@@ -2,22 +2,89 @@ package services
 
 import (
 	"context"
+	"log"
+	"sync"
+	"time"
 
+	"github.com/sayeed1999/share-a-ride/internal/config"
 	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
 	"github.com/sayeed1999/share-a-ride/internal/domain/models"
 	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
 	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/geo"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/geoindex"
 )
 
+// DriverIndex holds the available, verified drivers by current location
+type DriverIndex = geoindex.Index[models.Driver]
+
+func NewDriverIndex() *DriverIndex {
+	return geoindex.New[models.Driver](geoindex.DefaultCellSizeDeg)
+}
+
+// WarmDriverIndex loads every available, verified driver into index. It is
+// meant to be called once at startup before serving requests.
+func WarmDriverIndex(ctx context.Context, driverRepo repositories.DriverRepository, index *DriverIndex) error {
+	drivers, err := driverRepo.FindAllAvailable(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range drivers {
+		indexDriver(index, &drivers[i])
+	}
+	return nil
+}
+
+// indexDriver adds driver to index when it can take rides and removes it
+// otherwise
+func indexDriver(index *DriverIndex, driver *models.Driver) {
+	if !driver.IsAvailable || !driver.IsVerified() {
+		index.Remove(driver.ID)
+		return
+	}
+
+	snapshot := *driver
+	snapshot.User = nil
+	snapshot.Documents = nil
+	snapshot.Vehicles = nil
+	if driver.ActiveVehicle != nil {
+		vehicle := *driver.ActiveVehicle
+		snapshot.ActiveVehicle = &vehicle
+	}
+	index.Upsert(driver.ID, driver.CurrentLocation.Latitude, driver.CurrentLocation.Longitude, snapshot)
+}
+
 type driverService struct {
-	driverRepo repositories.DriverRepository
-	userRepo   repositories.UserRepository
+	driverRepo    repositories.DriverRepository
+	userRepo      repositories.UserRepository
+	documentFiles services.DocumentFileService
+	index         *DriverIndex
+	clock         clock.Clock
+	config        config.LocationConfig
+
+	mu sync.Mutex
+	// lastFix is when each driver's last location update was accepted, so
+	// that throttled updates are turned away before touching the database
+	lastFix map[string]time.Time
 }
 
-func NewDriverService(driverRepo repositories.DriverRepository, userRepo repositories.UserRepository) services.DriverService {
+func NewDriverService(
+	driverRepo repositories.DriverRepository,
+	userRepo repositories.UserRepository,
+	documentFiles services.DocumentFileService,
+	index *DriverIndex,
+	clk clock.Clock,
+	cfg config.LocationConfig,
+) services.DriverService {
 	return &driverService{
-		driverRepo: driverRepo,
-		userRepo:   userRepo,
+		driverRepo:    driverRepo,
+		userRepo:      userRepo,
+		documentFiles: documentFiles,
+		index:         index,
+		clock:         clk,
+		config:        cfg,
+		lastFix:       make(map[string]time.Time),
 	}
 }
 
@@ -31,23 +98,30 @@ func (s *driverService) VerifyDriver(ctx context.Context, userID string, input s
 		return nil, errors.ErrUnauthorizedAccess
 	}
 
-	// Check if driver already exists
-	if _, err := s.driverRepo.FindByUserID(ctx, userID); err == nil {
-		return nil, errors.ErrDriverExists
+	// Check if driver already exists; drivers asked to resubmit send their
+	// details again
+	if existing, err := s.driverRepo.FindByUserID(ctx, userID); err == nil {
+		if existing.VerificationStatus != models.VerificationStatusResubmissionRequired {
+			return nil, errors.ErrDriverExists
+		}
+		return s.resubmit(ctx, existing, input)
 	}
 
 	// Check if license number is already registered
 	if _, err := s.driverRepo.FindByLicenseNumber(ctx, input.LicenseNumber); err == nil {
 		return nil, errors.ErrLicenseExists
 	}
+	if _, err := s.driverRepo.FindVehicleByPlateNumber(ctx, input.Vehicle.PlateNumber); err == nil {
+		return nil, errors.ErrPlateNumberExists
+	}
 
 	// Create driver
 	driver := models.NewDriver(userID, input.LicenseNumber, input.Vehicle)
 
 	// Create driver documents
-	for _, doc := range input.Documents {
-		document := models.NewDocument(driver.ID, doc.Type, doc.FileURL)
-		driver.Documents = append(driver.Documents, *document)
+	driver.Documents, err = s.newDocuments(ctx, driver, input.Documents)
+	if err != nil {
+		return nil, err
 	}
 
 	// Save driver
@@ -58,21 +132,161 @@ func (s *driverService) VerifyDriver(ctx context.Context, userID string, input s
 	return driver, nil
 }
 
+// resubmit replaces the details of a driver asked to correct them and puts
+// the driver back in the review queue.
+func (s *driverService) resubmit(ctx context.Context, driver *models.Driver, input services.VerifyDriverInput) (*models.Driver, error) {
+	if other, err := s.driverRepo.FindByLicenseNumber(ctx, input.LicenseNumber); err == nil && other.ID != driver.ID {
+		return nil, errors.ErrLicenseExists
+	}
+	if other, err := s.driverRepo.FindVehicleByPlateNumber(ctx, input.Vehicle.PlateNumber); err == nil &&
+		(driver.ActiveVehicle == nil || other.ID != driver.ActiveVehicle.ID) {
+		return nil, errors.ErrPlateNumberExists
+	}
+
+	driver.Resubmit(input.LicenseNumber, input.Vehicle, s.clock.Now())
+	documents, err := s.newDocuments(ctx, driver, input.Documents)
+	if err != nil {
+		return nil, err
+	}
+	driver.Documents = documents
+
+	removed, err := s.driverRepo.Resubmit(ctx, driver, models.VerificationStatusResubmissionRequired)
+	if err != nil {
+		return nil, err
+	}
+	s.discardFiles(ctx, removed...)
+
+	return driver, nil
+}
+
+// newDocuments creates the documents of driver from files its user
+// uploaded.
+func (s *driverService) newDocuments(ctx context.Context, driver *models.Driver, inputs []services.DocumentInput) ([]models.Document, error) {
+	documents := make([]models.Document, 0, len(inputs))
+	for _, input := range inputs {
+		document, err := s.newDocument(ctx, driver, input)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, *document)
+	}
+	return documents, nil
+}
+
+func (s *driverService) newDocument(ctx context.Context, driver *models.Driver, input services.DocumentInput) (*models.Document, error) {
+	if input.ExpiresOn == nil && input.Type.RequiresExpiry() {
+		return nil, errors.ErrDocumentExpiryRequired
+	}
+	if input.IssuedOn != nil && input.ExpiresOn != nil && input.ExpiresOn.Before(*input.IssuedOn) {
+		return nil, errors.ErrInvalidDocumentDates
+	}
+
+	// Vehicle documents belong to the active vehicle unless another one of
+	// the driver's vehicles is named
+	var vehicle *models.Vehicle
+	if input.Type.IsVehicleDocument() {
+		vehicle = driver.ActiveVehicle
+		if input.VehicleID != "" {
+			vehicle = driver.FindVehicle(input.VehicleID)
+		}
+		if vehicle == nil {
+			return nil, errors.ErrVehicleNotFound
+		}
+	}
+
+	file, err := s.documentFiles.Resolve(ctx, driver.UserID, input.FileKey)
+	if err != nil {
+		return nil, err
+	}
+	document := models.NewDocument(driver.ID, input.Type, *file)
+	if vehicle != nil {
+		document.VehicleID = &vehicle.ID
+	}
+	document.IssuedOn = input.IssuedOn
+	document.ExpiresOn = input.ExpiresOn
+	if document.IsLapsed(s.clock.Now()) {
+		return nil, errors.ErrDocumentExpired
+	}
+	return document, nil
+}
+
 func (s *driverService) UpdateLocation(ctx context.Context, driverID string, input services.UpdateLocationInput) error {
+	if input.Latitude < -90 || input.Latitude > 90 || input.Longitude < -180 || input.Longitude > 180 {
+		return errors.ErrInvalidLocation
+	}
+
+	now := s.clock.Now()
+	if !s.claimFix(driverID, now) {
+		return errors.ErrLocationThrottled
+	}
+
 	// Validate driver
 	driver, err := s.driverRepo.FindByID(ctx, driverID)
 	if err != nil {
+		s.releaseFix(driverID, now)
 		return errors.ErrDriverNotFound
 	}
 
+	if !s.plausibleMove(driver, input, now) {
+		s.releaseFix(driverID, now)
+		return errors.ErrImplausibleLocation
+	}
+
 	// Update location
-	if err := s.driverRepo.UpdateLocation(ctx, driver.ID, input.Latitude, input.Longitude); err != nil {
+	if err := s.driverRepo.UpdateLocation(ctx, driver.ID, input.Latitude, input.Longitude, now); err != nil {
+		s.releaseFix(driverID, now)
 		return err
 	}
 
+	driver.UpdateLocation(input.Latitude, input.Longitude, now)
+	indexDriver(s.index, driver)
+
 	return nil
 }
 
+// claimFix records a location update of driverID at now unless the
+// previous one was accepted less than MinUpdateInterval ago.
+func (s *driverService) claimFix(driverID string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if last, ok := s.lastFix[driverID]; ok && now.Sub(last) < s.config.MinUpdateInterval {
+		return false
+	}
+	s.lastFix[driverID] = now
+	return true
+}
+
+// releaseFix forgets a claimed update that was not stored, so that the
+// driver can retry straight away.
+func (s *driverService) releaseFix(driverID string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if last, ok := s.lastFix[driverID]; ok && last.Equal(at) {
+		delete(s.lastFix, driverID)
+	}
+}
+
+// plausibleMove reports whether a driver could have travelled from its
+// previous fix to input without exceeding MaxSpeedKmh. The first fix of a
+// driver is always plausible.
+func (s *driverService) plausibleMove(driver *models.Driver, input services.UpdateLocationInput, now time.Time) bool {
+	if s.config.MaxSpeedKmh <= 0 || driver.LocationUpdatedAt == nil {
+		return true
+	}
+
+	distanceKm := geo.HaversineKm(
+		driver.CurrentLocation.Latitude, driver.CurrentLocation.Longitude,
+		input.Latitude, input.Longitude,
+	)
+	elapsed := now.Sub(*driver.LocationUpdatedAt)
+	if elapsed <= 0 {
+		return distanceKm == 0
+	}
+	return distanceKm/elapsed.Hours() <= s.config.MaxSpeedKmh
+}
+
 func (s *driverService) UpdateAvailability(ctx context.Context, driverID string, isAvailable bool) error {
 	// Validate driver
 	driver, err := s.driverRepo.FindByID(ctx, driverID)
@@ -81,18 +295,59 @@ func (s *driverService) UpdateAvailability(ctx context.Context, driverID string,
 	}
 
 	// Check if driver is verified
-	if !driver.IsVerified {
+	if !driver.IsVerified() {
 		return errors.ErrDriverNotVerified
 	}
+	if isAvailable && driver.ActiveVehicle == nil {
+		return errors.ErrVehicleNotFound
+	}
 
 	// Update availability
 	if err := s.driverRepo.UpdateAvailability(ctx, driver.ID, isAvailable); err != nil {
 		return err
 	}
 
+	driver.UpdateAvailability(isAvailable)
+	indexDriver(s.index, driver)
+
 	return nil
 }
 
+func (s *driverService) ExpireStaleLocations(ctx context.Context) (int, error) {
+	cutoff := s.clock.Now().Add(-s.config.StaleAfter)
+	ids, err := s.driverRepo.MarkStaleUnavailable(ctx, cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	// Throttling state of drivers that went quiet is no longer needed
+	s.mu.Lock()
+	for id, at := range s.lastFix {
+		if at.Before(cutoff) {
+			delete(s.lastFix, id)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, id := range ids {
+		s.index.Remove(id)
+	}
+	if len(ids) > 0 {
+		log.Printf("marked %d drivers with stale locations unavailable", len(ids))
+	}
+	return len(ids), nil
+}
+
+func (s *driverService) NearbyDrivers(ctx context.Context, lat, lng, radiusKm float64, k int) ([]models.Driver, error) {
+	neighbors := s.index.Nearby(lat, lng, radiusKm, k)
+
+	drivers := make([]models.Driver, len(neighbors))
+	for i, n := range neighbors {
+		drivers[i] = n.Value
+	}
+	return drivers, nil
+}
+
 func (s *driverService) GetDriverProfile(ctx context.Context, driverID string) (*models.Driver, error) {
 	driver, err := s.driverRepo.FindByID(ctx, driverID)
 	if err != nil {
@@ -109,19 +364,89 @@ func (s *driverService) GetDriverByUserID(ctx context.Context, userID string) (*
 	return driver, nil
 }
 
-func (s *driverService) AddDocument(ctx context.Context, driverID string, input services.DocumentInput) error {
+func (s *driverService) AddDocument(ctx context.Context, driverID string, input services.DocumentInput) (*models.Document, error) {
 	// Validate driver
 	driver, err := s.driverRepo.FindByID(ctx, driverID)
 	if err != nil {
-		return errors.ErrDriverNotFound
+		return nil, errors.ErrDriverNotFound
 	}
 
 	// Create and save document
-	document := models.NewDocument(driver.ID, input.Type, input.FileURL)
-	if err := s.driverRepo.AddDocument(ctx, document); err != nil {
+	document, err := s.newDocument(ctx, driver, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.saveDocument(ctx, driver, document, ""); err != nil {
+		return nil, err
+	}
+
+	return s.signDocument(ctx, document)
+}
+
+func (s *driverService) ReplaceDocument(ctx context.Context, driverID string, documentID string, input services.DocumentInput) (*models.Document, error) {
+	// Validate driver
+	driver, err := s.driverRepo.FindByID(ctx, driverID)
+	if err != nil {
+		return nil, errors.ErrDriverNotFound
+	}
+	replaced := findDocument(driver.Documents, documentID)
+	if replaced == nil {
+		return nil, errors.ErrDocumentNotFound
+	}
+
+	// Create and save the replacement
+	input.Type = replaced.Type
+	input.VehicleID = ""
+	if replaced.VehicleID != nil {
+		input.VehicleID = *replaced.VehicleID
+	}
+	document, err := s.newDocument(ctx, driver, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.saveDocument(ctx, driver, document, replaced.ID); err != nil {
+		return nil, err
+	}
+	s.discardFiles(ctx, *replaced)
+
+	return s.signDocument(ctx, document)
+}
+
+// saveDocument stores document in place of replacedID, if not empty. An
+// approved driver whose required documents change is put back in the
+// review queue in the same write, so they are never approved on documents
+// nobody has seen. Documents of vehicles the driver is not driving are
+// reviewed once the vehicle is activated.
+func (s *driverService) saveDocument(ctx context.Context, driver *models.Driver, document *models.Document, replacedID string) error {
+	drivenWith := document.VehicleID == nil ||
+		(driver.ActiveVehicle != nil && *document.VehicleID == driver.ActiveVehicle.ID)
+	if !document.Type.IsRequired() || !drivenWith || driver.VerificationStatus != models.VerificationStatusApproved {
+		return s.driverRepo.SaveDocument(ctx, document, replacedID, nil, "")
+	}
+
+	expected := driver.VerificationStatus
+	driver.Reverify(s.clock.Now())
+	if err := s.driverRepo.SaveDocument(ctx, document, replacedID, driver, expected); err != nil {
 		return err
 	}
+	indexDriver(s.index, driver)
+	return nil
+}
+
+func (s *driverService) signDocument(ctx context.Context, document *models.Document) (*models.Document, error) {
+	documents := []models.Document{*document}
+	if err := s.documentFiles.SignURLs(ctx, documents); err != nil {
+		return nil, err
+	}
+	return &documents[0], nil
+}
 
+func findDocument(documents []models.Document, documentID string) *models.Document {
+	for i := range documents {
+		if documents[i].ID == documentID {
+			return &documents[i]
+		}
+	}
 	return nil
 }
 
@@ -136,20 +461,172 @@ func (s *driverService) GetDocuments(ctx context.Context, driverID string) ([]mo
 	if err != nil {
 		return nil, err
 	}
+	if err := s.documentFiles.SignURLs(ctx, documents); err != nil {
+		return nil, err
+	}
 
 	return documents, nil
 }
 
 func (s *driverService) DeleteDocument(ctx context.Context, driverID string, documentID string) error {
 	// Validate driver
-	if _, err := s.driverRepo.FindByID(ctx, driverID); err != nil {
+	driver, err := s.driverRepo.FindByID(ctx, driverID)
+	if err != nil {
 		return errors.ErrDriverNotFound
 	}
+	document := findDocument(driver.Documents, documentID)
+	if document == nil {
+		return errors.ErrDocumentNotFound
+	}
+
+	// The last document of a required type can only be replaced
+	if document.Type.IsRequired() {
+		held := 0
+		for _, other := range driver.Documents {
+			if other.Type == document.Type && sameVehicle(other.VehicleID, document.VehicleID) {
+				held++
+			}
+		}
+		if held == 1 {
+			return errors.ErrRequiredDocument
+		}
+	}
 
 	// Delete document
-	if err := s.driverRepo.DeleteDocument(ctx, documentID); err != nil {
+	if err := s.driverRepo.DeleteDocument(ctx, driver.ID, document.ID); err != nil {
 		return err
 	}
+	s.discardFiles(ctx, *document)
 
 	return nil
 }
+
+// discardFiles deletes the stored files of removed documents once nothing
+// refers to them. Failures are logged only; the upload sweep deletes files
+// left behind.
+func (s *driverService) discardFiles(ctx context.Context, documents ...models.Document) {
+	var keys []string
+	for _, document := range documents {
+		if document.IsStored() {
+			keys = append(keys, document.StorageKey)
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+	if err := s.documentFiles.Discard(ctx, keys); err != nil {
+		log.Printf("failed to delete files of removed documents: %v", err)
+	}
+}
+
+// sameVehicle reports whether two documents belong to the same vehicle, or
+// both to the driver
+func sameVehicle(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func (s *driverService) AddVehicle(ctx context.Context, driverID string, vehicle models.Vehicle) (*models.Vehicle, error) {
+	// Validate driver
+	driver, err := s.driverRepo.FindByID(ctx, driverID)
+	if err != nil {
+		return nil, errors.ErrDriverNotFound
+	}
+
+	// Check if plate number is already registered
+	if _, err := s.driverRepo.FindVehicleByPlateNumber(ctx, vehicle.PlateNumber); err == nil {
+		return nil, errors.ErrPlateNumberExists
+	}
+
+	// Create and save vehicle
+	created := models.NewVehicle(driver.ID, vehicle)
+	if err := s.driverRepo.AddVehicle(ctx, created); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (s *driverService) ListVehicles(ctx context.Context, driverID string) ([]models.Vehicle, error) {
+	// Validate driver
+	driver, err := s.driverRepo.FindByID(ctx, driverID)
+	if err != nil {
+		return nil, errors.ErrDriverNotFound
+	}
+
+	return driver.Vehicles, nil
+}
+
+func (s *driverService) ActivateVehicle(ctx context.Context, driverID string, vehicleID string) (*models.Vehicle, error) {
+	// Validate driver
+	driver, err := s.driverRepo.FindByID(ctx, driverID)
+	if err != nil {
+		return nil, errors.ErrDriverNotFound
+	}
+	vehicle := driver.FindVehicle(vehicleID)
+	if vehicle == nil {
+		return nil, errors.ErrVehicleNotFound
+	}
+	if vehicle.IsActive {
+		return vehicle, nil
+	}
+
+	// Offers and pools are made for the vehicle the driver is online with
+	if driver.IsAvailable {
+		return nil, errors.ErrVehicleSwitchOnline
+	}
+	now := s.clock.Now()
+	if !hasVehicleDocuments(driver.Documents, vehicle.ID, now) {
+		return nil, errors.ErrVehicleDocumentsMissing
+	}
+
+	// Documents added to the vehicle since the driver's approval have not
+	// been seen by a reviewer
+	var reviewed *models.Driver
+	var expected models.VerificationStatus
+	if driver.VerificationStatus == models.VerificationStatusApproved && hasUnreviewedDocuments(driver, vehicle.ID) {
+		expected = driver.VerificationStatus
+		driver.Reverify(now)
+		reviewed = driver
+	}
+	if err := s.driverRepo.ActivateVehicle(ctx, driver.ID, vehicle.ID, now, reviewed, expected); err != nil {
+		return nil, err
+	}
+	driver.ActivateVehicle(vehicle, now)
+
+	return vehicle, nil
+}
+
+// hasUnreviewedDocuments reports whether the vehicle has documents added
+// after the driver was last reviewed
+func hasUnreviewedDocuments(driver *models.Driver, vehicleID string) bool {
+	for _, document := range driver.Documents {
+		if !sameVehicle(document.VehicleID, &vehicleID) {
+			continue
+		}
+		if driver.ReviewedAt == nil || document.CreatedAt.After(*driver.ReviewedAt) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasVehicleDocuments reports whether documents hold a registration and an
+// insurance for the vehicle that have not lapsed at now
+func hasVehicleDocuments(documents []models.Document, vehicleID string, now time.Time) bool {
+	registered, insured := false, false
+	for _, document := range documents {
+		if !sameVehicle(document.VehicleID, &vehicleID) || document.IsLapsed(now) {
+			continue
+		}
+		switch document.Type {
+		case models.DocumentTypeRegistration:
+			registered = true
+		case models.DocumentTypeInsurance:
+			insured = true
+		}
+	}
+	return registered && insured
+}
@@ -0,0 +1,460 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+)
+
+func TestDriverIndexFollowsUpdates(t *testing.T) {
+	ctx := context.Background()
+	driverRepo := new(MockDriverRepository)
+	index := NewDriverIndex()
+	svc := NewDriverService(driverRepo, new(MockUserRepository), new(MockDocumentFileService), index, clock.New(), config.LocationConfig{})
+
+	driver := &models.Driver{ID: "driver-1", VerificationStatus: models.VerificationStatusApproved,
+		ActiveVehicle: &models.Vehicle{ID: "vehicle-1", Type: models.VehicleTypeCar, IsActive: true}}
+	driverRepo.On("FindByID", ctx, driver.ID).Return(driver, nil)
+	driverRepo.On("UpdateLocation", ctx, driver.ID, 23.8103, 90.4125, mock.Anything).Return(nil)
+	driverRepo.On("UpdateAvailability", ctx, driver.ID, true).Return(nil)
+	driverRepo.On("UpdateAvailability", ctx, driver.ID, false).Return(nil)
+
+	nearby := func() []models.Driver {
+		drivers, err := svc.NearbyDrivers(ctx, 23.8103, 90.4125, 1, 10)
+		assert.NoError(t, err)
+		return drivers
+	}
+
+	// Offline drivers are not indexed even when they move
+	assert.NoError(t, svc.UpdateLocation(ctx, driver.ID, services.UpdateLocationInput{Latitude: 23.8103, Longitude: 90.4125}))
+	assert.Empty(t, nearby())
+
+	assert.NoError(t, svc.UpdateAvailability(ctx, driver.ID, true))
+	if drivers := nearby(); assert.Len(t, drivers, 1) {
+		assert.Equal(t, driver.ID, drivers[0].ID)
+	}
+
+	assert.NoError(t, svc.UpdateAvailability(ctx, driver.ID, false))
+	assert.Empty(t, nearby())
+}
+
+func TestUpdateLocationValidation(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.LocationConfig{MaxSpeedKmh: 200, MinUpdateInterval: 2 * time.Second}
+	start := time.Date(2024, 2, 20, 12, 0, 0, 0, time.UTC)
+	lastFix := start.Add(-time.Minute)
+
+	tests := []struct {
+		name     string
+		previous *time.Time
+		wait     time.Duration
+		input    services.UpdateLocationInput
+		expected error
+	}{
+		{
+			name:  "Zero coordinates are valid",
+			input: services.UpdateLocationInput{Latitude: 0, Longitude: 0},
+		},
+		{
+			name:     "Latitude out of range",
+			input:    services.UpdateLocationInput{Latitude: 90.5, Longitude: 90.4125},
+			expected: errors.ErrInvalidLocation,
+		},
+		{
+			name:     "Longitude out of range",
+			input:    services.UpdateLocationInput{Latitude: 23.8103, Longitude: -180.5},
+			expected: errors.ErrInvalidLocation,
+		},
+		{
+			// About 1.1km in a minute is 66km/h
+			name:     "Plausible move",
+			previous: &lastFix,
+			input:    services.UpdateLocationInput{Latitude: 23.8203, Longitude: 90.4125},
+		},
+		{
+			// About 11km in a minute is 660km/h
+			name:     "Impossible jump",
+			previous: &lastFix,
+			input:    services.UpdateLocationInput{Latitude: 23.9103, Longitude: 90.4125},
+			expected: errors.ErrImplausibleLocation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driverRepo := new(MockDriverRepository)
+			svc := NewDriverService(driverRepo, new(MockUserRepository), new(MockDocumentFileService), NewDriverIndex(), clock.NewFake(start), cfg)
+
+			driver := &models.Driver{ID: "driver-1", CurrentLocation: models.Location{Latitude: 23.8103, Longitude: 90.4125},
+				LocationUpdatedAt: tt.previous}
+			driverRepo.On("FindByID", ctx, driver.ID).Return(driver, nil)
+			driverRepo.On("UpdateLocation", ctx, driver.ID, tt.input.Latitude, tt.input.Longitude, start).Return(nil)
+
+			err := svc.UpdateLocation(ctx, driver.ID, tt.input)
+
+			assert.Equal(t, tt.expected, err)
+			if tt.expected != nil {
+				driverRepo.AssertNotCalled(t, "UpdateLocation", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestUpdateLocationThrottle(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 2, 20, 12, 0, 0, 0, time.UTC))
+	driverRepo := new(MockDriverRepository)
+	svc := NewDriverService(driverRepo, new(MockUserRepository), new(MockDocumentFileService), NewDriverIndex(), clk,
+		config.LocationConfig{MinUpdateInterval: 2 * time.Second})
+
+	driverRepo.On("FindByID", ctx, "driver-1").Return(&models.Driver{ID: "driver-1"}, nil)
+	driverRepo.On("UpdateLocation", ctx, "driver-1", 23.8103, 90.4125, mock.Anything).Return(nil)
+	input := services.UpdateLocationInput{Latitude: 23.8103, Longitude: 90.4125}
+
+	assert.NoError(t, svc.UpdateLocation(ctx, "driver-1", input))
+	assert.Equal(t, errors.ErrLocationThrottled, svc.UpdateLocation(ctx, "driver-1", input))
+
+	clk.Advance(2 * time.Second)
+	assert.NoError(t, svc.UpdateLocation(ctx, "driver-1", input))
+	driverRepo.AssertNumberOfCalls(t, "FindByID", 2)
+}
+
+func TestExpireStaleLocations(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 2, 20, 12, 0, 0, 0, time.UTC)
+	driverRepo := new(MockDriverRepository)
+	index := NewDriverIndex()
+	svc := NewDriverService(driverRepo, new(MockUserRepository), new(MockDocumentFileService), index, clock.NewFake(now),
+		config.LocationConfig{StaleAfter: 10 * time.Minute})
+
+	index.Upsert("stale", 23.8103, 90.4125, models.Driver{ID: "stale"})
+	index.Upsert("fresh", 23.8103, 90.4125, models.Driver{ID: "fresh"})
+	driverRepo.On("MarkStaleUnavailable", ctx, now.Add(-10*time.Minute)).Return([]string{"stale"}, nil)
+
+	expired, err := svc.ExpireStaleLocations(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, expired)
+	if drivers, _ := svc.NearbyDrivers(ctx, 23.8103, 90.4125, 1, 0); assert.Len(t, drivers, 1) {
+		assert.Equal(t, "fresh", drivers[0].ID)
+	}
+}
+
+func TestVerifyDriverResubmission(t *testing.T) {
+	ctx := context.Background()
+	user := &models.User{ID: "user-1", UserType: models.UserTypeDriver}
+	expiresOn := time.Now().AddDate(1, 0, 0)
+	input := services.VerifyDriverInput{
+		LicenseNumber: "DL-2",
+		Vehicle:       models.Vehicle{Type: models.VehicleTypeCar, Model: "Axio", PlateNumber: "DHA-1"},
+		Documents:     []services.DocumentInput{{Type: models.DocumentTypeLicense, FileKey: "documents/user-1/license.jpg", ExpiresOn: &expiresOn}},
+	}
+	newTestDriverService := func(existing *models.Driver) (services.DriverService, *MockDriverRepository) {
+		driverRepo := new(MockDriverRepository)
+		userRepo := new(MockUserRepository)
+		documentFiles := new(MockDocumentFileService)
+		userRepo.On("FindByID", ctx, user.ID).Return(user, nil)
+		driverRepo.On("FindByUserID", ctx, user.ID).Return(existing, nil)
+		documentFiles.On("Resolve", ctx, user.ID, "documents/user-1/license.jpg").
+			Return(&models.DocumentFile{StorageKey: "documents/user-1/license.jpg"}, nil)
+		return NewDriverService(driverRepo, userRepo, documentFiles, NewDriverIndex(), clock.New(), config.LocationConfig{}), driverRepo
+	}
+
+	t.Run("a driver asked to resubmit goes back in the queue", func(t *testing.T) {
+		existing := &models.Driver{ID: "driver-1", UserID: user.ID, LicenseNumber: "DL-1", VerificationStatus: models.VerificationStatusResubmissionRequired}
+		svc, driverRepo := newTestDriverService(existing)
+		driverRepo.On("FindByLicenseNumber", ctx, "DL-2").Return(nil, errors.ErrDriverNotFound)
+		driverRepo.On("FindVehicleByPlateNumber", ctx, "DHA-1").Return(nil, errors.ErrVehicleNotFound)
+		driverRepo.On("Resubmit", ctx, existing, models.VerificationStatusResubmissionRequired).Return(nil, nil)
+
+		driver, err := svc.VerifyDriver(ctx, user.ID, input)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.VerificationStatusPending, driver.VerificationStatus)
+		assert.Equal(t, "DL-2", driver.LicenseNumber)
+		assert.Len(t, driver.Documents, 1)
+		driverRepo.AssertExpectations(t)
+	})
+
+	t.Run("another driver's licence is turned away", func(t *testing.T) {
+		existing := &models.Driver{ID: "driver-1", UserID: user.ID, VerificationStatus: models.VerificationStatusResubmissionRequired}
+		svc, driverRepo := newTestDriverService(existing)
+		driverRepo.On("FindByLicenseNumber", ctx, "DL-2").Return(&models.Driver{ID: "driver-2"}, nil)
+
+		_, err := svc.VerifyDriver(ctx, user.ID, input)
+
+		assert.Equal(t, errors.ErrLicenseExists, err)
+	})
+
+	t.Run("drivers under review cannot submit again", func(t *testing.T) {
+		existing := &models.Driver{ID: "driver-1", UserID: user.ID, VerificationStatus: models.VerificationStatusPending}
+		svc, _ := newTestDriverService(existing)
+
+		_, err := svc.VerifyDriver(ctx, user.ID, input)
+
+		assert.Equal(t, errors.ErrDriverExists, err)
+	})
+}
+
+func TestAddDocumentDates(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	day := func(offset int) *time.Time {
+		d := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC).AddDate(0, 0, offset)
+		return &d
+	}
+	driver := &models.Driver{ID: "driver-1", UserID: "user-1", ActiveVehicle: &models.Vehicle{ID: "vehicle-1", IsActive: true}}
+	driverRepo := new(MockDriverRepository)
+	documentFiles := new(MockDocumentFileService)
+	driverRepo.On("FindByID", ctx, driver.ID).Return(driver, nil)
+	documentFiles.On("Resolve", ctx, driver.UserID, "documents/user-1/doc.pdf").Return(&models.DocumentFile{StorageKey: "documents/user-1/doc.pdf"}, nil)
+	driverRepo.On("SaveDocument", ctx, mock.AnythingOfType("*models.Document"), "", (*models.Driver)(nil), models.VerificationStatus("")).Return(nil)
+	documentFiles.On("SignURLs", ctx, mock.Anything).Return(nil)
+	svc := NewDriverService(driverRepo, new(MockUserRepository), documentFiles, NewDriverIndex(), clock.NewFake(now), config.LocationConfig{})
+
+	tests := []struct {
+		name     string
+		input    services.DocumentInput
+		expected error
+	}{
+		{"a licence expiring today is accepted", services.DocumentInput{Type: models.DocumentTypeLicense, ExpiresOn: day(0)}, nil},
+		{"a registration needs no expiry", services.DocumentInput{Type: models.DocumentTypeRegistration}, nil},
+		{"insurance needs an expiry", services.DocumentInput{Type: models.DocumentTypeInsurance}, errors.ErrDocumentExpiryRequired},
+		{"a lapsed licence is refused", services.DocumentInput{Type: models.DocumentTypeLicense, ExpiresOn: day(-1)}, errors.ErrDocumentExpired},
+		{"expiry before issue is refused", services.DocumentInput{Type: models.DocumentTypeLicense, IssuedOn: day(30), ExpiresOn: day(10)}, errors.ErrInvalidDocumentDates},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.input.FileKey = "documents/user-1/doc.pdf"
+			_, err := svc.AddDocument(ctx, driver.ID, tt.input)
+			assert.Equal(t, tt.expected, err)
+		})
+	}
+}
+
+func TestDocumentManagement(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	expiresOn := now.AddDate(1, 0, 0)
+	fileKey := "documents/user-1/renewed.pdf"
+
+	newTestDriverService := func() (services.DriverService, *MockDriverRepository, *MockDocumentFileService, *models.Driver, *DriverIndex) {
+		driver := &models.Driver{
+			ID: "driver-1", UserID: "user-1", VerificationStatus: models.VerificationStatusApproved, IsAvailable: true,
+			ActiveVehicle: &models.Vehicle{ID: "vehicle-1", IsActive: true},
+			Documents: []models.Document{
+				{ID: "license", DriverID: "driver-1", Type: models.DocumentTypeLicense},
+				{ID: "registration", DriverID: "driver-1", Type: models.DocumentTypeRegistration,
+					DocumentFile: models.DocumentFile{StorageKey: "documents/user-1/registration.pdf"}},
+			},
+		}
+		driverRepo := new(MockDriverRepository)
+		documentFiles := new(MockDocumentFileService)
+		index := NewDriverIndex()
+		index.Upsert(driver.ID, 0, 0, *driver)
+		driverRepo.On("FindByID", ctx, driver.ID).Return(driver, nil)
+		documentFiles.On("Resolve", ctx, driver.UserID, fileKey).Return(&models.DocumentFile{StorageKey: fileKey}, nil)
+		documentFiles.On("SignURLs", ctx, mock.Anything).Return(nil)
+		svc := NewDriverService(driverRepo, new(MockUserRepository), documentFiles, index, clock.NewFake(now), config.LocationConfig{})
+		return svc, driverRepo, documentFiles, driver, index
+	}
+
+	t.Run("replacing a licence puts the driver back in review", func(t *testing.T) {
+		svc, driverRepo, _, driver, index := newTestDriverService()
+		driverRepo.On("SaveDocument", ctx, mock.AnythingOfType("*models.Document"), "license", driver, models.VerificationStatusApproved).Return(nil)
+
+		document, err := svc.ReplaceDocument(ctx, driver.ID, "license", services.DocumentInput{
+			Type: models.DocumentTypeRegistration, FileKey: fileKey, ExpiresOn: &expiresOn,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.DocumentTypeLicense, document.Type)
+		assert.Equal(t, models.VerificationStatusPending, driver.VerificationStatus)
+		assert.Equal(t, now, driver.SubmittedAt)
+		assert.Empty(t, index.Nearby(0, 0, 1, 0))
+		driverRepo.AssertExpectations(t)
+	})
+
+	t.Run("replacing a registration keeps the approval", func(t *testing.T) {
+		svc, driverRepo, documentFiles, driver, _ := newTestDriverService()
+		driverRepo.On("SaveDocument", ctx, mock.AnythingOfType("*models.Document"), "registration", (*models.Driver)(nil), models.VerificationStatus("")).Return(nil)
+		documentFiles.On("Discard", ctx, []string{"documents/user-1/registration.pdf"}).Return(nil)
+
+		_, err := svc.ReplaceDocument(ctx, driver.ID, "registration", services.DocumentInput{FileKey: fileKey})
+
+		assert.NoError(t, err)
+		assert.True(t, driver.IsVerified())
+		driverRepo.AssertExpectations(t)
+		documentFiles.AssertCalled(t, "Discard", ctx, []string{"documents/user-1/registration.pdf"})
+	})
+
+	t.Run("insurance for a spare vehicle keeps the approval", func(t *testing.T) {
+		svc, driverRepo, _, driver, _ := newTestDriverService()
+		driver.Vehicles = []models.Vehicle{*driver.ActiveVehicle, {ID: "vehicle-2"}}
+		driverRepo.On("SaveDocument", ctx, mock.AnythingOfType("*models.Document"), "", (*models.Driver)(nil), models.VerificationStatus("")).Return(nil)
+
+		document, err := svc.AddDocument(ctx, driver.ID, services.DocumentInput{
+			Type: models.DocumentTypeInsurance, FileKey: fileKey, ExpiresOn: &expiresOn, VehicleID: "vehicle-2",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "vehicle-2", *document.VehicleID)
+		assert.True(t, driver.IsVerified())
+		driverRepo.AssertExpectations(t)
+	})
+
+	t.Run("other drivers' documents cannot be changed", func(t *testing.T) {
+		svc, driverRepo, _, driver, _ := newTestDriverService()
+
+		_, err := svc.ReplaceDocument(ctx, driver.ID, "someone-elses", services.DocumentInput{FileKey: fileKey})
+		assert.Equal(t, errors.ErrDocumentNotFound, err)
+		assert.Equal(t, errors.ErrDocumentNotFound, svc.DeleteDocument(ctx, driver.ID, "someone-elses"))
+		driverRepo.AssertNotCalled(t, "DeleteDocument", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("the last licence cannot be deleted", func(t *testing.T) {
+		svc, driverRepo, _, driver, _ := newTestDriverService()
+
+		assert.Equal(t, errors.ErrRequiredDocument, svc.DeleteDocument(ctx, driver.ID, "license"))
+		driverRepo.AssertNotCalled(t, "DeleteDocument", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("optional documents can be deleted", func(t *testing.T) {
+		svc, driverRepo, documentFiles, driver, _ := newTestDriverService()
+		driverRepo.On("DeleteDocument", ctx, driver.ID, "registration").Return(nil)
+		documentFiles.On("Discard", ctx, []string{"documents/user-1/registration.pdf"}).Return(nil)
+
+		assert.NoError(t, svc.DeleteDocument(ctx, driver.ID, "registration"))
+		driverRepo.AssertExpectations(t)
+		documentFiles.AssertCalled(t, "Discard", ctx, []string{"documents/user-1/registration.pdf"})
+	})
+}
+
+func TestVehicleManagement(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	expiresOn := now.AddDate(1, 0, 0)
+	lapsedOn := now.AddDate(0, 0, -1)
+
+	newTestDriverService := func(available bool, insuranceExpiresOn time.Time) (services.DriverService, *MockDriverRepository, *models.Driver) {
+		driver := models.NewDriver("user-1", "DL-1", models.Vehicle{Type: models.VehicleTypeCar, PlateNumber: "DHA-1"})
+		driver.Review(models.VerificationStatusApproved, "admin-1", "ok", now.Add(-time.Hour))
+		driver.IsAvailable = available
+		spare := models.NewVehicle(driver.ID, models.Vehicle{Type: models.VehicleTypeBike, PlateNumber: "DHA-2"})
+		driver.Vehicles = append(driver.Vehicles, *spare)
+		driver.ActiveVehicle = &driver.Vehicles[0]
+		driver.Documents = []models.Document{
+			{ID: "insurance", DriverID: driver.ID, VehicleID: &spare.ID, Type: models.DocumentTypeInsurance, ExpiresOn: &insuranceExpiresOn,
+				CreatedAt: now.Add(-2 * time.Hour)},
+			{ID: "registration", DriverID: driver.ID, VehicleID: &spare.ID, Type: models.DocumentTypeRegistration,
+				CreatedAt: now.Add(-2 * time.Hour)},
+		}
+		driverRepo := new(MockDriverRepository)
+		driverRepo.On("FindByID", ctx, driver.ID).Return(driver, nil)
+		svc := NewDriverService(driverRepo, new(MockUserRepository), new(MockDocumentFileService), NewDriverIndex(), clock.NewFake(now), config.LocationConfig{})
+		return svc, driverRepo, driver
+	}
+
+	t.Run("a new vehicle is registered inactive", func(t *testing.T) {
+		svc, driverRepo, driver := newTestDriverService(false, expiresOn)
+		driverRepo.On("FindVehicleByPlateNumber", ctx, "DHA-3").Return(nil, errors.ErrVehicleNotFound)
+		driverRepo.On("AddVehicle", ctx, mock.AnythingOfType("*models.Vehicle")).Return(nil)
+
+		vehicle, err := svc.AddVehicle(ctx, driver.ID, models.Vehicle{Type: models.VehicleTypeCar, PlateNumber: "DHA-3", Colour: "white", Year: 2019})
+
+		assert.NoError(t, err)
+		assert.Equal(t, driver.ID, vehicle.DriverID)
+		assert.False(t, vehicle.IsActive)
+		assert.Equal(t, "white", vehicle.Colour)
+		driverRepo.AssertExpectations(t)
+	})
+
+	t.Run("a registered plate is turned away", func(t *testing.T) {
+		svc, driverRepo, driver := newTestDriverService(false, expiresOn)
+		driverRepo.On("FindVehicleByPlateNumber", ctx, "DHA-1").Return(driver.ActiveVehicle, nil)
+
+		_, err := svc.AddVehicle(ctx, driver.ID, models.Vehicle{Type: models.VehicleTypeCar, PlateNumber: "DHA-1"})
+
+		assert.Equal(t, errors.ErrPlateNumberExists, err)
+		driverRepo.AssertNotCalled(t, "AddVehicle", mock.Anything, mock.Anything)
+	})
+
+	t.Run("a registered and insured vehicle becomes the active one", func(t *testing.T) {
+		svc, driverRepo, driver := newTestDriverService(false, expiresOn)
+		spareID := driver.Vehicles[1].ID
+		driverRepo.On("ActivateVehicle", ctx, driver.ID, spareID, now, (*models.Driver)(nil), models.VerificationStatus("")).Return(nil)
+
+		vehicle, err := svc.ActivateVehicle(ctx, driver.ID, spareID)
+
+		assert.NoError(t, err)
+		assert.True(t, vehicle.IsActive)
+		assert.Equal(t, spareID, driver.ActiveVehicle.ID)
+		assert.False(t, driver.Vehicles[0].IsActive)
+		assert.True(t, driver.IsVerified())
+		driverRepo.AssertExpectations(t)
+	})
+
+	t.Run("documents added since the approval are reviewed on activation", func(t *testing.T) {
+		svc, driverRepo, driver := newTestDriverService(false, expiresOn)
+		driver.Documents[0].CreatedAt = now
+		spareID := driver.Vehicles[1].ID
+		driverRepo.On("ActivateVehicle", ctx, driver.ID, spareID, now, driver, models.VerificationStatusApproved).Return(nil)
+
+		_, err := svc.ActivateVehicle(ctx, driver.ID, spareID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.VerificationStatusPending, driver.VerificationStatus)
+		assert.Equal(t, now, driver.SubmittedAt)
+		driverRepo.AssertExpectations(t)
+	})
+
+	tests := []struct {
+		name      string
+		available bool
+		insurance time.Time
+		vehicleID func(driver *models.Driver) string
+		expected  error
+	}{
+		{"online drivers cannot switch", true, expiresOn, func(d *models.Driver) string { return d.Vehicles[1].ID }, errors.ErrVehicleSwitchOnline},
+		{"a vehicle with lapsed insurance cannot be driven", false, lapsedOn, func(d *models.Driver) string { return d.Vehicles[1].ID }, errors.ErrVehicleDocumentsMissing},
+		{"other drivers' vehicles cannot be driven", false, expiresOn, func(*models.Driver) string { return "someone-elses" }, errors.ErrVehicleNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, driverRepo, driver := newTestDriverService(tt.available, tt.insurance)
+
+			_, err := svc.ActivateVehicle(ctx, driver.ID, tt.vehicleID(driver))
+
+			assert.Equal(t, tt.expected, err)
+			driverRepo.AssertNotCalled(t, "ActivateVehicle", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("an unregistered vehicle cannot be driven", func(t *testing.T) {
+		svc, driverRepo, driver := newTestDriverService(false, expiresOn)
+		driver.Documents = driver.Documents[:1]
+
+		_, err := svc.ActivateVehicle(ctx, driver.ID, driver.Vehicles[1].ID)
+
+		assert.Equal(t, errors.ErrVehicleDocumentsMissing, err)
+		driverRepo.AssertNotCalled(t, "ActivateVehicle", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("drivers without an active vehicle cannot go online", func(t *testing.T) {
+		svc, _, driver := newTestDriverService(false, expiresOn)
+		driver.ActiveVehicle = nil
+
+		assert.Equal(t, errors.ErrVehicleNotFound, svc.UpdateAvailability(ctx, driver.ID, true))
+	})
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/dateutil"
+)
+
+const (
+	defaultStatementPageLimit = 10
+	maxStatementPageLimit     = 52
+)
+
+type earningsService struct {
+	earningsRepo repositories.EarningsRepository
+	clock        clock.Clock
+	config       config.EarningsConfig
+}
+
+func NewEarningsService(
+	earningsRepo repositories.EarningsRepository,
+	clk clock.Clock,
+	cfg config.EarningsConfig,
+) services.EarningsService {
+	return &earningsService{
+		earningsRepo: earningsRepo,
+		clock:        clk,
+		config:       cfg,
+	}
+}
+
+func (s *earningsService) RecordRide(ctx context.Context, ride *models.Ride) error {
+	if ride.Status != models.RideStatusCompleted || ride.DriverID == nil {
+		return nil
+	}
+
+	earnedAt := s.clock.Now()
+	if ride.CompletedAt != nil {
+		earnedAt = *ride.CompletedAt
+	}
+	// Drivers earn on the full fare; promo discounts come out of the
+	// platform's share
+	earning := models.NewDriverEarning(*ride.DriverID, ride.ID, ride.Fare+ride.Discount, s.config.CommissionRate, earnedAt)
+	return s.earningsRepo.Create(ctx, earning)
+}
+
+func (s *earningsService) GetDriverEarnings(ctx context.Context, driverID string, input services.ListPayoutStatementsInput) (*services.DriverEarnings, error) {
+	page := input.Page
+	if page < 1 {
+		page = 1
+	}
+	limit := input.Limit
+	if limit < 1 {
+		limit = defaultStatementPageLimit
+	}
+	if limit > maxStatementPageLimit {
+		limit = maxStatementPageLimit
+	}
+
+	weekStart := dateutil.StartOfWeek(s.clock.Now().UTC())
+	currentWeek, err := s.earningsRepo.Sum(ctx, repositories.EarningsFilter{DriverID: driverID, From: &weekStart})
+	if err != nil {
+		return nil, err
+	}
+	unpaid, err := s.earningsRepo.Sum(ctx, repositories.EarningsFilter{DriverID: driverID, Unpaid: true})
+	if err != nil {
+		return nil, err
+	}
+	lifetime, err := s.earningsRepo.Sum(ctx, repositories.EarningsFilter{DriverID: driverID})
+	if err != nil {
+		return nil, err
+	}
+
+	statements, total, err := s.earningsRepo.ListStatements(ctx, driverID, page, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &services.DriverEarnings{
+		CommissionRate: s.config.CommissionRate,
+		CurrentWeek:    *currentWeek,
+		Unpaid:         *unpaid,
+		Lifetime:       *lifetime,
+		Statements:     statements,
+		Total:          total,
+		Page:           page,
+		Limit:          limit,
+		HasMore:        int64(page*limit) < total,
+	}, nil
+}
+
+func (s *earningsService) GetStatement(ctx context.Context, driverID string, statementID string) (*models.PayoutStatement, error) {
+	statement, err := s.earningsRepo.FindStatement(ctx, statementID)
+	if err != nil {
+		return nil, err
+	}
+	// Other drivers' statements are reported as missing
+	if statement.DriverID != driverID {
+		return nil, errors.ErrPayoutStatementNotFound
+	}
+	return statement, nil
+}
+
+func (s *earningsService) RunPayouts(ctx context.Context, weekOf time.Time) ([]models.PayoutStatement, error) {
+	periodStart := dateutil.StartOfWeek(weekOf.UTC())
+	periodEnd := periodStart.AddDate(0, 0, 7)
+	if periodEnd.After(s.clock.Now()) {
+		return nil, errors.ErrPayoutPeriodOpen
+	}
+
+	statements, err := s.earningsRepo.CreateStatements(ctx, periodStart, periodEnd)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("created %d payout statements for the week of %s", len(statements), dateutil.FormatDate(periodStart))
+	return statements, nil
+}
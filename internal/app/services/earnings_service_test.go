@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+)
+
+// Wednesday
+var testEarningsNow = time.Date(2024, 3, 13, 10, 0, 0, 0, time.UTC)
+
+func newTestEarningsService() (*earningsService, *MockEarningsRepository) {
+	earningsRepo := new(MockEarningsRepository)
+	svc := NewEarningsService(earningsRepo, clock.NewFake(testEarningsNow), config.EarningsConfig{CommissionRate: 0.2}).(*earningsService)
+	return svc, earningsRepo
+}
+
+func TestRecordRide(t *testing.T) {
+	ctx := context.Background()
+	driverID := "driver-1"
+
+	t.Run("splits the fare before discount", func(t *testing.T) {
+		svc, earningsRepo := newTestEarningsService()
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, testEarningsNow)
+		ride.Accept(driverID, testEarningsNow)
+		ride.MarkDriverArrived(testEarningsNow)
+		ride.Start(testEarningsNow)
+		ride.Complete(280, 42, testEarningsNow)
+		earningsRepo.On("Create", ctx, mock.MatchedBy(func(e *models.DriverEarning) bool {
+			return e.DriverID == driverID && e.RideID == ride.ID &&
+				e.GrossFare == 280 && e.Commission == 56 && e.Earnings == 224 &&
+				e.EarnedAt.Equal(*ride.CompletedAt)
+		})).Return(nil)
+
+		err := svc.RecordRide(ctx, ride)
+
+		assert.NoError(t, err)
+		earningsRepo.AssertExpectations(t)
+	})
+
+	t.Run("ignores rides that did not complete", func(t *testing.T) {
+		svc, earningsRepo := newTestEarningsService()
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, testEarningsNow)
+		ride.Cancel("rider-1", "", testEarningsNow)
+
+		err := svc.RecordRide(ctx, ride)
+
+		assert.NoError(t, err)
+		earningsRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestRunPayouts(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("pays out the whole week from Monday", func(t *testing.T) {
+		svc, earningsRepo := newTestEarningsService()
+		monday := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+		statements := []models.PayoutStatement{*models.NewPayoutStatement("driver-1", monday, monday.AddDate(0, 0, 7))}
+		earningsRepo.On("CreateStatements", ctx, monday, monday.AddDate(0, 0, 7)).Return(statements, nil)
+
+		created, err := svc.RunPayouts(ctx, time.Date(2024, 3, 9, 18, 30, 0, 0, time.UTC))
+
+		assert.NoError(t, err)
+		assert.Equal(t, statements, created)
+	})
+
+	t.Run("refuses the week in progress", func(t *testing.T) {
+		svc, earningsRepo := newTestEarningsService()
+
+		_, err := svc.RunPayouts(ctx, testEarningsNow)
+
+		assert.Equal(t, errors.ErrPayoutPeriodOpen, err)
+		earningsRepo.AssertNotCalled(t, "CreateStatements", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetStatement(t *testing.T) {
+	ctx := context.Background()
+	svc, earningsRepo := newTestEarningsService()
+	statement := models.NewPayoutStatement("driver-1", testEarningsNow, testEarningsNow)
+	earningsRepo.On("FindStatement", ctx, statement.ID).Return(statement, nil)
+
+	found, err := svc.GetStatement(ctx, "driver-1", statement.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, statement, found)
+
+	_, err = svc.GetStatement(ctx, "driver-2", statement.ID)
+	assert.Equal(t, errors.ErrPayoutStatementNotFound, err)
+}
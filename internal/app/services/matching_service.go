@@ -0,0 +1,393 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/geo"
+)
+
+// noDriverCancellationReason is recorded on rides the dispatcher gives up on
+const noDriverCancellationReason = "no drivers available"
+
+// candidate is a driver considered for a ride together with its ranking inputs
+type candidate struct {
+	driver         models.Driver
+	distanceKm     float64
+	acceptanceRate float64
+	score          float64
+}
+
+type matchingService struct {
+	rideRepo    repositories.RideRepository
+	locator     services.DriverLocator
+	offerRepo   repositories.RideOfferRepository
+	ratingRepo  repositories.RatingRepository
+	rideService services.RideService
+	poolService services.PoolService
+	clock       clock.Clock
+	config      config.MatchingConfig
+
+	mu sync.Mutex
+	// responses delivers a driver's answer to the dispatcher waiting on an offer
+	responses map[string]chan bool
+	// running holds the cancel functions of background dispatches by ride ID
+	running map[string]context.CancelFunc
+}
+
+func NewMatchingService(
+	rideRepo repositories.RideRepository,
+	locator services.DriverLocator,
+	offerRepo repositories.RideOfferRepository,
+	ratingRepo repositories.RatingRepository,
+	rideService services.RideService,
+	poolService services.PoolService,
+	clk clock.Clock,
+	cfg config.MatchingConfig,
+) services.MatchingService {
+	return &matchingService{
+		rideRepo:    rideRepo,
+		locator:     locator,
+		offerRepo:   offerRepo,
+		ratingRepo:  ratingRepo,
+		rideService: rideService,
+		poolService: poolService,
+		clock:       clk,
+		config:      cfg,
+		responses:   make(map[string]chan bool),
+		running:     make(map[string]context.CancelFunc),
+	}
+}
+
+func (s *matchingService) Dispatch(ctx context.Context, ride *models.Ride) (*models.Ride, error) {
+	tried := make(map[string]bool)
+
+	// Widen the search only once every driver in the smaller radius has
+	// had a chance to respond
+	for _, radiusKm := range s.config.SearchRadiiKm {
+		candidates, err := s.findCandidates(ctx, ride, radiusKm, tried)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range candidates {
+			tried[c.driver.ID] = true
+
+			// Stop as soon as the ride is no longer waiting for a driver
+			current, err := s.rideRepo.FindByID(ctx, ride.ID)
+			if err != nil {
+				return nil, err
+			}
+			if current.Status != models.RideStatusRequested {
+				return nil, errors.NewRideTransitionError(string(current.Status), string(models.RideStatusAccepted))
+			}
+
+			accepted, err := s.offer(ctx, ride, c)
+			if err != nil {
+				return nil, err
+			}
+			if !accepted {
+				continue
+			}
+
+			acceptedRide, err := s.rideService.AcceptRide(ctx, ride.ID, c.driver.ID)
+			if err == nil {
+				return acceptedRide, nil
+			}
+			// The ride was cancelled; no other driver can take it either
+			if err == errors.ErrPaymentDeclined || err == errors.ErrInsufficientBalance {
+				return nil, err
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			// The driver may have taken another ride in the meantime
+			log.Printf("driver %s accepted ride %s but assignment failed: %v", c.driver.ID, ride.ID, err)
+		}
+	}
+
+	return nil, errors.ErrNoDriversAvailable
+}
+
+func (s *matchingService) StartDispatch(ride *models.Ride) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.MaxDispatchDuration)
+
+	s.mu.Lock()
+	s.running[ride.ID] = cancel
+	s.mu.Unlock()
+
+	go func() {
+		defer s.StopDispatch(ride.ID)
+
+		if _, err := s.Dispatch(ctx, ride); err != nil {
+			log.Printf("dispatch for ride %s stopped: %v", ride.ID, err)
+			if err == errors.ErrNoDriversAvailable || err == context.DeadlineExceeded {
+				s.cancelUnmatched(ride.ID)
+			}
+		}
+	}()
+}
+
+func (s *matchingService) ResumeDispatch(ctx context.Context) (int, error) {
+	rides, err := s.rideRepo.FindOpenRequests(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	resumed := 0
+	for i := range rides {
+		s.mu.Lock()
+		_, running := s.running[rides[i].ID]
+		s.mu.Unlock()
+		if running {
+			continue
+		}
+		s.StartDispatch(&rides[i])
+		resumed++
+	}
+	return resumed, nil
+}
+
+func (s *matchingService) StopDispatch(rideID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cancel, ok := s.running[rideID]; ok {
+		cancel()
+		delete(s.running, rideID)
+	}
+}
+
+func (s *matchingService) GetPendingOffer(ctx context.Context, driverID string) (*models.RideOffer, error) {
+	return s.offerRepo.FindPendingByDriverID(ctx, driverID, s.clock.Now())
+}
+
+func (s *matchingService) RespondToOffer(ctx context.Context, driverID string, offerID string, accept bool) error {
+	offer, err := s.offerRepo.FindByID(ctx, offerID)
+	if err != nil {
+		return err
+	}
+	// Do not reveal offers made to other drivers
+	if offer.DriverID != driverID {
+		return errors.ErrOfferNotFound
+	}
+	if s.clock.Now().After(offer.ExpiresAt) {
+		return errors.ErrOfferExpired
+	}
+
+	status := models.RideOfferStatusDeclined
+	if accept {
+		status = models.RideOfferStatusAccepted
+	}
+	if err := s.offerRepo.Resolve(ctx, offer.ID, status, s.clock.Now()); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if ch, ok := s.responses[offer.ID]; ok {
+		ch <- accept
+	}
+	s.mu.Unlock()
+
+	return nil
+}
+
+// findCandidates returns the ranked drivers within radiusKm of the pickup
+// that have not been offered this ride yet. Low-rated drivers and drivers
+// who fell out with the rider over an earlier ride are left out. Pooled
+// rides only go to drivers whose pool they fit, sharing drivers first.
+func (s *matchingService) findCandidates(ctx context.Context, ride *models.Ride, radiusKm float64, tried map[string]bool) ([]candidate, error) {
+	pickup := ride.PickupLocation
+	drivers, err := s.locator.NearbyDrivers(ctx, pickup.Latitude, pickup.Longitude, radiusKm, s.config.MaxCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	avoided, err := s.avoidedPartners(ctx, ride.RiderID)
+	if err != nil {
+		return nil, err
+	}
+
+	var fresh []models.Driver
+	var ids []string
+	sharing := make(map[string]bool)
+	for _, driver := range drivers {
+		if tried[driver.ID] || !driver.IsVerified() || !driver.IsAvailable {
+			continue
+		}
+		if avoided[driver.UserID] || s.isLowRated(driver) {
+			continue
+		}
+		if ride.Pooled {
+			shared, err := s.poolService.CanJoin(ctx, ride, &driver)
+			if err == errors.ErrRideDoesNotFit || err == errors.ErrActiveRideExists {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			sharing[driver.ID] = shared
+		}
+		fresh = append(fresh, driver)
+		ids = append(ids, driver.ID)
+	}
+	if len(fresh) == 0 {
+		return nil, nil
+	}
+
+	rates, err := s.offerRepo.AcceptanceRates(ctx, ids, s.clock.Now().Add(-s.config.AcceptanceWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := rankCandidates(s.config, ride, fresh, rates)
+	// Filling a car that is already on its way beats starting a new pool
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return sharing[candidates[i].driver.ID] && !sharing[candidates[j].driver.ID]
+	})
+	return candidates, nil
+}
+
+// avoidedPartners returns the users the rider must not be matched with
+// because one of them rated the other too low
+func (s *matchingService) avoidedPartners(ctx context.Context, riderID string) (map[string]bool, error) {
+	if s.config.AvoidPairsRatedBelow <= 0 {
+		return nil, nil
+	}
+
+	partners, err := s.ratingRepo.FindLowRatedPartners(ctx, riderID, s.config.AvoidPairsRatedBelow)
+	if err != nil {
+		return nil, err
+	}
+	avoided := make(map[string]bool, len(partners))
+	for _, id := range partners {
+		avoided[id] = true
+	}
+	return avoided, nil
+}
+
+// isLowRated reports whether the driver's average is below the minimum.
+// Drivers with few ratings are given the benefit of the doubt.
+func (s *matchingService) isLowRated(driver models.Driver) bool {
+	return s.config.MinDriverRating > 0 &&
+		driver.RatingCount >= s.config.MinRatingsToFilter &&
+		driver.RatingAverage < s.config.MinDriverRating
+}
+
+// rankCandidates orders drivers from best to worst. The score is the
+// pickup distance plus penalties, in kilometres, for a vehicle type other
+// than the one requested and for a low recent acceptance rate. Drivers
+// without recent offers are treated as always accepting.
+func rankCandidates(cfg config.MatchingConfig, ride *models.Ride, drivers []models.Driver, rates map[string]float64) []candidate {
+	candidates := make([]candidate, 0, len(drivers))
+	for _, driver := range drivers {
+		c := candidate{
+			driver: driver,
+			distanceKm: geo.HaversineKm(
+				ride.PickupLocation.Latitude, ride.PickupLocation.Longitude,
+				driver.CurrentLocation.Latitude, driver.CurrentLocation.Longitude,
+			),
+			acceptanceRate: 1,
+		}
+		if rate, ok := rates[driver.ID]; ok {
+			c.acceptanceRate = rate
+		}
+
+		c.score = c.distanceKm + (1-c.acceptanceRate)*cfg.LowAcceptancePenaltyKm
+		if ride.VehicleType != "" && (driver.ActiveVehicle == nil || driver.ActiveVehicle.Type != ride.VehicleType) {
+			c.score += cfg.VehicleMismatchPenaltyKm
+		}
+		candidates = append(candidates, c)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score < candidates[j].score
+	})
+	return candidates
+}
+
+// offer hands ride to a single driver and waits for an answer until the
+// offer times out. It reports whether the driver accepted.
+func (s *matchingService) offer(ctx context.Context, ride *models.Ride, c candidate) (bool, error) {
+	// Skip drivers who are already considering another ride
+	if _, err := s.offerRepo.FindPendingByDriverID(ctx, c.driver.ID, s.clock.Now()); err == nil {
+		return false, nil
+	} else if err != errors.ErrOfferNotFound {
+		return false, err
+	}
+
+	now := s.clock.Now()
+	offer := models.NewRideOffer(ride.ID, &c.driver, c.distanceKm, now, now.Add(s.config.OfferTimeout))
+
+	responses := make(chan bool, 1)
+	s.mu.Lock()
+	s.responses[offer.ID] = responses
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.responses, offer.ID)
+		s.mu.Unlock()
+	}()
+
+	if err := s.offerRepo.Create(ctx, offer); err != nil {
+		return false, err
+	}
+
+	select {
+	case accepted := <-responses:
+		return accepted, nil
+	case <-s.clock.After(s.config.OfferTimeout):
+	case <-ctx.Done():
+	}
+
+	// Expire the offer unless the driver answered at the last moment
+	err := s.offerRepo.Resolve(context.WithoutCancel(ctx), offer.ID, models.RideOfferStatusExpired, s.clock.Now())
+	if err == errors.ErrOfferNotPending {
+		return s.answerOf(ctx, offer.ID, responses)
+	}
+	if err != nil {
+		return false, err
+	}
+	return false, ctx.Err()
+}
+
+// answerOf reports whether the driver accepted an offer they resolved
+// themselves. The answer normally arrives on responses, but it may have
+// been handled by another instance, so the stored status is what counts.
+func (s *matchingService) answerOf(ctx context.Context, offerID string, responses <-chan bool) (bool, error) {
+	select {
+	case accepted := <-responses:
+		return accepted && ctx.Err() == nil, ctx.Err()
+	default:
+	}
+
+	offer, err := s.offerRepo.FindByID(context.WithoutCancel(ctx), offerID)
+	if err != nil {
+		return false, err
+	}
+	return offer.Status == models.RideOfferStatusAccepted && ctx.Err() == nil, ctx.Err()
+}
+
+// cancelUnmatched cancels a ride that is still waiting for a driver after
+// dispatch gave up on it.
+func (s *matchingService) cancelUnmatched(rideID string) {
+	ctx := context.Background()
+
+	ride, err := s.rideRepo.FindByID(ctx, rideID)
+	if err != nil || ride.Status != models.RideStatusRequested {
+		return
+	}
+
+	ride.Cancel("", noDriverCancellationReason, s.clock.Now())
+	if err := s.rideRepo.UpdateStatus(ctx, ride, models.RideStatusRequested); err != nil {
+		log.Printf("failed to cancel unmatched ride %s: %v", rideID, err)
+	}
+}
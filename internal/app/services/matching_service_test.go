@@ -0,0 +1,397 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+)
+
+// fakeRideOfferRepository keeps offers in memory so that dispatch and
+// driver responses can run concurrently in tests.
+type fakeRideOfferRepository struct {
+	mu     sync.Mutex
+	offers map[string]*models.RideOffer
+	rates  map[string]float64
+}
+
+func newFakeRideOfferRepository() *fakeRideOfferRepository {
+	return &fakeRideOfferRepository{offers: make(map[string]*models.RideOffer)}
+}
+
+func (r *fakeRideOfferRepository) Create(ctx context.Context, offer *models.RideOffer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *offer
+	r.offers[offer.ID] = &copied
+	return nil
+}
+
+func (r *fakeRideOfferRepository) FindByID(ctx context.Context, id string) (*models.RideOffer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	offer, ok := r.offers[id]
+	if !ok {
+		return nil, errors.ErrOfferNotFound
+	}
+	copied := *offer
+	return &copied, nil
+}
+
+func (r *fakeRideOfferRepository) FindPendingByDriverID(ctx context.Context, driverID string, now time.Time) (*models.RideOffer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, offer := range r.offers {
+		if offer.DriverID == driverID && offer.Status == models.RideOfferStatusPending && offer.ExpiresAt.After(now) {
+			copied := *offer
+			return &copied, nil
+		}
+	}
+	return nil, errors.ErrOfferNotFound
+}
+
+func (r *fakeRideOfferRepository) Resolve(ctx context.Context, offerID string, status models.RideOfferStatus, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	offer, ok := r.offers[offerID]
+	if !ok || offer.Status != models.RideOfferStatusPending {
+		return errors.ErrOfferNotPending
+	}
+	offer.Status = status
+	offer.RespondedAt = &at
+	return nil
+}
+
+func (r *fakeRideOfferRepository) AcceptanceRates(ctx context.Context, driverIDs []string, since time.Time) (map[string]float64, error) {
+	return r.rates, nil
+}
+
+func (r *fakeRideOfferRepository) statusOf(driverID string) models.RideOfferStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, offer := range r.offers {
+		if offer.DriverID == driverID {
+			return offer.Status
+		}
+	}
+	return ""
+}
+
+var testMatchingConfig = config.MatchingConfig{
+	SearchRadiiKm:            []float64{2, 5},
+	OfferTimeout:             15 * time.Second,
+	AcceptanceWindow:         7 * 24 * time.Hour,
+	VehicleMismatchPenaltyKm: 5,
+	LowAcceptancePenaltyKm:   2,
+}
+
+type matchingFixture struct {
+	svc         *matchingService
+	clock       *clock.Fake
+	rideRepo    *MockRideRepository
+	locator     *MockDriverLocator
+	offerRepo   *fakeRideOfferRepository
+	ratingRepo  *MockRatingRepository
+	rideService *MockRideService
+	poolService *MockPoolService
+	ride        *models.Ride
+	near        models.Driver
+	far         models.Driver
+}
+
+func newMatchingFixture() *matchingFixture {
+	f := &matchingFixture{
+		clock:       clock.NewFake(time.Date(2024, 2, 20, 12, 0, 0, 0, time.UTC)),
+		rideRepo:    new(MockRideRepository),
+		locator:     new(MockDriverLocator),
+		offerRepo:   newFakeRideOfferRepository(),
+		ratingRepo:  new(MockRatingRepository),
+		rideService: new(MockRideService),
+		poolService: new(MockPoolService),
+	}
+	f.svc = NewMatchingService(f.rideRepo, f.locator, f.offerRepo, f.ratingRepo, f.rideService, f.poolService,
+		f.clock, testMatchingConfig).(*matchingService)
+
+	pickup := models.Location{Latitude: 23.8103, Longitude: 90.4125}
+	f.ride = models.NewRide("rider-1", pickup, models.Location{Latitude: 23.7509, Longitude: 90.3935}, f.clock.Now())
+	f.near = models.Driver{ID: "driver-near", UserID: "user-near", VerificationStatus: models.VerificationStatusApproved, IsAvailable: true,
+		CurrentLocation: models.Location{Latitude: 23.8150, Longitude: 90.4125},
+		ActiveVehicle:   &models.Vehicle{ID: "vehicle-near", Type: models.VehicleTypeCar, IsActive: true}}
+	f.far = models.Driver{ID: "driver-far", UserID: "user-far", VerificationStatus: models.VerificationStatusApproved, IsAvailable: true,
+		CurrentLocation: models.Location{Latitude: 23.8250, Longitude: 90.4125}}
+
+	f.rideRepo.On("FindByID", mock.Anything, f.ride.ID).Return(f.ride, nil)
+	f.locator.On("NearbyDrivers", mock.Anything, pickup.Latitude, pickup.Longitude, mock.Anything, mock.Anything).
+		Return([]models.Driver{f.far, f.near}, nil)
+	return f
+}
+
+type dispatchResult struct {
+	ride *models.Ride
+	err  error
+}
+
+func (f *matchingFixture) dispatch(ctx context.Context) <-chan dispatchResult {
+	done := make(chan dispatchResult, 1)
+	go func() {
+		ride, err := f.svc.Dispatch(ctx, f.ride)
+		done <- dispatchResult{ride: ride, err: err}
+	}()
+	return done
+}
+
+// respond waits for driverID to receive an offer and answers it
+func (f *matchingFixture) respond(t *testing.T, driverID string, accept bool) {
+	var offer *models.RideOffer
+	assert.Eventually(t, func() bool {
+		var err error
+		offer, err = f.svc.GetPendingOffer(context.Background(), driverID)
+		return err == nil
+	}, time.Second, time.Millisecond)
+	assert.NoError(t, f.svc.RespondToOffer(context.Background(), driverID, offer.ID, accept))
+}
+
+func TestDispatchFallsBackAfterTimeout(t *testing.T) {
+	f := newMatchingFixture()
+	accepted := *f.ride
+	accepted.Accept(f.far.ID, f.clock.Now())
+	f.rideService.On("AcceptRide", mock.Anything, f.ride.ID, f.far.ID).Return(&accepted, nil)
+
+	done := f.dispatch(context.Background())
+
+	// The nearest driver is offered first and lets the offer time out
+	f.clock.BlockUntil(1)
+	offer, err := f.svc.GetPendingOffer(context.Background(), f.near.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, f.ride.ID, offer.RideID)
+	assert.Equal(t, &f.near.ActiveVehicle.ID, offer.VehicleID)
+	f.clock.Advance(testMatchingConfig.OfferTimeout)
+
+	f.respond(t, f.far.ID, true)
+
+	result := <-done
+	assert.NoError(t, result.err)
+	assert.True(t, result.ride.IsAssignedTo(f.far.ID))
+	assert.Equal(t, models.RideOfferStatusExpired, f.offerRepo.statusOf(f.near.ID))
+	assert.Equal(t, models.RideOfferStatusAccepted, f.offerRepo.statusOf(f.far.ID))
+}
+
+func TestDispatchTakesAnswerResolvedElsewhere(t *testing.T) {
+	f := newMatchingFixture()
+	accepted := *f.ride
+	accepted.Accept(f.near.ID, f.clock.Now())
+	f.rideService.On("AcceptRide", mock.Anything, f.ride.ID, f.near.ID).Return(&accepted, nil)
+
+	done := f.dispatch(context.Background())
+
+	// Another instance records the driver's acceptance just as the offer
+	// times out here
+	f.clock.BlockUntil(1)
+	offer, err := f.svc.GetPendingOffer(context.Background(), f.near.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, f.offerRepo.Resolve(context.Background(), offer.ID, models.RideOfferStatusAccepted, f.clock.Now()))
+	f.clock.Advance(testMatchingConfig.OfferTimeout)
+
+	result := <-done
+	assert.NoError(t, result.err)
+	assert.True(t, result.ride.IsAssignedTo(f.near.ID))
+}
+
+func TestDispatchIgnoresStalePendingOffers(t *testing.T) {
+	f := newMatchingFixture()
+	accepted := *f.ride
+	accepted.Accept(f.near.ID, f.clock.Now())
+	f.rideService.On("AcceptRide", mock.Anything, f.ride.ID, f.near.ID).Return(&accepted, nil)
+
+	// Left pending by a dispatcher that stopped before it expired the offer
+	stale := models.NewRideOffer("ride-0", &f.near, 0.5, f.clock.Now().Add(-time.Hour), f.clock.Now().Add(-59*time.Minute))
+	assert.NoError(t, f.offerRepo.Create(context.Background(), stale))
+
+	done := f.dispatch(context.Background())
+	f.respond(t, f.near.ID, true)
+
+	result := <-done
+	assert.NoError(t, result.err)
+	assert.True(t, result.ride.IsAssignedTo(f.near.ID))
+}
+
+func TestResumeDispatch(t *testing.T) {
+	f := newMatchingFixture()
+	f.svc.config.MaxDispatchDuration = time.Minute
+	f.rideRepo.On("FindOpenRequests", mock.Anything).Return([]models.Ride{*f.ride}, nil)
+
+	resumed, err := f.svc.ResumeDispatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, resumed)
+
+	// A ride already being dispatched is left alone
+	resumed, err = f.svc.ResumeDispatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, resumed)
+
+	// The ride is offered to drivers again
+	assert.Eventually(t, func() bool {
+		_, err := f.svc.GetPendingOffer(context.Background(), f.near.ID)
+		return err == nil
+	}, time.Second, time.Millisecond)
+	f.svc.StopDispatch(f.ride.ID)
+}
+
+func TestDispatchNoDriversAvailable(t *testing.T) {
+	f := newMatchingFixture()
+
+	done := f.dispatch(context.Background())
+	f.respond(t, f.near.ID, false)
+	f.respond(t, f.far.ID, false)
+
+	result := <-done
+	assert.Equal(t, errors.ErrNoDriversAvailable, result.err)
+	f.rideService.AssertNotCalled(t, "AcceptRide", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDispatchStopsOnContextCancel(t *testing.T) {
+	f := newMatchingFixture()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := f.dispatch(ctx)
+	f.clock.BlockUntil(1)
+	cancel()
+
+	result := <-done
+	assert.Equal(t, context.Canceled, result.err)
+	assert.Equal(t, models.RideOfferStatusExpired, f.offerRepo.statusOf(f.near.ID))
+}
+
+func TestFindCandidatesAvoidsLowRatedPairs(t *testing.T) {
+	f := newMatchingFixture()
+	f.svc.config.AvoidPairsRatedBelow = 3
+	f.ratingRepo.On("FindLowRatedPartners", mock.Anything, f.ride.RiderID, 3).Return([]string{f.near.UserID}, nil)
+
+	candidates, err := f.svc.findCandidates(context.Background(), f.ride, 5, map[string]bool{})
+
+	assert.NoError(t, err)
+	if assert.Len(t, candidates, 1) {
+		assert.Equal(t, f.far.ID, candidates[0].driver.ID)
+	}
+}
+
+func TestFindCandidatesForPooledRide(t *testing.T) {
+	f := newMatchingFixture()
+	f.ride.Pooled = true
+	f.poolService.On("CanJoin", mock.Anything, f.ride, mock.MatchedBy(func(d *models.Driver) bool {
+		return d.ID == f.near.ID
+	})).Return(false, nil)
+	f.poolService.On("CanJoin", mock.Anything, f.ride, mock.MatchedBy(func(d *models.Driver) bool {
+		return d.ID == f.far.ID
+	})).Return(true, nil)
+
+	candidates, err := f.svc.findCandidates(context.Background(), f.ride, 5, map[string]bool{})
+
+	// The far driver already carries riders going the same way
+	assert.NoError(t, err)
+	if assert.Len(t, candidates, 2) {
+		assert.Equal(t, f.far.ID, candidates[0].driver.ID)
+		assert.Equal(t, f.near.ID, candidates[1].driver.ID)
+	}
+}
+
+func TestFindCandidatesSkipsFullPools(t *testing.T) {
+	f := newMatchingFixture()
+	f.ride.Pooled = true
+	f.poolService.On("CanJoin", mock.Anything, f.ride, mock.MatchedBy(func(d *models.Driver) bool {
+		return d.ID == f.near.ID
+	})).Return(false, errors.ErrRideDoesNotFit)
+	f.poolService.On("CanJoin", mock.Anything, f.ride, mock.MatchedBy(func(d *models.Driver) bool {
+		return d.ID == f.far.ID
+	})).Return(false, errors.ErrActiveRideExists)
+
+	candidates, err := f.svc.findCandidates(context.Background(), f.ride, 5, map[string]bool{})
+
+	assert.NoError(t, err)
+	assert.Empty(t, candidates)
+}
+
+func TestIsLowRated(t *testing.T) {
+	f := newMatchingFixture()
+	f.svc.config.MinDriverRating = 4
+	f.svc.config.MinRatingsToFilter = 5
+
+	tests := []struct {
+		name     string
+		average  float64
+		count    int
+		expected bool
+	}{
+		{name: "Below the minimum", average: 3.9, count: 5, expected: true},
+		{name: "At the minimum", average: 4, count: 5, expected: false},
+		{name: "Too few ratings to judge", average: 2, count: 4, expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver := models.Driver{RatingAverage: tt.average, RatingCount: tt.count}
+			assert.Equal(t, tt.expected, f.svc.isLowRated(driver))
+		})
+	}
+}
+
+func TestRespondToOfferOfAnotherDriver(t *testing.T) {
+	f := newMatchingFixture()
+	offer := models.NewRideOffer(f.ride.ID, &f.near, 0.5, f.clock.Now(), f.clock.Now().Add(time.Minute))
+	assert.NoError(t, f.offerRepo.Create(context.Background(), offer))
+
+	err := f.svc.RespondToOffer(context.Background(), f.far.ID, offer.ID, true)
+	assert.Equal(t, errors.ErrOfferNotFound, err)
+
+	f.clock.Advance(2 * time.Minute)
+	err = f.svc.RespondToOffer(context.Background(), f.near.ID, offer.ID, true)
+	assert.Equal(t, errors.ErrOfferExpired, err)
+}
+
+func TestRankCandidates(t *testing.T) {
+	ride := models.NewRide("rider-1", models.Location{Latitude: 23.8103, Longitude: 90.4125}, models.Location{}, time.Now())
+	ride.VehicleType = models.VehicleTypeCar
+
+	// Roughly 0.5km, 1km and 1.5km north of the pickup
+	closeBike := models.Driver{ID: "close-bike", ActiveVehicle: &models.Vehicle{Type: models.VehicleTypeBike},
+		CurrentLocation: models.Location{Latitude: 23.8148, Longitude: 90.4125}}
+	midCar := models.Driver{ID: "mid-car", ActiveVehicle: &models.Vehicle{Type: models.VehicleTypeCar},
+		CurrentLocation: models.Location{Latitude: 23.8193, Longitude: 90.4125}}
+	farCar := models.Driver{ID: "far-car", ActiveVehicle: &models.Vehicle{Type: models.VehicleTypeCar},
+		CurrentLocation: models.Location{Latitude: 23.8238, Longitude: 90.4125}}
+
+	tests := []struct {
+		name     string
+		rates    map[string]float64
+		expected []string
+	}{
+		{
+			name:     "Vehicle mismatch outweighs distance",
+			expected: []string{"mid-car", "far-car", "close-bike"},
+		},
+		{
+			name:     "Low acceptance rate pushes driver down",
+			rates:    map[string]float64{"mid-car": 0},
+			expected: []string{"far-car", "mid-car", "close-bike"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranked := rankCandidates(testMatchingConfig, ride, []models.Driver{closeBike, midCar, farCar}, tt.rates)
+
+			ids := make([]string, len(ranked))
+			for i, c := range ranked {
+				ids[i] = c.driver.ID
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+}
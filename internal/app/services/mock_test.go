@@ -0,0 +1,746 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/receipt"
+	"github.com/sayeed1999/share-a-ride/internal/provider/email"
+)
+
+// MockUserRepository is a mock implementation of repositories.UserRepository
+type MockUserRepository struct {
+	mock.Mock
+	repositories.UserRepository
+}
+
+func (m *MockUserRepository) FindByID(ctx context.Context, id string) (*models.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+// MockDriverRepository is a mock implementation of repositories.DriverRepository
+type MockDriverRepository struct {
+	mock.Mock
+	repositories.DriverRepository
+}
+
+func (m *MockDriverRepository) FindByID(ctx context.Context, id string) (*models.Driver, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Driver), args.Error(1)
+}
+
+func (m *MockDriverRepository) FindByUserID(ctx context.Context, userID string) (*models.Driver, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Driver), args.Error(1)
+}
+
+func (m *MockDriverRepository) FindAllAvailable(ctx context.Context) ([]models.Driver, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Driver), args.Error(1)
+}
+
+func (m *MockDriverRepository) UpdateLocation(ctx context.Context, driverID string, lat, lng float64, at time.Time) error {
+	args := m.Called(ctx, driverID, lat, lng, at)
+	return args.Error(0)
+}
+
+func (m *MockDriverRepository) MarkStaleUnavailable(ctx context.Context, before time.Time) ([]string, error) {
+	args := m.Called(ctx, before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockDriverRepository) UpdateAvailability(ctx context.Context, driverID string, isAvailable bool) error {
+	args := m.Called(ctx, driverID, isAvailable)
+	return args.Error(0)
+}
+
+func (m *MockDriverRepository) ListByVerificationStatus(ctx context.Context, filter repositories.VerificationQueueFilter) ([]models.Driver, int64, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]models.Driver), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockDriverRepository) FindByLicenseNumber(ctx context.Context, licenseNumber string) (*models.Driver, error) {
+	args := m.Called(ctx, licenseNumber)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Driver), args.Error(1)
+}
+
+func (m *MockDriverRepository) UpdateVerification(ctx context.Context, driver *models.Driver, expected models.VerificationStatus) error {
+	args := m.Called(ctx, driver, expected)
+	return args.Error(0)
+}
+
+func (m *MockDriverRepository) Resubmit(ctx context.Context, driver *models.Driver, expected models.VerificationStatus) ([]models.Document, error) {
+	args := m.Called(ctx, driver, expected)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Document), args.Error(1)
+}
+
+func (m *MockDriverRepository) FindStorageKeysInUse(ctx context.Context, keys []string) ([]string, error) {
+	args := m.Called(ctx, keys)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockDriverRepository) Suspend(ctx context.Context, driver *models.Driver, expected models.VerificationStatus, entry *models.AuditEntry) error {
+	args := m.Called(ctx, driver, expected, entry)
+	return args.Error(0)
+}
+
+func (m *MockDriverRepository) FindApprovedWithLapsedDocuments(ctx context.Context, day time.Time) ([]models.Driver, error) {
+	args := m.Called(ctx, day)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Driver), args.Error(1)
+}
+
+func (m *MockDriverRepository) FindDocumentsExpiringBetween(ctx context.Context, from, to time.Time) ([]models.Document, error) {
+	args := m.Called(ctx, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Document), args.Error(1)
+}
+
+func (m *MockDriverRepository) SaveDocument(ctx context.Context, document *models.Document, replacedID string, driver *models.Driver, expected models.VerificationStatus) error {
+	args := m.Called(ctx, document, replacedID, driver, expected)
+	return args.Error(0)
+}
+
+func (m *MockDriverRepository) DeleteDocument(ctx context.Context, driverID string, documentID string) error {
+	args := m.Called(ctx, driverID, documentID)
+	return args.Error(0)
+}
+
+func (m *MockDriverRepository) UpdateDocumentReminder(ctx context.Context, documentID string, days int) error {
+	args := m.Called(ctx, documentID, days)
+	return args.Error(0)
+}
+
+func (m *MockDriverRepository) AddVehicle(ctx context.Context, vehicle *models.Vehicle) error {
+	args := m.Called(ctx, vehicle)
+	return args.Error(0)
+}
+
+func (m *MockDriverRepository) FindVehicleByPlateNumber(ctx context.Context, plateNumber string) (*models.Vehicle, error) {
+	args := m.Called(ctx, plateNumber)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Vehicle), args.Error(1)
+}
+
+func (m *MockDriverRepository) ActivateVehicle(ctx context.Context, driverID string, vehicleID string, at time.Time, driver *models.Driver, expected models.VerificationStatus) error {
+	args := m.Called(ctx, driverID, vehicleID, at, driver, expected)
+	return args.Error(0)
+}
+
+// MockDocumentFileService is a mock implementation of services.DocumentFileService
+type MockDocumentFileService struct {
+	mock.Mock
+	services.DocumentFileService
+}
+
+func (m *MockDocumentFileService) Resolve(ctx context.Context, userID string, fileKey string) (*models.DocumentFile, error) {
+	args := m.Called(ctx, userID, fileKey)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DocumentFile), args.Error(1)
+}
+
+func (m *MockDocumentFileService) SignURLs(ctx context.Context, documents []models.Document) error {
+	args := m.Called(ctx, documents)
+	return args.Error(0)
+}
+
+func (m *MockDocumentFileService) Discard(ctx context.Context, keys []string) error {
+	args := m.Called(ctx, keys)
+	return args.Error(0)
+}
+
+// MockDriverLocator is a mock implementation of services.DriverLocator
+type MockDriverLocator struct {
+	mock.Mock
+}
+
+func (m *MockDriverLocator) NearbyDrivers(ctx context.Context, lat, lng, radiusKm float64, k int) ([]models.Driver, error) {
+	args := m.Called(ctx, lat, lng, radiusKm, k)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Driver), args.Error(1)
+}
+
+// MockDriverService is a mock implementation of services.DriverService
+type MockDriverService struct {
+	mock.Mock
+	services.DriverService
+}
+
+func (m *MockDriverService) GetDriverByUserID(ctx context.Context, userID string) (*models.Driver, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Driver), args.Error(1)
+}
+
+func (m *MockDriverService) UpdateLocation(ctx context.Context, driverID string, input services.UpdateLocationInput) error {
+	args := m.Called(ctx, driverID, input)
+	return args.Error(0)
+}
+
+// MockRideRepository is a mock implementation of repositories.RideRepository
+type MockRideRepository struct {
+	mock.Mock
+	repositories.RideRepository
+}
+
+func (m *MockRideRepository) Create(ctx context.Context, ride *models.Ride) error {
+	args := m.Called(ctx, ride)
+	return args.Error(0)
+}
+
+func (m *MockRideRepository) FindByID(ctx context.Context, id string) (*models.Ride, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Ride), args.Error(1)
+}
+
+func (m *MockRideRepository) FindActiveByRiderID(ctx context.Context, riderID string) (*models.Ride, error) {
+	args := m.Called(ctx, riderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Ride), args.Error(1)
+}
+
+func (m *MockRideRepository) FindActiveByDriverID(ctx context.Context, driverID string) (*models.Ride, error) {
+	args := m.Called(ctx, driverID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Ride), args.Error(1)
+}
+
+func (m *MockRideRepository) ListActiveByDriverID(ctx context.Context, driverID string) ([]models.Ride, error) {
+	args := m.Called(ctx, driverID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Ride), args.Error(1)
+}
+
+func (m *MockRideRepository) List(ctx context.Context, filter repositories.RideFilter) ([]models.Ride, int64, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]models.Ride), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRideRepository) FindScheduledBefore(ctx context.Context, before time.Time) ([]models.Ride, error) {
+	args := m.Called(ctx, before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Ride), args.Error(1)
+}
+
+func (m *MockRideRepository) FindUncharged(ctx context.Context, before time.Time) ([]models.Ride, error) {
+	args := m.Called(ctx, before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Ride), args.Error(1)
+}
+
+func (m *MockRideRepository) FindOpenRequests(ctx context.Context) ([]models.Ride, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Ride), args.Error(1)
+}
+
+func (m *MockRideRepository) UpdateStatus(ctx context.Context, ride *models.Ride, expected models.RideStatus) error {
+	args := m.Called(ctx, ride, expected)
+	return args.Error(0)
+}
+
+func (m *MockRideRepository) UpdateWaypoints(ctx context.Context, ride *models.Ride, expected models.RideStatus) error {
+	args := m.Called(ctx, ride, expected)
+	return args.Error(0)
+}
+
+// MockLocationHistoryRepository is a mock implementation of repositories.LocationHistoryRepository
+type MockLocationHistoryRepository struct {
+	mock.Mock
+	repositories.LocationHistoryRepository
+}
+
+func (m *MockLocationHistoryRepository) Create(ctx context.Context, ping *models.LocationPing) error {
+	args := m.Called(ctx, ping)
+	return args.Error(0)
+}
+
+func (m *MockLocationHistoryRepository) ListByRide(ctx context.Context, rideID string) ([]models.LocationPing, error) {
+	args := m.Called(ctx, rideID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.LocationPing), args.Error(1)
+}
+
+func (m *MockLocationHistoryRepository) DeleteRecordedBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	args := m.Called(ctx, before, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockSurgeService is a mock implementation of services.SurgeService
+type MockSurgeService struct {
+	mock.Mock
+	services.SurgeService
+}
+
+func (m *MockSurgeService) MultiplierAt(lat, lng float64) float64 {
+	args := m.Called(lat, lng)
+	return args.Get(0).(float64)
+}
+
+// MockPromoCodeRepository is a mock implementation of repositories.PromoCodeRepository
+type MockPromoCodeRepository struct {
+	mock.Mock
+	repositories.PromoCodeRepository
+}
+
+func (m *MockPromoCodeRepository) FindByID(ctx context.Context, id string) (*models.PromoCode, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PromoCode), args.Error(1)
+}
+
+func (m *MockPromoCodeRepository) FindByCode(ctx context.Context, code string) (*models.PromoCode, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PromoCode), args.Error(1)
+}
+
+func (m *MockPromoCodeRepository) CountRedemptions(ctx context.Context, promoCodeID string, userID string) (int64, error) {
+	args := m.Called(ctx, promoCodeID, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPromoCodeRepository) Redeem(ctx context.Context, redemption *models.PromoRedemption) error {
+	args := m.Called(ctx, redemption)
+	return args.Error(0)
+}
+
+// MockPromoService is a mock implementation of services.PromoService
+type MockPromoService struct {
+	mock.Mock
+	services.PromoService
+}
+
+func (m *MockPromoService) CheckPromoCode(ctx context.Context, userID string, code string, vehicleType models.VehicleType) (*models.PromoCode, error) {
+	args := m.Called(ctx, userID, code, vehicleType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PromoCode), args.Error(1)
+}
+
+func (m *MockPromoService) RedeemForRide(ctx context.Context, ride *models.Ride, vehicleType models.VehicleType, fare float64) (float64, error) {
+	args := m.Called(ctx, ride, vehicleType, fare)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func (m *MockPromoService) ReleaseRedemption(ctx context.Context, rideID string) error {
+	args := m.Called(ctx, rideID)
+	return args.Error(0)
+}
+
+// MockPricingService is a mock implementation of services.PricingService
+type MockPricingService struct {
+	mock.Mock
+	services.PricingService
+}
+
+func (m *MockPricingService) EstimateRide(ride *models.Ride, vehicleType models.VehicleType) (*services.FareEstimate, error) {
+	args := m.Called(ride, vehicleType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.FareEstimate), args.Error(1)
+}
+
+func (m *MockPricingService) QuoteRide(ride *models.Ride, vehicleType models.VehicleType, path []models.LocationPing, endedAt time.Time) (*services.FareEstimate, error) {
+	args := m.Called(ride, vehicleType, path, endedAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.FareEstimate), args.Error(1)
+}
+
+func (m *MockPricingService) QuotePooledRide(ride *models.Ride, vehicleType models.VehicleType, sharedDistanceKm float64) (*services.FareEstimate, error) {
+	args := m.Called(ride, vehicleType, sharedDistanceKm)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.FareEstimate), args.Error(1)
+}
+
+// MockPaymentRepository is a mock implementation of repositories.PaymentRepository
+type MockPaymentRepository struct {
+	mock.Mock
+	repositories.PaymentRepository
+}
+
+func (m *MockPaymentRepository) Create(ctx context.Context, payment *models.Payment) error {
+	args := m.Called(ctx, payment)
+	return args.Error(0)
+}
+
+func (m *MockPaymentRepository) FindByRideID(ctx context.Context, rideID string) (*models.Payment, error) {
+	args := m.Called(ctx, rideID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Payment), args.Error(1)
+}
+
+func (m *MockPaymentRepository) Update(ctx context.Context, payment *models.Payment) error {
+	args := m.Called(ctx, payment)
+	return args.Error(0)
+}
+
+// MockPaymentService is a mock implementation of services.PaymentService
+type MockPaymentService struct {
+	mock.Mock
+	services.PaymentService
+}
+
+func (m *MockPaymentService) AuthorizeRide(ctx context.Context, ride *models.Ride, estimatedFare float64) (*models.Payment, error) {
+	args := m.Called(ctx, ride, estimatedFare)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Payment), args.Error(1)
+}
+
+func (m *MockPaymentService) CaptureRide(ctx context.Context, ride *models.Ride) (*models.Payment, error) {
+	args := m.Called(ctx, ride)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Payment), args.Error(1)
+}
+
+func (m *MockPaymentService) VoidRide(ctx context.Context, rideID string) error {
+	args := m.Called(ctx, rideID)
+	return args.Error(0)
+}
+
+// MockLedgerRepository is a mock implementation of repositories.LedgerRepository
+type MockLedgerRepository struct {
+	mock.Mock
+	repositories.LedgerRepository
+}
+
+func (m *MockLedgerRepository) FindOrCreateAccount(ctx context.Context, account *models.LedgerAccount) (*models.LedgerAccount, error) {
+	args := m.Called(ctx, account)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.LedgerAccount), args.Error(1)
+}
+
+func (m *MockLedgerRepository) FindAccountByCode(ctx context.Context, code string) (*models.LedgerAccount, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.LedgerAccount), args.Error(1)
+}
+
+func (m *MockLedgerRepository) Post(ctx context.Context, entry *models.JournalEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockLedgerRepository) PostWithinBalance(ctx context.Context, account *models.LedgerAccount, entries ...*models.JournalEntry) error {
+	args := m.Called(ctx, account, entries)
+	return args.Error(0)
+}
+
+func (m *MockLedgerRepository) FindEntryByKey(ctx context.Context, idempotencyKey string) (*models.JournalEntry, error) {
+	args := m.Called(ctx, idempotencyKey)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.JournalEntry), args.Error(1)
+}
+
+func (m *MockLedgerRepository) SumPostings(ctx context.Context, accountID string) (float64, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+// MockWalletService is a mock implementation of services.WalletService
+type MockWalletService struct {
+	mock.Mock
+	services.WalletService
+}
+
+func (m *MockWalletService) GetWallet(ctx context.Context, userID string) (*services.Wallet, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.Wallet), args.Error(1)
+}
+
+func (m *MockWalletService) HoldRideFare(ctx context.Context, ride *models.Ride, amount float64) (*services.WalletTransaction, error) {
+	args := m.Called(ctx, ride, amount)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.WalletTransaction), args.Error(1)
+}
+
+func (m *MockWalletService) ReleaseRideHold(ctx context.Context, ride *models.Ride) error {
+	args := m.Called(ctx, ride)
+	return args.Error(0)
+}
+
+func (m *MockWalletService) ChargeRide(ctx context.Context, ride *models.Ride) (*services.WalletTransaction, error) {
+	args := m.Called(ctx, ride)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.WalletTransaction), args.Error(1)
+}
+
+// MockEarningsRepository is a mock implementation of repositories.EarningsRepository
+type MockEarningsRepository struct {
+	mock.Mock
+	repositories.EarningsRepository
+}
+
+func (m *MockEarningsRepository) Create(ctx context.Context, earning *models.DriverEarning) error {
+	args := m.Called(ctx, earning)
+	return args.Error(0)
+}
+
+func (m *MockEarningsRepository) CreateStatements(ctx context.Context, periodStart, periodEnd time.Time) ([]models.PayoutStatement, error) {
+	args := m.Called(ctx, periodStart, periodEnd)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.PayoutStatement), args.Error(1)
+}
+
+func (m *MockEarningsRepository) FindStatement(ctx context.Context, id string) (*models.PayoutStatement, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PayoutStatement), args.Error(1)
+}
+
+// MockEarningsService is a mock implementation of services.EarningsService
+type MockEarningsService struct {
+	mock.Mock
+	services.EarningsService
+}
+
+func (m *MockEarningsService) RecordRide(ctx context.Context, ride *models.Ride) error {
+	args := m.Called(ctx, ride)
+	return args.Error(0)
+}
+
+// MockReceiptService is a mock implementation of services.ReceiptService
+type MockReceiptService struct {
+	mock.Mock
+	services.ReceiptService
+}
+
+func (m *MockReceiptService) QueueRideReceipt(rideID string) {
+	m.Called(rideID)
+}
+
+// MockEmailService is a mock implementation of email.EmailServiceInterface
+type MockEmailService struct {
+	mock.Mock
+	email.EmailServiceInterface
+}
+
+func (m *MockEmailService) SendRideReceipt(to string, r *receipt.Receipt) error {
+	args := m.Called(to, r)
+	return args.Error(0)
+}
+
+func (m *MockEmailService) SendRideReminder(to string, scheduledAt time.Time) error {
+	args := m.Called(to, scheduledAt)
+	return args.Error(0)
+}
+
+func (m *MockEmailService) SendDriverVerificationDecision(to, status, reason string) error {
+	args := m.Called(to, status, reason)
+	return args.Error(0)
+}
+
+func (m *MockEmailService) SendDocumentExpiryReminder(to, documentType string, expiresOn time.Time) error {
+	args := m.Called(to, documentType, expiresOn)
+	return args.Error(0)
+}
+
+// MockRatingRepository is a mock implementation of repositories.RatingRepository
+type MockRatingRepository struct {
+	mock.Mock
+	repositories.RatingRepository
+}
+
+func (m *MockRatingRepository) Create(ctx context.Context, rating *models.Rating, window int) error {
+	args := m.Called(ctx, rating, window)
+	return args.Error(0)
+}
+
+func (m *MockRatingRepository) FindLowRatedPartners(ctx context.Context, userID string, stars int) ([]string, error) {
+	args := m.Called(ctx, userID, stars)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// MockRideService is a mock implementation of services.RideService
+type MockRideService struct {
+	mock.Mock
+	services.RideService
+}
+
+func (m *MockRideService) AcceptRide(ctx context.Context, rideID string, driverID string) (*models.Ride, error) {
+	args := m.Called(ctx, rideID, driverID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Ride), args.Error(1)
+}
+
+// MockPoolService is a mock implementation of services.PoolService
+type MockPoolService struct {
+	mock.Mock
+	services.PoolService
+}
+
+func (m *MockPoolService) CanJoin(ctx context.Context, ride *models.Ride, driver *models.Driver) (bool, error) {
+	args := m.Called(ctx, ride, driver)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPoolService) Join(ctx context.Context, ride *models.Ride, driver *models.Driver) (*models.Pool, error) {
+	args := m.Called(ctx, ride, driver)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Pool), args.Error(1)
+}
+
+func (m *MockPoolService) Leave(ctx context.Context, ride *models.Ride) error {
+	args := m.Called(ctx, ride)
+	return args.Error(0)
+}
+
+func (m *MockPoolService) Visit(ctx context.Context, ride *models.Ride, kind models.PoolStopKind) (*models.Pool, error) {
+	args := m.Called(ctx, ride, kind)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Pool), args.Error(1)
+}
+
+func (m *MockPoolService) SharedDistanceKm(ctx context.Context, ride *models.Ride) (float64, error) {
+	args := m.Called(ctx, ride)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+// MockPoolRepository is a mock implementation of repositories.PoolRepository
+type MockPoolRepository struct {
+	mock.Mock
+	repositories.PoolRepository
+}
+
+func (m *MockPoolRepository) Create(ctx context.Context, pool *models.Pool) error {
+	args := m.Called(ctx, pool)
+	return args.Error(0)
+}
+
+func (m *MockPoolRepository) FindByID(ctx context.Context, id string) (*models.Pool, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Pool), args.Error(1)
+}
+
+func (m *MockPoolRepository) FindOpenByDriverID(ctx context.Context, driverID string) (*models.Pool, error) {
+	args := m.Called(ctx, driverID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Pool), args.Error(1)
+}
+
+func (m *MockPoolRepository) Update(ctx context.Context, pool *models.Pool) error {
+	args := m.Called(ctx, pool)
+	return args.Error(0)
+}
+
+// MockMatchingService is a mock implementation of services.MatchingService
+type MockMatchingService struct {
+	mock.Mock
+	services.MatchingService
+}
+
+func (m *MockMatchingService) StartDispatch(ride *models.Ride) {
+	m.Called(ride)
+}
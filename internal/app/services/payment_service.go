@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/provider/payment"
+)
+
+type paymentService struct {
+	paymentRepo repositories.PaymentRepository
+	gateway     payment.Gateway
+	currency    string
+	config      config.PaymentConfig
+}
+
+func NewPaymentService(
+	paymentRepo repositories.PaymentRepository,
+	gateway payment.Gateway,
+	currency string,
+	cfg config.PaymentConfig,
+) services.PaymentService {
+	return &paymentService{
+		paymentRepo: paymentRepo,
+		gateway:     gateway,
+		currency:    currency,
+		config:      cfg,
+	}
+}
+
+func (s *paymentService) AuthorizeRide(ctx context.Context, ride *models.Ride, estimatedFare float64) (*models.Payment, error) {
+	// A ride is only authorized once, even when acceptance is retried
+	if existing, err := s.paymentRepo.FindByRideID(ctx, ride.ID); err == nil {
+		return existing, nil
+	} else if err != errors.ErrPaymentNotFound {
+		return nil, err
+	}
+
+	amount := toMinorUnits(estimatedFare * (1 + s.config.AuthorizationBuffer))
+	auth, err := s.gateway.Authorize(ctx, payment.AuthorizeRequest{
+		Reference:  ride.ID,
+		CustomerID: ride.RiderID,
+		Amount:     amount,
+		Currency:   s.currency,
+	})
+	if err == payment.ErrDeclined {
+		return nil, errors.ErrPaymentDeclined
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p := models.NewPayment(ride.ID, ride.RiderID, s.currency, fromMinorUnits(auth.Amount), auth.ID)
+	if err := s.paymentRepo.Create(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *paymentService) CaptureRide(ctx context.Context, ride *models.Ride) (*models.Payment, error) {
+	p, err := s.paymentRepo.FindByRideID(ctx, ride.ID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status == models.PaymentStatusCaptured {
+		return p, nil
+	}
+
+	amount := toMinorUnits(ride.Fare)
+	if amount == 0 {
+		// Nothing to charge, e.g. the promo code covered the whole fare
+		if err := s.gateway.Void(ctx, p.AuthorizationID); err != nil {
+			return nil, err
+		}
+		p.MarkVoided()
+		return p, s.paymentRepo.Update(ctx, p)
+	}
+
+	if amount > toMinorUnits(p.AuthorizedAmount) {
+		// The trip cost more than was held; hold the final fare instead, or
+		// settle for what was held if the rider's card refuses more
+		err := s.reauthorize(ctx, p, ride, amount)
+		if err == payment.ErrDeclined {
+			amount = toMinorUnits(p.AuthorizedAmount)
+		} else if err != nil {
+			return s.captureFailed(ctx, p, err)
+		}
+	}
+
+	capture, err := s.gateway.Capture(ctx, p.AuthorizationID, amount)
+	if err != nil {
+		return s.captureFailed(ctx, p, err)
+	}
+
+	p.MarkCaptured(fromMinorUnits(capture.Amount), capture.ID)
+	if err := s.paymentRepo.Update(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// captureFailed records err on p and returns it
+func (s *paymentService) captureFailed(ctx context.Context, p *models.Payment, err error) (*models.Payment, error) {
+	p.MarkCaptureFailed(err.Error())
+	if updateErr := s.paymentRepo.Update(ctx, p); updateErr != nil {
+		return nil, updateErr
+	}
+	return p, err
+}
+
+// reauthorize replaces the authorization of p with a new one for amount.
+// The old hold is only released once the new one is in place, so a
+// declined authorization leaves the old hold to capture.
+func (s *paymentService) reauthorize(ctx context.Context, p *models.Payment, ride *models.Ride, amount int64) error {
+	auth, err := s.gateway.Authorize(ctx, payment.AuthorizeRequest{
+		Reference:  ride.ID + ":final",
+		CustomerID: ride.RiderID,
+		Amount:     amount,
+		Currency:   p.Currency,
+	})
+	if err != nil {
+		return err
+	}
+
+	// An old hold left behind expires at the gateway eventually
+	if err := s.gateway.Void(ctx, p.AuthorizationID); err != nil && err != payment.ErrInvalidState {
+		log.Printf("failed to void replaced authorization of ride %s: %v", ride.ID, err)
+	}
+
+	p.AuthorizationID = auth.ID
+	p.AuthorizedAmount = fromMinorUnits(auth.Amount)
+	return nil
+}
+
+func (s *paymentService) VoidRide(ctx context.Context, rideID string) error {
+	p, err := s.paymentRepo.FindByRideID(ctx, rideID)
+	if err == errors.ErrPaymentNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if p.Status != models.PaymentStatusAuthorized {
+		return nil
+	}
+
+	if err := s.gateway.Void(ctx, p.AuthorizationID); err != nil {
+		return err
+	}
+	p.MarkVoided()
+	return s.paymentRepo.Update(ctx, p)
+}
+
+func (s *paymentService) RefundRide(ctx context.Context, rideID string, amount float64) (*models.Payment, error) {
+	p, err := s.paymentRepo.FindByRideID(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != models.PaymentStatusCaptured {
+		return nil, errors.ErrPaymentNotRefundable
+	}
+	if toMinorUnits(amount) > toMinorUnits(p.RefundableAmount()) {
+		return nil, errors.ErrRefundTooLarge
+	}
+
+	refund, err := s.gateway.Refund(ctx, p.CaptureID, toMinorUnits(amount))
+	if err != nil {
+		return nil, err
+	}
+
+	p.AddRefund(fromMinorUnits(refund.Amount))
+	if err := s.paymentRepo.Update(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *paymentService) GetRidePayment(ctx context.Context, rideID string) (*models.Payment, error) {
+	return s.paymentRepo.FindByRideID(ctx, rideID)
+}
+
+func toMinorUnits(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func fromMinorUnits(amount int64) float64 {
+	return float64(amount) / 100
+}
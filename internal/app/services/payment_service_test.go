@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/provider/payment"
+)
+
+func newTestPaymentService() (*paymentService, *MockPaymentRepository, *payment.Fake) {
+	paymentRepo := new(MockPaymentRepository)
+	gateway := payment.NewFake()
+	svc := NewPaymentService(paymentRepo, gateway, "BDT", config.PaymentConfig{AuthorizationBuffer: 0.3}).(*paymentService)
+	return svc, paymentRepo, gateway
+}
+
+func TestAuthorizeRide(t *testing.T) {
+	ctx := context.Background()
+	ride := models.NewRide("rider-1", models.Location{}, models.Location{}, time.Now())
+
+	t.Run("holds the estimate plus the buffer", func(t *testing.T) {
+		svc, paymentRepo, _ := newTestPaymentService()
+		paymentRepo.On("FindByRideID", ctx, ride.ID).Return(nil, errors.ErrPaymentNotFound)
+		paymentRepo.On("Create", ctx, mock.AnythingOfType("*models.Payment")).Return(nil)
+
+		p, err := svc.AuthorizeRide(ctx, ride, 200)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.PaymentStatusAuthorized, p.Status)
+		assert.Equal(t, 260.0, p.AuthorizedAmount)
+		assert.Equal(t, "BDT", p.Currency)
+	})
+
+	t.Run("reuses an existing authorization", func(t *testing.T) {
+		svc, paymentRepo, _ := newTestPaymentService()
+		existing := models.NewPayment(ride.ID, ride.RiderID, "BDT", 260, "fake_auth_1")
+		paymentRepo.On("FindByRideID", ctx, ride.ID).Return(existing, nil)
+
+		p, err := svc.AuthorizeRide(ctx, ride, 200)
+
+		assert.NoError(t, err)
+		assert.Same(t, existing, p)
+		paymentRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("declined", func(t *testing.T) {
+		svc, paymentRepo, gateway := newTestPaymentService()
+		gateway.DeclineCustomer(ride.RiderID)
+		paymentRepo.On("FindByRideID", ctx, ride.ID).Return(nil, errors.ErrPaymentNotFound)
+
+		_, err := svc.AuthorizeRide(ctx, ride, 200)
+
+		assert.Equal(t, errors.ErrPaymentDeclined, err)
+		paymentRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCaptureRide(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name             string
+		fare             float64
+		wantStatus       models.PaymentStatus
+		wantCaptured     float64
+		wantAuthorized   float64
+		wantReauthorized bool
+	}{
+		{name: "less than held", fare: 230, wantStatus: models.PaymentStatusCaptured, wantCaptured: 230, wantAuthorized: 260},
+		{name: "more than held", fare: 300, wantStatus: models.PaymentStatusCaptured, wantCaptured: 300, wantAuthorized: 300, wantReauthorized: true},
+		{name: "nothing to charge", fare: 0, wantStatus: models.PaymentStatusVoided, wantAuthorized: 260},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, paymentRepo, gateway := newTestPaymentService()
+			ride := models.NewRide("rider-1", models.Location{}, models.Location{}, time.Now())
+			ride.Fare = tt.fare
+			auth, _ := gateway.Authorize(ctx, payment.AuthorizeRequest{Reference: ride.ID, CustomerID: ride.RiderID, Amount: 26000})
+			p := models.NewPayment(ride.ID, ride.RiderID, "BDT", 260, auth.ID)
+			paymentRepo.On("FindByRideID", ctx, ride.ID).Return(p, nil)
+			paymentRepo.On("Update", ctx, p).Return(nil)
+
+			captured, err := svc.CaptureRide(ctx, ride)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, captured.Status)
+			assert.Equal(t, tt.wantCaptured, captured.CapturedAmount)
+			assert.Equal(t, tt.wantAuthorized, captured.AuthorizedAmount)
+			assert.Equal(t, tt.wantReauthorized, captured.AuthorizationID != auth.ID)
+			// The original hold is never left behind
+			assert.Equal(t, payment.ErrInvalidState, gateway.Void(ctx, auth.ID))
+		})
+	}
+
+	t.Run("captures the original hold when the final fare is declined", func(t *testing.T) {
+		svc, paymentRepo, gateway := newTestPaymentService()
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, time.Now())
+		ride.Fare = 300
+		auth, _ := gateway.Authorize(ctx, payment.AuthorizeRequest{Reference: ride.ID, CustomerID: ride.RiderID, Amount: 26000})
+		gateway.DeclineCustomer(ride.RiderID)
+		p := models.NewPayment(ride.ID, ride.RiderID, "BDT", 260, auth.ID)
+		paymentRepo.On("FindByRideID", ctx, ride.ID).Return(p, nil)
+		paymentRepo.On("Update", ctx, p).Return(nil)
+
+		captured, err := svc.CaptureRide(ctx, ride)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.PaymentStatusCaptured, captured.Status)
+		assert.Equal(t, 260.0, captured.CapturedAmount)
+		assert.Equal(t, auth.ID, captured.AuthorizationID)
+	})
+
+	t.Run("records a failed capture", func(t *testing.T) {
+		svc, paymentRepo, _ := newTestPaymentService()
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, time.Now())
+		ride.Fare = 230
+		p := models.NewPayment(ride.ID, ride.RiderID, "BDT", 260, "unknown")
+		paymentRepo.On("FindByRideID", ctx, ride.ID).Return(p, nil)
+		paymentRepo.On("Update", ctx, p).Return(nil)
+
+		_, err := svc.CaptureRide(ctx, ride)
+
+		assert.Equal(t, payment.ErrUnknownTransaction, err)
+		assert.Equal(t, models.PaymentStatusFailed, p.Status)
+		assert.NotEmpty(t, p.FailureReason)
+	})
+}
+
+func TestRefundRide(t *testing.T) {
+	ctx := context.Background()
+
+	newCaptured := func() (*paymentService, *models.Payment) {
+		svc, paymentRepo, gateway := newTestPaymentService()
+		auth, _ := gateway.Authorize(ctx, payment.AuthorizeRequest{Reference: "ride-1", CustomerID: "rider-1", Amount: 26000})
+		capture, _ := gateway.Capture(ctx, auth.ID, 23000)
+		p := models.NewPayment("ride-1", "rider-1", "BDT", 260, auth.ID)
+		p.MarkCaptured(230, capture.ID)
+		paymentRepo.On("FindByRideID", ctx, "ride-1").Return(p, nil)
+		paymentRepo.On("Update", ctx, p).Return(nil)
+		return svc, p
+	}
+
+	t.Run("partial then full refund", func(t *testing.T) {
+		svc, p := newCaptured()
+
+		_, err := svc.RefundRide(ctx, "ride-1", 30)
+		assert.NoError(t, err)
+		assert.Equal(t, models.PaymentStatusCaptured, p.Status)
+		assert.Equal(t, 200.0, p.RefundableAmount())
+
+		_, err = svc.RefundRide(ctx, "ride-1", 200)
+		assert.NoError(t, err)
+		assert.Equal(t, models.PaymentStatusRefunded, p.Status)
+		assert.Equal(t, 230.0, p.RefundedAmount)
+	})
+
+	t.Run("more than captured", func(t *testing.T) {
+		svc, _ := newCaptured()
+
+		_, err := svc.RefundRide(ctx, "ride-1", 230.01)
+
+		assert.Equal(t, errors.ErrRefundTooLarge, err)
+	})
+
+	t.Run("not captured", func(t *testing.T) {
+		svc, paymentRepo, _ := newTestPaymentService()
+		paymentRepo.On("FindByRideID", ctx, "ride-1").Return(models.NewPayment("ride-1", "rider-1", "BDT", 260, "auth"), nil)
+
+		_, err := svc.RefundRide(ctx, "ride-1", 10)
+
+		assert.Equal(t, errors.ErrPaymentNotRefundable, err)
+	})
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/pool"
+)
+
+// maxPoolUpdateAttempts bounds how often a change to a pool is retried
+// after losing a race with another change
+const maxPoolUpdateAttempts = 3
+
+type poolService struct {
+	poolRepo repositories.PoolRepository
+	rideRepo repositories.RideRepository
+	clock    clock.Clock
+	config   config.PoolConfig
+}
+
+func NewPoolService(
+	poolRepo repositories.PoolRepository,
+	rideRepo repositories.RideRepository,
+	clk clock.Clock,
+	cfg config.PoolConfig,
+) services.PoolService {
+	return &poolService{
+		poolRepo: poolRepo,
+		rideRepo: rideRepo,
+		clock:    clk,
+		config:   cfg,
+	}
+}
+
+func (s *poolService) GetDriverPool(ctx context.Context, driverID string) (*models.Pool, error) {
+	return s.poolRepo.FindOpenByDriverID(ctx, driverID)
+}
+
+func (s *poolService) CanJoin(ctx context.Context, ride *models.Ride, driver *models.Driver) (bool, error) {
+	_, isNew, err := s.plan(ctx, ride, driver)
+	if err != nil {
+		return false, err
+	}
+	return !isNew, nil
+}
+
+func (s *poolService) Join(ctx context.Context, ride *models.Ride, driver *models.Driver) (*models.Pool, error) {
+	for attempt := 1; ; attempt++ {
+		p, isNew, err := s.plan(ctx, ride, driver)
+		if err != nil {
+			return nil, err
+		}
+
+		if isNew {
+			err = s.poolRepo.Create(ctx, p)
+		} else {
+			err = s.poolRepo.Update(ctx, p)
+		}
+		if err == errors.ErrPoolConflict && attempt < maxPoolUpdateAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+}
+
+// plan works out the driver's pool with ride added without saving it.
+// isNew tells whether the pool has yet to be created.
+func (s *poolService) plan(ctx context.Context, ride *models.Ride, driver *models.Driver) (*models.Pool, bool, error) {
+	isNew := false
+	p, err := s.poolRepo.FindOpenByDriverID(ctx, driver.ID)
+	if err == errors.ErrPoolNotFound {
+		// Only a driver without a ride can start a pool
+		if _, err := s.rideRepo.FindActiveByDriverID(ctx, driver.ID); err == nil {
+			return nil, false, errors.ErrActiveRideExists
+		} else if err != errors.ErrRideNotFound {
+			return nil, false, err
+		}
+		if driver.ActiveVehicle == nil {
+			return nil, false, errors.ErrVehicleNotFound
+		}
+		p, isNew = models.NewPool(driver.ID, driver.ActiveVehicle.PassengerSeats(), s.clock.Now()), true
+	} else if err != nil {
+		return nil, false, err
+	}
+	// A retried acceptance finds the ride already planned in
+	for _, stop := range p.Route {
+		if stop.RideID == ride.ID {
+			return p, false, nil
+		}
+	}
+
+	start := toPoint(driver.CurrentLocation)
+	pickup := pool.Stop{RideID: ride.ID, Kind: pool.Pickup, Point: toPoint(ride.PickupLocation), Seats: ride.Seats}
+	dropoff := pool.Stop{RideID: ride.ID, Kind: pool.Dropoff, Point: toPoint(ride.DropoffLocation), Seats: ride.Seats}
+	route, ok := pool.Insert(start, toPoolStops(p.Route), pickup, dropoff, pool.Limits{
+		Capacity:        p.Capacity,
+		MaxDetourFactor: s.config.MaxDetourFactor,
+		MaxPickupKm:     s.config.MaxPickupKm,
+	})
+	if !ok {
+		return nil, false, errors.ErrRideDoesNotFit
+	}
+
+	p.Route = fromPoolStops(route)
+	p.UpdatedAt = s.clock.Now()
+	return p, isNew, nil
+}
+
+func (s *poolService) Leave(ctx context.Context, ride *models.Ride) error {
+	if ride.PoolID == nil {
+		return nil
+	}
+	_, err := s.modify(ctx, *ride.PoolID, func(p *models.Pool) bool {
+		return p.Remove(ride.ID, s.clock.Now())
+	})
+	return err
+}
+
+func (s *poolService) Visit(ctx context.Context, ride *models.Ride, kind models.PoolStopKind) (*models.Pool, error) {
+	if ride.PoolID == nil {
+		return nil, errors.ErrPoolNotFound
+	}
+	return s.modify(ctx, *ride.PoolID, func(p *models.Pool) bool {
+		return p.Visit(ride.ID, kind, s.clock.Now())
+	})
+}
+
+// modify applies change to a freshly loaded pool and saves it if change
+// reports that it did anything, retrying when another change got there
+// first
+func (s *poolService) modify(ctx context.Context, poolID string, change func(p *models.Pool) bool) (*models.Pool, error) {
+	for attempt := 1; ; attempt++ {
+		p, err := s.poolRepo.FindByID(ctx, poolID)
+		if err != nil {
+			return nil, err
+		}
+		if !change(p) {
+			return p, nil
+		}
+
+		err = s.poolRepo.Update(ctx, p)
+		if err == errors.ErrPoolConflict && attempt < maxPoolUpdateAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+}
+
+func (s *poolService) SharedDistanceKm(ctx context.Context, ride *models.Ride) (float64, error) {
+	if ride.PoolID == nil {
+		return 0, errors.ErrPoolNotFound
+	}
+	p, err := s.poolRepo.FindByID(ctx, *ride.PoolID)
+	if err != nil {
+		return 0, err
+	}
+	// A ride being completed has not had its dropoff recorded yet; the
+	// pool is not saved, so this only closes its last leg for the count
+	p.Visit(ride.ID, models.PoolStopDropoff, s.clock.Now())
+	return pool.SharedDistance(toPoolStops(p.Visited), ride.ID), nil
+}
+
+func toPoint(location models.Location) pool.Point {
+	return pool.Point{Lat: location.Latitude, Lng: location.Longitude}
+}
+
+func toPoolStops(stops []models.PoolStop) []pool.Stop {
+	converted := make([]pool.Stop, len(stops))
+	for i, stop := range stops {
+		converted[i] = pool.Stop{
+			RideID: stop.RideID,
+			Kind:   pool.Kind(stop.Kind),
+			Point:  toPoint(stop.Location),
+			Seats:  stop.Seats,
+		}
+	}
+	return converted
+}
+
+func fromPoolStops(stops []pool.Stop) []models.PoolStop {
+	converted := make([]models.PoolStop, len(stops))
+	for i, stop := range stops {
+		converted[i] = models.PoolStop{
+			RideID:   stop.RideID,
+			Kind:     models.PoolStopKind(stop.Kind),
+			Location: models.Location{Latitude: stop.Point.Lat, Longitude: stop.Point.Lng},
+			Seats:    stop.Seats,
+		}
+	}
+	return converted
+}
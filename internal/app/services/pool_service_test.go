@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+)
+
+// kmEast returns a location about km kilometres east of the test origin
+func kmEast(km float64) models.Location {
+	return models.Location{Latitude: 23.8, Longitude: 90.4 + km*0.00983}
+}
+
+func TestJoinPool(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	driver := &models.Driver{ID: "driver-1", CurrentLocation: kmEast(0), ActiveVehicle: &models.Vehicle{Type: models.VehicleTypeCar}}
+	cfg := config.PoolConfig{MaxDetourFactor: 1.5, MaxPickupKm: 5, MaxSeatsPerRide: 2}
+
+	newPooledRide := func(from, to float64) *models.Ride {
+		ride := models.NewRide("rider-1", kmEast(from), kmEast(to), now)
+		ride.Pooled = true
+		return ride
+	}
+	newTestPoolService := func() (*poolService, *MockPoolRepository, *MockRideRepository) {
+		poolRepo := new(MockPoolRepository)
+		rideRepo := new(MockRideRepository)
+		return NewPoolService(poolRepo, rideRepo, clock.NewFake(now), cfg).(*poolService), poolRepo, rideRepo
+	}
+
+	t.Run("a free driver opens a pool", func(t *testing.T) {
+		svc, poolRepo, rideRepo := newTestPoolService()
+		ride := newPooledRide(1, 10)
+		poolRepo.On("FindOpenByDriverID", ctx, driver.ID).Return(nil, errors.ErrPoolNotFound)
+		rideRepo.On("FindActiveByDriverID", ctx, driver.ID).Return(nil, errors.ErrRideNotFound)
+		poolRepo.On("Create", ctx, mock.Anything).Return(nil)
+
+		p, err := svc.Join(ctx, ride, driver)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, p.Capacity)
+		assert.Len(t, p.Route, 2)
+		poolRepo.AssertExpectations(t)
+	})
+
+	t.Run("a driver on a private ride cannot open a pool", func(t *testing.T) {
+		svc, poolRepo, rideRepo := newTestPoolService()
+		poolRepo.On("FindOpenByDriverID", ctx, driver.ID).Return(nil, errors.ErrPoolNotFound)
+		rideRepo.On("FindActiveByDriverID", ctx, driver.ID).Return(&models.Ride{}, nil)
+
+		shared, err := svc.CanJoin(ctx, newPooledRide(1, 10), driver)
+
+		assert.Equal(t, errors.ErrActiveRideExists, err)
+		assert.False(t, shared)
+	})
+
+	t.Run("a ride on the way joins the open pool", func(t *testing.T) {
+		svc, poolRepo, _ := newTestPoolService()
+		open := models.NewPool(driver.ID, 3, now)
+		open.Route = []models.PoolStop{
+			{RideID: "a", Kind: models.PoolStopPickup, Location: kmEast(1), Seats: 1},
+			{RideID: "a", Kind: models.PoolStopDropoff, Location: kmEast(10), Seats: 1},
+		}
+		ride := newPooledRide(3, 8)
+		poolRepo.On("FindOpenByDriverID", ctx, driver.ID).Return(open, nil)
+		poolRepo.On("Update", ctx, open).Return(nil)
+
+		shared, err := svc.CanJoin(ctx, ride, driver)
+		assert.NoError(t, err)
+		assert.True(t, shared)
+
+		p, err := svc.Join(ctx, ride, driver)
+
+		assert.NoError(t, err)
+		kinds := make([]string, len(p.Route))
+		for i, stop := range p.Route {
+			kinds[i] = string(stop.Kind) + ":" + stop.RideID
+		}
+		assert.Equal(t, []string{"pickup:a", "pickup:" + ride.ID, "dropoff:" + ride.ID, "dropoff:a"}, kinds)
+	})
+
+	t.Run("a ride the other way does not fit", func(t *testing.T) {
+		svc, poolRepo, _ := newTestPoolService()
+		open := models.NewPool(driver.ID, 3, now)
+		open.Route = []models.PoolStop{
+			{RideID: "a", Kind: models.PoolStopPickup, Location: kmEast(1), Seats: 1},
+			{RideID: "a", Kind: models.PoolStopDropoff, Location: kmEast(10), Seats: 1},
+		}
+		poolRepo.On("FindOpenByDriverID", ctx, driver.ID).Return(open, nil)
+
+		_, err := svc.Join(ctx, newPooledRide(9, 0), driver)
+
+		assert.Equal(t, errors.ErrRideDoesNotFit, err)
+		poolRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("retries after losing a race for the pool", func(t *testing.T) {
+		svc, poolRepo, rideRepo := newTestPoolService()
+		ride := newPooledRide(1, 10)
+		poolRepo.On("FindOpenByDriverID", ctx, driver.ID).Return(nil, errors.ErrPoolNotFound)
+		rideRepo.On("FindActiveByDriverID", ctx, driver.ID).Return(nil, errors.ErrRideNotFound)
+		poolRepo.On("Create", ctx, mock.Anything).Return(errors.ErrPoolConflict).Once()
+		poolRepo.On("Create", ctx, mock.Anything).Return(nil).Once()
+
+		_, err := svc.Join(ctx, ride, driver)
+
+		assert.NoError(t, err)
+		poolRepo.AssertNumberOfCalls(t, "Create", 2)
+	})
+}
+
+func TestSharedDistanceKm(t *testing.T) {
+	ctx := context.Background()
+	poolRepo := new(MockPoolRepository)
+	svc := NewPoolService(poolRepo, new(MockRideRepository), clock.NewFake(time.Now()), config.PoolConfig{})
+
+	p := models.NewPool("driver-1", 3, time.Now())
+	p.Visited = []models.PoolStop{
+		{RideID: "a", Kind: models.PoolStopPickup, Location: kmEast(0), Seats: 1},
+		{RideID: "b", Kind: models.PoolStopPickup, Location: kmEast(2), Seats: 1},
+		{RideID: "a", Kind: models.PoolStopDropoff, Location: kmEast(6), Seats: 1},
+		{RideID: "b", Kind: models.PoolStopDropoff, Location: kmEast(10), Seats: 1},
+	}
+	poolRepo.On("FindByID", ctx, p.ID).Return(p, nil)
+
+	km, err := svc.SharedDistanceKm(ctx, &models.Ride{ID: "a", PoolID: &p.ID})
+
+	// a rides 2 km alone and shares 4 km with b
+	assert.NoError(t, err)
+	assert.InDelta(t, 4, km, 0.01)
+
+	t.Run("counts a dropoff still ahead as served", func(t *testing.T) {
+		ahead := models.NewPool("driver-1", 3, time.Now())
+		ahead.Visited = append([]models.PoolStop(nil), p.Visited[:2]...)
+		ahead.Route = append([]models.PoolStop(nil), p.Visited[2:]...)
+		poolRepo.On("FindByID", ctx, ahead.ID).Return(ahead, nil)
+
+		km, err := svc.SharedDistanceKm(ctx, &models.Ride{ID: "a", PoolID: &ahead.ID})
+
+		assert.NoError(t, err)
+		assert.InDelta(t, 4, km, 0.01)
+	})
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/geo"
+)
+
+type pricingService struct {
+	surgeService services.SurgeService
+	config       config.PricingConfig
+}
+
+func NewPricingService(surgeService services.SurgeService, cfg config.PricingConfig) services.PricingService {
+	return &pricingService{surgeService: surgeService, config: cfg}
+}
+
+func (s *pricingService) EstimateFare(ctx context.Context, input services.EstimateFareInput) ([]services.FareEstimate, error) {
+	vehicleTypes := []models.VehicleType{input.VehicleType}
+	if input.VehicleType == "" {
+		vehicleTypes = s.vehicleTypes()
+	}
+
+	route := append(append([]models.Location{input.Pickup}, input.Waypoints...), input.Dropoff)
+	distanceKm := s.roadDistanceKm(route)
+	surgeMultiplier := s.surgeService.MultiplierAt(input.Pickup.Latitude, input.Pickup.Longitude)
+
+	estimates := make([]services.FareEstimate, 0, len(vehicleTypes))
+	for _, vehicleType := range vehicleTypes {
+		tariff, ok := s.config.Tariffs[string(vehicleType)]
+		if !ok {
+			return nil, errors.ErrTariffNotFound
+		}
+
+		duration := estimateDuration(distanceKm, tariff.AverageSpeedKmh)
+		estimate, err := s.QuoteTrip(vehicleType, distanceKm, duration, surgeMultiplier)
+		if err != nil {
+			return nil, err
+		}
+		estimates = append(estimates, *estimate)
+	}
+	return estimates, nil
+}
+
+func (s *pricingService) QuoteTrip(
+	vehicleType models.VehicleType,
+	distanceKm float64,
+	duration time.Duration,
+	surgeMultiplier float64,
+) (*services.FareEstimate, error) {
+	tariff, ok := s.config.Tariffs[string(vehicleType)]
+	if !ok {
+		return nil, errors.ErrTariffNotFound
+	}
+
+	return &services.FareEstimate{
+		VehicleType:     vehicleType,
+		Currency:        s.config.Currency,
+		DistanceKm:      distanceKm,
+		DurationMinutes: math.Round(duration.Minutes()*10) / 10,
+		Breakdown:       tariff.Quote(distanceKm, duration, surgeMultiplier),
+	}, nil
+}
+
+func (s *pricingService) EstimateRide(ride *models.Ride, vehicleType models.VehicleType) (*services.FareEstimate, error) {
+	tariff, ok := s.config.Tariffs[string(vehicleType)]
+	if !ok {
+		return nil, errors.ErrTariffNotFound
+	}
+
+	distanceKm := s.roadDistanceKm(ride.Route())
+	duration := estimateDuration(distanceKm, tariff.AverageSpeedKmh)
+	return s.QuoteTrip(vehicleType, distanceKm, duration, ride.SurgeMultiplier)
+}
+
+func (s *pricingService) QuoteRide(
+	ride *models.Ride,
+	vehicleType models.VehicleType,
+	path []models.LocationPing,
+	endedAt time.Time,
+) (*services.FareEstimate, error) {
+	// Only the part of the path after pickup is charged
+	var distanceKm float64
+	var previous *models.LocationPing
+	for i := range path {
+		ping := &path[i]
+		if ride.StartedAt != nil && ping.RecordedAt.Before(*ride.StartedAt) {
+			continue
+		}
+		if previous != nil {
+			distanceKm += geo.HaversineKm(
+				previous.Location.Latitude, previous.Location.Longitude,
+				ping.Location.Latitude, ping.Location.Longitude,
+			)
+		}
+		previous = ping
+	}
+	distanceKm = math.Round(distanceKm*100) / 100
+
+	// Without a usable track fall back to the estimate
+	if distanceKm == 0 {
+		distanceKm = s.roadDistanceKm(ride.Route())
+	}
+
+	var duration time.Duration
+	if ride.StartedAt != nil && endedAt.After(*ride.StartedAt) {
+		duration = endedAt.Sub(*ride.StartedAt)
+	}
+
+	return s.QuoteTrip(vehicleType, distanceKm, duration, ride.SurgeMultiplier)
+}
+
+func (s *pricingService) QuotePooledRide(
+	ride *models.Ride,
+	vehicleType models.VehicleType,
+	sharedDistanceKm float64,
+) (*services.FareEstimate, error) {
+	tariff, ok := s.config.Tariffs[string(vehicleType)]
+	if !ok {
+		return nil, errors.ErrTariffNotFound
+	}
+
+	distanceKm := math.Round(sharedDistanceKm*s.config.RoadDistanceFactor*100) / 100
+	duration := estimateDuration(distanceKm, tariff.AverageSpeedKmh)
+	return s.QuoteTrip(vehicleType, distanceKm, duration, ride.SurgeMultiplier)
+}
+
+// roadDistanceKm estimates the distance by road through the points of
+// route in order. There is no routing engine; roads are assumed to be a
+// fixed factor longer than the straight line.
+func (s *pricingService) roadDistanceKm(route []models.Location) float64 {
+	var distanceKm float64
+	for i := 1; i < len(route); i++ {
+		from, to := route[i-1], route[i]
+		distanceKm += geo.HaversineKm(from.Latitude, from.Longitude, to.Latitude, to.Longitude)
+	}
+	return math.Round(distanceKm*s.config.RoadDistanceFactor*100) / 100
+}
+
+// vehicleTypes lists the vehicle types that have a tariff, in name order
+func (s *pricingService) vehicleTypes() []models.VehicleType {
+	types := make([]models.VehicleType, 0, len(s.config.Tariffs))
+	for name := range s.config.Tariffs {
+		types = append(types, models.VehicleType(name))
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// estimateDuration is the time to cover distanceKm at speedKmh, rounded up
+// to whole minutes
+func estimateDuration(distanceKm, speedKmh float64) time.Duration {
+	if speedKmh <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(distanceKm/speedKmh*60)) * time.Minute
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/pricing"
+)
+
+var testPricingConfig = config.PricingConfig{
+	Currency:           "BDT",
+	RoadDistanceFactor: 1.3,
+	Tariffs: map[string]config.TariffConfig{
+		"car": {
+			Tariff:          pricing.Tariff{BaseFare: 50, PerKm: 20, PerMinute: 2, MinimumFare: 100, BookingFee: 10},
+			AverageSpeedKmh: 20,
+		},
+		"bike": {
+			Tariff:          pricing.Tariff{BaseFare: 20, PerKm: 12, PerMinute: 1, MinimumFare: 50, BookingFee: 5},
+			AverageSpeedKmh: 25,
+		},
+	},
+}
+
+func newTestPricingService(surgeMultiplier float64) services.PricingService {
+	surgeService := new(MockSurgeService)
+	surgeService.On("MultiplierAt", mock.Anything, mock.Anything).Return(surgeMultiplier)
+	return NewPricingService(surgeService, testPricingConfig)
+}
+
+func TestEstimateFare(t *testing.T) {
+	svc := newTestPricingService(1)
+	// Roughly 6.9km apart in a straight line
+	input := services.EstimateFareInput{
+		Pickup:  models.Location{Latitude: 23.8103, Longitude: 90.4125},
+		Dropoff: models.Location{Latitude: 23.7509, Longitude: 90.3935},
+	}
+
+	t.Run("all vehicle types in name order", func(t *testing.T) {
+		estimates, err := svc.EstimateFare(context.Background(), input)
+
+		assert.NoError(t, err)
+		if assert.Len(t, estimates, 2) {
+			assert.Equal(t, models.VehicleTypeBike, estimates[0].VehicleType)
+			assert.Equal(t, models.VehicleTypeCar, estimates[1].VehicleType)
+			assert.Equal(t, 8.95, estimates[1].DistanceKm)
+			// 8.95km at 20km/h, rounded up to whole minutes
+			assert.Equal(t, float64(27), estimates[1].DurationMinutes)
+			assert.Equal(t, "BDT", estimates[1].Currency)
+		}
+	})
+
+	t.Run("single vehicle type", func(t *testing.T) {
+		input := input
+		input.VehicleType = models.VehicleTypeCar
+
+		estimates, err := svc.EstimateFare(context.Background(), input)
+
+		assert.NoError(t, err)
+		if assert.Len(t, estimates, 1) {
+			b := estimates[0].Breakdown
+			assert.Equal(t, b.BaseFare+b.DistanceFare+b.TimeFare+b.MinimumFareAdjustment+b.BookingFee, b.Total)
+		}
+	})
+
+	t.Run("surge at the pickup point", func(t *testing.T) {
+		input := input
+		input.VehicleType = models.VehicleTypeCar
+
+		estimates, err := newTestPricingService(1.5).EstimateFare(context.Background(), input)
+
+		assert.NoError(t, err)
+		if assert.Len(t, estimates, 1) {
+			b := estimates[0].Breakdown
+			assert.Equal(t, 1.5, b.SurgeMultiplier)
+			assert.Greater(t, b.SurgeAmount, 0.0)
+			assert.Equal(t, b.BaseFare+b.DistanceFare+b.TimeFare+b.SurgeAmount+b.BookingFee, b.Total)
+		}
+	})
+
+	t.Run("through waypoints", func(t *testing.T) {
+		input := input
+		input.VehicleType = models.VehicleTypeCar
+		// Doubling back to the pickup point on the way adds the trip twice
+		input.Waypoints = []models.Location{input.Dropoff, input.Pickup}
+
+		estimates, err := svc.EstimateFare(context.Background(), input)
+
+		assert.NoError(t, err)
+		if assert.Len(t, estimates, 1) {
+			assert.InDelta(t, 3*8.95, estimates[0].DistanceKm, 0.02)
+		}
+	})
+
+	t.Run("vehicle type without tariff", func(t *testing.T) {
+		input := input
+		input.VehicleType = "rickshaw"
+
+		_, err := svc.EstimateFare(context.Background(), input)
+
+		assert.Equal(t, errors.ErrTariffNotFound, err)
+	})
+}
+
+func TestQuoteTrip(t *testing.T) {
+	svc := newTestPricingService(1)
+
+	estimate, err := svc.QuoteTrip(models.VehicleTypeCar, 8.5, 25*time.Minute, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 280.0, estimate.Breakdown.Total)
+}
+
+func TestQuoteRide(t *testing.T) {
+	svc := newTestPricingService(1)
+	startedAt := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	ride := models.NewRide("rider-1",
+		models.Location{Latitude: 23.8103, Longitude: 90.4125},
+		models.Location{Latitude: 23.7509, Longitude: 90.3935}, startedAt)
+	ride.StartedAt = &startedAt
+
+	ping := func(lat float64, at time.Time) models.LocationPing {
+		return models.LocationPing{Location: models.Location{Latitude: lat, Longitude: 90.4125}, RecordedAt: at}
+	}
+
+	t.Run("charges the recorded path after pickup", func(t *testing.T) {
+		path := []models.LocationPing{
+			// Driving to the pickup is not charged
+			ping(23.90, startedAt.Add(-5*time.Minute)),
+			ping(23.80, startedAt),
+			ping(23.81, startedAt.Add(2*time.Minute)),
+			ping(23.82, startedAt.Add(4*time.Minute)),
+		}
+
+		estimate, err := svc.QuoteRide(ride, models.VehicleTypeCar, path, startedAt.Add(10*time.Minute))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2.22, estimate.DistanceKm)
+		assert.Equal(t, float64(10), estimate.DurationMinutes)
+	})
+
+	t.Run("falls back to the estimated distance without a path", func(t *testing.T) {
+		estimate, err := svc.QuoteRide(ride, models.VehicleTypeCar, nil, startedAt.Add(10*time.Minute))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 8.95, estimate.DistanceKm)
+	})
+}
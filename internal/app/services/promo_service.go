@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+)
+
+type promoService struct {
+	promoRepo      repositories.PromoCodeRepository
+	pricingService services.PricingService
+	clock          clock.Clock
+}
+
+func NewPromoService(
+	promoRepo repositories.PromoCodeRepository,
+	pricingService services.PricingService,
+	clk clock.Clock,
+) services.PromoService {
+	return &promoService{
+		promoRepo:      promoRepo,
+		pricingService: pricingService,
+		clock:          clk,
+	}
+}
+
+func (s *promoService) CreatePromoCode(ctx context.Context, input services.CreatePromoCodeInput) (*models.PromoCode, error) {
+	if _, err := s.promoRepo.FindByCode(ctx, input.Code); err == nil {
+		return nil, errors.ErrPromoCodeExists
+	} else if err != errors.ErrPromoCodeNotFound {
+		return nil, err
+	}
+
+	promo := models.NewPromoCode(input.Code, input.DiscountType, input.DiscountValue)
+	promo.MaxDiscount = input.MaxDiscount
+	promo.MaxUses = input.MaxUses
+	promo.PerUserLimit = input.PerUserLimit
+	promo.VehicleType = input.VehicleType
+	promo.StartsAt = input.StartsAt
+	promo.EndsAt = input.EndsAt
+	if err := s.promoRepo.Create(ctx, promo); err != nil {
+		return nil, err
+	}
+
+	return promo, nil
+}
+
+func (s *promoService) ListPromoCodes(ctx context.Context) ([]models.PromoCode, error) {
+	return s.promoRepo.List(ctx)
+}
+
+func (s *promoService) ApplyToEstimate(ctx context.Context, userID string, input services.ApplyPromoCodeInput) ([]services.FareEstimate, error) {
+	promo, err := s.findUsable(ctx, userID, input.Code)
+	if err != nil {
+		return nil, err
+	}
+	if input.Estimate.VehicleType != "" && !promo.AppliesTo(input.Estimate.VehicleType) {
+		return nil, errors.ErrPromoCodeNotApplicable
+	}
+
+	estimates, err := s.pricingService.EstimateFare(ctx, input.Estimate)
+	if err != nil {
+		return nil, err
+	}
+
+	discounted := make([]services.FareEstimate, 0, len(estimates))
+	for _, estimate := range estimates {
+		if !promo.AppliesTo(estimate.VehicleType) {
+			continue
+		}
+		estimate.PromoCode = promo.Code
+		estimate.Breakdown = estimate.Breakdown.WithDiscount(promo.DiscountOn(estimate.Breakdown.Total))
+		discounted = append(discounted, estimate)
+	}
+	if len(discounted) == 0 {
+		return nil, errors.ErrPromoCodeNotApplicable
+	}
+	return discounted, nil
+}
+
+func (s *promoService) CheckPromoCode(ctx context.Context, userID string, code string, vehicleType models.VehicleType) (*models.PromoCode, error) {
+	promo, err := s.findUsable(ctx, userID, code)
+	if err != nil {
+		return nil, err
+	}
+	if !promo.AppliesTo(vehicleType) {
+		return nil, errors.ErrPromoCodeNotApplicable
+	}
+	return promo, nil
+}
+
+// findUsable looks up a code and checks its validity window and limits
+// as of now. Redemption enforces the limits again atomically.
+func (s *promoService) findUsable(ctx context.Context, userID string, code string) (*models.PromoCode, error) {
+	promo, err := s.promoRepo.FindByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if !promo.IsValidAt(s.clock.Now()) {
+		return nil, errors.ErrPromoCodeNotValid
+	}
+	if promo.IsExhausted() {
+		return nil, errors.ErrPromoCodeExhausted
+	}
+
+	if promo.PerUserLimit > 0 {
+		used, err := s.promoRepo.CountRedemptions(ctx, promo.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if used >= int64(promo.PerUserLimit) {
+			return nil, errors.ErrPromoCodeUserLimit
+		}
+	}
+	return promo, nil
+}
+
+func (s *promoService) RedeemForRide(ctx context.Context, ride *models.Ride, vehicleType models.VehicleType, fare float64) (float64, error) {
+	if ride.PromoCodeID == nil {
+		return 0, nil
+	}
+
+	// The code was checked when the ride was booked, so a window that closed
+	// during the ride is still honoured; only the use limits are enforced
+	promo, err := s.promoRepo.FindByID(ctx, *ride.PromoCodeID)
+	if err != nil {
+		return 0, err
+	}
+	// Matching may hand the ride to another vehicle type than was booked
+	if !promo.AppliesTo(vehicleType) {
+		log.Printf("promo code %s not redeemed for ride %s: not valid for %s", promo.Code, ride.ID, vehicleType)
+		return 0, nil
+	}
+
+	discount := promo.DiscountOn(fare)
+	redemption := models.NewPromoRedemption(promo.ID, ride.RiderID, ride.ID, discount, s.clock.Now())
+	err = s.promoRepo.Redeem(ctx, redemption)
+	if err == errors.ErrPromoCodeExhausted || err == errors.ErrPromoCodeUserLimit {
+		log.Printf("promo code %s not redeemed for ride %s: %v", promo.Code, ride.ID, err)
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return discount, nil
+}
+
+func (s *promoService) ReleaseRedemption(ctx context.Context, rideID string) error {
+	return s.promoRepo.ReleaseRedemption(ctx, rideID)
+}
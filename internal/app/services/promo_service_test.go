@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+)
+
+func newTestPromoService() (services.PromoService, *MockPromoCodeRepository, *clock.Fake) {
+	promoRepo := new(MockPromoCodeRepository)
+	clk := clock.NewFake(time.Date(2024, 2, 20, 12, 0, 0, 0, time.UTC))
+	return NewPromoService(promoRepo, newTestPricingService(1), clk), promoRepo, clk
+}
+
+func TestApplyPromoCodeToEstimate(t *testing.T) {
+	ctx := context.Background()
+	estimate := services.EstimateFareInput{
+		Pickup:  models.Location{Latitude: 23.8103, Longitude: 90.4125},
+		Dropoff: models.Location{Latitude: 23.7509, Longitude: 90.3935},
+	}
+
+	t.Run("discounts eligible vehicle types only", func(t *testing.T) {
+		svc, promoRepo, _ := newTestPromoService()
+		promo := models.NewPromoCode("CAR10", models.DiscountTypePercentage, 10)
+		promo.VehicleType = models.VehicleTypeCar
+		promoRepo.On("FindByCode", ctx, "car10").Return(promo, nil)
+
+		estimates, err := svc.ApplyToEstimate(ctx, "rider-1", services.ApplyPromoCodeInput{Code: "car10", Estimate: estimate})
+
+		assert.NoError(t, err)
+		if assert.Len(t, estimates, 1) {
+			b := estimates[0].Breakdown
+			assert.Equal(t, models.VehicleTypeCar, estimates[0].VehicleType)
+			assert.Equal(t, "CAR10", estimates[0].PromoCode)
+			assert.Greater(t, b.Discount, 0.0)
+			assert.InDelta(t, b.BaseFare+b.DistanceFare+b.TimeFare+b.BookingFee-b.Discount, b.Total, 0.001)
+		}
+	})
+
+	t.Run("rejects an ineligible vehicle type", func(t *testing.T) {
+		svc, promoRepo, _ := newTestPromoService()
+		promo := models.NewPromoCode("CAR10", models.DiscountTypePercentage, 10)
+		promo.VehicleType = models.VehicleTypeCar
+		promoRepo.On("FindByCode", ctx, "CAR10").Return(promo, nil)
+
+		input := estimate
+		input.VehicleType = models.VehicleTypeBike
+		_, err := svc.ApplyToEstimate(ctx, "rider-1", services.ApplyPromoCodeInput{Code: "CAR10", Estimate: input})
+
+		assert.Equal(t, errors.ErrPromoCodeNotApplicable, err)
+	})
+
+	t.Run("rejects an expired code", func(t *testing.T) {
+		svc, promoRepo, clk := newTestPromoService()
+		promo := models.NewPromoCode("OLD", models.DiscountTypeFlat, 20)
+		ended := clk.Now().Add(-time.Hour)
+		promo.EndsAt = &ended
+		promoRepo.On("FindByCode", ctx, "OLD").Return(promo, nil)
+
+		_, err := svc.ApplyToEstimate(ctx, "rider-1", services.ApplyPromoCodeInput{Code: "OLD", Estimate: estimate})
+
+		assert.Equal(t, errors.ErrPromoCodeNotValid, err)
+	})
+
+	t.Run("rejects a code the rider used up", func(t *testing.T) {
+		svc, promoRepo, _ := newTestPromoService()
+		promo := models.NewPromoCode("ONCE", models.DiscountTypeFlat, 20)
+		promo.PerUserLimit = 1
+		promoRepo.On("FindByCode", ctx, "ONCE").Return(promo, nil)
+		promoRepo.On("CountRedemptions", ctx, promo.ID, "rider-1").Return(int64(1), nil)
+
+		_, err := svc.ApplyToEstimate(ctx, "rider-1", services.ApplyPromoCodeInput{Code: "ONCE", Estimate: estimate})
+
+		assert.Equal(t, errors.ErrPromoCodeUserLimit, err)
+	})
+}
+
+func TestRedeemPromoCodeForRide(t *testing.T) {
+	ctx := context.Background()
+	promo := models.NewPromoCode("FLAT50", models.DiscountTypeFlat, 50)
+
+	t.Run("ride without a code", func(t *testing.T) {
+		svc, promoRepo, _ := newTestPromoService()
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, time.Now())
+
+		discount, err := svc.RedeemForRide(ctx, ride, models.VehicleTypeCar, 280)
+
+		assert.NoError(t, err)
+		assert.Zero(t, discount)
+		promoRepo.AssertNotCalled(t, "Redeem", mock.Anything, mock.Anything)
+	})
+
+	t.Run("records the redemption", func(t *testing.T) {
+		svc, promoRepo, _ := newTestPromoService()
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, time.Now())
+		ride.PromoCodeID = &promo.ID
+		promoRepo.On("FindByID", ctx, promo.ID).Return(promo, nil)
+		promoRepo.On("Redeem", ctx, mock.MatchedBy(func(r *models.PromoRedemption) bool {
+			return r.RideID == ride.ID && r.UserID == "rider-1" && r.Discount == 50
+		})).Return(nil)
+
+		discount, err := svc.RedeemForRide(ctx, ride, models.VehicleTypeCar, 280)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 50.0, discount)
+	})
+
+	t.Run("code that ran out gives no discount", func(t *testing.T) {
+		svc, promoRepo, _ := newTestPromoService()
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, time.Now())
+		ride.PromoCodeID = &promo.ID
+		promoRepo.On("FindByID", ctx, promo.ID).Return(promo, nil)
+		promoRepo.On("Redeem", ctx, mock.Anything).Return(errors.ErrPromoCodeExhausted)
+
+		discount, err := svc.RedeemForRide(ctx, ride, models.VehicleTypeCar, 280)
+
+		assert.NoError(t, err)
+		assert.Zero(t, discount)
+	})
+	t.Run("code for another vehicle type gives no discount", func(t *testing.T) {
+		carOnly := models.NewPromoCode("CAR50", models.DiscountTypeFlat, 50)
+		carOnly.VehicleType = models.VehicleTypeCar
+		svc, promoRepo, _ := newTestPromoService()
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, time.Now())
+		ride.PromoCodeID = &carOnly.ID
+		promoRepo.On("FindByID", ctx, carOnly.ID).Return(carOnly, nil)
+
+		discount, err := svc.RedeemForRide(ctx, ride, models.VehicleTypeBike, 280)
+
+		assert.NoError(t, err)
+		assert.Zero(t, discount)
+		promoRepo.AssertNotCalled(t, "Redeem", mock.Anything, mock.Anything)
+	})
+}
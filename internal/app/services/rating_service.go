@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+)
+
+type ratingService struct {
+	ratingRepo repositories.RatingRepository
+	rideRepo   repositories.RideRepository
+	driverRepo repositories.DriverRepository
+	config     config.RatingConfig
+}
+
+func NewRatingService(
+	ratingRepo repositories.RatingRepository,
+	rideRepo repositories.RideRepository,
+	driverRepo repositories.DriverRepository,
+	cfg config.RatingConfig,
+) services.RatingService {
+	return &ratingService{
+		ratingRepo: ratingRepo,
+		rideRepo:   rideRepo,
+		driverRepo: driverRepo,
+		config:     cfg,
+	}
+}
+
+func (s *ratingService) RateDriver(ctx context.Context, rideID string, riderID string, input services.RateRideInput) (*models.Rating, error) {
+	ride, err := s.rideRepo.FindByID(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+	if ride.RiderID != riderID {
+		return nil, errors.ErrNotRideParticipant
+	}
+	driver, err := s.findDriverOfCompletedRide(ctx, ride)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.rate(ctx, models.NewRating(ride.ID, riderID, driver.UserID, models.RatingRoleDriver,
+		input.Stars, input.Comment, input.Tags))
+}
+
+func (s *ratingService) RateRider(ctx context.Context, rideID string, driverID string, input services.RateRideInput) (*models.Rating, error) {
+	ride, err := s.rideRepo.FindByID(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+	if !ride.IsAssignedTo(driverID) {
+		return nil, errors.ErrNotRideParticipant
+	}
+	driver, err := s.findDriverOfCompletedRide(ctx, ride)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.rate(ctx, models.NewRating(ride.ID, driver.UserID, ride.RiderID, models.RatingRoleRider,
+		input.Stars, input.Comment, input.Tags))
+}
+
+// findDriverOfCompletedRide returns the driver of ride. Rides that are
+// still going or were cancelled cannot be rated.
+func (s *ratingService) findDriverOfCompletedRide(ctx context.Context, ride *models.Ride) (*models.Driver, error) {
+	if ride.Status != models.RideStatusCompleted || ride.DriverID == nil {
+		return nil, errors.ErrRideNotCompleted
+	}
+
+	driver, err := s.driverRepo.FindByID(ctx, *ride.DriverID)
+	if err != nil {
+		return nil, errors.ErrDriverNotFound
+	}
+	return driver, nil
+}
+
+func (s *ratingService) rate(ctx context.Context, rating *models.Rating) (*models.Rating, error) {
+	if !rating.IsValid() {
+		return nil, errors.ErrInvalidRating
+	}
+	if err := s.ratingRepo.Create(ctx, rating, s.config.AverageWindow); err != nil {
+		return nil, err
+	}
+	return rating, nil
+}
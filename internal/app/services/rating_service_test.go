@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+)
+
+func TestRateRide(t *testing.T) {
+	ctx := context.Background()
+	driver := &models.Driver{ID: "driver-1", UserID: "driver-user-1"}
+
+	newCompletedRide := func() *models.Ride {
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, time.Now())
+		ride.Accept(driver.ID, time.Now())
+		ride.MarkDriverArrived(time.Now())
+		ride.Start(time.Now())
+		ride.Complete(280, 0, time.Now())
+		return ride
+	}
+	newTestRatingService := func(ride *models.Ride) (*ratingService, *MockRatingRepository) {
+		ratingRepo := new(MockRatingRepository)
+		rideRepo := new(MockRideRepository)
+		driverRepo := new(MockDriverRepository)
+		rideRepo.On("FindByID", ctx, ride.ID).Return(ride, nil)
+		driverRepo.On("FindByID", ctx, driver.ID).Return(driver, nil)
+		svc := NewRatingService(ratingRepo, rideRepo, driverRepo, config.RatingConfig{AverageWindow: 100}).(*ratingService)
+		return svc, ratingRepo
+	}
+
+	t.Run("rider rates the driver", func(t *testing.T) {
+		ride := newCompletedRide()
+		svc, ratingRepo := newTestRatingService(ride)
+		ratingRepo.On("Create", ctx, mock.MatchedBy(func(r *models.Rating) bool {
+			return r.RideID == ride.ID && r.RaterID == "rider-1" && r.RateeID == driver.UserID &&
+				r.RateeRole == models.RatingRoleDriver && r.Stars == 5
+		}), 100).Return(nil)
+
+		rating, err := svc.RateDriver(ctx, ride.ID, "rider-1", services.RateRideInput{
+			Stars: 5, Comment: " Smooth ride ", Tags: []string{"Safe driving", "safe driving ", ""},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Smooth ride", rating.Comment)
+		assert.Equal(t, []string{"safe driving"}, rating.Tags)
+		ratingRepo.AssertExpectations(t)
+	})
+
+	t.Run("driver rates the rider", func(t *testing.T) {
+		ride := newCompletedRide()
+		svc, ratingRepo := newTestRatingService(ride)
+		ratingRepo.On("Create", ctx, mock.MatchedBy(func(r *models.Rating) bool {
+			return r.RaterID == driver.UserID && r.RateeID == "rider-1" && r.RateeRole == models.RatingRoleRider
+		}), 100).Return(nil)
+
+		_, err := svc.RateRider(ctx, ride.ID, driver.ID, services.RateRideInput{Stars: 4})
+
+		assert.NoError(t, err)
+		ratingRepo.AssertExpectations(t)
+	})
+
+	t.Run("only participants may rate", func(t *testing.T) {
+		ride := newCompletedRide()
+		svc, ratingRepo := newTestRatingService(ride)
+
+		_, err := svc.RateDriver(ctx, ride.ID, "rider-2", services.RateRideInput{Stars: 5})
+		assert.Equal(t, errors.ErrNotRideParticipant, err)
+
+		_, err = svc.RateRider(ctx, ride.ID, "driver-2", services.RateRideInput{Stars: 5})
+		assert.Equal(t, errors.ErrNotRideParticipant, err)
+		ratingRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("rides that did not complete cannot be rated", func(t *testing.T) {
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, time.Now())
+		ride.Accept(driver.ID, time.Now())
+		svc, _ := newTestRatingService(ride)
+
+		_, err := svc.RateDriver(ctx, ride.ID, "rider-1", services.RateRideInput{Stars: 5})
+
+		assert.Equal(t, errors.ErrRideNotCompleted, err)
+	})
+
+	t.Run("stars must be between 1 and 5", func(t *testing.T) {
+		ride := newCompletedRide()
+		svc, _ := newTestRatingService(ride)
+
+		_, err := svc.RateDriver(ctx, ride.ID, "rider-1", services.RateRideInput{Stars: 6})
+
+		assert.Equal(t, errors.ErrInvalidRating, err)
+	})
+
+	t.Run("a ride is rated once", func(t *testing.T) {
+		ride := newCompletedRide()
+		svc, ratingRepo := newTestRatingService(ride)
+		ratingRepo.On("Create", ctx, mock.Anything, 100).Return(errors.ErrRatingExists)
+
+		_, err := svc.RateDriver(ctx, ride.ID, "rider-1", services.RateRideInput{Stars: 5})
+
+		assert.Equal(t, errors.ErrRatingExists, err)
+	})
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/pricing"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/receipt"
+	"github.com/sayeed1999/share-a-ride/internal/provider/email"
+)
+
+// receiptSendTimeout bounds how long sending a queued receipt may take
+const receiptSendTimeout = time.Minute
+
+type receiptService struct {
+	rideRepo     repositories.RideRepository
+	driverRepo   repositories.DriverRepository
+	userRepo     repositories.UserRepository
+	emailService email.EmailServiceInterface
+	currency     string
+}
+
+func NewReceiptService(
+	rideRepo repositories.RideRepository,
+	driverRepo repositories.DriverRepository,
+	userRepo repositories.UserRepository,
+	emailService email.EmailServiceInterface,
+	currency string,
+) services.ReceiptService {
+	return &receiptService{
+		rideRepo:     rideRepo,
+		driverRepo:   driverRepo,
+		userRepo:     userRepo,
+		emailService: emailService,
+		currency:     currency,
+	}
+}
+
+func (s *receiptService) GetRideReceipt(ctx context.Context, rideID string) (*receipt.Receipt, error) {
+	r, _, err := s.buildReceipt(ctx, rideID)
+	return r, err
+}
+
+func (s *receiptService) SendRideReceipt(ctx context.Context, rideID string) error {
+	r, rider, err := s.buildReceipt(ctx, rideID)
+	if err != nil {
+		return err
+	}
+	return s.emailService.SendRideReceipt(rider.Email, r)
+}
+
+func (s *receiptService) QueueRideReceipt(rideID string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), receiptSendTimeout)
+		defer cancel()
+
+		if err := s.SendRideReceipt(ctx, rideID); err != nil {
+			log.Printf("failed to send receipt of ride %s: %v", rideID, err)
+		}
+	}()
+}
+
+func (s *receiptService) buildReceipt(ctx context.Context, rideID string) (*receipt.Receipt, *models.User, error) {
+	ride, err := s.rideRepo.FindByID(ctx, rideID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ride.Status != models.RideStatusCompleted || ride.DriverID == nil || ride.CompletedAt == nil {
+		return nil, nil, errors.ErrRideNotCompleted
+	}
+
+	rider, err := s.userRepo.FindByID(ctx, ride.RiderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	driver, err := s.driverRepo.FindByID(ctx, *ride.DriverID)
+	if err != nil {
+		return nil, nil, errors.ErrDriverNotFound
+	}
+	driverUser, err := s.userRepo.FindByID(ctx, driver.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Rides completed before breakdowns were stored only know their total
+	fare := pricing.Breakdown{Discount: ride.Discount, Total: ride.Fare}
+	if ride.FareBreakdown != nil {
+		fare = *ride.FareBreakdown
+	}
+
+	r := &receipt.Receipt{
+		RideID:        ride.ID,
+		RiderName:     rider.Name,
+		Pickup:        receipt.Place{Latitude: ride.PickupLocation.Latitude, Longitude: ride.PickupLocation.Longitude},
+		Dropoff:       receipt.Place{Latitude: ride.DropoffLocation.Latitude, Longitude: ride.DropoffLocation.Longitude},
+		CompletedAt:   *ride.CompletedAt,
+		DistanceKm:    ride.DistanceKm,
+		Currency:      s.currency,
+		Fare:          fare,
+		PaymentMethod: string(ride.PaymentMethod),
+		Driver: receipt.Driver{
+			Name: driverUser.Name,
+		},
+	}
+	if vehicle := driver.RideVehicle(ride); vehicle != nil {
+		r.Driver.VehicleType = string(vehicle.Type)
+		r.Driver.VehicleModel = vehicle.Model
+		r.Driver.PlateNumber = vehicle.PlateNumber
+	}
+	r.StartedAt = r.CompletedAt
+	if ride.StartedAt != nil {
+		r.StartedAt = *ride.StartedAt
+	}
+	return r, rider, nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/pricing"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/receipt"
+)
+
+func TestSendRideReceipt(t *testing.T) {
+	ctx := context.Background()
+	driver := models.NewDriver("driver-user-1", "DL-1", models.Vehicle{Type: models.VehicleTypeCar, Model: "Toyota Axio", PlateNumber: "DHA-1234"})
+
+	newTestReceiptService := func(ride *models.Ride) (*receiptService, *MockEmailService) {
+		rideRepo := new(MockRideRepository)
+		driverRepo := new(MockDriverRepository)
+		userRepo := new(MockUserRepository)
+		emailService := new(MockEmailService)
+		rideRepo.On("FindByID", ctx, ride.ID).Return(ride, nil)
+		driverRepo.On("FindByID", ctx, driver.ID).Return(driver, nil)
+		userRepo.On("FindByID", ctx, "rider-1").Return(&models.User{ID: "rider-1", Name: "Jane", Email: "jane@example.com"}, nil)
+		userRepo.On("FindByID", ctx, "driver-user-1").Return(&models.User{ID: "driver-user-1", Name: "John"}, nil)
+		svc := NewReceiptService(rideRepo, driverRepo, userRepo, emailService, "BDT").(*receiptService)
+		return svc, emailService
+	}
+
+	t.Run("emails the receipt to the rider", func(t *testing.T) {
+		ride := models.NewRide("rider-1", models.Location{Latitude: 23.8103, Longitude: 90.4125}, models.Location{}, time.Now())
+		ride.Accept(driver.ID, time.Now())
+		ride.MarkDriverArrived(time.Now())
+		ride.Start(time.Now())
+		ride.DistanceKm = 8.5
+		ride.FareBreakdown = &pricing.Breakdown{BaseFare: 50, DistanceFare: 170, BookingFee: 10, Discount: 30, Total: 200}
+		ride.Complete(230, 30, time.Now())
+		svc, emailService := newTestReceiptService(ride)
+		emailService.On("SendRideReceipt", "jane@example.com", mock.MatchedBy(func(r *receipt.Receipt) bool {
+			return r.RideID == ride.ID && r.RiderName == "Jane" && r.Currency == "BDT" &&
+				r.Fare.Total == 200 && r.DistanceKm == 8.5 &&
+				r.Pickup == receipt.Place{Latitude: 23.8103, Longitude: 90.4125} &&
+				r.StartedAt.Equal(*ride.StartedAt) && r.CompletedAt.Equal(*ride.CompletedAt) &&
+				r.Driver == receipt.Driver{Name: "John", VehicleType: "car", VehicleModel: "Toyota Axio", PlateNumber: "DHA-1234"}
+		})).Return(nil)
+
+		err := svc.SendRideReceipt(ctx, ride.ID)
+
+		assert.NoError(t, err)
+		emailService.AssertExpectations(t)
+	})
+
+	t.Run("falls back to the total without a stored breakdown", func(t *testing.T) {
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, time.Now())
+		ride.Accept(driver.ID, time.Now())
+		ride.Start(time.Now())
+		ride.Complete(230, 30, time.Now())
+		svc, _ := newTestReceiptService(ride)
+
+		r, err := svc.GetRideReceipt(ctx, ride.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, pricing.Breakdown{Discount: 30, Total: 200}, r.Fare)
+	})
+
+	t.Run("refuses rides that did not complete", func(t *testing.T) {
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, time.Now())
+		ride.Accept(driver.ID, time.Now())
+		svc, emailService := newTestReceiptService(ride)
+
+		err := svc.SendRideReceipt(ctx, ride.ID)
+
+		assert.Equal(t, errors.ErrRideNotCompleted, err)
+		emailService.AssertNotCalled(t, "SendRideReceipt", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,668 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+)
+
+const (
+	defaultRidePageLimit = 10
+	maxRidePageLimit     = 100
+
+	paymentDeclinedCancellationReason = "payment declined"
+)
+
+type rideService struct {
+	rideRepo        repositories.RideRepository
+	driverRepo      repositories.DriverRepository
+	userRepo        repositories.UserRepository
+	historyRepo     repositories.LocationHistoryRepository
+	surgeService    services.SurgeService
+	pricingService  services.PricingService
+	promoService    services.PromoService
+	paymentService  services.PaymentService
+	walletService   services.WalletService
+	earningsService services.EarningsService
+	receiptService  services.ReceiptService
+	poolService     services.PoolService
+	clock           clock.Clock
+	scheduling      config.SchedulingConfig
+	pooling         config.PoolConfig
+	waypoints       config.WaypointConfig
+}
+
+func NewRideService(
+	rideRepo repositories.RideRepository,
+	driverRepo repositories.DriverRepository,
+	userRepo repositories.UserRepository,
+	historyRepo repositories.LocationHistoryRepository,
+	surgeService services.SurgeService,
+	pricingService services.PricingService,
+	promoService services.PromoService,
+	paymentService services.PaymentService,
+	walletService services.WalletService,
+	earningsService services.EarningsService,
+	receiptService services.ReceiptService,
+	poolService services.PoolService,
+	clk clock.Clock,
+	scheduling config.SchedulingConfig,
+	pooling config.PoolConfig,
+	waypoints config.WaypointConfig,
+) services.RideService {
+	return &rideService{
+		rideRepo:        rideRepo,
+		driverRepo:      driverRepo,
+		userRepo:        userRepo,
+		historyRepo:     historyRepo,
+		surgeService:    surgeService,
+		pricingService:  pricingService,
+		promoService:    promoService,
+		paymentService:  paymentService,
+		walletService:   walletService,
+		earningsService: earningsService,
+		receiptService:  receiptService,
+		poolService:     poolService,
+		clock:           clk,
+		scheduling:      scheduling,
+		pooling:         pooling,
+		waypoints:       waypoints,
+	}
+}
+
+func (s *rideService) RequestRide(ctx context.Context, riderID string, input services.RequestRideInput) (*models.Ride, error) {
+	// Check if user exists and is a rider
+	user, err := s.userRepo.FindByID(ctx, riderID)
+	if err != nil {
+		return nil, errors.ErrUserNotFound
+	}
+	if !user.IsRider() {
+		return nil, errors.ErrUnauthorizedAccess
+	}
+
+	ride := models.NewRide(riderID, input.Pickup, input.Dropoff, s.clock.Now())
+	ride.VehicleType = input.VehicleType
+	if input.Pooled {
+		if input.Seats > s.pooling.MaxSeatsPerRide {
+			return nil, errors.ErrTooManyPoolSeats
+		}
+		ride.Pooled = true
+		if input.Seats > 0 {
+			ride.Seats = input.Seats
+		}
+	}
+	if len(input.Waypoints) > 0 {
+		if input.Pooled {
+			return nil, errors.ErrPooledRideWaypoints
+		}
+		if len(input.Waypoints) > s.waypoints.MaxPerRide {
+			return nil, errors.ErrTooManyWaypoints
+		}
+		for _, location := range input.Waypoints {
+			ride.AddWaypoint(location, len(ride.Waypoints), ride.CreatedAt)
+		}
+	}
+	if input.ScheduledAt != nil {
+		lead := input.ScheduledAt.Sub(ride.CreatedAt)
+		if lead < s.scheduling.MinLeadTime || lead > s.scheduling.MaxAdvance {
+			return nil, errors.ErrInvalidScheduledTime
+		}
+		ride.Schedule(*input.ScheduledAt, ride.CreatedAt)
+	} else {
+		// A rider can only have one ride in flight; scheduled rides are
+		// checked again when they are released for dispatch
+		if _, err := s.rideRepo.FindActiveByRiderID(ctx, riderID); err == nil {
+			return nil, errors.ErrActiveRideExists
+		} else if err != errors.ErrRideNotFound {
+			return nil, err
+		}
+	}
+	if input.PaymentMethod == models.PaymentMethodWallet {
+		// The fare is held from the balance once a driver accepts; an
+		// empty wallet cannot pay for anything
+		wallet, err := s.walletService.GetWallet(ctx, riderID)
+		if err != nil {
+			return nil, err
+		}
+		if wallet.Balance <= 0 {
+			return nil, errors.ErrInsufficientBalance
+		}
+		ride.PaymentMethod = models.PaymentMethodWallet
+	}
+	if input.PromoCode != "" {
+		promo, err := s.promoService.CheckPromoCode(ctx, riderID, input.PromoCode, input.VehicleType)
+		if err != nil {
+			return nil, err
+		}
+		ride.PromoCodeID = &promo.ID
+	}
+	// Lock in the surge the rider was quoted so the final fare matches it
+	ride.SurgeMultiplier = s.surgeService.MultiplierAt(input.Pickup.Latitude, input.Pickup.Longitude)
+	if err := s.rideRepo.Create(ctx, ride); err != nil {
+		return nil, err
+	}
+
+	return ride, nil
+}
+
+func (s *rideService) GetRide(ctx context.Context, rideID string) (*models.Ride, error) {
+	return s.rideRepo.FindByID(ctx, rideID)
+}
+
+func (s *rideService) GetCurrentRide(ctx context.Context, riderID string) (*models.Ride, error) {
+	return s.rideRepo.FindActiveByRiderID(ctx, riderID)
+}
+
+func (s *rideService) ListRiderRides(ctx context.Context, riderID string, input services.ListRidesInput) (*services.RideList, error) {
+	return s.listRides(ctx, repositories.RideFilter{RiderID: riderID}, input)
+}
+
+func (s *rideService) AddWaypoint(ctx context.Context, rideID string, riderID string, input services.AddWaypointInput) (*services.RideRoute, error) {
+	ride, err := s.findRiderRide(ctx, rideID, riderID)
+	if err != nil {
+		return nil, err
+	}
+	if ride.Pooled {
+		return nil, errors.ErrPooledRideWaypoints
+	}
+
+	switch ride.Status {
+	case models.RideStatusScheduled, models.RideStatusRequested, models.RideStatusAccepted, models.RideStatusDriverArrived:
+	case models.RideStatusInProgress:
+		// Only one detour may be asked of the driver once under way, after
+		// the stops they may already have passed
+		if ride.HasTripWaypoint() {
+			return nil, errors.ErrWaypointsLocked
+		}
+		if input.Position != nil && *input.Position < len(ride.Waypoints) {
+			return nil, errors.ErrWaypointsLocked
+		}
+	default:
+		return nil, errors.ErrWaypointsLocked
+	}
+	if len(ride.Waypoints) >= s.waypoints.MaxPerRide {
+		return nil, errors.ErrTooManyWaypoints
+	}
+
+	position := len(ride.Waypoints)
+	if input.Position != nil {
+		position = *input.Position
+	}
+	ride.AddWaypoint(input.Location, position, s.clock.Now())
+	if err := s.rideRepo.UpdateWaypoints(ctx, ride, ride.Status); err != nil {
+		return nil, err
+	}
+	return s.routeOf(ctx, ride)
+}
+
+func (s *rideService) RemoveWaypoint(ctx context.Context, rideID string, riderID string, waypointID string) (*services.RideRoute, error) {
+	ride, err := s.findRiderRide(ctx, rideID, riderID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch ride.Status {
+	case models.RideStatusScheduled, models.RideStatusRequested, models.RideStatusAccepted, models.RideStatusDriverArrived:
+	default:
+		return nil, errors.ErrWaypointsLocked
+	}
+	if !ride.RemoveWaypoint(waypointID, s.clock.Now()) {
+		return nil, errors.ErrWaypointNotFound
+	}
+	if err := s.rideRepo.UpdateWaypoints(ctx, ride, ride.Status); err != nil {
+		return nil, err
+	}
+	return s.routeOf(ctx, ride)
+}
+
+// routeOf estimates the fare and trip time of ride over its current stops.
+// The estimate is for the assigned driver's vehicle, else the rider's
+// preferred one; riders without a preference are quoted for a car.
+func (s *rideService) routeOf(ctx context.Context, ride *models.Ride) (*services.RideRoute, error) {
+	vehicleType := ride.VehicleType
+	if ride.DriverID != nil {
+		driver, err := s.driverRepo.FindByID(ctx, *ride.DriverID)
+		if err != nil {
+			return nil, err
+		}
+		if vehicle := driver.RideVehicle(ride); vehicle != nil {
+			vehicleType = vehicle.Type
+		}
+	}
+	if vehicleType == "" {
+		vehicleType = models.VehicleTypeCar
+	}
+
+	estimate, err := s.pricingService.EstimateRide(ride, vehicleType)
+	if err != nil {
+		return nil, err
+	}
+
+	route := &services.RideRoute{Ride: ride, Estimate: estimate}
+	if ride.StartedAt != nil {
+		arrivalAt := ride.StartedAt.Add(time.Duration(estimate.DurationMinutes * float64(time.Minute)))
+		route.ArrivalAt = &arrivalAt
+	}
+	return route, nil
+}
+
+func (s *rideService) AcceptRide(ctx context.Context, rideID string, driverID string) (*models.Ride, error) {
+	// Validate driver
+	driver, err := s.driverRepo.FindByID(ctx, driverID)
+	if err != nil {
+		return nil, errors.ErrDriverNotFound
+	}
+	if !driver.IsVerified() {
+		return nil, errors.ErrDriverNotVerified
+	}
+	vehicle := driver.ActiveVehicle
+	if vehicle == nil {
+		return nil, errors.ErrVehicleNotFound
+	}
+
+	ride, err := s.rideRepo.FindByID(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+	if !ride.Status.CanTransitionTo(models.RideStatusAccepted) {
+		return nil, errors.NewRideTransitionError(string(ride.Status), string(models.RideStatusAccepted))
+	}
+
+	// A driver can only serve one ride at a time, unless the rides share
+	// the driver's pool
+	if !ride.Pooled {
+		if _, err := s.rideRepo.FindActiveByDriverID(ctx, driverID); err == nil {
+			return nil, errors.ErrActiveRideExists
+		} else if err != errors.ErrRideNotFound {
+			return nil, err
+		}
+	}
+
+	// Secure the fare before the driver sets off
+	estimate, err := s.pricingService.EstimateRide(ride, vehicle.Type)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.secureFare(ctx, ride, estimate.Breakdown.Total); err != nil {
+		if err == errors.ErrPaymentDeclined || err == errors.ErrInsufficientBalance {
+			s.cancelDeclined(ctx, ride)
+		}
+		return nil, err
+	}
+
+	var poolID *string
+	if ride.Pooled {
+		pool, err := s.poolService.Join(ctx, ride, driver)
+		if err != nil {
+			s.releaseFare(ctx, ride)
+			return nil, err
+		}
+		poolID = &pool.ID
+	}
+
+	accepted, err := s.transition(ctx, ride, models.RideStatusAccepted, func() {
+		ride.Accept(driver.ID, s.clock.Now())
+		ride.VehicleID = &vehicle.ID
+		ride.PoolID = poolID
+	})
+	if err != nil {
+		s.releaseFare(ctx, ride)
+		if poolID != nil {
+			s.leavePool(ctx, ride)
+		}
+	}
+	return accepted, err
+}
+
+func (s *rideService) MarkDriverArrived(ctx context.Context, rideID string, driverID string) (*models.Ride, error) {
+	ride, err := s.findAssignedRide(ctx, rideID, driverID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.transition(ctx, ride, models.RideStatusDriverArrived, func() {
+		ride.MarkDriverArrived(s.clock.Now())
+	})
+}
+
+func (s *rideService) StartRide(ctx context.Context, rideID string, driverID string) (*models.Ride, error) {
+	ride, err := s.findAssignedRide(ctx, rideID, driverID)
+	if err != nil {
+		return nil, err
+	}
+
+	started, err := s.transition(ctx, ride, models.RideStatusInProgress, func() {
+		ride.Start(s.clock.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if started.PoolID != nil {
+		if _, err := s.poolService.Visit(ctx, started, models.PoolStopPickup); err != nil {
+			log.Printf("failed to record pickup of ride %s in its pool: %v", ride.ID, err)
+		}
+	}
+	return started, nil
+}
+
+func (s *rideService) CompleteRide(ctx context.Context, rideID string, driverID string) (*models.Ride, error) {
+	ride, err := s.findAssignedRide(ctx, rideID, driverID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check before redeeming so a ride that cannot complete does not use
+	// up the rider's promo code
+	if !ride.Status.CanTransitionTo(models.RideStatusCompleted) {
+		return nil, errors.NewRideTransitionError(string(ride.Status), string(models.RideStatusCompleted))
+	}
+
+	now := s.clock.Now()
+	quote, err := s.quoteRide(ctx, ride, driverID, now)
+	if err != nil {
+		return nil, err
+	}
+	fare := quote.Breakdown.Total
+
+	discount, err := s.promoService.RedeemForRide(ctx, ride, quote.VehicleType, fare)
+	if err != nil {
+		return nil, err
+	}
+
+	completed, err := s.transition(ctx, ride, models.RideStatusCompleted, func() {
+		breakdown := quote.Breakdown.WithDiscount(discount)
+		ride.DistanceKm = quote.DistanceKm
+		ride.FareBreakdown = &breakdown
+		ride.Complete(fare, discount, now)
+	})
+	if err != nil {
+		if discount > 0 {
+			if releaseErr := s.promoService.ReleaseRedemption(ctx, ride.ID); releaseErr != nil {
+				log.Printf("failed to release promo redemption of ride %s: %v", ride.ID, releaseErr)
+			}
+		}
+		return nil, err
+	}
+
+	// The dropoff is only taken off the pool's route once the ride has
+	// completed; its share was priced as if it had been
+	if ride.PoolID != nil {
+		if _, err := s.poolService.Visit(ctx, completed, models.PoolStopDropoff); err != nil {
+			log.Printf("failed to record dropoff of ride %s in its pool: %v", ride.ID, err)
+		}
+	}
+
+	// The trip has happened either way; a failed charge is logged and
+	// picked up by RetryCharges, as both charges are idempotent
+	if err := s.chargeFare(ctx, completed); err != nil {
+		log.Printf("failed to charge ride %s: %v", ride.ID, err)
+	}
+	if err := s.earningsService.RecordRide(ctx, completed); err != nil {
+		log.Printf("failed to record driver earnings of ride %s: %v", ride.ID, err)
+	}
+	s.receiptService.QueueRideReceipt(completed.ID)
+	return completed, nil
+}
+
+// secureFare holds the estimated fare, on the card or in the wallet, so
+// the rider can pay for the ride.
+func (s *rideService) secureFare(ctx context.Context, ride *models.Ride, estimatedFare float64) error {
+	if ride.PaymentMethod == models.PaymentMethodWallet {
+		_, err := s.walletService.HoldRideFare(ctx, ride, estimatedFare)
+		return err
+	}
+
+	_, err := s.paymentService.AuthorizeRide(ctx, ride, estimatedFare)
+	return err
+}
+
+// releaseFare gives back the fare held by secureFare for a ride that will
+// not be charged
+func (s *rideService) releaseFare(ctx context.Context, ride *models.Ride) {
+	if ride.PaymentMethod == models.PaymentMethodWallet {
+		if err := s.walletService.ReleaseRideHold(ctx, ride); err != nil {
+			log.Printf("failed to release wallet hold of ride %s: %v", ride.ID, err)
+		}
+		return
+	}
+	s.voidPayment(ctx, ride.ID)
+}
+
+func (s *rideService) chargeFare(ctx context.Context, ride *models.Ride) error {
+	if ride.PaymentMethod == models.PaymentMethodWallet {
+		if ride.Fare == 0 {
+			return s.walletService.ReleaseRideHold(ctx, ride)
+		}
+		_, err := s.walletService.ChargeRide(ctx, ride)
+		return err
+	}
+
+	_, err := s.paymentService.CaptureRide(ctx, ride)
+	return err
+}
+
+// quoteRide prices a ride finished at endedAt from the path its driver
+// recorded, or a pooled ride from its share of the pool's distance
+func (s *rideService) quoteRide(ctx context.Context, ride *models.Ride, driverID string, endedAt time.Time) (*services.FareEstimate, error) {
+	driver, err := s.driverRepo.FindByID(ctx, driverID)
+	if err != nil {
+		return nil, errors.ErrDriverNotFound
+	}
+	// Rides are priced for the vehicle that served them, falling back to
+	// the type the rider asked for
+	vehicleType := ride.VehicleType
+	if vehicle := driver.RideVehicle(ride); vehicle != nil {
+		vehicleType = vehicle.Type
+	}
+
+	if ride.PoolID != nil {
+		sharedKm, err := s.poolService.SharedDistanceKm(ctx, ride)
+		if err != nil {
+			return nil, err
+		}
+		return s.pricingService.QuotePooledRide(ride, vehicleType, sharedKm)
+	}
+
+	path, err := s.historyRepo.ListByRide(ctx, ride.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.pricingService.QuoteRide(ride, vehicleType, path, endedAt)
+}
+
+func (s *rideService) ListDriverRides(ctx context.Context, driverID string, input services.ListRidesInput) (*services.RideList, error) {
+	return s.listRides(ctx, repositories.RideFilter{DriverID: driverID}, input)
+}
+
+func (s *rideService) CancelRide(ctx context.Context, rideID string, userID string, input services.CancelRideInput) (*models.Ride, error) {
+	ride, err := s.rideRepo.FindByID(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the rider or the assigned driver may cancel
+	if ride.RiderID != userID {
+		driver, err := s.driverRepo.FindByUserID(ctx, userID)
+		if err != nil || !ride.IsAssignedTo(driver.ID) {
+			return nil, errors.ErrNotRideParticipant
+		}
+	}
+
+	fee := s.cancellationFee(ride, userID)
+	cancelled, err := s.transition(ctx, ride, models.RideStatusCancelled, func() {
+		ride.Cancel(userID, input.Reason, s.clock.Now())
+		ride.Fare = fee
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if ride.PoolID != nil {
+		s.leavePool(ctx, ride)
+	}
+	if fee > 0 {
+		if err := s.chargeCancellationFee(ctx, cancelled); err != nil {
+			log.Printf("failed to charge cancellation fee of ride %s: %v", ride.ID, err)
+		}
+	} else {
+		s.releaseFare(ctx, ride)
+	}
+	return cancelled, nil
+}
+
+func (s *rideService) RetryCharges(ctx context.Context, endedBefore time.Time) (int, error) {
+	rides, err := s.rideRepo.FindUncharged(ctx, endedBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	charged := 0
+	for i := range rides {
+		ride := &rides[i]
+		charge := s.chargeFare
+		if ride.Status == models.RideStatusCancelled {
+			charge = s.chargeCancellationFee
+		}
+		if err := charge(ctx, ride); err != nil {
+			log.Printf("failed to retry charge of ride %s: %v", ride.ID, err)
+			continue
+		}
+		charged++
+	}
+	return charged, nil
+}
+
+// cancellationFee is what userID pays for cancelling ride. Only riders
+// cancelling a scheduled ride within the free cancellation window before
+// pickup are charged; on-demand rides are free to cancel.
+func (s *rideService) cancellationFee(ride *models.Ride, userID string) float64 {
+	if !ride.IsScheduled() || ride.RiderID != userID {
+		return 0
+	}
+	if ride.ScheduledAt.Sub(s.clock.Now()) > s.scheduling.FreeCancellationWindow {
+		return 0
+	}
+	return s.scheduling.LateCancellationFee
+}
+
+// chargeCancellationFee charges a cancelled ride's fee like a fare. Card
+// rides not yet accepted have no authorization, so one is made for the fee.
+func (s *rideService) chargeCancellationFee(ctx context.Context, ride *models.Ride) error {
+	if ride.PaymentMethod == models.PaymentMethodCard {
+		if _, err := s.paymentService.AuthorizeRide(ctx, ride, ride.Fare); err != nil {
+			return err
+		}
+	}
+	return s.chargeFare(ctx, ride)
+}
+
+// cancelDeclined cancels a ride whose fare could not be held, so that
+// dispatch stops offering it
+func (s *rideService) cancelDeclined(ctx context.Context, ride *models.Ride) {
+	current := ride.Status
+	ride.Cancel("", paymentDeclinedCancellationReason, s.clock.Now())
+	if err := s.rideRepo.UpdateStatus(ctx, ride, current); err != nil {
+		log.Printf("failed to cancel ride %s after declined payment: %v", ride.ID, err)
+	}
+}
+
+// leavePool takes a ride that will not be served off its pool. Failures
+// are logged only; the driver skips stops of rides that are not active.
+func (s *rideService) leavePool(ctx context.Context, ride *models.Ride) {
+	if err := s.poolService.Leave(ctx, ride); err != nil {
+		log.Printf("failed to remove ride %s from its pool: %v", ride.ID, err)
+	}
+}
+
+// voidPayment releases the funds held for a ride. Failures are logged
+// only; an unused authorization expires at the gateway eventually.
+func (s *rideService) voidPayment(ctx context.Context, rideID string) {
+	if err := s.paymentService.VoidRide(ctx, rideID); err != nil {
+		log.Printf("failed to void payment of ride %s: %v", rideID, err)
+	}
+}
+
+// listRides applies input on top of the ownership filter and normalises
+// pagination before querying.
+func (s *rideService) listRides(ctx context.Context, filter repositories.RideFilter, input services.ListRidesInput) (*services.RideList, error) {
+	page := input.Page
+	if page < 1 {
+		page = 1
+	}
+	limit := input.Limit
+	if limit < 1 {
+		limit = defaultRidePageLimit
+	}
+	if limit > maxRidePageLimit {
+		limit = maxRidePageLimit
+	}
+
+	filter.Statuses = input.Statuses
+	filter.From = input.From
+	filter.To = input.To
+	filter.Page = page
+	filter.Limit = limit
+
+	rides, total, err := s.rideRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &services.RideList{
+		Rides:   rides,
+		Total:   total,
+		Page:    page,
+		Limit:   limit,
+		HasMore: int64(page*limit) < total,
+	}, nil
+}
+
+// findAssignedRide loads a ride and checks that driverID is its driver.
+func (s *rideService) findAssignedRide(ctx context.Context, rideID string, driverID string) (*models.Ride, error) {
+	ride, err := s.rideRepo.FindByID(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+	if !ride.IsAssignedTo(driverID) {
+		return nil, errors.ErrNotRideParticipant
+	}
+	return ride, nil
+}
+
+// findRiderRide loads a ride and checks that riderID booked it
+func (s *rideService) findRiderRide(ctx context.Context, rideID string, riderID string) (*models.Ride, error) {
+	ride, err := s.rideRepo.FindByID(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+	if ride.RiderID != riderID {
+		return nil, errors.ErrNotRideParticipant
+	}
+	return ride, nil
+}
+
+// transition validates a move of ride to next, applies it and persists it
+// guarded by the status the ride was loaded with.
+func (s *rideService) transition(ctx context.Context, ride *models.Ride, next models.RideStatus, apply func()) (*models.Ride, error) {
+	current := ride.Status
+	if !current.CanTransitionTo(next) {
+		return nil, errors.NewRideTransitionError(string(current), string(next))
+	}
+
+	apply()
+
+	if err := s.rideRepo.UpdateStatus(ctx, ride, current); err != nil {
+		return nil, err
+	}
+
+	return ride, nil
+}
@@ -0,0 +1,672 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/pricing"
+)
+
+var testRideNow = time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+
+func newTestRideService() (*rideService, *MockRideRepository, *MockDriverRepository, *MockUserRepository) {
+	rideRepo := new(MockRideRepository)
+	driverRepo := new(MockDriverRepository)
+	userRepo := new(MockUserRepository)
+	surgeService := new(MockSurgeService)
+	surgeService.On("MultiplierAt", mock.Anything, mock.Anything).Return(1.0)
+	svc := NewRideService(rideRepo, driverRepo, userRepo, new(MockLocationHistoryRepository),
+		surgeService, new(MockPricingService), new(MockPromoService), new(MockPaymentService), new(MockWalletService), new(MockEarningsService),
+		new(MockReceiptService), new(MockPoolService), clock.NewFake(testRideNow), config.SchedulingConfig{
+			MinLeadTime:            30 * time.Minute,
+			MaxAdvance:             7 * 24 * time.Hour,
+			FreeCancellationWindow: time.Hour,
+			LateCancellationFee:    50,
+		}, config.PoolConfig{MaxSeatsPerRide: 2}, config.WaypointConfig{MaxPerRide: 2}).(*rideService)
+	return svc, rideRepo, driverRepo, userRepo
+}
+
+func TestRequestRide(t *testing.T) {
+	ctx := context.Background()
+	rider := &models.User{ID: "rider-1", UserType: models.UserTypeRider}
+
+	t.Run("creates a requested ride", func(t *testing.T) {
+		svc, rideRepo, _, userRepo := newTestRideService()
+		userRepo.On("FindByID", ctx, rider.ID).Return(rider, nil)
+		rideRepo.On("FindActiveByRiderID", ctx, rider.ID).Return(nil, errors.ErrRideNotFound)
+		rideRepo.On("Create", ctx, mock.AnythingOfType("*models.Ride")).Return(nil)
+
+		ride, err := svc.RequestRide(ctx, rider.ID, services.RequestRideInput{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.RideStatusRequested, ride.Status)
+		assert.Equal(t, rider.ID, ride.RiderID)
+	})
+
+	t.Run("rejects a second active ride", func(t *testing.T) {
+		svc, rideRepo, _, userRepo := newTestRideService()
+		userRepo.On("FindByID", ctx, rider.ID).Return(rider, nil)
+		rideRepo.On("FindActiveByRiderID", ctx, rider.ID).Return(&models.Ride{}, nil)
+
+		_, err := svc.RequestRide(ctx, rider.ID, services.RequestRideInput{})
+
+		assert.Equal(t, errors.ErrActiveRideExists, err)
+	})
+
+	t.Run("rejects wallet payment from an empty wallet", func(t *testing.T) {
+		svc, rideRepo, _, userRepo := newTestRideService()
+		walletService := svc.walletService.(*MockWalletService)
+		userRepo.On("FindByID", ctx, rider.ID).Return(rider, nil)
+		rideRepo.On("FindActiveByRiderID", ctx, rider.ID).Return(nil, errors.ErrRideNotFound)
+		walletService.On("GetWallet", ctx, rider.ID).Return(&services.Wallet{UserID: rider.ID}, nil)
+
+		_, err := svc.RequestRide(ctx, rider.ID, services.RequestRideInput{PaymentMethod: models.PaymentMethodWallet})
+
+		assert.Equal(t, errors.ErrInsufficientBalance, err)
+		rideRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("stores a usable promo code", func(t *testing.T) {
+		svc, rideRepo, _, userRepo := newTestRideService()
+		promoService := svc.promoService.(*MockPromoService)
+		promo := models.NewPromoCode("FLAT50", models.DiscountTypeFlat, 50)
+		input := services.RequestRideInput{VehicleType: models.VehicleTypeCar, PromoCode: "flat50"}
+		userRepo.On("FindByID", ctx, rider.ID).Return(rider, nil)
+		rideRepo.On("FindActiveByRiderID", ctx, rider.ID).Return(nil, errors.ErrRideNotFound)
+		promoService.On("CheckPromoCode", ctx, rider.ID, "flat50", models.VehicleTypeCar).Return(promo, nil)
+		rideRepo.On("Create", ctx, mock.AnythingOfType("*models.Ride")).Return(nil)
+
+		ride, err := svc.RequestRide(ctx, rider.ID, input)
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, ride.PromoCodeID) {
+			assert.Equal(t, promo.ID, *ride.PromoCodeID)
+		}
+	})
+
+	t.Run("rejects an unusable promo code", func(t *testing.T) {
+		svc, rideRepo, _, userRepo := newTestRideService()
+		promoService := svc.promoService.(*MockPromoService)
+		userRepo.On("FindByID", ctx, rider.ID).Return(rider, nil)
+		rideRepo.On("FindActiveByRiderID", ctx, rider.ID).Return(nil, errors.ErrRideNotFound)
+		promoService.On("CheckPromoCode", ctx, rider.ID, "GONE", models.VehicleType("")).Return(nil, errors.ErrPromoCodeExhausted)
+
+		_, err := svc.RequestRide(ctx, rider.ID, services.RequestRideInput{PromoCode: "GONE"})
+
+		assert.Equal(t, errors.ErrPromoCodeExhausted, err)
+		rideRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("schedules a ride while another is active", func(t *testing.T) {
+		svc, rideRepo, _, userRepo := newTestRideService()
+		scheduledAt := testRideNow.Add(24 * time.Hour)
+		userRepo.On("FindByID", ctx, rider.ID).Return(rider, nil)
+		rideRepo.On("Create", ctx, mock.AnythingOfType("*models.Ride")).Return(nil)
+
+		ride, err := svc.RequestRide(ctx, rider.ID, services.RequestRideInput{ScheduledAt: &scheduledAt})
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.RideStatusScheduled, ride.Status)
+		assert.Equal(t, scheduledAt, *ride.ScheduledAt)
+		rideRepo.AssertNotCalled(t, "FindActiveByRiderID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects a pickup outside the booking window", func(t *testing.T) {
+		for _, lead := range []time.Duration{10 * time.Minute, 8 * 24 * time.Hour} {
+			svc, rideRepo, _, userRepo := newTestRideService()
+			scheduledAt := testRideNow.Add(lead)
+			userRepo.On("FindByID", ctx, rider.ID).Return(rider, nil)
+
+			_, err := svc.RequestRide(ctx, rider.ID, services.RequestRideInput{ScheduledAt: &scheduledAt})
+
+			assert.Equal(t, errors.ErrInvalidScheduledTime, err)
+			rideRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestCancelScheduledRide(t *testing.T) {
+	ctx := context.Background()
+
+	newScheduledRide := func(svc *rideService, lead time.Duration) *models.Ride {
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, testRideNow)
+		ride.Schedule(testRideNow.Add(lead), testRideNow)
+		return ride
+	}
+
+	t.Run("is free well ahead of pickup", func(t *testing.T) {
+		svc, rideRepo, _, _ := newTestRideService()
+		paymentService := svc.paymentService.(*MockPaymentService)
+		ride := newScheduledRide(svc, 3*time.Hour)
+		rideRepo.On("FindByID", ctx, ride.ID).Return(ride, nil)
+		rideRepo.On("UpdateStatus", ctx, ride, models.RideStatusScheduled).Return(nil)
+		paymentService.On("VoidRide", ctx, ride.ID).Return(nil)
+
+		cancelled, err := svc.CancelRide(ctx, ride.ID, "rider-1", services.CancelRideInput{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.RideStatusCancelled, cancelled.Status)
+		assert.Zero(t, cancelled.Fare)
+		paymentService.AssertNotCalled(t, "AuthorizeRide", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("charges the late fee close to pickup", func(t *testing.T) {
+		svc, rideRepo, _, _ := newTestRideService()
+		paymentService := svc.paymentService.(*MockPaymentService)
+		ride := newScheduledRide(svc, 40*time.Minute)
+		rideRepo.On("FindByID", ctx, ride.ID).Return(ride, nil)
+		rideRepo.On("UpdateStatus", ctx, ride, models.RideStatusScheduled).Return(nil)
+		paymentService.On("AuthorizeRide", ctx, ride, 50.0).Return(&models.Payment{}, nil)
+		paymentService.On("CaptureRide", ctx, ride).Return(&models.Payment{}, nil)
+
+		cancelled, err := svc.CancelRide(ctx, ride.ID, "rider-1", services.CancelRideInput{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 50.0, cancelled.Fare)
+		paymentService.AssertExpectations(t)
+		paymentService.AssertNotCalled(t, "VoidRide", mock.Anything, mock.Anything)
+	})
+
+	t.Run("charges the late fee to the wallet", func(t *testing.T) {
+		svc, rideRepo, _, _ := newTestRideService()
+		walletService := svc.walletService.(*MockWalletService)
+		ride := newScheduledRide(svc, 40*time.Minute)
+		ride.PaymentMethod = models.PaymentMethodWallet
+		rideRepo.On("FindByID", ctx, ride.ID).Return(ride, nil)
+		rideRepo.On("UpdateStatus", ctx, ride, models.RideStatusScheduled).Return(nil)
+		walletService.On("ChargeRide", ctx, ride).Return(&services.WalletTransaction{}, nil)
+
+		_, err := svc.CancelRide(ctx, ride.ID, "rider-1", services.CancelRideInput{})
+
+		assert.NoError(t, err)
+		walletService.AssertExpectations(t)
+	})
+
+	t.Run("on-demand rides are free to cancel", func(t *testing.T) {
+		svc, rideRepo, _, _ := newTestRideService()
+		paymentService := svc.paymentService.(*MockPaymentService)
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, testRideNow)
+		rideRepo.On("FindByID", ctx, ride.ID).Return(ride, nil)
+		rideRepo.On("UpdateStatus", ctx, ride, models.RideStatusRequested).Return(nil)
+		paymentService.On("VoidRide", ctx, ride.ID).Return(nil)
+
+		cancelled, err := svc.CancelRide(ctx, ride.ID, "rider-1", services.CancelRideInput{})
+
+		assert.NoError(t, err)
+		assert.Zero(t, cancelled.Fare)
+		paymentService.AssertExpectations(t)
+	})
+
+	t.Run("releases the wallet hold of a free cancellation", func(t *testing.T) {
+		svc, rideRepo, _, _ := newTestRideService()
+		walletService := svc.walletService.(*MockWalletService)
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, testRideNow)
+		ride.PaymentMethod = models.PaymentMethodWallet
+		ride.Accept("driver-1", testRideNow)
+		rideRepo.On("FindByID", ctx, ride.ID).Return(ride, nil)
+		rideRepo.On("UpdateStatus", ctx, ride, models.RideStatusAccepted).Return(nil)
+		walletService.On("ReleaseRideHold", ctx, ride).Return(nil)
+
+		_, err := svc.CancelRide(ctx, ride.ID, "rider-1", services.CancelRideInput{})
+
+		assert.NoError(t, err)
+		walletService.AssertExpectations(t)
+		walletService.AssertNotCalled(t, "ChargeRide", mock.Anything, mock.Anything)
+	})
+}
+
+func TestRetryCharges(t *testing.T) {
+	ctx := context.Background()
+	endedBefore := testRideNow.Add(-15 * time.Minute)
+
+	svc, rideRepo, _, _ := newTestRideService()
+	paymentService := svc.paymentService.(*MockPaymentService)
+	completed := *models.NewRide("rider-1", models.Location{}, models.Location{}, testRideNow)
+	completed.Complete(280, 0, testRideNow)
+	cancelled := *models.NewRide("rider-2", models.Location{}, models.Location{}, testRideNow)
+	cancelled.Cancel("rider-2", "", testRideNow)
+	cancelled.Fare = 50
+	declined := *models.NewRide("rider-3", models.Location{}, models.Location{}, testRideNow)
+	declined.Complete(120, 0, testRideNow)
+	// A wallet ride whose balance has been topped up since
+	wallet := *models.NewRide("rider-4", models.Location{}, models.Location{}, testRideNow)
+	wallet.PaymentMethod = models.PaymentMethodWallet
+	wallet.Complete(90, 0, testRideNow)
+	rideRepo.On("FindUncharged", ctx, endedBefore).Return([]models.Ride{completed, cancelled, declined, wallet}, nil)
+	walletService := svc.walletService.(*MockWalletService)
+	walletService.On("ChargeRide", ctx, mock.MatchedBy(func(r *models.Ride) bool {
+		return r.ID == wallet.ID
+	})).Return(&services.WalletTransaction{}, nil)
+	paymentService.On("CaptureRide", ctx, mock.MatchedBy(func(r *models.Ride) bool {
+		return r.ID == completed.ID || r.ID == cancelled.ID
+	})).Return(&models.Payment{}, nil)
+	paymentService.On("CaptureRide", ctx, mock.MatchedBy(func(r *models.Ride) bool {
+		return r.ID == declined.ID
+	})).Return(nil, errors.ErrPaymentDeclined)
+	// Cancellation fees are authorized before they are captured
+	paymentService.On("AuthorizeRide", ctx, mock.MatchedBy(func(r *models.Ride) bool {
+		return r.ID == cancelled.ID
+	}), 50.0).Return(&models.Payment{}, nil)
+
+	charged, err := svc.RetryCharges(ctx, endedBefore)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, charged)
+	paymentService.AssertExpectations(t)
+	walletService.AssertExpectations(t)
+}
+
+func TestRideTransitions(t *testing.T) {
+	ctx := context.Background()
+	driverID := "driver-1"
+	vehicle := &models.Vehicle{ID: "vehicle-1", Type: models.VehicleTypeCar, IsActive: true}
+
+	t.Run("start from accepted is rejected", func(t *testing.T) {
+		svc, rideRepo, _, _ := newTestRideService()
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, testRideNow)
+		ride.Accept(driverID, testRideNow)
+		rideRepo.On("FindByID", ctx, ride.ID).Return(ride, nil)
+
+		_, err := svc.StartRide(ctx, ride.ID, driverID)
+
+		assert.True(t, stderrors.Is(err, errors.ErrInvalidRideTransition))
+		var transitionErr *errors.RideTransitionError
+		assert.True(t, stderrors.As(err, &transitionErr))
+		assert.Equal(t, string(models.RideStatusAccepted), transitionErr.From)
+		assert.Equal(t, string(models.RideStatusInProgress), transitionErr.To)
+		rideRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("arrival by another driver is rejected", func(t *testing.T) {
+		svc, rideRepo, _, _ := newTestRideService()
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, testRideNow)
+		ride.Accept(driverID, testRideNow)
+		rideRepo.On("FindByID", ctx, ride.ID).Return(ride, nil)
+
+		_, err := svc.MarkDriverArrived(ctx, ride.ID, "driver-2")
+
+		assert.Equal(t, errors.ErrNotRideParticipant, err)
+	})
+
+	t.Run("accept persists guarded by previous status", func(t *testing.T) {
+		svc, rideRepo, driverRepo, _ := newTestRideService()
+		pricingService := svc.pricingService.(*MockPricingService)
+		paymentService := svc.paymentService.(*MockPaymentService)
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, testRideNow)
+		driverRepo.On("FindByID", ctx, driverID).Return(&models.Driver{ID: driverID, VerificationStatus: models.VerificationStatusApproved, ActiveVehicle: vehicle}, nil)
+		rideRepo.On("FindActiveByDriverID", ctx, driverID).Return(nil, errors.ErrRideNotFound)
+		rideRepo.On("FindByID", ctx, ride.ID).Return(ride, nil)
+		pricingService.On("EstimateRide", ride, mock.Anything).Return(&services.FareEstimate{Breakdown: pricing.Breakdown{Total: 200}}, nil)
+		paymentService.On("AuthorizeRide", ctx, ride, 200.0).Return(&models.Payment{}, nil)
+		rideRepo.On("UpdateStatus", ctx, ride, models.RideStatusRequested).Return(nil)
+
+		accepted, err := svc.AcceptRide(ctx, ride.ID, driverID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.RideStatusAccepted, accepted.Status)
+		assert.True(t, accepted.IsAssignedTo(driverID))
+		assert.Equal(t, &vehicle.ID, accepted.VehicleID)
+		rideRepo.AssertExpectations(t)
+		paymentService.AssertExpectations(t)
+	})
+
+	t.Run("pooled ride joins the driver's pool", func(t *testing.T) {
+		svc, rideRepo, driverRepo, _ := newTestRideService()
+		pricingService := svc.pricingService.(*MockPricingService)
+		paymentService := svc.paymentService.(*MockPaymentService)
+		poolService := svc.poolService.(*MockPoolService)
+		driver := &models.Driver{ID: driverID, VerificationStatus: models.VerificationStatusApproved, ActiveVehicle: vehicle}
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, testRideNow)
+		ride.Pooled = true
+		pool := models.NewPool(driverID, 3, time.Now())
+		driverRepo.On("FindByID", ctx, driverID).Return(driver, nil)
+		rideRepo.On("FindByID", ctx, ride.ID).Return(ride, nil)
+		pricingService.On("EstimateRide", ride, mock.Anything).Return(&services.FareEstimate{Breakdown: pricing.Breakdown{Total: 120}}, nil)
+		paymentService.On("AuthorizeRide", ctx, ride, 120.0).Return(&models.Payment{}, nil)
+		poolService.On("Join", ctx, ride, driver).Return(pool, nil)
+		rideRepo.On("UpdateStatus", ctx, ride, models.RideStatusRequested).Return(nil)
+
+		accepted, err := svc.AcceptRide(ctx, ride.ID, driverID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, pool.ID, *accepted.PoolID)
+		rideRepo.AssertNotCalled(t, "FindActiveByDriverID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("pooled ride that no longer fits releases the hold", func(t *testing.T) {
+		svc, rideRepo, driverRepo, _ := newTestRideService()
+		pricingService := svc.pricingService.(*MockPricingService)
+		paymentService := svc.paymentService.(*MockPaymentService)
+		poolService := svc.poolService.(*MockPoolService)
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, testRideNow)
+		ride.Pooled = true
+		driverRepo.On("FindByID", ctx, driverID).Return(&models.Driver{ID: driverID, VerificationStatus: models.VerificationStatusApproved, ActiveVehicle: vehicle}, nil)
+		rideRepo.On("FindByID", ctx, ride.ID).Return(ride, nil)
+		pricingService.On("EstimateRide", ride, mock.Anything).Return(&services.FareEstimate{Breakdown: pricing.Breakdown{Total: 120}}, nil)
+		paymentService.On("AuthorizeRide", ctx, ride, 120.0).Return(&models.Payment{}, nil)
+		paymentService.On("VoidRide", ctx, ride.ID).Return(nil)
+		poolService.On("Join", ctx, ride, mock.Anything).Return(nil, errors.ErrRideDoesNotFit)
+
+		_, err := svc.AcceptRide(ctx, ride.ID, driverID)
+
+		assert.Equal(t, errors.ErrRideDoesNotFit, err)
+		assert.Equal(t, models.RideStatusRequested, ride.Status)
+		paymentService.AssertCalled(t, "VoidRide", ctx, ride.ID)
+	})
+
+	t.Run("declined payment cancels the ride", func(t *testing.T) {
+		svc, rideRepo, driverRepo, _ := newTestRideService()
+		pricingService := svc.pricingService.(*MockPricingService)
+		paymentService := svc.paymentService.(*MockPaymentService)
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, testRideNow)
+		driverRepo.On("FindByID", ctx, driverID).Return(&models.Driver{ID: driverID, VerificationStatus: models.VerificationStatusApproved, ActiveVehicle: vehicle}, nil)
+		rideRepo.On("FindActiveByDriverID", ctx, driverID).Return(nil, errors.ErrRideNotFound)
+		rideRepo.On("FindByID", ctx, ride.ID).Return(ride, nil)
+		pricingService.On("EstimateRide", ride, mock.Anything).Return(&services.FareEstimate{Breakdown: pricing.Breakdown{Total: 200}}, nil)
+		paymentService.On("AuthorizeRide", ctx, ride, 200.0).Return(nil, errors.ErrPaymentDeclined)
+		rideRepo.On("UpdateStatus", ctx, ride, models.RideStatusRequested).Return(nil)
+
+		_, err := svc.AcceptRide(ctx, ride.ID, driverID)
+
+		assert.Equal(t, errors.ErrPaymentDeclined, err)
+		assert.Equal(t, models.RideStatusCancelled, ride.Status)
+		assert.Equal(t, paymentDeclinedCancellationReason, ride.CancellationReason)
+		assert.Nil(t, ride.DriverID)
+	})
+
+	t.Run("wallet too low for the estimate cancels the ride", func(t *testing.T) {
+		svc, rideRepo, driverRepo, _ := newTestRideService()
+		pricingService := svc.pricingService.(*MockPricingService)
+		walletService := svc.walletService.(*MockWalletService)
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, testRideNow)
+		ride.PaymentMethod = models.PaymentMethodWallet
+		driverRepo.On("FindByID", ctx, driverID).Return(&models.Driver{ID: driverID, VerificationStatus: models.VerificationStatusApproved, ActiveVehicle: vehicle}, nil)
+		rideRepo.On("FindActiveByDriverID", ctx, driverID).Return(nil, errors.ErrRideNotFound)
+		rideRepo.On("FindByID", ctx, ride.ID).Return(ride, nil)
+		pricingService.On("EstimateRide", ride, mock.Anything).Return(&services.FareEstimate{Breakdown: pricing.Breakdown{Total: 200}}, nil)
+		walletService.On("HoldRideFare", ctx, ride, 200.0).Return(nil, errors.ErrInsufficientBalance)
+		rideRepo.On("UpdateStatus", ctx, ride, models.RideStatusRequested).Return(nil)
+
+		_, err := svc.AcceptRide(ctx, ride.ID, driverID)
+
+		assert.Equal(t, errors.ErrInsufficientBalance, err)
+		assert.Equal(t, models.RideStatusCancelled, ride.Status)
+		svc.paymentService.(*MockPaymentService).AssertNotCalled(t, "AuthorizeRide", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("cancel by a stranger is rejected", func(t *testing.T) {
+		svc, rideRepo, driverRepo, _ := newTestRideService()
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, testRideNow)
+		rideRepo.On("FindByID", ctx, ride.ID).Return(ride, nil)
+		driverRepo.On("FindByUserID", ctx, "someone").Return(nil, errors.ErrDriverNotFound)
+
+		_, err := svc.CancelRide(ctx, ride.ID, "someone", services.CancelRideInput{})
+
+		assert.Equal(t, errors.ErrNotRideParticipant, err)
+	})
+}
+
+func TestCompleteRideRedeemsPromoCode(t *testing.T) {
+	ctx := context.Background()
+	driverID := "driver-1"
+	promoID := "promo-1"
+
+	newInProgressRide := func() *models.Ride {
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, testRideNow)
+		ride.PromoCodeID = &promoID
+		ride.Accept(driverID, testRideNow)
+		ride.MarkDriverArrived(testRideNow)
+		ride.Start(testRideNow)
+		return ride
+	}
+	newTestService := func(ride *models.Ride) (*rideService, *MockRideRepository, *MockPromoService) {
+		svc, rideRepo, driverRepo, _ := newTestRideService()
+		historyRepo := svc.historyRepo.(*MockLocationHistoryRepository)
+		pricingService := svc.pricingService.(*MockPricingService)
+		paymentService := svc.paymentService.(*MockPaymentService)
+		rideRepo.On("FindByID", ctx, ride.ID).Return(ride, nil)
+		driverRepo.On("FindByID", ctx, driverID).Return(&models.Driver{ID: driverID}, nil)
+		historyRepo.On("ListByRide", ctx, ride.ID).Return([]models.LocationPing{}, nil)
+		pricingService.On("QuoteRide", ride, mock.Anything, mock.Anything, testRideNow).
+			Return(&services.FareEstimate{VehicleType: models.VehicleTypeCar, DistanceKm: 12.5, Breakdown: pricing.Breakdown{Total: 280}}, nil)
+		paymentService.On("CaptureRide", ctx, ride).Return(&models.Payment{}, nil)
+		svc.earningsService.(*MockEarningsService).On("RecordRide", ctx, ride).Return(nil)
+		svc.receiptService.(*MockReceiptService).On("QueueRideReceipt", ride.ID).Return()
+		return svc, rideRepo, svc.promoService.(*MockPromoService)
+	}
+
+	t.Run("charges the fare less the discount", func(t *testing.T) {
+		ride := newInProgressRide()
+		svc, rideRepo, promoService := newTestService(ride)
+		promoService.On("RedeemForRide", ctx, ride, models.VehicleTypeCar, 280.0).Return(42.0, nil)
+		rideRepo.On("UpdateStatus", ctx, ride, models.RideStatusInProgress).Return(nil)
+
+		completed, err := svc.CompleteRide(ctx, ride.ID, driverID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 238.0, completed.Fare)
+		assert.Equal(t, 42.0, completed.Discount)
+		assert.Equal(t, 12.5, completed.DistanceKm)
+		assert.Equal(t, &pricing.Breakdown{Discount: 42, Total: 238}, completed.FareBreakdown)
+		assert.Equal(t, testRideNow, *completed.CompletedAt)
+		svc.paymentService.(*MockPaymentService).AssertCalled(t, "CaptureRide", ctx, ride)
+		svc.earningsService.(*MockEarningsService).AssertCalled(t, "RecordRide", ctx, ride)
+		svc.receiptService.(*MockReceiptService).AssertCalled(t, "QueueRideReceipt", ride.ID)
+	})
+
+	t.Run("gives the use back when completion fails", func(t *testing.T) {
+		ride := newInProgressRide()
+		svc, rideRepo, promoService := newTestService(ride)
+		promoService.On("RedeemForRide", ctx, ride, models.VehicleTypeCar, 280.0).Return(42.0, nil)
+		rideRepo.On("UpdateStatus", ctx, ride, models.RideStatusInProgress).Return(errors.ErrRideStatusConflict)
+		promoService.On("ReleaseRedemption", ctx, ride.ID).Return(nil)
+
+		_, err := svc.CompleteRide(ctx, ride.ID, driverID)
+
+		assert.Equal(t, errors.ErrRideStatusConflict, err)
+		promoService.AssertExpectations(t)
+		svc.paymentService.(*MockPaymentService).AssertNotCalled(t, "CaptureRide", mock.Anything, mock.Anything)
+	})
+
+	t.Run("charges a wallet ride to the wallet", func(t *testing.T) {
+		ride := newInProgressRide()
+		ride.PaymentMethod = models.PaymentMethodWallet
+		svc, rideRepo, promoService := newTestService(ride)
+		walletService := svc.walletService.(*MockWalletService)
+		promoService.On("RedeemForRide", ctx, ride, models.VehicleTypeCar, 280.0).Return(0.0, nil)
+		rideRepo.On("UpdateStatus", ctx, ride, models.RideStatusInProgress).Return(nil)
+		walletService.On("ChargeRide", ctx, ride).Return(&services.WalletTransaction{}, nil)
+
+		_, err := svc.CompleteRide(ctx, ride.ID, driverID)
+
+		assert.NoError(t, err)
+		walletService.AssertExpectations(t)
+		svc.paymentService.(*MockPaymentService).AssertNotCalled(t, "CaptureRide", mock.Anything, mock.Anything)
+	})
+
+	t.Run("records a pooled dropoff only once the ride completed", func(t *testing.T) {
+		for _, updateErr := range []error{nil, errors.ErrRideStatusConflict} {
+			ride := newInProgressRide()
+			ride.PromoCodeID = nil
+			poolID := "pool-1"
+			ride.PoolID = &poolID
+			svc, rideRepo, promoService := newTestService(ride)
+			poolService := svc.poolService.(*MockPoolService)
+			poolService.On("SharedDistanceKm", ctx, ride).Return(4.0, nil)
+			poolService.On("Visit", ctx, ride, models.PoolStopDropoff).Return(&models.Pool{}, nil)
+			svc.pricingService.(*MockPricingService).On("QuotePooledRide", ride, mock.Anything, 4.0).
+				Return(&services.FareEstimate{VehicleType: models.VehicleTypeCar, Breakdown: pricing.Breakdown{Total: 90}}, nil)
+			promoService.On("RedeemForRide", ctx, ride, models.VehicleTypeCar, 90.0).Return(0.0, nil)
+			rideRepo.On("UpdateStatus", ctx, ride, models.RideStatusInProgress).Return(updateErr)
+
+			_, err := svc.CompleteRide(ctx, ride.ID, driverID)
+
+			assert.Equal(t, updateErr, err)
+			if updateErr == nil {
+				poolService.AssertCalled(t, "Visit", ctx, ride, models.PoolStopDropoff)
+			} else {
+				poolService.AssertNotCalled(t, "Visit", mock.Anything, mock.Anything, mock.Anything)
+			}
+		}
+	})
+
+	t.Run("does not redeem for a ride that cannot complete", func(t *testing.T) {
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, testRideNow)
+		ride.Accept(driverID, testRideNow)
+		svc, _, promoService := newTestService(ride)
+
+		_, err := svc.CompleteRide(ctx, ride.ID, driverID)
+
+		assert.True(t, stderrors.Is(err, errors.ErrInvalidRideTransition))
+		promoService.AssertNotCalled(t, "RedeemForRide", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestRideWaypoints(t *testing.T) {
+	ctx := context.Background()
+	driverID := "driver-1"
+	pharmacy := models.Location{Latitude: 23.78, Longitude: 90.40}
+
+	t.Run("adding a stop before pickup re-estimates the fare", func(t *testing.T) {
+		svc, rideRepo, _, _ := newTestRideService()
+		pricingService := svc.pricingService.(*MockPricingService)
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, testRideNow)
+		rideRepo.On("FindByID", ctx, ride.ID).Return(ride, nil)
+		rideRepo.On("UpdateWaypoints", ctx, ride, models.RideStatusRequested).Return(nil)
+		pricingService.On("EstimateRide", ride, models.VehicleTypeCar).Return(&services.FareEstimate{Breakdown: pricing.Breakdown{Total: 180}}, nil)
+
+		route, err := svc.AddWaypoint(ctx, ride.ID, "rider-1", services.AddWaypointInput{Location: pharmacy})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 180.0, route.Estimate.Breakdown.Total)
+		assert.Nil(t, route.ArrivalAt)
+		if assert.Len(t, route.Ride.Waypoints, 1) {
+			assert.Equal(t, pharmacy, route.Ride.Waypoints[0].Location)
+			assert.Equal(t, testRideNow, route.Ride.Waypoints[0].AddedAt)
+		}
+	})
+
+	t.Run("one stop may be added during the trip", func(t *testing.T) {
+		svc, rideRepo, driverRepo, _ := newTestRideService()
+		pricingService := svc.pricingService.(*MockPricingService)
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, testRideNow)
+		ride.Accept(driverID, testRideNow)
+		ride.MarkDriverArrived(testRideNow)
+		ride.Start(testRideNow)
+		rideRepo.On("FindByID", ctx, ride.ID).Return(ride, nil)
+		rideRepo.On("UpdateWaypoints", ctx, ride, models.RideStatusInProgress).Return(nil)
+		driverRepo.On("FindByID", ctx, driverID).Return(&models.Driver{ID: driverID, ActiveVehicle: &models.Vehicle{Type: models.VehicleTypeBike}}, nil)
+		pricingService.On("EstimateRide", ride, models.VehicleTypeBike).Return(&services.FareEstimate{DurationMinutes: 25}, nil)
+
+		route, err := svc.AddWaypoint(ctx, ride.ID, "rider-1", services.AddWaypointInput{Location: pharmacy})
+		assert.NoError(t, err)
+		assert.Equal(t, ride.StartedAt.Add(25*time.Minute), *route.ArrivalAt)
+
+		_, err = svc.AddWaypoint(ctx, ride.ID, "rider-1", services.AddWaypointInput{Location: pharmacy})
+		assert.Equal(t, errors.ErrWaypointsLocked, err)
+
+		_, err = svc.RemoveWaypoint(ctx, ride.ID, "rider-1", ride.Waypoints[0].ID)
+		assert.Equal(t, errors.ErrWaypointsLocked, err)
+		rideRepo.AssertNumberOfCalls(t, "UpdateWaypoints", 1)
+	})
+
+	t.Run("a stop added during the trip cannot go before earlier stops", func(t *testing.T) {
+		svc, rideRepo, _, _ := newTestRideService()
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, testRideNow)
+		ride.AddWaypoint(pharmacy, 0, testRideNow)
+		ride.Accept(driverID, testRideNow)
+		ride.MarkDriverArrived(testRideNow)
+		ride.Start(testRideNow)
+		rideRepo.On("FindByID", ctx, ride.ID).Return(ride, nil)
+		first := 0
+
+		_, err := svc.AddWaypoint(ctx, ride.ID, "rider-1", services.AddWaypointInput{Location: pharmacy, Position: &first})
+
+		assert.Equal(t, errors.ErrWaypointsLocked, err)
+		assert.Len(t, ride.Waypoints, 1)
+		rideRepo.AssertNotCalled(t, "UpdateWaypoints", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("stops are limited", func(t *testing.T) {
+		svc, rideRepo, _, _ := newTestRideService()
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, testRideNow)
+		ride.AddWaypoint(pharmacy, 0, testRideNow)
+		ride.AddWaypoint(pharmacy, 0, testRideNow)
+		rideRepo.On("FindByID", ctx, ride.ID).Return(ride, nil)
+
+		_, err := svc.AddWaypoint(ctx, ride.ID, "rider-1", services.AddWaypointInput{Location: pharmacy})
+
+		assert.Equal(t, errors.ErrTooManyWaypoints, err)
+	})
+
+	t.Run("only the rider may change stops", func(t *testing.T) {
+		svc, rideRepo, _, _ := newTestRideService()
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, testRideNow)
+		rideRepo.On("FindByID", ctx, ride.ID).Return(ride, nil)
+
+		_, err := svc.AddWaypoint(ctx, ride.ID, "rider-2", services.AddWaypointInput{Location: pharmacy})
+
+		assert.Equal(t, errors.ErrNotRideParticipant, err)
+	})
+
+	t.Run("removing an unknown stop", func(t *testing.T) {
+		svc, rideRepo, _, _ := newTestRideService()
+		ride := models.NewRide("rider-1", models.Location{}, models.Location{}, testRideNow)
+		rideRepo.On("FindByID", ctx, ride.ID).Return(ride, nil)
+
+		_, err := svc.RemoveWaypoint(ctx, ride.ID, "rider-1", "waypoint-1")
+
+		assert.Equal(t, errors.ErrWaypointNotFound, err)
+	})
+
+	t.Run("pooled rides have no stops", func(t *testing.T) {
+		svc, _, _, userRepo := newTestRideService()
+		userRepo.On("FindByID", ctx, "rider-1").Return(&models.User{ID: "rider-1", UserType: models.UserTypeRider}, nil)
+
+		_, err := svc.RequestRide(ctx, "rider-1", services.RequestRideInput{
+			Pooled: true, Waypoints: []models.Location{pharmacy},
+		})
+
+		assert.Equal(t, errors.ErrPooledRideWaypoints, err)
+	})
+}
+
+func TestListDriverRides(t *testing.T) {
+	ctx := context.Background()
+	driverID := "driver-1"
+
+	t.Run("applies default pagination", func(t *testing.T) {
+		svc, rideRepo, _, _ := newTestRideService()
+		rideRepo.On("List", ctx, repositories.RideFilter{DriverID: driverID, Page: 1, Limit: defaultRidePageLimit}).
+			Return([]models.Ride{{ID: "ride-1"}}, int64(25), nil)
+
+		list, err := svc.ListDriverRides(ctx, driverID, services.ListRidesInput{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(25), list.Total)
+		assert.True(t, list.HasMore)
+	})
+
+	t.Run("last page has no more", func(t *testing.T) {
+		svc, rideRepo, _, _ := newTestRideService()
+		statuses := []models.RideStatus{models.RideStatusCompleted}
+		rideRepo.On("List", ctx, repositories.RideFilter{DriverID: driverID, Statuses: statuses, Page: 3, Limit: 10}).
+			Return([]models.Ride{{ID: "ride-1"}}, int64(25), nil)
+
+		list, err := svc.ListDriverRides(ctx, driverID, services.ListRidesInput{Statuses: statuses, Page: 3, Limit: 10})
+
+		assert.NoError(t, err)
+		assert.False(t, list.HasMore)
+	})
+}
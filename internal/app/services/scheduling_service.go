@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+	"github.com/sayeed1999/share-a-ride/internal/provider/email"
+)
+
+const riderBusyCancellationReason = "rider already on another ride"
+
+type schedulingService struct {
+	rideRepo        repositories.RideRepository
+	userRepo        repositories.UserRepository
+	matchingService services.MatchingService
+	emailService    email.EmailServiceInterface
+	clock           clock.Clock
+	config          config.SchedulingConfig
+}
+
+func NewSchedulingService(
+	rideRepo repositories.RideRepository,
+	userRepo repositories.UserRepository,
+	matchingService services.MatchingService,
+	emailService email.EmailServiceInterface,
+	clk clock.Clock,
+	cfg config.SchedulingConfig,
+) services.SchedulingService {
+	return &schedulingService{
+		rideRepo:        rideRepo,
+		userRepo:        userRepo,
+		matchingService: matchingService,
+		emailService:    emailService,
+		clock:           clk,
+		config:          cfg,
+	}
+}
+
+func (s *schedulingService) ReleaseDueRides(ctx context.Context) (int, error) {
+	rides, err := s.rideRepo.FindScheduledBefore(ctx, s.clock.Now().Add(s.config.DispatchLeadTime))
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for i := range rides {
+		ride := &rides[i]
+		if err := s.release(ctx, ride); err != nil {
+			// A conflict means the rider cancelled in the meantime
+			if err != errors.ErrRideStatusConflict {
+				log.Printf("failed to release scheduled ride %s: %v", ride.ID, err)
+			}
+			continue
+		}
+		if ride.Status == models.RideStatusRequested {
+			s.matchingService.StartDispatch(ride)
+			released++
+		}
+	}
+	return released, nil
+}
+
+// release moves ride on to requested, or cancels it when its rider already
+// has a ride in flight, since a rider can only be on one ride at a time
+func (s *schedulingService) release(ctx context.Context, ride *models.Ride) error {
+	if _, err := s.rideRepo.FindActiveByRiderID(ctx, ride.RiderID); err == nil {
+		ride.Cancel("", riderBusyCancellationReason, s.clock.Now())
+	} else if err == errors.ErrRideNotFound {
+		ride.Release(s.clock.Now())
+	} else {
+		return err
+	}
+	return s.rideRepo.UpdateStatus(ctx, ride, models.RideStatusScheduled)
+}
+
+func (s *schedulingService) SendReminders(ctx context.Context) (int, error) {
+	now := s.clock.Now()
+	rides, err := s.rideRepo.FindScheduledBefore(ctx, now.Add(s.config.ReminderLeadTime))
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range rides {
+		ride := &rides[i]
+		// Rides booked at shorter notice than the reminder need none
+		if ride.ReminderSentAt != nil || ride.ScheduledAt.Sub(ride.CreatedAt) <= s.config.ReminderLeadTime {
+			continue
+		}
+		if err := s.remind(ctx, ride); err != nil {
+			if err != errors.ErrRideStatusConflict {
+				log.Printf("failed to remind rider of scheduled ride %s: %v", ride.ID, err)
+			}
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// remind marks the reminder as sent before sending it, so that a rider is
+// reminded at most once even if sending fails
+func (s *schedulingService) remind(ctx context.Context, ride *models.Ride) error {
+	rider, err := s.userRepo.FindByID(ctx, ride.RiderID)
+	if err != nil {
+		return err
+	}
+
+	now := s.clock.Now()
+	ride.ReminderSentAt = &now
+	if err := s.rideRepo.UpdateStatus(ctx, ride, models.RideStatusScheduled); err != nil {
+		return err
+	}
+	return s.emailService.SendRideReminder(rider.Email, *ride.ScheduledAt)
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+)
+
+var testSchedulingConfig = config.SchedulingConfig{
+	DispatchLeadTime: 15 * time.Minute,
+	ReminderLeadTime: time.Hour,
+}
+
+type schedulingFixture struct {
+	svc             *schedulingService
+	rideRepo        *MockRideRepository
+	userRepo        *MockUserRepository
+	matchingService *MockMatchingService
+	emailService    *MockEmailService
+	now             time.Time
+}
+
+func newSchedulingFixture() *schedulingFixture {
+	f := &schedulingFixture{
+		rideRepo:        new(MockRideRepository),
+		userRepo:        new(MockUserRepository),
+		matchingService: new(MockMatchingService),
+		emailService:    new(MockEmailService),
+		now:             time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC),
+	}
+	f.svc = NewSchedulingService(f.rideRepo, f.userRepo, f.matchingService, f.emailService,
+		clock.NewFake(f.now), testSchedulingConfig).(*schedulingService)
+	return f
+}
+
+// scheduledRide is booked a day ahead for pickup lead after f.now
+func (f *schedulingFixture) scheduledRide(riderID string, lead time.Duration) models.Ride {
+	ride := models.NewRide(riderID, models.Location{}, models.Location{}, f.now)
+	ride.CreatedAt = f.now.Add(-24 * time.Hour)
+	ride.Schedule(f.now.Add(lead), f.now)
+	return *ride
+}
+
+func TestReleaseDueRides(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("hands due rides to dispatch", func(t *testing.T) {
+		f := newSchedulingFixture()
+		rides := []models.Ride{f.scheduledRide("rider-1", 10*time.Minute)}
+		f.rideRepo.On("FindScheduledBefore", ctx, f.now.Add(15*time.Minute)).Return(rides, nil)
+		f.rideRepo.On("FindActiveByRiderID", ctx, "rider-1").Return(nil, errors.ErrRideNotFound)
+		f.rideRepo.On("UpdateStatus", ctx, mock.Anything, models.RideStatusScheduled).Return(nil)
+		f.matchingService.On("StartDispatch", mock.MatchedBy(func(r *models.Ride) bool {
+			return r.ID == rides[0].ID && r.Status == models.RideStatusRequested
+		})).Return()
+
+		released, err := f.svc.ReleaseDueRides(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, released)
+		f.matchingService.AssertExpectations(t)
+	})
+
+	t.Run("cancels the ride of a rider already on another ride", func(t *testing.T) {
+		f := newSchedulingFixture()
+		rides := []models.Ride{f.scheduledRide("rider-1", 10*time.Minute)}
+		f.rideRepo.On("FindScheduledBefore", ctx, mock.Anything).Return(rides, nil)
+		f.rideRepo.On("FindActiveByRiderID", ctx, "rider-1").Return(&models.Ride{}, nil)
+		f.rideRepo.On("UpdateStatus", ctx, mock.MatchedBy(func(r *models.Ride) bool {
+			return r.Status == models.RideStatusCancelled && r.CancellationReason == riderBusyCancellationReason
+		}), models.RideStatusScheduled).Return(nil)
+
+		released, err := f.svc.ReleaseDueRides(ctx)
+
+		assert.NoError(t, err)
+		assert.Zero(t, released)
+		f.rideRepo.AssertExpectations(t)
+		f.matchingService.AssertNotCalled(t, "StartDispatch", mock.Anything)
+	})
+
+	t.Run("skips rides cancelled in the meantime", func(t *testing.T) {
+		f := newSchedulingFixture()
+		rides := []models.Ride{f.scheduledRide("rider-1", 10*time.Minute)}
+		f.rideRepo.On("FindScheduledBefore", ctx, mock.Anything).Return(rides, nil)
+		f.rideRepo.On("FindActiveByRiderID", ctx, "rider-1").Return(nil, errors.ErrRideNotFound)
+		f.rideRepo.On("UpdateStatus", ctx, mock.Anything, models.RideStatusScheduled).Return(errors.ErrRideStatusConflict)
+
+		released, err := f.svc.ReleaseDueRides(ctx)
+
+		assert.NoError(t, err)
+		assert.Zero(t, released)
+		f.matchingService.AssertNotCalled(t, "StartDispatch", mock.Anything)
+	})
+}
+
+func TestSendReminders(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reminds each rider once", func(t *testing.T) {
+		f := newSchedulingFixture()
+		due := f.scheduledRide("rider-1", 45*time.Minute)
+		reminded := f.scheduledRide("rider-2", 30*time.Minute)
+		reminded.ReminderSentAt = &f.now
+		f.rideRepo.On("FindScheduledBefore", ctx, f.now.Add(time.Hour)).Return([]models.Ride{due, reminded}, nil)
+		f.userRepo.On("FindByID", ctx, "rider-1").Return(&models.User{ID: "rider-1", Email: "jane@example.com"}, nil)
+		f.rideRepo.On("UpdateStatus", ctx, mock.MatchedBy(func(r *models.Ride) bool {
+			return r.ID == due.ID && r.ReminderSentAt != nil
+		}), models.RideStatusScheduled).Return(nil)
+		f.emailService.On("SendRideReminder", "jane@example.com", *due.ScheduledAt).Return(nil)
+
+		sent, err := f.svc.SendReminders(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, sent)
+		f.emailService.AssertExpectations(t)
+	})
+
+	t.Run("skips rides booked at short notice", func(t *testing.T) {
+		f := newSchedulingFixture()
+		ride := f.scheduledRide("rider-1", 40*time.Minute)
+		ride.CreatedAt = f.now
+		f.rideRepo.On("FindScheduledBefore", ctx, mock.Anything).Return([]models.Ride{ride}, nil)
+
+		sent, err := f.svc.SendReminders(ctx)
+
+		assert.NoError(t, err)
+		assert.Zero(t, sent)
+		f.emailService.AssertNotCalled(t, "SendRideReminder", mock.Anything, mock.Anything)
+	})
+}
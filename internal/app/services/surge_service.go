@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/surge"
+)
+
+type surgeService struct {
+	rideRepo   repositories.RideRepository
+	driverRepo repositories.DriverRepository
+	clock      clock.Clock
+	config     config.SurgeConfig
+
+	mu       sync.RWMutex
+	snapshot *services.SurgeSnapshot
+	// multipliers holds the snapshot's cells above 1 for quick lookups
+	multipliers map[surge.Cell]float64
+}
+
+func NewSurgeService(
+	rideRepo repositories.RideRepository,
+	driverRepo repositories.DriverRepository,
+	clk clock.Clock,
+	cfg config.SurgeConfig,
+) services.SurgeService {
+	return &surgeService{
+		rideRepo:   rideRepo,
+		driverRepo: driverRepo,
+		clock:      clk,
+		config:     cfg,
+	}
+}
+
+func (s *surgeService) Recompute(ctx context.Context) error {
+	rides, err := s.rideRepo.FindOpenRequests(ctx)
+	if err != nil {
+		return err
+	}
+	drivers, err := s.driverRepo.FindAllAvailable(ctx)
+	if err != nil {
+		return err
+	}
+
+	cells := make(map[surge.Cell]*services.SurgeCell)
+	cellAt := func(lat, lng float64) *services.SurgeCell {
+		key := surge.CellOf(lat, lng, s.config.CellSizeDeg)
+		cell, ok := cells[key]
+		if !ok {
+			cell = &services.SurgeCell{Cell: key, Bounds: key.Bounds(s.config.CellSizeDeg)}
+			cells[key] = cell
+		}
+		return cell
+	}
+	for _, ride := range rides {
+		cellAt(ride.PickupLocation.Latitude, ride.PickupLocation.Longitude).Demand++
+	}
+	for _, driver := range drivers {
+		cellAt(driver.CurrentLocation.Latitude, driver.CurrentLocation.Longitude).Supply++
+	}
+
+	params := surge.Params{
+		Sensitivity:   s.config.Sensitivity,
+		MaxMultiplier: s.config.MaxMultiplier,
+		Step:          s.config.Step,
+	}
+	snapshot := &services.SurgeSnapshot{
+		ComputedAt:  s.clock.Now(),
+		CellSizeDeg: s.config.CellSizeDeg,
+		Cells:       make([]services.SurgeCell, 0, len(cells)),
+	}
+	multipliers := make(map[surge.Cell]float64)
+	for key, cell := range cells {
+		cell.Multiplier = surge.Multiplier(cell.Demand, cell.Supply, params)
+		if cell.Multiplier > 1 {
+			multipliers[key] = cell.Multiplier
+		}
+		snapshot.Cells = append(snapshot.Cells, *cell)
+	}
+	sort.Slice(snapshot.Cells, func(i, j int) bool {
+		a, b := snapshot.Cells[i], snapshot.Cells[j]
+		if a.Row != b.Row {
+			return a.Row < b.Row
+		}
+		return a.Col < b.Col
+	})
+
+	s.mu.Lock()
+	s.snapshot = snapshot
+	s.multipliers = multipliers
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *surgeService) MultiplierAt(lat, lng float64) float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if m, ok := s.multipliers[surge.CellOf(lat, lng, s.config.CellSizeDeg)]; ok {
+		return m
+	}
+	return 1
+}
+
+func (s *surgeService) Heatmap() *services.SurgeSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.snapshot
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+)
+
+var testSurgeConfig = config.SurgeConfig{
+	CellSizeDeg:   0.02,
+	Sensitivity:   0.5,
+	MaxMultiplier: 2,
+	Step:          0.1,
+}
+
+func TestSurgeRecompute(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 2, 20, 12, 0, 0, 0, time.UTC))
+	rideRepo := new(MockRideRepository)
+	driverRepo := new(MockDriverRepository)
+	svc := NewSurgeService(rideRepo, driverRepo, clk, testSurgeConfig)
+
+	gulshan := models.Location{Latitude: 23.7925, Longitude: 90.4078}
+	motijheel := models.Location{Latitude: 23.7330, Longitude: 90.4172}
+
+	// Before the first computation there is no surge anywhere
+	assert.Equal(t, 1.0, svc.MultiplierAt(gulshan.Latitude, gulshan.Longitude))
+	assert.Nil(t, svc.Heatmap())
+
+	rides := []models.Ride{
+		*models.NewRide("rider-1", gulshan, motijheel, clk.Now()),
+		*models.NewRide("rider-2", gulshan, motijheel, clk.Now()),
+		*models.NewRide("rider-3", gulshan, motijheel, clk.Now()),
+		*models.NewRide("rider-4", motijheel, gulshan, clk.Now()),
+	}
+	drivers := []models.Driver{
+		{ID: "driver-1", CurrentLocation: gulshan},
+		{ID: "driver-2", CurrentLocation: motijheel},
+		{ID: "driver-3", CurrentLocation: motijheel},
+	}
+	rideRepo.On("FindOpenRequests", ctx).Return(rides, nil)
+	driverRepo.On("FindAllAvailable", ctx).Return(drivers, nil)
+
+	require.NoError(t, svc.Recompute(ctx))
+
+	// Three riders for one driver: 1 + (3-1)*0.5, capped at 2
+	assert.Equal(t, 2.0, svc.MultiplierAt(gulshan.Latitude, gulshan.Longitude))
+	assert.Equal(t, 1.0, svc.MultiplierAt(motijheel.Latitude, motijheel.Longitude))
+
+	heatmap := svc.Heatmap()
+	require.NotNil(t, heatmap)
+	assert.Equal(t, clk.Now(), heatmap.ComputedAt)
+	if assert.Len(t, heatmap.Cells, 2) {
+		// Cells are ordered south to north
+		assert.Equal(t, 1, heatmap.Cells[0].Demand)
+		assert.Equal(t, 2, heatmap.Cells[0].Supply)
+		assert.Equal(t, 3, heatmap.Cells[1].Demand)
+		assert.Equal(t, 1, heatmap.Cells[1].Supply)
+		assert.Equal(t, 2.0, heatmap.Cells[1].Multiplier)
+	}
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/pubsub"
+)
+
+// historyPruneBatchSize bounds how many positions one delete statement removes
+const historyPruneBatchSize = 10000
+
+// PositionHub fans driver positions out to riders by ride ID
+type PositionHub = pubsub.Hub[services.DriverPosition]
+
+func NewPositionHub() *PositionHub {
+	return pubsub.New[services.DriverPosition]()
+}
+
+type trackingService struct {
+	driverService services.DriverService
+	rideRepo      repositories.RideRepository
+	historyRepo   repositories.LocationHistoryRepository
+	hub           *PositionHub
+	clock         clock.Clock
+	config        config.LocationConfig
+	// buffer is how many positions a subscriber may lag behind
+	buffer int
+}
+
+func NewTrackingService(
+	driverService services.DriverService,
+	rideRepo repositories.RideRepository,
+	historyRepo repositories.LocationHistoryRepository,
+	hub *PositionHub,
+	clk clock.Clock,
+	cfg config.LocationConfig,
+	buffer int,
+) services.TrackingService {
+	return &trackingService{
+		driverService: driverService,
+		rideRepo:      rideRepo,
+		historyRepo:   historyRepo,
+		hub:           hub,
+		clock:         clk,
+		config:        cfg,
+		buffer:        buffer,
+	}
+}
+
+func (s *trackingService) PublishLocation(ctx context.Context, driverID string, input services.UpdateLocationInput) error {
+	if err := s.driverService.UpdateLocation(ctx, driverID, input); err != nil {
+		return err
+	}
+
+	rides, err := s.rideRepo.ListActiveByDriverID(ctx, driverID)
+	if err != nil {
+		return err
+	}
+
+	now := s.clock.Now()
+	location := models.Location{Latitude: input.Latitude, Longitude: input.Longitude}
+
+	// Nobody is watching a driver without a ride
+	if len(rides) == 0 {
+		s.recordPing(ctx, models.NewLocationPing(driverID, nil, location, now))
+		return nil
+	}
+
+	// Each ride of a pool keeps its own path
+	for i := range rides {
+		ride := &rides[i]
+		s.recordPing(ctx, models.NewLocationPing(driverID, &ride.ID, location, now))
+		s.hub.Publish(ride.ID, services.DriverPosition{
+			RideID:     ride.ID,
+			DriverID:   driverID,
+			Location:   location,
+			RecordedAt: now,
+		})
+	}
+	return nil
+}
+
+// recordPing adds ping to the location history. The current location is
+// already stored, so a gap in the history is not worth failing the update
+// for.
+func (s *trackingService) recordPing(ctx context.Context, ping *models.LocationPing) {
+	if err := s.historyRepo.Create(ctx, ping); err != nil {
+		log.Printf("failed to record location history of driver %s: %v", ping.DriverID, err)
+	}
+}
+
+func (s *trackingService) SubscribeToCurrentRide(ctx context.Context, riderID string) (*services.RideSubscription, error) {
+	ride, err := s.rideRepo.FindActiveByRiderID(ctx, riderID)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := s.hub.Subscribe(ride.ID, s.buffer)
+	return &services.RideSubscription{
+		Ride:      ride,
+		Positions: sub.C(),
+		Close:     sub.Close,
+	}, nil
+}
+
+func (s *trackingService) GetRidePath(ctx context.Context, rideID string, userID string) ([]models.LocationPing, error) {
+	ride, err := s.rideRepo.FindByID(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the rider or the assigned driver may see the route
+	if ride.RiderID != userID {
+		driver, err := s.driverService.GetDriverByUserID(ctx, userID)
+		if err != nil || !ride.IsAssignedTo(driver.ID) {
+			return nil, errors.ErrNotRideParticipant
+		}
+	}
+
+	return s.historyRepo.ListByRide(ctx, ride.ID)
+}
+
+func (s *trackingService) PruneLocationHistory(ctx context.Context) (int64, error) {
+	before := s.clock.Now().Add(-s.config.HistoryRetention)
+
+	var total int64
+	for {
+		deleted, err := s.historyRepo.DeleteRecordedBefore(ctx, before, historyPruneBatchSize)
+		total += deleted
+		if err != nil {
+			return total, err
+		}
+		if deleted < historyPruneBatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		log.Printf("pruned %d location history points", total)
+	}
+	return total, nil
+}
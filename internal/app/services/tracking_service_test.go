@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+)
+
+type trackingFixture struct {
+	svc           services.TrackingService
+	clock         *clock.Fake
+	driverService *MockDriverService
+	rideRepo      *MockRideRepository
+	historyRepo   *MockLocationHistoryRepository
+}
+
+func newTrackingFixture() *trackingFixture {
+	f := &trackingFixture{
+		clock:         clock.NewFake(time.Date(2024, 2, 20, 12, 0, 0, 0, time.UTC)),
+		driverService: new(MockDriverService),
+		rideRepo:      new(MockRideRepository),
+		historyRepo:   new(MockLocationHistoryRepository),
+	}
+	f.svc = NewTrackingService(f.driverService, f.rideRepo, f.historyRepo, NewPositionHub(), f.clock,
+		config.LocationConfig{HistoryRetention: 30 * 24 * time.Hour}, 4)
+	return f
+}
+
+func TestPublishLocationReachesRider(t *testing.T) {
+	ctx := context.Background()
+	f := newTrackingFixture()
+
+	ride := models.NewRide("rider-1", models.Location{}, models.Location{}, f.clock.Now())
+	ride.Accept("driver-1", f.clock.Now())
+	input := services.UpdateLocationInput{Latitude: 23.8103, Longitude: 90.4125}
+	f.driverService.On("UpdateLocation", ctx, "driver-1", input).Return(nil)
+	f.rideRepo.On("ListActiveByDriverID", ctx, "driver-1").Return([]models.Ride{*ride}, nil)
+	f.rideRepo.On("FindActiveByRiderID", ctx, "rider-1").Return(ride, nil)
+	f.historyRepo.On("Create", ctx, mock.MatchedBy(func(p *models.LocationPing) bool {
+		return p.RideID != nil && *p.RideID == ride.ID && p.RecordedAt.Equal(f.clock.Now())
+	})).Return(nil)
+
+	sub, err := f.svc.SubscribeToCurrentRide(ctx, "rider-1")
+	require.NoError(t, err)
+	defer sub.Close()
+
+	require.NoError(t, f.svc.PublishLocation(ctx, "driver-1", input))
+
+	position := <-sub.Positions
+	assert.Equal(t, ride.ID, position.RideID)
+	assert.Equal(t, "driver-1", position.DriverID)
+	assert.Equal(t, 23.8103, position.Location.Latitude)
+	f.historyRepo.AssertExpectations(t)
+}
+
+func TestPublishLocationWithoutRide(t *testing.T) {
+	ctx := context.Background()
+	f := newTrackingFixture()
+
+	input := services.UpdateLocationInput{Latitude: 23.8103, Longitude: 90.4125}
+	f.driverService.On("UpdateLocation", ctx, "driver-1", input).Return(nil)
+	f.rideRepo.On("ListActiveByDriverID", ctx, "driver-1").Return([]models.Ride{}, nil)
+	f.historyRepo.On("Create", ctx, mock.MatchedBy(func(p *models.LocationPing) bool {
+		return p.DriverID == "driver-1" && p.RideID == nil
+	})).Return(nil)
+
+	assert.NoError(t, f.svc.PublishLocation(ctx, "driver-1", input))
+	f.driverService.AssertExpectations(t)
+	f.historyRepo.AssertExpectations(t)
+}
+
+func TestSubscribeWithoutActiveRide(t *testing.T) {
+	ctx := context.Background()
+	f := newTrackingFixture()
+	f.rideRepo.On("FindActiveByRiderID", ctx, "rider-1").Return(nil, errors.ErrRideNotFound)
+
+	_, err := f.svc.SubscribeToCurrentRide(ctx, "rider-1")
+
+	assert.Equal(t, errors.ErrRideNotFound, err)
+}
+
+func TestGetRidePath(t *testing.T) {
+	ctx := context.Background()
+	ride := models.NewRide("rider-1", models.Location{}, models.Location{}, time.Now())
+	ride.Accept("driver-1", time.Now())
+	pings := []models.LocationPing{{ID: "ping-1"}, {ID: "ping-2"}}
+
+	tests := []struct {
+		name     string
+		userID   string
+		driver   *models.Driver
+		expected error
+	}{
+		{name: "Rider", userID: "rider-1"},
+		{name: "Assigned driver", userID: "user-2", driver: &models.Driver{ID: "driver-1"}},
+		{name: "Other driver", userID: "user-3", driver: &models.Driver{ID: "driver-2"}, expected: errors.ErrNotRideParticipant},
+		{name: "Other rider", userID: "rider-2", expected: errors.ErrNotRideParticipant},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTrackingFixture()
+			f.rideRepo.On("FindByID", ctx, ride.ID).Return(ride, nil)
+			f.historyRepo.On("ListByRide", ctx, ride.ID).Return(pings, nil)
+			if tt.driver != nil {
+				f.driverService.On("GetDriverByUserID", ctx, tt.userID).Return(tt.driver, nil)
+			} else {
+				f.driverService.On("GetDriverByUserID", ctx, tt.userID).Return(nil, errors.ErrDriverNotFound)
+			}
+
+			path, err := f.svc.GetRidePath(ctx, ride.ID, tt.userID)
+
+			assert.Equal(t, tt.expected, err)
+			if tt.expected == nil {
+				assert.Equal(t, pings, path)
+			}
+		})
+	}
+}
+
+func TestPruneLocationHistoryDeletesInBatches(t *testing.T) {
+	ctx := context.Background()
+	f := newTrackingFixture()
+	before := f.clock.Now().Add(-30 * 24 * time.Hour)
+	f.historyRepo.On("DeleteRecordedBefore", ctx, before, historyPruneBatchSize).Return(int64(historyPruneBatchSize), nil).Once()
+	f.historyRepo.On("DeleteRecordedBefore", ctx, before, historyPruneBatchSize).Return(int64(42), nil).Once()
+
+	deleted, err := f.svc.PruneLocationHistory(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(historyPruneBatchSize+42), deleted)
+	f.historyRepo.AssertExpectations(t)
+}
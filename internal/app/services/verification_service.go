@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/dateutil"
+	"github.com/sayeed1999/share-a-ride/internal/provider/email"
+)
+
+const (
+	defaultVerificationPageLimit = 20
+	maxVerificationPageLimit     = 100
+)
+
+type verificationService struct {
+	driverRepo    repositories.DriverRepository
+	userRepo      repositories.UserRepository
+	documentFiles services.DocumentFileService
+	emailService  email.EmailServiceInterface
+	index         *DriverIndex
+	clock         clock.Clock
+	config        config.DocumentConfig
+}
+
+func NewVerificationService(
+	driverRepo repositories.DriverRepository,
+	userRepo repositories.UserRepository,
+	documentFiles services.DocumentFileService,
+	emailService email.EmailServiceInterface,
+	index *DriverIndex,
+	clk clock.Clock,
+	cfg config.DocumentConfig,
+) services.VerificationService {
+	return &verificationService{
+		driverRepo:    driverRepo,
+		userRepo:      userRepo,
+		documentFiles: documentFiles,
+		emailService:  emailService,
+		index:         index,
+		clock:         clk,
+		config:        cfg,
+	}
+}
+
+func (s *verificationService) ListVerifications(ctx context.Context, input services.ListVerificationsInput) (*services.DriverList, error) {
+	status := input.Status
+	if status == "" {
+		status = models.VerificationStatusPending
+	}
+	page := input.Page
+	if page < 1 {
+		page = 1
+	}
+	limit := input.Limit
+	if limit < 1 {
+		limit = defaultVerificationPageLimit
+	}
+	if limit > maxVerificationPageLimit {
+		limit = maxVerificationPageLimit
+	}
+
+	drivers, total, err := s.driverRepo.ListByVerificationStatus(ctx, repositories.VerificationQueueFilter{
+		Status: status,
+		Page:   page,
+		Limit:  limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range drivers {
+		if err := s.documentFiles.SignURLs(ctx, drivers[i].Documents); err != nil {
+			return nil, err
+		}
+	}
+
+	return &services.DriverList{
+		Drivers: drivers,
+		Total:   total,
+		Page:    page,
+		Limit:   limit,
+		HasMore: int64(page*limit) < total,
+	}, nil
+}
+
+func (s *verificationService) ReviewDriver(ctx context.Context, driverID string, reviewerID string, input services.ReviewDriverInput) (*models.Driver, error) {
+	reason := strings.TrimSpace(input.Reason)
+	if reason == "" {
+		return nil, errors.ErrReviewReasonRequired
+	}
+
+	driver, err := s.driverRepo.FindByID(ctx, driverID)
+	if err != nil {
+		return nil, errors.ErrDriverNotFound
+	}
+
+	// Only the driver puts their details back in the queue
+	expected := driver.VerificationStatus
+	if input.Status == models.VerificationStatusPending || !expected.CanTransitionTo(input.Status) {
+		return nil, errors.ErrInvalidVerificationTransition
+	}
+
+	driver.Review(input.Status, reviewerID, reason, s.clock.Now())
+	if err := s.driverRepo.UpdateVerification(ctx, driver, expected); err != nil {
+		return nil, err
+	}
+	indexDriver(s.index, driver)
+
+	// The decision stands even if the driver cannot be told about it
+	if err := s.notify(ctx, driver); err != nil {
+		log.Printf("failed to notify driver %s of verification decision: %v", driver.ID, err)
+	}
+
+	return driver, nil
+}
+
+func (s *verificationService) notify(ctx context.Context, driver *models.Driver) error {
+	user, err := s.userRepo.FindByID(ctx, driver.UserID)
+	if err != nil {
+		return err
+	}
+	return s.emailService.SendDriverVerificationDecision(user.Email, string(driver.VerificationStatus), driver.ReviewReason)
+}
+
+func (s *verificationService) RemindExpiringDocuments(ctx context.Context) (int, error) {
+	thresholds := append([]int(nil), s.config.ExpiryReminderDays...)
+	sort.Ints(thresholds)
+	if len(thresholds) == 0 {
+		return 0, nil
+	}
+
+	now := s.clock.Now()
+	today := dateutil.StartOfDay(now.UTC())
+	documents, err := s.driverRepo.FindDocumentsExpiringBetween(ctx, today, today.AddDate(0, 0, thresholds[len(thresholds)-1]))
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range documents {
+		document := &documents[i]
+		daysLeft, ok := document.DaysUntilExpiry(now)
+		if !ok || daysLeft < 0 {
+			continue
+		}
+		threshold, due := reminderThreshold(thresholds, daysLeft)
+		// Each threshold is reminded of once, and a reminder sent late
+		// covers the thresholds it skipped
+		if !due || (document.ExpiryReminderDays != 0 && document.ExpiryReminderDays <= threshold) {
+			continue
+		}
+		reminded, err := s.remindExpiry(ctx, document, threshold)
+		if err != nil {
+			log.Printf("failed to remind driver of expiring document %s: %v", document.ID, err)
+			continue
+		}
+		if reminded {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// reminderThreshold returns the smallest threshold that daysLeft has
+// reached
+func reminderThreshold(thresholds []int, daysLeft int) (int, bool) {
+	for _, threshold := range thresholds {
+		if daysLeft <= threshold {
+			return threshold, true
+		}
+	}
+	return 0, false
+}
+
+// remindExpiry marks the reminder as sent before sending it, so that a
+// driver is reminded at most once per threshold even if sending fails.
+// Drivers who already hold a document of the same type that expires later
+// have renewed it and are not reminded.
+func (s *verificationService) remindExpiry(ctx context.Context, document *models.Document, threshold int) (bool, error) {
+	driver, err := s.driverRepo.FindByID(ctx, document.DriverID)
+	if err != nil {
+		return false, err
+	}
+	if hasRenewal(driver.Documents, document) {
+		return false, nil
+	}
+	user, err := s.userRepo.FindByID(ctx, driver.UserID)
+	if err != nil {
+		return false, err
+	}
+
+	if err := s.driverRepo.UpdateDocumentReminder(ctx, document.ID, threshold); err != nil {
+		return false, err
+	}
+	document.ExpiryReminderDays = threshold
+	return true, s.emailService.SendDocumentExpiryReminder(user.Email, string(document.Type), *document.ExpiresOn)
+}
+
+func hasRenewal(documents []models.Document, document *models.Document) bool {
+	for _, other := range documents {
+		if other.ID != document.ID && other.Type == document.Type && sameVehicle(other.VehicleID, document.VehicleID) &&
+			(other.ExpiresOn == nil || other.ExpiresOn.After(*document.ExpiresOn)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *verificationService) SuspendLapsedDrivers(ctx context.Context) (int, error) {
+	now := s.clock.Now()
+	drivers, err := s.driverRepo.FindApprovedWithLapsedDocuments(ctx, dateutil.StartOfDay(now.UTC()))
+	if err != nil {
+		return 0, err
+	}
+
+	suspended := 0
+	for i := range drivers {
+		driver := &drivers[i]
+		reason := lapsedReason(driver, now)
+		if reason == "" {
+			continue
+		}
+		if err := s.suspend(ctx, driver, reason, now); err != nil {
+			// A conflict means an admin reviewed the driver in the meantime
+			if err != errors.ErrVerificationConflict {
+				log.Printf("failed to suspend driver %s: %v", driver.ID, err)
+			}
+			continue
+		}
+		suspended++
+	}
+	return suspended, nil
+}
+
+func (s *verificationService) suspend(ctx context.Context, driver *models.Driver, reason string, now time.Time) error {
+	expected := driver.VerificationStatus
+	driver.Suspend(reason, now)
+	entry := models.NewAuditEntry(models.AuditActionDriverSuspended, "driver", driver.ID, nil, reason, now)
+	if err := s.driverRepo.Suspend(ctx, driver, expected, entry); err != nil {
+		return err
+	}
+	indexDriver(s.index, driver)
+
+	// The suspension stands even if the driver cannot be told about it
+	if err := s.notify(ctx, driver); err != nil {
+		log.Printf("failed to notify driver %s of suspension: %v", driver.ID, err)
+	}
+	return nil
+}
+
+// lapsedReason describes the document types of the driver's own and
+// active vehicle's documents that have all lapsed at now, or returns "" if
+// every type still has a valid document
+func lapsedReason(driver *models.Driver, now time.Time) string {
+	lapsed := make(map[models.DocumentType]time.Time)
+	valid := make(map[models.DocumentType]bool)
+	for _, document := range driver.Documents {
+		// Vehicles left parked do not keep the driver off the road
+		if document.VehicleID != nil && (driver.ActiveVehicle == nil || *document.VehicleID != driver.ActiveVehicle.ID) {
+			continue
+		}
+		if !document.IsLapsed(now) {
+			valid[document.Type] = true
+			continue
+		}
+		if last, ok := lapsed[document.Type]; !ok || document.ExpiresOn.After(last) {
+			lapsed[document.Type] = *document.ExpiresOn
+		}
+	}
+
+	var reasons []string
+	for docType, expiresOn := range lapsed {
+		if !valid[docType] {
+			reasons = append(reasons, fmt.Sprintf("%s expired on %s", docType, expiresOn.Format("2006-01-02")))
+		}
+	}
+	sort.Strings(reasons)
+	return strings.Join(reasons, "; ")
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+)
+
+func TestReviewDriver(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	user := &models.User{ID: "user-1", Email: "driver@example.com", UserType: models.UserTypeDriver}
+
+	newTestVerificationService := func(driver *models.Driver) (*verificationService, *MockDriverRepository, *MockEmailService, *DriverIndex) {
+		driverRepo := new(MockDriverRepository)
+		userRepo := new(MockUserRepository)
+		emailService := new(MockEmailService)
+		index := NewDriverIndex()
+		driverRepo.On("FindByID", ctx, driver.ID).Return(driver, nil)
+		userRepo.On("FindByID", ctx, user.ID).Return(user, nil)
+		svc := NewVerificationService(driverRepo, userRepo, new(MockDocumentFileService), emailService, index, clock.NewFake(now), config.DocumentConfig{}).(*verificationService)
+		return svc, driverRepo, emailService, index
+	}
+
+	t.Run("approving a pending driver notifies them", func(t *testing.T) {
+		driver := &models.Driver{ID: "driver-1", UserID: user.ID, VerificationStatus: models.VerificationStatusPending}
+		svc, driverRepo, emailService, _ := newTestVerificationService(driver)
+		driverRepo.On("UpdateVerification", ctx, driver, models.VerificationStatusPending).Return(nil)
+		emailService.On("SendDriverVerificationDecision", user.Email, "approved", "Documents check out").Return(nil)
+
+		reviewed, err := svc.ReviewDriver(ctx, driver.ID, "admin-1", services.ReviewDriverInput{
+			Status: models.VerificationStatusApproved, Reason: " Documents check out ",
+		})
+
+		assert.NoError(t, err)
+		assert.True(t, reviewed.IsVerified())
+		assert.Equal(t, "admin-1", *reviewed.ReviewedBy)
+		assert.Equal(t, now, *reviewed.ReviewedAt)
+		emailService.AssertExpectations(t)
+	})
+
+	t.Run("revoking an approval takes the driver offline", func(t *testing.T) {
+		driver := &models.Driver{ID: "driver-1", UserID: user.ID, VerificationStatus: models.VerificationStatusApproved, IsAvailable: true}
+		svc, driverRepo, emailService, index := newTestVerificationService(driver)
+		index.Upsert(driver.ID, 0, 0, *driver)
+		driverRepo.On("UpdateVerification", ctx, driver, models.VerificationStatusApproved).Return(nil)
+		emailService.On("SendDriverVerificationDecision", user.Email, "rejected", "Licence revoked").Return(nil)
+
+		reviewed, err := svc.ReviewDriver(ctx, driver.ID, "admin-1", services.ReviewDriverInput{
+			Status: models.VerificationStatusRejected, Reason: "Licence revoked",
+		})
+
+		assert.NoError(t, err)
+		assert.False(t, reviewed.IsAvailable)
+		assert.Empty(t, index.Nearby(0, 0, 1, 0))
+	})
+
+	t.Run("a reason is required", func(t *testing.T) {
+		driver := &models.Driver{ID: "driver-1", UserID: user.ID, VerificationStatus: models.VerificationStatusPending}
+		svc, driverRepo, _, _ := newTestVerificationService(driver)
+
+		_, err := svc.ReviewDriver(ctx, driver.ID, "admin-1", services.ReviewDriverInput{
+			Status: models.VerificationStatusRejected, Reason: "  ",
+		})
+
+		assert.Equal(t, errors.ErrReviewReasonRequired, err)
+		driverRepo.AssertNotCalled(t, "UpdateVerification", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejection is final", func(t *testing.T) {
+		driver := &models.Driver{ID: "driver-1", UserID: user.ID, VerificationStatus: models.VerificationStatusRejected}
+		svc, _, _, _ := newTestVerificationService(driver)
+
+		_, err := svc.ReviewDriver(ctx, driver.ID, "admin-1", services.ReviewDriverInput{
+			Status: models.VerificationStatusApproved, Reason: "Changed my mind",
+		})
+
+		assert.Equal(t, errors.ErrInvalidVerificationTransition, err)
+	})
+
+	t.Run("a failed notification does not undo the decision", func(t *testing.T) {
+		driver := &models.Driver{ID: "driver-1", UserID: user.ID, VerificationStatus: models.VerificationStatusPending}
+		svc, driverRepo, emailService, _ := newTestVerificationService(driver)
+		driverRepo.On("UpdateVerification", ctx, driver, models.VerificationStatusPending).Return(nil)
+		emailService.On("SendDriverVerificationDecision", mock.Anything, mock.Anything, mock.Anything).Return(assert.AnError)
+
+		reviewed, err := svc.ReviewDriver(ctx, driver.ID, "admin-1", services.ReviewDriverInput{
+			Status: models.VerificationStatusResubmissionRequired, Reason: "Insurance photo is blurry",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.VerificationStatusResubmissionRequired, reviewed.VerificationStatus)
+	})
+}
+
+func TestListVerifications(t *testing.T) {
+	ctx := context.Background()
+	driverRepo := new(MockDriverRepository)
+	documentFiles := new(MockDocumentFileService)
+	svc := NewVerificationService(driverRepo, new(MockUserRepository), documentFiles, new(MockEmailService), NewDriverIndex(), clock.New(), config.DocumentConfig{})
+	documents := []models.Document{{ID: "document-1"}}
+	driverRepo.On("ListByVerificationStatus", ctx, repositories.VerificationQueueFilter{
+		Status: models.VerificationStatusPending, Page: 1, Limit: defaultVerificationPageLimit,
+	}).Return([]models.Driver{{ID: "driver-1", Documents: documents}}, int64(21), nil)
+	documentFiles.On("SignURLs", ctx, documents).Return(nil)
+
+	list, err := svc.ListVerifications(ctx, services.ListVerificationsInput{})
+
+	assert.NoError(t, err)
+	assert.Len(t, list.Drivers, 1)
+	assert.True(t, list.HasMore)
+	documentFiles.AssertExpectations(t)
+}
+
+func TestRemindExpiringDocuments(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	today := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	user := &models.User{ID: "user-1", Email: "driver@example.com", UserType: models.UserTypeDriver}
+	cfg := config.DocumentConfig{ExpiryReminderDays: []int{30, 7, 1}}
+	expiring := func(id string, days, reminded int) models.Document {
+		expiresOn := today.AddDate(0, 0, days)
+		return models.Document{ID: id, DriverID: "driver-1", Type: models.DocumentTypeLicense, ExpiresOn: &expiresOn, ExpiryReminderDays: reminded}
+	}
+
+	newTestVerificationService := func(documents []models.Document, held []models.Document) (services.VerificationService, *MockDriverRepository, *MockEmailService) {
+		driverRepo := new(MockDriverRepository)
+		userRepo := new(MockUserRepository)
+		emailService := new(MockEmailService)
+		driverRepo.On("FindDocumentsExpiringBetween", ctx, today, today.AddDate(0, 0, 30)).Return(documents, nil)
+		driverRepo.On("FindByID", ctx, "driver-1").Return(&models.Driver{ID: "driver-1", UserID: user.ID, Documents: held}, nil)
+		userRepo.On("FindByID", ctx, user.ID).Return(user, nil)
+		svc := NewVerificationService(driverRepo, userRepo, new(MockDocumentFileService), emailService, NewDriverIndex(), clock.NewFake(now), cfg)
+		return svc, driverRepo, emailService
+	}
+
+	t.Run("each threshold is reminded of once", func(t *testing.T) {
+		documents := []models.Document{
+			expiring("due-30", 30, 0),
+			expiring("due-7", 5, 30),
+			expiring("sent-7", 6, 7),
+			expiring("not-yet", 12, 30),
+		}
+		svc, driverRepo, emailService := newTestVerificationService(documents, nil)
+		driverRepo.On("UpdateDocumentReminder", ctx, "due-30", 30).Return(nil)
+		driverRepo.On("UpdateDocumentReminder", ctx, "due-7", 7).Return(nil)
+		emailService.On("SendDocumentExpiryReminder", user.Email, "license", mock.Anything).Return(nil)
+
+		sent, err := svc.RemindExpiringDocuments(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, sent)
+		driverRepo.AssertExpectations(t)
+		driverRepo.AssertNumberOfCalls(t, "UpdateDocumentReminder", 2)
+	})
+
+	t.Run("renewed documents are not reminded of", func(t *testing.T) {
+		document := expiring("old", 7, 30)
+		renewal := expiring("new", 400, 0)
+		svc, driverRepo, emailService := newTestVerificationService([]models.Document{document}, []models.Document{document, renewal})
+
+		sent, err := svc.RemindExpiringDocuments(ctx)
+
+		assert.NoError(t, err)
+		assert.Zero(t, sent)
+		driverRepo.AssertNotCalled(t, "UpdateDocumentReminder", mock.Anything, mock.Anything, mock.Anything)
+		emailService.AssertNotCalled(t, "SendDocumentExpiryReminder", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestSuspendLapsedDrivers(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	today := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	user := &models.User{ID: "user-1", Email: "driver@example.com", UserType: models.UserTypeDriver}
+	lapsedOn := today.AddDate(0, 0, -1)
+	validUntil := today.AddDate(1, 0, 0)
+
+	driver := &models.Driver{
+		ID: "driver-1", UserID: user.ID, VerificationStatus: models.VerificationStatusApproved, IsAvailable: true,
+		Documents: []models.Document{
+			{ID: "license", Type: models.DocumentTypeLicense, ExpiresOn: &lapsedOn},
+			{ID: "insurance", Type: models.DocumentTypeInsurance, ExpiresOn: &validUntil},
+		},
+	}
+	driverRepo := new(MockDriverRepository)
+	userRepo := new(MockUserRepository)
+	emailService := new(MockEmailService)
+	index := NewDriverIndex()
+	index.Upsert(driver.ID, 0, 0, *driver)
+	driverRepo.On("FindApprovedWithLapsedDocuments", ctx, today).Return([]models.Driver{*driver}, nil)
+	driverRepo.On("Suspend", ctx, mock.AnythingOfType("*models.Driver"), models.VerificationStatusApproved, mock.MatchedBy(func(entry *models.AuditEntry) bool {
+		return entry.Action == models.AuditActionDriverSuspended && entry.SubjectID == driver.ID &&
+			entry.ActorID == nil && entry.Detail == "license expired on 2024-03-03"
+	})).Return(nil)
+	userRepo.On("FindByID", ctx, user.ID).Return(user, nil)
+	emailService.On("SendDriverVerificationDecision", user.Email, "resubmission_required", "license expired on 2024-03-03").Return(nil)
+	svc := NewVerificationService(driverRepo, userRepo, new(MockDocumentFileService), emailService, index, clock.NewFake(now), config.DocumentConfig{})
+
+	suspended, err := svc.SuspendLapsedDrivers(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, suspended)
+	assert.Empty(t, index.Nearby(0, 0, 1, 0))
+	driverRepo.AssertExpectations(t)
+	emailService.AssertExpectations(t)
+}
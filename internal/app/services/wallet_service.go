@@ -0,0 +1,314 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/provider/payment"
+)
+
+const (
+	defaultWalletPageLimit = 20
+	maxWalletPageLimit     = 100
+)
+
+type walletService struct {
+	ledgerRepo repositories.LedgerRepository
+	gateway    payment.Gateway
+	currency   string
+}
+
+func NewWalletService(
+	ledgerRepo repositories.LedgerRepository,
+	gateway payment.Gateway,
+	currency string,
+) services.WalletService {
+	return &walletService{
+		ledgerRepo: ledgerRepo,
+		gateway:    gateway,
+		currency:   currency,
+	}
+}
+
+func (s *walletService) GetWallet(ctx context.Context, userID string) (*services.Wallet, error) {
+	wallet := &services.Wallet{UserID: userID, Currency: s.currency}
+
+	account, err := s.ledgerRepo.FindAccountByCode(ctx, models.WalletAccountCode(userID))
+	if err == errors.ErrLedgerAccountNotFound {
+		// Wallets are opened by the first top-up
+		return wallet, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sum, err := s.ledgerRepo.SumPostings(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	wallet.Balance = account.BalanceOf(sum)
+	return wallet, nil
+}
+
+func (s *walletService) TopUp(ctx context.Context, userID string, input services.TopUpInput) (*services.WalletTransaction, error) {
+	key := fmt.Sprintf("top-up:%s:%s", userID, input.IdempotencyKey)
+	if entry, err := s.ledgerRepo.FindEntryByKey(ctx, key); err == nil {
+		return s.walletTransaction(ctx, userID, entry)
+	} else if err != errors.ErrJournalEntryNotFound {
+		return nil, err
+	}
+
+	amount := toMinorUnits(input.Amount)
+	auth, err := s.gateway.Authorize(ctx, payment.AuthorizeRequest{
+		Reference:  key,
+		CustomerID: userID,
+		Amount:     amount,
+		Currency:   s.currency,
+	})
+	if err == payment.ErrDeclined {
+		return nil, errors.ErrPaymentDeclined
+	}
+	if err != nil {
+		return nil, err
+	}
+	capture, err := s.captureTopUp(ctx, auth.ID, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	cash, err := s.platformAccount(ctx, models.LedgerAccountCash, models.LedgerAccountTypeAsset)
+	if err != nil {
+		return nil, err
+	}
+	wallet, err := s.walletAccount(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := models.NewJournalEntry(key, models.JournalEntryKindTopUp, "Wallet top-up")
+	entry.AddTransfer(cash, wallet, fromMinorUnits(capture.Amount))
+	return s.post(ctx, userID, nil, entry)
+}
+
+// captureTopUp captures a top-up authorization. An earlier attempt with
+// the same key may have captured it and failed before crediting the
+// wallet, in which case its capture is credited instead of charging again.
+func (s *walletService) captureTopUp(ctx context.Context, authorizationID string, amount int64) (*payment.Transaction, error) {
+	capture, err := s.gateway.Capture(ctx, authorizationID, amount)
+	if err == payment.ErrInvalidState {
+		return s.gateway.FindCapture(ctx, authorizationID)
+	}
+	return capture, err
+}
+
+func (s *walletService) ChargeRide(ctx context.Context, ride *models.Ride) (*services.WalletTransaction, error) {
+	key := "ride-charge:" + ride.ID
+	if entry, err := s.ledgerRepo.FindEntryByKey(ctx, key); err == nil {
+		return s.walletTransaction(ctx, ride.RiderID, entry)
+	} else if err != errors.ErrJournalEntryNotFound {
+		return nil, err
+	}
+
+	wallet, err := s.walletAccount(ctx, ride.RiderID)
+	if err != nil {
+		return nil, err
+	}
+	revenue, err := s.platformAccount(ctx, models.LedgerAccountRideRevenue, models.LedgerAccountTypeRevenue)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*models.JournalEntry
+	release, err := s.holdRelease(ctx, ride, wallet)
+	if err != nil {
+		return nil, err
+	}
+	if release != nil {
+		entries = append(entries, release)
+	}
+
+	entry := models.NewJournalEntry(key, models.JournalEntryKindRideCharge, "Ride fare")
+	entry.RideID = &ride.ID
+	entry.AddTransfer(wallet, revenue, ride.Fare)
+	// The fare can exceed the estimate held when the ride was accepted;
+	// the rest comes from the balance
+	return s.post(ctx, ride.RiderID, wallet, append(entries, entry)...)
+}
+
+func (s *walletService) HoldRideFare(ctx context.Context, ride *models.Ride, amount float64) (*services.WalletTransaction, error) {
+	key := "ride-hold:" + ride.ID
+	if entry, err := s.ledgerRepo.FindEntryByKey(ctx, key); err == nil {
+		return s.walletTransaction(ctx, ride.RiderID, entry)
+	} else if err != errors.ErrJournalEntryNotFound {
+		return nil, err
+	}
+
+	wallet, err := s.walletAccount(ctx, ride.RiderID)
+	if err != nil {
+		return nil, err
+	}
+	holds, err := s.platformAccount(ctx, models.LedgerAccountRideHolds, models.LedgerAccountTypeLiability)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := models.NewJournalEntry(key, models.JournalEntryKindRideHold, "Fare held")
+	entry.RideID = &ride.ID
+	entry.AddTransfer(wallet, holds, amount)
+	return s.post(ctx, ride.RiderID, wallet, entry)
+}
+
+func (s *walletService) ReleaseRideHold(ctx context.Context, ride *models.Ride) error {
+	wallet, err := s.walletAccount(ctx, ride.RiderID)
+	if err != nil {
+		return err
+	}
+	release, err := s.holdRelease(ctx, ride, wallet)
+	if err != nil || release == nil {
+		return err
+	}
+	if err := s.ledgerRepo.Post(ctx, release); err != nil && err != errors.ErrJournalEntryExists {
+		return err
+	}
+	return nil
+}
+
+// holdRelease builds the entry returning the amount held for ride to
+// wallet, or returns nil if nothing is held or it was released already
+func (s *walletService) holdRelease(ctx context.Context, ride *models.Ride, wallet *models.LedgerAccount) (*models.JournalEntry, error) {
+	hold, err := s.ledgerRepo.FindEntryByKey(ctx, "ride-hold:"+ride.ID)
+	if err == errors.ErrJournalEntryNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	key := "ride-hold-release:" + ride.ID
+	if _, err := s.ledgerRepo.FindEntryByKey(ctx, key); err == nil {
+		return nil, nil
+	} else if err != errors.ErrJournalEntryNotFound {
+		return nil, err
+	}
+
+	holds, err := s.platformAccount(ctx, models.LedgerAccountRideHolds, models.LedgerAccountTypeLiability)
+	if err != nil {
+		return nil, err
+	}
+	var held float64
+	for _, posting := range hold.Postings {
+		if posting.AccountID == wallet.ID {
+			held += posting.Amount
+		}
+	}
+
+	entry := models.NewJournalEntry(key, models.JournalEntryKindHoldRelease, "Held fare released")
+	entry.RideID = &ride.ID
+	entry.AddTransfer(holds, wallet, held)
+	return entry, nil
+}
+
+// post records entries, or returns the entry recorded first under the key
+// of the last one by a concurrent request. The wallet transaction is that
+// of the last entry. If debited is not nil the entries are recorded
+// together and must not take its balance below zero; otherwise a single
+// entry is expected.
+func (s *walletService) post(ctx context.Context, userID string, debited *models.LedgerAccount, entries ...*models.JournalEntry) (*services.WalletTransaction, error) {
+	entry := entries[len(entries)-1]
+	var err error
+	if debited != nil {
+		err = s.ledgerRepo.PostWithinBalance(ctx, debited, entries...)
+	} else {
+		err = s.ledgerRepo.Post(ctx, entry)
+	}
+	if err == errors.ErrJournalEntryExists {
+		existing, err := s.ledgerRepo.FindEntryByKey(ctx, entry.IdempotencyKey)
+		if err != nil {
+			return nil, err
+		}
+		entry = existing
+	} else if err != nil {
+		return nil, err
+	}
+	return s.walletTransaction(ctx, userID, entry)
+}
+
+func (s *walletService) ListTransactions(ctx context.Context, userID string, input services.ListWalletTransactionsInput) (*services.WalletTransactionList, error) {
+	page := input.Page
+	if page < 1 {
+		page = 1
+	}
+	limit := input.Limit
+	if limit < 1 {
+		limit = defaultWalletPageLimit
+	}
+	if limit > maxWalletPageLimit {
+		limit = maxWalletPageLimit
+	}
+
+	list := &services.WalletTransactionList{
+		Transactions: []services.WalletTransaction{},
+		Page:         page,
+		Limit:        limit,
+	}
+
+	account, err := s.ledgerRepo.FindAccountByCode(ctx, models.WalletAccountCode(userID))
+	if err == errors.ErrLedgerAccountNotFound {
+		return list, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	postings, total, err := s.ledgerRepo.ListPostings(ctx, account.ID, page, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, posting := range postings {
+		list.Transactions = append(list.Transactions, newWalletTransaction(posting.Entry, account, posting.Amount))
+	}
+	list.Total = total
+	list.HasMore = int64(page*limit) < total
+	return list, nil
+}
+
+// walletTransaction presents entry from the point of view of userID's
+// wallet
+func (s *walletService) walletTransaction(ctx context.Context, userID string, entry *models.JournalEntry) (*services.WalletTransaction, error) {
+	account, err := s.ledgerRepo.FindAccountByCode(ctx, models.WalletAccountCode(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	var amount float64
+	for _, posting := range entry.Postings {
+		if posting.AccountID == account.ID {
+			amount += posting.Amount
+		}
+	}
+	transaction := newWalletTransaction(entry, account, amount)
+	return &transaction, nil
+}
+
+func newWalletTransaction(entry *models.JournalEntry, wallet *models.LedgerAccount, postingAmount float64) services.WalletTransaction {
+	return services.WalletTransaction{
+		ID:          entry.ID,
+		Kind:        entry.Kind,
+		Description: entry.Description,
+		RideID:      entry.RideID,
+		Amount:      wallet.BalanceOf(postingAmount),
+		CreatedAt:   entry.CreatedAt,
+	}
+}
+
+func (s *walletService) walletAccount(ctx context.Context, userID string) (*models.LedgerAccount, error) {
+	return s.ledgerRepo.FindOrCreateAccount(ctx, models.NewLedgerAccount(
+		models.WalletAccountCode(userID), models.LedgerAccountTypeLiability, &userID, s.currency))
+}
+
+func (s *walletService) platformAccount(ctx context.Context, code string, accountType models.LedgerAccountType) (*models.LedgerAccount, error) {
+	return s.ledgerRepo.FindOrCreateAccount(ctx, models.NewLedgerAccount(code, accountType, nil, s.currency))
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/services"
+	"github.com/sayeed1999/share-a-ride/internal/provider/payment"
+)
+
+func newTestWalletService() (*walletService, *MockLedgerRepository, *payment.Fake) {
+	ledgerRepo := new(MockLedgerRepository)
+	gateway := payment.NewFake()
+	svc := NewWalletService(ledgerRepo, gateway, "BDT").(*walletService)
+	return svc, ledgerRepo, gateway
+}
+
+// expectAccounts makes FindOrCreateAccount and FindAccountByCode return the
+// given accounts by code
+func expectAccounts(ledgerRepo *MockLedgerRepository, accounts ...*models.LedgerAccount) {
+	ctx := context.Background()
+	for _, account := range accounts {
+		account := account
+		ledgerRepo.On("FindOrCreateAccount", ctx, mock.MatchedBy(func(a *models.LedgerAccount) bool {
+			return a.Code == account.Code
+		})).Return(account, nil)
+		ledgerRepo.On("FindAccountByCode", ctx, account.Code).Return(account, nil)
+	}
+}
+
+func TestTopUp(t *testing.T) {
+	ctx := context.Background()
+	userID := "rider-1"
+	cash := models.NewLedgerAccount(models.LedgerAccountCash, models.LedgerAccountTypeAsset, nil, "BDT")
+	wallet := models.NewLedgerAccount(models.WalletAccountCode(userID), models.LedgerAccountTypeLiability, &userID, "BDT")
+	input := services.TopUpInput{Amount: 500, IdempotencyKey: "key-1"}
+
+	t.Run("moves the captured amount into the wallet", func(t *testing.T) {
+		svc, ledgerRepo, _ := newTestWalletService()
+		expectAccounts(ledgerRepo, cash, wallet)
+		ledgerRepo.On("FindEntryByKey", ctx, "top-up:rider-1:key-1").Return(nil, errors.ErrJournalEntryNotFound)
+		var posted *models.JournalEntry
+		ledgerRepo.On("Post", ctx, mock.AnythingOfType("*models.JournalEntry")).
+			Run(func(args mock.Arguments) { posted = args.Get(1).(*models.JournalEntry) }).
+			Return(nil)
+
+		transaction, err := svc.TopUp(ctx, userID, input)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.JournalEntryKindTopUp, transaction.Kind)
+		assert.Equal(t, 500.0, transaction.Amount)
+		if assert.Len(t, posted.Postings, 2) {
+			assert.Equal(t, cash.ID, posted.Postings[0].AccountID)
+			assert.Equal(t, 500.0, posted.Postings[0].Amount)
+			assert.Equal(t, wallet.ID, posted.Postings[1].AccountID)
+			assert.Equal(t, -500.0, posted.Postings[1].Amount)
+		}
+	})
+
+	t.Run("a repeated key returns the first top-up", func(t *testing.T) {
+		svc, ledgerRepo, gateway := newTestWalletService()
+		expectAccounts(ledgerRepo, wallet)
+		entry := models.NewJournalEntry("top-up:rider-1:key-1", models.JournalEntryKindTopUp, "Wallet top-up")
+		entry.AddTransfer(cash, wallet, 500)
+		ledgerRepo.On("FindEntryByKey", ctx, entry.IdempotencyKey).Return(entry, nil)
+		gateway.DeclineCustomer(userID)
+
+		transaction, err := svc.TopUp(ctx, userID, input)
+
+		assert.NoError(t, err)
+		assert.Equal(t, entry.ID, transaction.ID)
+		assert.Equal(t, 500.0, transaction.Amount)
+		ledgerRepo.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+
+	t.Run("a concurrent duplicate returns the recorded top-up", func(t *testing.T) {
+		svc, ledgerRepo, _ := newTestWalletService()
+		expectAccounts(ledgerRepo, cash, wallet)
+		entry := models.NewJournalEntry("top-up:rider-1:key-1", models.JournalEntryKindTopUp, "Wallet top-up")
+		entry.AddTransfer(cash, wallet, 500)
+		ledgerRepo.On("FindEntryByKey", ctx, entry.IdempotencyKey).Return(nil, errors.ErrJournalEntryNotFound).Once()
+		ledgerRepo.On("Post", ctx, mock.AnythingOfType("*models.JournalEntry")).Return(errors.ErrJournalEntryExists)
+		ledgerRepo.On("FindEntryByKey", ctx, entry.IdempotencyKey).Return(entry, nil)
+
+		transaction, err := svc.TopUp(ctx, userID, input)
+
+		assert.NoError(t, err)
+		assert.Equal(t, entry.ID, transaction.ID)
+	})
+
+	t.Run("a retry after a failed post credits the first capture", func(t *testing.T) {
+		svc, ledgerRepo, gateway := newTestWalletService()
+		expectAccounts(ledgerRepo, cash, wallet)
+		ledgerRepo.On("FindEntryByKey", ctx, "top-up:rider-1:key-1").Return(nil, errors.ErrJournalEntryNotFound)
+		ledgerRepo.On("Post", ctx, mock.AnythingOfType("*models.JournalEntry")).Return(assert.AnError).Once()
+		ledgerRepo.On("Post", ctx, mock.AnythingOfType("*models.JournalEntry")).Return(nil)
+
+		_, err := svc.TopUp(ctx, userID, input)
+		assert.Equal(t, assert.AnError, err)
+
+		transaction, err := svc.TopUp(ctx, userID, input)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 500.0, transaction.Amount)
+		auth, _ := gateway.Authorize(ctx, payment.AuthorizeRequest{Reference: "top-up:rider-1:key-1"})
+		capture, err := gateway.FindCapture(ctx, auth.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(50000), capture.Amount)
+	})
+
+	t.Run("declined", func(t *testing.T) {
+		svc, ledgerRepo, gateway := newTestWalletService()
+		gateway.DeclineCustomer(userID)
+		ledgerRepo.On("FindEntryByKey", ctx, "top-up:rider-1:key-1").Return(nil, errors.ErrJournalEntryNotFound)
+
+		_, err := svc.TopUp(ctx, userID, input)
+
+		assert.Equal(t, errors.ErrPaymentDeclined, err)
+		ledgerRepo.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+}
+
+func TestChargeRide(t *testing.T) {
+	ctx := context.Background()
+	ride := models.NewRide("rider-1", models.Location{}, models.Location{}, time.Now())
+	ride.Fare = 180.4
+	wallet := models.NewLedgerAccount(models.WalletAccountCode(ride.RiderID), models.LedgerAccountTypeLiability, &ride.RiderID, "BDT")
+	revenue := models.NewLedgerAccount(models.LedgerAccountRideRevenue, models.LedgerAccountTypeRevenue, nil, "BDT")
+	holds := models.NewLedgerAccount(models.LedgerAccountRideHolds, models.LedgerAccountTypeLiability, nil, "BDT")
+	newTestService := func() (*walletService, *MockLedgerRepository) {
+		svc, ledgerRepo, _ := newTestWalletService()
+		expectAccounts(ledgerRepo, wallet, revenue, holds)
+		ledgerRepo.On("FindEntryByKey", ctx, "ride-charge:"+ride.ID).Return(nil, errors.ErrJournalEntryNotFound)
+		return svc, ledgerRepo
+	}
+
+	t.Run("moves the fare out of the wallet", func(t *testing.T) {
+		svc, ledgerRepo := newTestService()
+		ledgerRepo.On("FindEntryByKey", ctx, "ride-hold:"+ride.ID).Return(nil, errors.ErrJournalEntryNotFound)
+		ledgerRepo.On("PostWithinBalance", ctx, wallet, mock.MatchedBy(func(entries []*models.JournalEntry) bool {
+			e := entries[0]
+			return len(entries) == 1 && e.IsBalanced() && e.Postings[0].AccountID == wallet.ID && e.Postings[1].AccountID == revenue.ID
+		})).Return(nil)
+
+		transaction, err := svc.ChargeRide(ctx, ride)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.JournalEntryKindRideCharge, transaction.Kind)
+		assert.Equal(t, -180.4, transaction.Amount)
+		assert.Equal(t, &ride.ID, transaction.RideID)
+	})
+
+	t.Run("releases the hold with the charge", func(t *testing.T) {
+		svc, ledgerRepo := newTestService()
+		hold := models.NewJournalEntry("ride-hold:"+ride.ID, models.JournalEntryKindRideHold, "Fare held")
+		hold.AddTransfer(wallet, holds, 150)
+		ledgerRepo.On("FindEntryByKey", ctx, hold.IdempotencyKey).Return(hold, nil)
+		ledgerRepo.On("FindEntryByKey", ctx, "ride-hold-release:"+ride.ID).Return(nil, errors.ErrJournalEntryNotFound)
+		var posted []*models.JournalEntry
+		ledgerRepo.On("PostWithinBalance", ctx, wallet, mock.Anything).
+			Run(func(args mock.Arguments) { posted = args.Get(2).([]*models.JournalEntry) }).
+			Return(nil)
+
+		transaction, err := svc.ChargeRide(ctx, ride)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.JournalEntryKindRideCharge, transaction.Kind)
+		if assert.Len(t, posted, 2) {
+			release := posted[0]
+			assert.Equal(t, models.JournalEntryKindHoldRelease, release.Kind)
+			assert.Equal(t, holds.ID, release.Postings[0].AccountID)
+			assert.Equal(t, 150.0, release.Postings[0].Amount)
+			assert.Equal(t, wallet.ID, release.Postings[1].AccountID)
+		}
+	})
+
+	t.Run("never overdraws the wallet", func(t *testing.T) {
+		svc, ledgerRepo := newTestService()
+		ledgerRepo.On("FindEntryByKey", ctx, "ride-hold:"+ride.ID).Return(nil, errors.ErrJournalEntryNotFound)
+		ledgerRepo.On("PostWithinBalance", ctx, wallet, mock.Anything).Return(errors.ErrInsufficientBalance)
+
+		_, err := svc.ChargeRide(ctx, ride)
+
+		assert.Equal(t, errors.ErrInsufficientBalance, err)
+		ledgerRepo.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+}
+
+func TestHoldRideFare(t *testing.T) {
+	ctx := context.Background()
+	ride := models.NewRide("rider-1", models.Location{}, models.Location{}, time.Now())
+	wallet := models.NewLedgerAccount(models.WalletAccountCode(ride.RiderID), models.LedgerAccountTypeLiability, &ride.RiderID, "BDT")
+	holds := models.NewLedgerAccount(models.LedgerAccountRideHolds, models.LedgerAccountTypeLiability, nil, "BDT")
+
+	t.Run("sets the amount aside from the wallet", func(t *testing.T) {
+		svc, ledgerRepo, _ := newTestWalletService()
+		expectAccounts(ledgerRepo, wallet, holds)
+		ledgerRepo.On("FindEntryByKey", ctx, "ride-hold:"+ride.ID).Return(nil, errors.ErrJournalEntryNotFound)
+		ledgerRepo.On("PostWithinBalance", ctx, wallet, mock.MatchedBy(func(entries []*models.JournalEntry) bool {
+			e := entries[0]
+			return len(entries) == 1 && e.Postings[0].AccountID == wallet.ID && e.Postings[1].AccountID == holds.ID
+		})).Return(nil)
+
+		transaction, err := svc.HoldRideFare(ctx, ride, 200)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.JournalEntryKindRideHold, transaction.Kind)
+		assert.Equal(t, -200.0, transaction.Amount)
+	})
+
+	t.Run("balance below the amount", func(t *testing.T) {
+		svc, ledgerRepo, _ := newTestWalletService()
+		expectAccounts(ledgerRepo, wallet, holds)
+		ledgerRepo.On("FindEntryByKey", ctx, "ride-hold:"+ride.ID).Return(nil, errors.ErrJournalEntryNotFound)
+		ledgerRepo.On("PostWithinBalance", ctx, wallet, mock.Anything).Return(errors.ErrInsufficientBalance)
+
+		_, err := svc.HoldRideFare(ctx, ride, 200)
+
+		assert.Equal(t, errors.ErrInsufficientBalance, err)
+	})
+}
+
+func TestReleaseRideHold(t *testing.T) {
+	ctx := context.Background()
+	ride := models.NewRide("rider-1", models.Location{}, models.Location{}, time.Now())
+	wallet := models.NewLedgerAccount(models.WalletAccountCode(ride.RiderID), models.LedgerAccountTypeLiability, &ride.RiderID, "BDT")
+	holds := models.NewLedgerAccount(models.LedgerAccountRideHolds, models.LedgerAccountTypeLiability, nil, "BDT")
+	hold := models.NewJournalEntry("ride-hold:"+ride.ID, models.JournalEntryKindRideHold, "Fare held")
+	hold.AddTransfer(wallet, holds, 200)
+
+	t.Run("returns the held amount to the wallet", func(t *testing.T) {
+		svc, ledgerRepo, _ := newTestWalletService()
+		expectAccounts(ledgerRepo, wallet, holds)
+		ledgerRepo.On("FindEntryByKey", ctx, hold.IdempotencyKey).Return(hold, nil)
+		ledgerRepo.On("FindEntryByKey", ctx, "ride-hold-release:"+ride.ID).Return(nil, errors.ErrJournalEntryNotFound)
+		var posted *models.JournalEntry
+		ledgerRepo.On("Post", ctx, mock.AnythingOfType("*models.JournalEntry")).
+			Run(func(args mock.Arguments) { posted = args.Get(1).(*models.JournalEntry) }).
+			Return(nil)
+
+		err := svc.ReleaseRideHold(ctx, ride)
+
+		assert.NoError(t, err)
+		if assert.Len(t, posted.Postings, 2) {
+			assert.Equal(t, wallet.ID, posted.Postings[1].AccountID)
+			assert.Equal(t, -200.0, posted.Postings[1].Amount)
+		}
+	})
+
+	t.Run("nothing held", func(t *testing.T) {
+		svc, ledgerRepo, _ := newTestWalletService()
+		expectAccounts(ledgerRepo, wallet)
+		ledgerRepo.On("FindEntryByKey", ctx, hold.IdempotencyKey).Return(nil, errors.ErrJournalEntryNotFound)
+
+		err := svc.ReleaseRideHold(ctx, ride)
+
+		assert.NoError(t, err)
+		ledgerRepo.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+
+	t.Run("released already", func(t *testing.T) {
+		svc, ledgerRepo, _ := newTestWalletService()
+		expectAccounts(ledgerRepo, wallet)
+		ledgerRepo.On("FindEntryByKey", ctx, hold.IdempotencyKey).Return(hold, nil)
+		ledgerRepo.On("FindEntryByKey", ctx, "ride-hold-release:"+ride.ID).Return(&models.JournalEntry{}, nil)
+
+		err := svc.ReleaseRideHold(ctx, ride)
+
+		assert.NoError(t, err)
+		ledgerRepo.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetWallet(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("without an account", func(t *testing.T) {
+		svc, ledgerRepo, _ := newTestWalletService()
+		ledgerRepo.On("FindAccountByCode", ctx, "wallet:rider-1").Return(nil, errors.ErrLedgerAccountNotFound)
+
+		wallet, err := svc.GetWallet(ctx, "rider-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0.0, wallet.Balance)
+		assert.Equal(t, "BDT", wallet.Currency)
+	})
+
+	t.Run("balance derived from postings", func(t *testing.T) {
+		svc, ledgerRepo, _ := newTestWalletService()
+		account := models.NewLedgerAccount("wallet:rider-1", models.LedgerAccountTypeLiability, nil, "BDT")
+		ledgerRepo.On("FindAccountByCode", ctx, "wallet:rider-1").Return(account, nil)
+		ledgerRepo.On("SumPostings", ctx, account.ID).Return(-319.6, nil)
+
+		wallet, err := svc.GetWallet(ctx, "rider-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 319.6, wallet.Balance)
+	})
+}
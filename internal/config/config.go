@@ -4,16 +4,32 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/sayeed1999/share-a-ride/internal/pkg/pricing"
 )
 
 // Config holds all configuration of the application
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	App      AppConfig
-	Email    EmailConfig
+	Server     ServerConfig
+	Database   DatabaseConfig
+	JWT        JWTConfig
+	App        AppConfig
+	Email      EmailConfig
+	Matching   MatchingConfig
+	Realtime   RealtimeConfig
+	Location   LocationConfig
+	Pricing    PricingConfig
+	Surge      SurgeConfig
+	Payment    PaymentConfig
+	Earnings   EarningsConfig
+	Rating     RatingConfig
+	Scheduling SchedulingConfig
+	Pool       PoolConfig
+	Waypoint   WaypointConfig
+	Storage    StorageConfig
+	Document   DocumentConfig
 }
 
 type ServerConfig struct {
@@ -53,6 +69,165 @@ type EmailConfig struct {
 	From     string
 }
 
+type MatchingConfig struct {
+	// SearchRadiiKm are tried in order until a driver accepts
+	SearchRadiiKm []float64
+	// MaxCandidates caps how many drivers are considered per radius
+	MaxCandidates       int
+	OfferTimeout        time.Duration
+	MaxDispatchDuration time.Duration
+	// AcceptanceWindow is how far back offers count towards a driver's acceptance rate
+	AcceptanceWindow time.Duration
+	// Ranking penalties, expressed as extra kilometres of distance
+	VehicleMismatchPenaltyKm float64
+	LowAcceptancePenaltyKm   float64
+	// MinDriverRating skips drivers rated lower on average once they have
+	// MinRatingsToFilter ratings; zero disables the filter
+	MinDriverRating    float64
+	MinRatingsToFilter int
+	// AvoidPairsRatedBelow keeps a rider and driver apart once either gave
+	// the other fewer stars than this; zero disables the filter
+	AvoidPairsRatedBelow int
+}
+
+type RealtimeConfig struct {
+	// SubscriberBuffer is how many updates a slow subscriber may lag behind
+	// before the oldest ones are dropped
+	SubscriberBuffer int
+	// PingInterval must be shorter than PongTimeout
+	PingInterval    time.Duration
+	PongTimeout     time.Duration
+	WriteTimeout    time.Duration
+	MaxMessageBytes int64
+}
+
+type LocationConfig struct {
+	// MaxSpeedKmh rejects fixes that imply faster travel since the previous one
+	MaxSpeedKmh float64
+	// MinUpdateInterval is the shortest accepted gap between two fixes of a driver
+	MinUpdateInterval time.Duration
+	// StaleAfter takes drivers offline whose last fix is older than this
+	StaleAfter         time.Duration
+	StaleCheckInterval time.Duration
+	// HistoryRetention is how long location history is kept
+	HistoryRetention     time.Duration
+	HistoryPruneInterval time.Duration
+}
+
+type PricingConfig struct {
+	Currency string
+	// RoadDistanceFactor scales straight-line distance to an expected road distance
+	RoadDistanceFactor float64
+	// Tariffs are keyed by vehicle type
+	Tariffs map[string]TariffConfig
+}
+
+type TariffConfig struct {
+	pricing.Tariff
+	// AverageSpeedKmh is used to estimate trip duration
+	AverageSpeedKmh float64
+}
+
+type SurgeConfig struct {
+	// CellSizeDeg is the side of a surge grid cell in degrees
+	CellSizeDeg       float64
+	RecomputeInterval time.Duration
+	// Sensitivity is how much the multiplier rises per waiting rider in
+	// excess of each available driver
+	Sensitivity   float64
+	MaxMultiplier float64
+	// Step rounds multipliers down, e.g. to 1.3x rather than 1.37x
+	Step float64
+}
+
+type PaymentConfig struct {
+	// Gateway selects the payment provider; only "fake" is available
+	Gateway string
+	// AuthorizationBuffer is the share added to the estimated fare when
+	// funds are held, e.g. 0.3 holds 130% of the estimate
+	AuthorizationBuffer float64
+	// ChargeRetryInterval is how often fares that could not be collected
+	// are charged again. Rides that ended more recently are left alone.
+	ChargeRetryInterval time.Duration
+}
+
+type EarningsConfig struct {
+	// CommissionRate is the platform's share of each fare, e.g. 0.2 keeps
+	// 20% and pays out 80% to the driver
+	CommissionRate float64
+}
+
+type RatingConfig struct {
+	// AverageWindow is how many of the most recent ratings a user's
+	// average covers
+	AverageWindow int
+}
+
+type PoolConfig struct {
+	// MaxDetourFactor caps each pooled rider's distance relative to the
+	// direct route to their dropoff
+	MaxDetourFactor float64
+	// MaxPickupKm caps the distance a driver covers before each pickup
+	MaxPickupKm float64
+	// MaxSeatsPerRide is the largest party a pooled ride may book for
+	MaxSeatsPerRide int
+}
+
+type WaypointConfig struct {
+	// MaxPerRide is how many stops a ride may have between pickup and
+	// dropoff
+	MaxPerRide int
+}
+
+type StorageConfig struct {
+	// Backend selects where uploaded files are kept: "local" or "s3"
+	Backend string
+	// LocalDir is the directory of the local backend
+	LocalDir string
+	// SigningKey signs the download URLs of the local backend
+	SigningKey string
+	// S3 settings apply to any S3 compatible service; PathStyle is needed
+	// by most self-hosted ones
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	S3PathStyle       bool
+}
+
+type DocumentConfig struct {
+	// MaxUploadBytes caps the size of an uploaded document file
+	MaxUploadBytes int64
+	// URLExpiry is how long a signed download URL stays valid
+	URLExpiry time.Duration
+	// ExpiryReminderDays are the days before a document expires on which
+	// its driver is reminded
+	ExpiryReminderDays []int
+	// ExpiryCheckInterval is how often expiry reminders are sent and
+	// drivers with lapsed documents suspended
+	ExpiryCheckInterval time.Duration
+	// UploadSweepInterval is how often files no document refers to are
+	// deleted
+	UploadSweepInterval time.Duration
+}
+
+type SchedulingConfig struct {
+	// MinLeadTime and MaxAdvance bound how far ahead a ride can be booked
+	MinLeadTime time.Duration
+	MaxAdvance  time.Duration
+	// DispatchLeadTime is how long before pickup the search for a driver
+	// starts
+	DispatchLeadTime time.Duration
+	// ReminderLeadTime is how long before pickup the rider is reminded
+	ReminderLeadTime time.Duration
+	PollInterval     time.Duration
+	// Riders cancelling within FreeCancellationWindow of pickup pay
+	// LateCancellationFee
+	FreeCancellationWindow time.Duration
+	LateCancellationFee    float64
+}
+
 var cfg *Config
 
 // Load returns a Config struct populated with values from environment variables
@@ -112,6 +287,126 @@ func Load() (*Config, error) {
 		From:     getEnv("EMAIL_FROM", "noreply@example.com"),
 	}
 
+	// Matching configuration
+	cfg.Matching = MatchingConfig{
+		SearchRadiiKm:            getFloatSliceEnv("MATCHING_SEARCH_RADII_KM", []float64{2, 5, 10}),
+		MaxCandidates:            getIntEnv("MATCHING_MAX_CANDIDATES", 20),
+		OfferTimeout:             getDurationEnv("MATCHING_OFFER_TIMEOUT", 15*time.Second),
+		MaxDispatchDuration:      getDurationEnv("MATCHING_MAX_DISPATCH_DURATION", 5*time.Minute),
+		AcceptanceWindow:         getDurationEnv("MATCHING_ACCEPTANCE_WINDOW", 7*24*time.Hour),
+		VehicleMismatchPenaltyKm: getFloatEnv("MATCHING_VEHICLE_MISMATCH_PENALTY_KM", 5),
+		LowAcceptancePenaltyKm:   getFloatEnv("MATCHING_LOW_ACCEPTANCE_PENALTY_KM", 2),
+		MinDriverRating:          getFloatEnv("MATCHING_MIN_DRIVER_RATING", 0),
+		MinRatingsToFilter:       getIntEnv("MATCHING_MIN_RATINGS_TO_FILTER", 5),
+		AvoidPairsRatedBelow:     getIntEnv("MATCHING_AVOID_PAIRS_RATED_BELOW", 3),
+	}
+
+	// Realtime configuration
+	cfg.Realtime = RealtimeConfig{
+		SubscriberBuffer: getIntEnv("REALTIME_SUBSCRIBER_BUFFER", 16),
+		PingInterval:     getDurationEnv("REALTIME_PING_INTERVAL", 25*time.Second),
+		PongTimeout:      getDurationEnv("REALTIME_PONG_TIMEOUT", 60*time.Second),
+		WriteTimeout:     getDurationEnv("REALTIME_WRITE_TIMEOUT", 10*time.Second),
+		MaxMessageBytes:  int64(getIntEnv("REALTIME_MAX_MESSAGE_BYTES", 1024)),
+	}
+
+	// Location configuration
+	cfg.Location = LocationConfig{
+		MaxSpeedKmh:          getFloatEnv("LOCATION_MAX_SPEED_KMH", 200),
+		MinUpdateInterval:    getDurationEnv("LOCATION_MIN_UPDATE_INTERVAL", 2*time.Second),
+		StaleAfter:           getDurationEnv("LOCATION_STALE_AFTER", 10*time.Minute),
+		StaleCheckInterval:   getDurationEnv("LOCATION_STALE_CHECK_INTERVAL", time.Minute),
+		HistoryRetention:     getDurationEnv("LOCATION_HISTORY_RETENTION", 30*24*time.Hour),
+		HistoryPruneInterval: getDurationEnv("LOCATION_HISTORY_PRUNE_INTERVAL", time.Hour),
+	}
+
+	// Pricing configuration
+	cfg.Pricing = PricingConfig{
+		Currency:           getEnv("PRICING_CURRENCY", "BDT"),
+		RoadDistanceFactor: getFloatEnv("PRICING_ROAD_DISTANCE_FACTOR", 1.3),
+		Tariffs: map[string]TariffConfig{
+			"car": getTariffEnv("PRICING_CAR", TariffConfig{
+				Tariff:          pricing.Tariff{BaseFare: 50, PerKm: 20, PerMinute: 2, MinimumFare: 100, BookingFee: 10},
+				AverageSpeedKmh: 20,
+			}),
+			"bike": getTariffEnv("PRICING_BIKE", TariffConfig{
+				Tariff:          pricing.Tariff{BaseFare: 20, PerKm: 12, PerMinute: 1, MinimumFare: 50, BookingFee: 5},
+				AverageSpeedKmh: 25,
+			}),
+		},
+	}
+
+	// Surge configuration
+	cfg.Surge = SurgeConfig{
+		CellSizeDeg:       getFloatEnv("SURGE_CELL_SIZE_DEG", 0.02),
+		RecomputeInterval: getDurationEnv("SURGE_RECOMPUTE_INTERVAL", time.Minute),
+		Sensitivity:       getFloatEnv("SURGE_SENSITIVITY", 0.5),
+		MaxMultiplier:     getFloatEnv("SURGE_MAX_MULTIPLIER", 2.5),
+		Step:              getFloatEnv("SURGE_STEP", 0.1),
+	}
+
+	// Payment configuration
+	cfg.Payment = PaymentConfig{
+		Gateway:             getEnv("PAYMENT_GATEWAY", "fake"),
+		AuthorizationBuffer: getFloatEnv("PAYMENT_AUTHORIZATION_BUFFER", 0.3),
+		ChargeRetryInterval: getDurationEnv("PAYMENT_CHARGE_RETRY_INTERVAL", 15*time.Minute),
+	}
+
+	// Earnings configuration
+	cfg.Earnings = EarningsConfig{
+		CommissionRate: getFloatEnv("EARNINGS_COMMISSION_RATE", 0.2),
+	}
+
+	// Rating configuration
+	cfg.Rating = RatingConfig{
+		AverageWindow: getIntEnv("RATING_AVERAGE_WINDOW", 100),
+	}
+
+	// Pool configuration
+	cfg.Pool = PoolConfig{
+		MaxDetourFactor: getFloatEnv("POOL_MAX_DETOUR_FACTOR", 1.5),
+		MaxPickupKm:     getFloatEnv("POOL_MAX_PICKUP_KM", 5),
+		MaxSeatsPerRide: getIntEnv("POOL_MAX_SEATS_PER_RIDE", 2),
+	}
+
+	// Waypoint configuration
+	cfg.Waypoint = WaypointConfig{
+		MaxPerRide: getIntEnv("WAYPOINT_MAX_PER_RIDE", 3),
+	}
+
+	// Storage configuration
+	cfg.Storage = StorageConfig{
+		Backend:           getEnv("STORAGE_BACKEND", "local"),
+		LocalDir:          getEnv("STORAGE_LOCAL_DIR", "./data/uploads"),
+		SigningKey:        getEnv("STORAGE_SIGNING_KEY", "your-storage-signing-key"),
+		S3Endpoint:        getEnv("STORAGE_S3_ENDPOINT", "https://s3.amazonaws.com"),
+		S3Region:          getEnv("STORAGE_S3_REGION", "us-east-1"),
+		S3Bucket:          getEnv("STORAGE_S3_BUCKET", ""),
+		S3AccessKeyID:     getEnv("STORAGE_S3_ACCESS_KEY_ID", ""),
+		S3SecretAccessKey: getEnv("STORAGE_S3_SECRET_ACCESS_KEY", ""),
+		S3PathStyle:       getBoolEnv("STORAGE_S3_PATH_STYLE", false),
+	}
+
+	// Document configuration
+	cfg.Document = DocumentConfig{
+		MaxUploadBytes:      int64(getIntEnv("DOCUMENT_MAX_UPLOAD_BYTES", 5<<20)),
+		URLExpiry:           getDurationEnv("DOCUMENT_URL_EXPIRY", 15*time.Minute),
+		ExpiryReminderDays:  getIntSliceEnv("DOCUMENT_EXPIRY_REMINDER_DAYS", []int{30, 7, 1}),
+		ExpiryCheckInterval: getDurationEnv("DOCUMENT_EXPIRY_CHECK_INTERVAL", 24*time.Hour),
+		UploadSweepInterval: getDurationEnv("DOCUMENT_UPLOAD_SWEEP_INTERVAL", time.Hour),
+	}
+
+	// Scheduling configuration
+	cfg.Scheduling = SchedulingConfig{
+		MinLeadTime:            getDurationEnv("SCHEDULING_MIN_LEAD_TIME", 30*time.Minute),
+		MaxAdvance:             getDurationEnv("SCHEDULING_MAX_ADVANCE", 7*24*time.Hour),
+		DispatchLeadTime:       getDurationEnv("SCHEDULING_DISPATCH_LEAD_TIME", 15*time.Minute),
+		ReminderLeadTime:       getDurationEnv("SCHEDULING_REMINDER_LEAD_TIME", time.Hour),
+		PollInterval:           getDurationEnv("SCHEDULING_POLL_INTERVAL", time.Minute),
+		FreeCancellationWindow: getDurationEnv("SCHEDULING_FREE_CANCELLATION_WINDOW", time.Hour),
+		LateCancellationFee:    getFloatEnv("SCHEDULING_LATE_CANCELLATION_FEE", 50),
+	}
+
 	return cfg, nil
 }
 
@@ -167,3 +462,63 @@ func getBoolEnv(key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+func getFloatEnv(key string, defaultValue float64) float64 {
+	if str, exists := os.LookupEnv(key); exists {
+		if value, err := strconv.ParseFloat(str, 64); err == nil {
+			return value
+		}
+	}
+	return defaultValue
+}
+
+// getFloatSliceEnv parses a comma separated list such as "2,5,10"
+func getFloatSliceEnv(key string, defaultValue []float64) []float64 {
+	str, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+
+	var values []float64
+	for _, part := range strings.Split(str, ",") {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return defaultValue
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// getIntSliceEnv parses a comma separated list such as "30,7,1"
+func getIntSliceEnv(key string, defaultValue []int) []int {
+	str, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+
+	var values []int
+	for _, part := range strings.Split(str, ",") {
+		value, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return defaultValue
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// getTariffEnv reads the rates of one vehicle type from variables named
+// after prefix, e.g. PRICING_CAR_BASE_FARE
+func getTariffEnv(prefix string, defaultValue TariffConfig) TariffConfig {
+	return TariffConfig{
+		Tariff: pricing.Tariff{
+			BaseFare:    getFloatEnv(prefix+"_BASE_FARE", defaultValue.BaseFare),
+			PerKm:       getFloatEnv(prefix+"_PER_KM", defaultValue.PerKm),
+			PerMinute:   getFloatEnv(prefix+"_PER_MINUTE", defaultValue.PerMinute),
+			MinimumFare: getFloatEnv(prefix+"_MINIMUM_FARE", defaultValue.MinimumFare),
+			BookingFee:  getFloatEnv(prefix+"_BOOKING_FEE", defaultValue.BookingFee),
+		},
+		AverageSpeedKmh: getFloatEnv(prefix+"_AVERAGE_SPEED_KMH", defaultValue.AverageSpeedKmh),
+	}
+}
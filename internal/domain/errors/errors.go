@@ -1,6 +1,9 @@
 package errors
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	// Authentication errors
@@ -20,10 +23,109 @@ var (
 	ErrMissingDocuments    = errors.New("missing required documents")
 	ErrDriverNotVerified   = errors.New("driver not verified")
 	ErrInvalidLocation     = errors.New("invalid location coordinates")
+	ErrLocationThrottled   = errors.New("location updates too frequent")
+	ErrImplausibleLocation = errors.New("location change exceeds maximum speed")
 	ErrDocumentNotFound    = errors.New("document not found")
 	ErrUnauthorizedAccess  = errors.New("unauthorized access")
+
+	// Driver verification errors
+	ErrInvalidVerificationTransition = errors.New("driver verification cannot move to this status")
+	ErrReviewReasonRequired          = errors.New("a reason is required for the decision")
+	ErrVerificationConflict          = errors.New("driver verification changed concurrently")
+
+	// Vehicle errors
+	ErrVehicleNotFound         = errors.New("vehicle not found")
+	ErrPlateNumberExists       = errors.New("plate number already registered")
+	ErrVehicleSwitchOnline     = errors.New("go offline before switching vehicles")
+	ErrVehicleDocumentsMissing = errors.New("vehicle needs a valid registration and insurance document")
+
+	// Ride errors
+	ErrRideNotFound          = errors.New("ride not found")
+	ErrInvalidRideTransition = errors.New("invalid ride status transition")
+	ErrActiveRideExists      = errors.New("an active ride already exists")
+	ErrNotRideParticipant    = errors.New("not a participant of this ride")
+	ErrRideStatusConflict    = errors.New("ride status changed concurrently")
+	ErrRideNotCompleted      = errors.New("ride is not completed")
+	ErrInvalidScheduledTime  = errors.New("scheduled time is outside the booking window")
+	ErrWaypointNotFound      = errors.New("stop not found")
+	ErrTooManyWaypoints      = errors.New("too many stops")
+	ErrWaypointsLocked       = errors.New("stops can no longer be changed")
+	ErrPooledRideWaypoints   = errors.New("pooled rides cannot have stops")
+
+	// Matching errors
+	ErrNoDriversAvailable = errors.New("no drivers available")
+	ErrOfferNotFound      = errors.New("ride offer not found")
+	ErrOfferNotPending    = errors.New("ride offer already resolved")
+	ErrOfferExpired       = errors.New("ride offer expired")
+
+	// Pricing errors
+	ErrTariffNotFound = errors.New("no tariff for vehicle type")
+
+	// Promotion errors
+	ErrPromoCodeNotFound      = errors.New("promo code not found")
+	ErrPromoCodeNotValid      = errors.New("promo code is not valid at this time")
+	ErrPromoCodeExhausted     = errors.New("promo code has no uses left")
+	ErrPromoCodeUserLimit     = errors.New("promo code already used the maximum number of times")
+	ErrPromoCodeNotApplicable = errors.New("promo code does not apply to this vehicle type")
+	ErrPromoCodeExists        = errors.New("promo code already exists")
+
+	// Payment errors
+	ErrPaymentNotFound      = errors.New("payment not found")
+	ErrPaymentDeclined      = errors.New("payment declined")
+	ErrPaymentNotRefundable = errors.New("payment cannot be refunded")
+	ErrRefundTooLarge       = errors.New("refund exceeds the captured amount")
+
+	// Wallet errors
+	ErrInsufficientBalance   = errors.New("insufficient wallet balance")
+	ErrLedgerAccountNotFound = errors.New("ledger account not found")
+	ErrJournalEntryNotFound  = errors.New("journal entry not found")
+	ErrJournalEntryExists    = errors.New("journal entry already recorded")
+	ErrUnbalancedEntry       = errors.New("journal entry is not balanced")
+
+	// Earnings errors
+	ErrPayoutStatementNotFound = errors.New("payout statement not found")
+	ErrPayoutPeriodOpen        = errors.New("payout period has not ended yet")
+
+	// Rating errors
+	ErrInvalidRating = errors.New("invalid rating")
+	ErrRatingExists  = errors.New("ride already rated")
+
+	// Pool errors
+	ErrPoolNotFound     = errors.New("pool not found")
+	ErrRideDoesNotFit   = errors.New("ride does not fit the driver's pool")
+	ErrPoolConflict     = errors.New("pool changed concurrently")
+	ErrTooManyPoolSeats = errors.New("too many seats for a pooled ride")
+
+	// Document upload errors
+	ErrDocumentTooLarge          = errors.New("document file is too large")
+	ErrUnsupportedDocumentFormat = errors.New("document must be a PDF, JPG or PNG file")
+	ErrUploadNotFound            = errors.New("uploaded file not found")
+	ErrDocumentExpiryRequired    = errors.New("document must state its expiry date")
+	ErrDocumentExpired           = errors.New("document has expired")
+	ErrInvalidDocumentDates      = errors.New("document expires before it was issued")
+	ErrRequiredDocument          = errors.New("driver must keep a license and insurance document")
 )
 
+// RideTransitionError reports an attempt to move a ride between two statuses
+// that the ride state machine does not connect. It matches
+// ErrInvalidRideTransition with errors.Is.
+type RideTransitionError struct {
+	From string
+	To   string
+}
+
+func NewRideTransitionError(from, to string) *RideTransitionError {
+	return &RideTransitionError{From: from, To: to}
+}
+
+func (e *RideTransitionError) Error() string {
+	return fmt.Sprintf("%s: %s -> %s", ErrInvalidRideTransition, e.From, e.To)
+}
+
+func (e *RideTransitionError) Is(target error) bool {
+	return target == ErrInvalidRideTransition
+}
+
 type ErrorResponse struct {
 	Success bool       `json:"success"`
 	Error   *ErrorData `json:"error"`
@@ -48,16 +150,72 @@ func NewErrorResponse(code, message string, details interface{}) *ErrorResponse
 
 // Error code mapping
 var ErrorCodes = map[error]string{
-	ErrInvalidCredentials:  "AUTH001",
-	ErrTokenExpired:        "AUTH002",
-	ErrInvalidToken:        "AUTH003",
-	ErrUserNotFound:        "AUTH004",
-	ErrEmailExists:         "AUTH005",
-	ErrPhoneExists:         "AUTH006",
-	ErrDriverNotFound:      "DRV001",
-	ErrInvalidVehicleType:  "DRV002",
-	ErrInvalidDocumentType: "DRV003",
-	ErrMissingDocuments:    "DRV004",
-	ErrDriverNotVerified:   "DRV005",
-	ErrInvalidLocation:     "DRV006",
+	ErrInvalidCredentials:            "AUTH001",
+	ErrTokenExpired:                  "AUTH002",
+	ErrInvalidToken:                  "AUTH003",
+	ErrUserNotFound:                  "AUTH004",
+	ErrEmailExists:                   "AUTH005",
+	ErrPhoneExists:                   "AUTH006",
+	ErrDriverNotFound:                "DRV001",
+	ErrInvalidVehicleType:            "DRV002",
+	ErrInvalidDocumentType:           "DRV003",
+	ErrMissingDocuments:              "DRV004",
+	ErrDriverNotVerified:             "DRV005",
+	ErrInvalidLocation:               "DRV006",
+	ErrLocationThrottled:             "DRV007",
+	ErrImplausibleLocation:           "DRV008",
+	ErrInvalidVerificationTransition: "DRV009",
+	ErrReviewReasonRequired:          "DRV010",
+	ErrVerificationConflict:          "DRV011",
+	ErrVehicleNotFound:               "DRV012",
+	ErrPlateNumberExists:             "DRV013",
+	ErrVehicleSwitchOnline:           "DRV014",
+	ErrVehicleDocumentsMissing:       "DRV015",
+	ErrRideNotFound:                  "RIDE001",
+	ErrInvalidRideTransition:         "RIDE002",
+	ErrActiveRideExists:              "RIDE003",
+	ErrNotRideParticipant:            "RIDE004",
+	ErrRideStatusConflict:            "RIDE005",
+	ErrRideNotCompleted:              "RIDE006",
+	ErrInvalidScheduledTime:          "RIDE007",
+	ErrWaypointNotFound:              "RIDE008",
+	ErrTooManyWaypoints:              "RIDE009",
+	ErrWaypointsLocked:               "RIDE010",
+	ErrPooledRideWaypoints:           "RIDE011",
+	ErrNoDriversAvailable:            "MATCH001",
+	ErrOfferNotFound:                 "MATCH002",
+	ErrOfferNotPending:               "MATCH003",
+	ErrOfferExpired:                  "MATCH004",
+	ErrTariffNotFound:                "PRICE001",
+	ErrPromoCodeNotFound:             "PROMO001",
+	ErrPromoCodeNotValid:             "PROMO002",
+	ErrPromoCodeExhausted:            "PROMO003",
+	ErrPromoCodeUserLimit:            "PROMO004",
+	ErrPromoCodeNotApplicable:        "PROMO005",
+	ErrPromoCodeExists:               "PROMO006",
+	ErrPaymentNotFound:               "PAY001",
+	ErrPaymentDeclined:               "PAY002",
+	ErrPaymentNotRefundable:          "PAY003",
+	ErrRefundTooLarge:                "PAY004",
+	ErrInsufficientBalance:           "WALLET001",
+	ErrLedgerAccountNotFound:         "WALLET002",
+	ErrJournalEntryNotFound:          "WALLET003",
+	ErrJournalEntryExists:            "WALLET004",
+	ErrUnbalancedEntry:               "WALLET005",
+	ErrPayoutStatementNotFound:       "EARN001",
+	ErrPayoutPeriodOpen:              "EARN002",
+	ErrInvalidRating:                 "RATE001",
+	ErrRatingExists:                  "RATE002",
+	ErrPoolNotFound:                  "POOL001",
+	ErrRideDoesNotFit:                "POOL002",
+	ErrPoolConflict:                  "POOL003",
+	ErrTooManyPoolSeats:              "POOL004",
+	ErrDocumentTooLarge:              "DOC001",
+	ErrUnsupportedDocumentFormat:     "DOC002",
+	ErrUploadNotFound:                "DOC003",
+	ErrDocumentExpiryRequired:        "DOC004",
+	ErrDocumentExpired:               "DOC005",
+	ErrInvalidDocumentDates:          "DOC006",
+	ErrDocumentNotFound:              "DOC007",
+	ErrRequiredDocument:              "DOC008",
 }
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuditAction string
+
+const (
+	// AuditActionDriverSuspended marks a driver taken off the road because
+	// a document lapsed
+	AuditActionDriverSuspended AuditAction = "driver_suspended"
+)
+
+// AuditEntry records a change made to a subject such as a driver, why it
+// was made and by whom. ActorID is nil for changes the system made on its
+// own. Entries are never updated.
+type AuditEntry struct {
+	ID          string      `json:"id" gorm:"primaryKey;type:uuid"`
+	Action      AuditAction `json:"action" gorm:"size:50;not null"`
+	SubjectType string      `json:"subject_type" gorm:"size:30;not null;index:idx_audit_entries_subject"`
+	SubjectID   string      `json:"subject_id" gorm:"type:uuid;not null;index:idx_audit_entries_subject"`
+	ActorID     *string     `json:"actor_id,omitempty" gorm:"type:uuid"`
+	Detail      string      `json:"detail" gorm:"size:500"`
+	CreatedAt   time.Time   `json:"created_at" gorm:"not null"`
+}
+
+func NewAuditEntry(action AuditAction, subjectType, subjectID string, actorID *string, detail string, at time.Time) *AuditEntry {
+	return &AuditEntry{
+		ID:          uuid.New().String(),
+		Action:      action,
+		SubjectType: subjectType,
+		SubjectID:   subjectID,
+		ActorID:     actorID,
+		Detail:      detail,
+		CreatedAt:   at,
+	}
+}
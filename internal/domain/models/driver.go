@@ -6,13 +6,6 @@ import (
 	"github.com/google/uuid"
 )
 
-type VehicleType string
-
-const (
-	VehicleTypeCar  VehicleType = "car"
-	VehicleTypeBike VehicleType = "bike"
-)
-
 type DocumentType string
 
 const (
@@ -21,18 +14,83 @@ const (
 	DocumentTypeInsurance    DocumentType = "insurance"
 )
 
-type Vehicle struct {
-	Type        VehicleType `json:"type" gorm:"size:20;not null"`
-	Model       string      `json:"model" gorm:"size:100;not null"`
-	PlateNumber string      `json:"plate_number" gorm:"size:20;not null"`
+// IsRequired reports whether an approved driver must hold a document of
+// type t, so that changing one needs a new review.
+func (t DocumentType) IsRequired() bool {
+	return t == DocumentTypeLicense || t == DocumentTypeInsurance
+}
+
+// IsVehicleDocument reports whether documents of type t belong to one of
+// the driver's vehicles rather than to the driver.
+func (t DocumentType) IsVehicleDocument() bool {
+	return t == DocumentTypeRegistration || t == DocumentTypeInsurance
+}
+
+// RequiresExpiry reports whether documents of type t must state when they
+// expire.
+func (t DocumentType) RequiresExpiry() bool {
+	return t == DocumentTypeLicense || t == DocumentTypeInsurance
+}
+
+type VerificationStatus string
+
+const (
+	VerificationStatusPending              VerificationStatus = "pending"
+	VerificationStatusApproved             VerificationStatus = "approved"
+	VerificationStatusRejected             VerificationStatus = "rejected"
+	VerificationStatusResubmissionRequired VerificationStatus = "resubmission_required"
+)
+
+// verificationTransitions lists the statuses a driver's verification may
+// move to from each status. Approved drivers can have their approval
+// revoked, and go back to pending when they change a required document;
+// rejection is final.
+var verificationTransitions = map[VerificationStatus][]VerificationStatus{
+	VerificationStatusPending:              {VerificationStatusApproved, VerificationStatusRejected, VerificationStatusResubmissionRequired},
+	VerificationStatusApproved:             {VerificationStatusPending, VerificationStatusRejected, VerificationStatusResubmissionRequired},
+	VerificationStatusResubmissionRequired: {VerificationStatusPending, VerificationStatusRejected},
+}
+
+// CanTransitionTo reports whether a verification in status s may move to
+// next.
+func (s VerificationStatus) CanTransitionTo(next VerificationStatus) bool {
+	for _, allowed := range verificationTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
 }
 
+// DocumentFile is an uploaded file kept in object storage. Checksum is the
+// hex encoded SHA-256 of its content.
+type DocumentFile struct {
+	StorageKey  string `json:"-" gorm:"size:255"`
+	ContentType string `json:"content_type,omitempty" gorm:"size:100"`
+	SizeBytes   int64  `json:"size_bytes,omitempty"`
+	Checksum    string `json:"checksum,omitempty" gorm:"size:64"`
+}
+
+// Document is a file a driver submitted for verification. For uploaded
+// files FileURL is not stored but filled in with a signed, expiring
+// download URL when documents are read; documents submitted before
+// uploads existed keep the URL they were given.
+//
+// IssuedOn and ExpiresOn are dates; a document is valid through the whole
+// of its expiry day. ExpiryReminderDays is the days-before-expiry of the
+// last reminder sent about it, zero before the first. VehicleID is set for
+// vehicle documents.
 type Document struct {
-	ID        string       `json:"id" gorm:"primaryKey;type:uuid"`
-	DriverID  string       `json:"driver_id" gorm:"type:uuid;not null"`
-	Type      DocumentType `json:"type" gorm:"size:20;not null"`
-	FileURL   string       `json:"file_url" gorm:"size:255;not null"`
-	CreatedAt time.Time    `json:"created_at" gorm:"not null"`
+	ID                 string       `json:"id" gorm:"primaryKey;type:uuid"`
+	DriverID           string       `json:"driver_id" gorm:"type:uuid;not null"`
+	VehicleID          *string      `json:"vehicle_id,omitempty" gorm:"type:uuid;index"`
+	Type               DocumentType `json:"type" gorm:"size:20;not null"`
+	DocumentFile       `gorm:"embedded"`
+	FileURL            string     `json:"file_url" gorm:"size:255"`
+	IssuedOn           *time.Time `json:"issued_on,omitempty" gorm:"type:date"`
+	ExpiresOn          *time.Time `json:"expires_on,omitempty" gorm:"type:date;index"`
+	ExpiryReminderDays int        `json:"-" gorm:"not null;default:0"`
+	CreatedAt          time.Time  `json:"created_at" gorm:"not null"`
 }
 
 type Location struct {
@@ -40,48 +98,93 @@ type Location struct {
 	Longitude float64 `json:"longitude" gorm:"type:decimal(11,8)"`
 }
 
+// Driver is a user who drives for the platform. Only drivers whose
+// verification was approved can go online. SubmittedAt is when the driver
+// last submitted their details for review; ReviewedBy, ReviewedAt and
+// ReviewReason record the admin decision on them. Drivers register one or
+// more Vehicles and drive the ActiveVehicle.
 type Driver struct {
-	ID              string     `json:"id" gorm:"primaryKey;type:uuid"`
-	UserID          string     `json:"user_id" gorm:"type:uuid;not null"`
-	User            *User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
-	LicenseNumber   string     `json:"license_number" gorm:"size:50;not null;unique"`
-	Vehicle         Vehicle    `json:"vehicle" gorm:"embedded"`
-	IsVerified      bool       `json:"is_verified" gorm:"default:false"`
-	IsAvailable     bool       `json:"is_available" gorm:"default:false"`
-	CurrentLocation Location   `json:"current_location" gorm:"embedded"`
-	Documents       []Document `json:"documents,omitempty" gorm:"foreignKey:DriverID"`
-	CreatedAt       time.Time  `json:"created_at" gorm:"not null"`
-	UpdatedAt       time.Time  `json:"updated_at" gorm:"not null"`
+	ID                 string             `json:"id" gorm:"primaryKey;type:uuid"`
+	UserID             string             `json:"user_id" gorm:"type:uuid;not null"`
+	User               *User              `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	LicenseNumber      string             `json:"license_number" gorm:"size:50;not null;unique"`
+	ActiveVehicle      *Vehicle           `json:"active_vehicle,omitempty" gorm:"foreignKey:DriverID"`
+	Vehicles           []Vehicle          `json:"vehicles,omitempty" gorm:"foreignKey:DriverID"`
+	VerificationStatus VerificationStatus `json:"verification_status" gorm:"size:30;not null;default:pending;index"`
+	SubmittedAt        time.Time          `json:"submitted_at" gorm:"index"`
+	ReviewedBy         *string            `json:"reviewed_by,omitempty" gorm:"type:uuid"`
+	ReviewedAt         *time.Time         `json:"reviewed_at,omitempty"`
+	ReviewReason       string             `json:"review_reason,omitempty" gorm:"size:500"`
+	IsAvailable        bool               `json:"is_available" gorm:"default:false"`
+	// RatingAverage and RatingCount are the ratings the driver received
+	// from riders
+	RatingAverage   float64  `json:"rating_average" gorm:"type:decimal(3,2);not null;default:0"`
+	RatingCount     int      `json:"rating_count" gorm:"not null;default:0"`
+	CurrentLocation Location `json:"current_location" gorm:"embedded;embeddedPrefix:current_"`
+	// LocationUpdatedAt is when CurrentLocation was last reported
+	LocationUpdatedAt *time.Time `json:"location_updated_at,omitempty" gorm:"index"`
+	Documents         []Document `json:"documents,omitempty" gorm:"foreignKey:DriverID"`
+	CreatedAt         time.Time  `json:"created_at" gorm:"not null"`
+	UpdatedAt         time.Time  `json:"updated_at" gorm:"not null"`
 }
 
+// NewDriver registers a driver with vehicle as their active vehicle
 func NewDriver(userID, licenseNumber string, vehicle Vehicle) *Driver {
-	return &Driver{
-		ID:            uuid.New().String(),
-		UserID:        userID,
-		LicenseNumber: licenseNumber,
-		Vehicle:       vehicle,
-		IsVerified:    false,
-		IsAvailable:   false,
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
+	now := time.Now()
+	driver := &Driver{
+		ID:                 uuid.New().String(),
+		UserID:             userID,
+		LicenseNumber:      licenseNumber,
+		VerificationStatus: VerificationStatusPending,
+		SubmittedAt:        now,
+		IsAvailable:        false,
+		CreatedAt:          now,
+		UpdatedAt:          now,
 	}
+	driver.setOnlyVehicle(vehicle)
+	return driver
 }
 
-func NewDocument(driverID string, docType DocumentType, fileURL string) *Document {
+func NewDocument(driverID string, docType DocumentType, file DocumentFile) *Document {
 	return &Document{
-		ID:        uuid.New().String(),
-		DriverID:  driverID,
-		Type:      docType,
-		FileURL:   fileURL,
-		CreatedAt: time.Now(),
+		ID:           uuid.New().String(),
+		DriverID:     driverID,
+		Type:         docType,
+		DocumentFile: file,
+		CreatedAt:    time.Now(),
 	}
 }
 
-func (d *Driver) UpdateLocation(lat, lng float64) {
+// IsStored reports whether the document's file is kept in object storage.
+func (d *Document) IsStored() bool {
+	return d.StorageKey != ""
+}
+
+// DaysUntilExpiry returns the number of whole days from the day of now to
+// the expiry day, negative once the document has lapsed. Days are counted
+// in UTC. Documents without an expiry date report false.
+func (d *Document) DaysUntilExpiry(now time.Time) (int, bool) {
+	if d.ExpiresOn == nil {
+		return 0, false
+	}
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	expiry := time.Date(d.ExpiresOn.Year(), d.ExpiresOn.Month(), d.ExpiresOn.Day(), 0, 0, 0, 0, time.UTC)
+	return int(expiry.Sub(today).Hours() / 24), true
+}
+
+// IsLapsed reports whether the document's expiry day is over at now.
+func (d *Document) IsLapsed(now time.Time) bool {
+	days, ok := d.DaysUntilExpiry(now)
+	return ok && days < 0
+}
+
+func (d *Driver) UpdateLocation(lat, lng float64, at time.Time) {
 	d.CurrentLocation = Location{
 		Latitude:  lat,
 		Longitude: lng,
 	}
+	d.LocationUpdatedAt = &at
 	d.UpdatedAt = time.Now()
 }
 
@@ -90,7 +193,108 @@ func (d *Driver) UpdateAvailability(isAvailable bool) {
 	d.UpdatedAt = time.Now()
 }
 
-func (d *Driver) SetVerified(verified bool) {
-	d.IsVerified = verified
-	d.UpdatedAt = time.Now()
+// IsVerified reports whether the driver was approved to take rides.
+func (d *Driver) IsVerified() bool {
+	return d.VerificationStatus == VerificationStatusApproved
+}
+
+// The methods below apply a verification transition without checking it;
+// callers are expected to consult CanTransitionTo first.
+
+// Review records an admin's decision on the driver's verification. A
+// driver who is no longer approved is taken offline.
+func (d *Driver) Review(status VerificationStatus, reviewerID, reason string, at time.Time) {
+	d.VerificationStatus = status
+	d.ReviewedBy = &reviewerID
+	d.ReviewedAt = &at
+	d.ReviewReason = reason
+	if status != VerificationStatusApproved {
+		d.IsAvailable = false
+	}
+	d.UpdatedAt = at
+}
+
+// Suspend withdraws the approval of a driver whose documents no longer
+// qualify them, without a reviewer, until they resubmit.
+func (d *Driver) Suspend(reason string, at time.Time) {
+	d.VerificationStatus = VerificationStatusResubmissionRequired
+	d.ReviewedBy = nil
+	d.ReviewedAt = &at
+	d.ReviewReason = reason
+	d.IsAvailable = false
+	d.UpdatedAt = at
+}
+
+// Reverify puts an approved driver back in the review queue, offline,
+// after they changed a required document.
+func (d *Driver) Reverify(at time.Time) {
+	d.VerificationStatus = VerificationStatusPending
+	d.SubmittedAt = at
+	d.IsAvailable = false
+	d.UpdatedAt = at
+}
+
+// Resubmit puts the driver's corrected details back in the review queue.
+// The details of vehicle replace those of their active vehicle.
+func (d *Driver) Resubmit(licenseNumber string, vehicle Vehicle, at time.Time) {
+	d.LicenseNumber = licenseNumber
+	if d.ActiveVehicle == nil {
+		d.setOnlyVehicle(vehicle)
+	} else {
+		d.ActiveVehicle.Type = vehicle.Type
+		d.ActiveVehicle.Model = vehicle.Model
+		d.ActiveVehicle.PlateNumber = vehicle.PlateNumber
+		d.ActiveVehicle.Seats = vehicle.Seats
+		d.ActiveVehicle.Colour = vehicle.Colour
+		d.ActiveVehicle.Year = vehicle.Year
+		d.ActiveVehicle.UpdatedAt = at
+	}
+	d.VerificationStatus = VerificationStatusPending
+	d.SubmittedAt = at
+	d.UpdatedAt = at
+}
+
+// FindVehicle returns the driver's vehicle with vehicleID, or nil if the
+// driver has no such vehicle or their vehicles were not loaded.
+func (d *Driver) FindVehicle(vehicleID string) *Vehicle {
+	if d.ActiveVehicle != nil && d.ActiveVehicle.ID == vehicleID {
+		return d.ActiveVehicle
+	}
+	for i := range d.Vehicles {
+		if d.Vehicles[i].ID == vehicleID {
+			return &d.Vehicles[i]
+		}
+	}
+	return nil
+}
+
+// RideVehicle returns the vehicle a ride was driven with, falling back to
+// the active vehicle for rides that did not record one.
+func (d *Driver) RideVehicle(ride *Ride) *Vehicle {
+	if ride.VehicleID != nil {
+		if vehicle := d.FindVehicle(*ride.VehicleID); vehicle != nil {
+			return vehicle
+		}
+	}
+	return d.ActiveVehicle
+}
+
+// ActivateVehicle makes vehicle the one the driver drives.
+func (d *Driver) ActivateVehicle(vehicle *Vehicle, at time.Time) {
+	if d.ActiveVehicle != nil {
+		d.ActiveVehicle.IsActive = false
+	}
+	for i := range d.Vehicles {
+		d.Vehicles[i].IsActive = d.Vehicles[i].ID == vehicle.ID
+	}
+	vehicle.IsActive = true
+	vehicle.UpdatedAt = at
+	d.ActiveVehicle = vehicle
+}
+
+func (d *Driver) setOnlyVehicle(details Vehicle) {
+	vehicle := NewVehicle(d.ID, details)
+	vehicle.IsActive = true
+	d.Vehicles = []Vehicle{*vehicle}
+	d.ActiveVehicle = &d.Vehicles[0]
 }
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerificationStatusCanTransitionTo(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     VerificationStatus
+		to       VerificationStatus
+		expected bool
+	}{
+		{name: "pending to approved", from: VerificationStatusPending, to: VerificationStatusApproved, expected: true},
+		{name: "pending to resubmission required", from: VerificationStatusPending, to: VerificationStatusResubmissionRequired, expected: true},
+		{name: "approved to rejected", from: VerificationStatusApproved, to: VerificationStatusRejected, expected: true},
+		{name: "approved to pending", from: VerificationStatusApproved, to: VerificationStatusPending, expected: true},
+		{name: "resubmission required to pending", from: VerificationStatusResubmissionRequired, to: VerificationStatusPending, expected: true},
+		{name: "resubmission required to approved", from: VerificationStatusResubmissionRequired, to: VerificationStatusApproved, expected: false},
+		{name: "rejected is terminal", from: VerificationStatusRejected, to: VerificationStatusApproved, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.from.CanTransitionTo(tt.to))
+		})
+	}
+}
+
+func TestDriverVerification(t *testing.T) {
+	at := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	driver := NewDriver("user-1", "DL-1", Vehicle{Type: VehicleTypeCar})
+	assert.False(t, driver.IsVerified())
+
+	driver.Review(VerificationStatusApproved, "admin-1", "ok", at)
+	driver.IsAvailable = true
+	assert.True(t, driver.IsVerified())
+
+	driver.Review(VerificationStatusResubmissionRequired, "admin-1", "licence expired", at)
+	assert.False(t, driver.IsAvailable)
+	assert.Equal(t, "licence expired", driver.ReviewReason)
+
+	driver.Review(VerificationStatusApproved, "admin-1", "ok", at)
+	driver.IsAvailable = true
+	driver.Suspend("license expired on 2024-03-03", at)
+	assert.False(t, driver.IsAvailable)
+	assert.Nil(t, driver.ReviewedBy)
+	assert.Equal(t, VerificationStatusResubmissionRequired, driver.VerificationStatus)
+
+	driver.Review(VerificationStatusApproved, "admin-1", "ok", at)
+	driver.IsAvailable = true
+	driver.Reverify(at.Add(time.Minute))
+	assert.False(t, driver.IsVerified())
+	assert.False(t, driver.IsAvailable)
+	assert.Equal(t, at.Add(time.Minute), driver.SubmittedAt)
+
+	driver.Suspend("license expired on 2024-03-03", at)
+	driver.Resubmit("DL-2", Vehicle{Type: VehicleTypeBike}, at.Add(time.Hour))
+	assert.Equal(t, VerificationStatusPending, driver.VerificationStatus)
+	assert.Equal(t, at.Add(time.Hour), driver.SubmittedAt)
+	assert.Equal(t, "DL-2", driver.LicenseNumber)
+	assert.Equal(t, VehicleTypeBike, driver.ActiveVehicle.Type)
+}
+
+func TestDriverVehicles(t *testing.T) {
+	at := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	driver := NewDriver("user-1", "DL-1", Vehicle{Type: VehicleTypeCar, PlateNumber: "DHA-1"})
+	first := driver.ActiveVehicle
+	if assert.NotNil(t, first) {
+		assert.True(t, first.IsActive)
+		assert.Equal(t, driver.ID, first.DriverID)
+	}
+
+	second := NewVehicle(driver.ID, Vehicle{Type: VehicleTypeBike, PlateNumber: "DHA-2"})
+	assert.False(t, second.IsActive)
+	assert.Equal(t, 1, second.PassengerSeats())
+	driver.Vehicles = append(driver.Vehicles, *second)
+	assert.Nil(t, driver.FindVehicle("missing"))
+
+	ride := &Ride{VehicleID: &first.ID}
+	driver.ActivateVehicle(driver.FindVehicle(second.ID), at)
+	assert.Equal(t, second.ID, driver.ActiveVehicle.ID)
+	assert.Equal(t, at, driver.ActiveVehicle.UpdatedAt)
+	for _, vehicle := range driver.Vehicles {
+		assert.Equal(t, vehicle.ID == second.ID, vehicle.IsActive)
+	}
+
+	// Rides keep the vehicle they were driven with
+	assert.Equal(t, VehicleTypeCar, driver.RideVehicle(ride).Type)
+	assert.Equal(t, VehicleTypeBike, driver.RideVehicle(&Ride{}).Type)
+}
+
+func TestDocumentExpiry(t *testing.T) {
+	expiresOn := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	document := &Document{Type: DocumentTypeLicense, ExpiresOn: &expiresOn}
+
+	tests := []struct {
+		name   string
+		now    time.Time
+		days   int
+		lapsed bool
+	}{
+		{"a week before", time.Date(2024, 3, 3, 23, 0, 0, 0, time.UTC), 7, false},
+		{"on the expiry day", time.Date(2024, 3, 10, 23, 59, 0, 0, time.UTC), 0, false},
+		{"the day after", time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC), -1, true},
+		{"counted in UTC", time.Date(2024, 3, 11, 1, 0, 0, 0, time.FixedZone("UTC+6", 6*60*60)), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			days, ok := document.DaysUntilExpiry(tt.now)
+			assert.True(t, ok)
+			assert.Equal(t, tt.days, days)
+			assert.Equal(t, tt.lapsed, document.IsLapsed(tt.now))
+		})
+	}
+
+	t.Run("documents without an expiry never lapse", func(t *testing.T) {
+		document := &Document{Type: DocumentTypeRegistration}
+		_, ok := document.DaysUntilExpiry(expiresOn)
+		assert.False(t, ok)
+		assert.False(t, document.IsLapsed(expiresOn.AddDate(10, 0, 0)))
+	})
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DriverEarning is the driver's share of one completed ride. GrossFare is
+// the fare before any promo discount, which the platform bears.
+// PayoutStatementID is set once the earning is included in a payout.
+type DriverEarning struct {
+	ID                string    `json:"id" gorm:"primaryKey;type:uuid"`
+	DriverID          string    `json:"driver_id" gorm:"type:uuid;not null;index:idx_driver_earnings_driver_time,priority:1"`
+	RideID            string    `json:"ride_id" gorm:"type:uuid;not null;unique"`
+	GrossFare         float64   `json:"gross_fare" gorm:"type:decimal(10,2);not null"`
+	CommissionRate    float64   `json:"commission_rate" gorm:"type:decimal(5,4);not null"`
+	Commission        float64   `json:"commission" gorm:"type:decimal(10,2);not null"`
+	Earnings          float64   `json:"earnings" gorm:"type:decimal(10,2);not null"`
+	PayoutStatementID *string   `json:"payout_statement_id,omitempty" gorm:"type:uuid;index"`
+	EarnedAt          time.Time `json:"earned_at" gorm:"not null;index:idx_driver_earnings_driver_time,priority:2"`
+	CreatedAt         time.Time `json:"created_at" gorm:"not null"`
+}
+
+// PayoutStatement sums up what a driver is paid for a week. It includes
+// every earning up to PeriodEnd that no earlier statement covered.
+type PayoutStatement struct {
+	ID          string          `json:"id" gorm:"primaryKey;type:uuid"`
+	DriverID    string          `json:"driver_id" gorm:"type:uuid;not null;uniqueIndex:idx_payout_statements_driver_period,priority:1"`
+	PeriodStart time.Time       `json:"period_start" gorm:"not null;uniqueIndex:idx_payout_statements_driver_period,priority:2"`
+	PeriodEnd   time.Time       `json:"period_end" gorm:"not null"`
+	RideCount   int             `json:"ride_count" gorm:"not null;default:0"`
+	GrossFares  float64         `json:"gross_fares" gorm:"type:decimal(12,2);not null;default:0"`
+	Commission  float64         `json:"commission" gorm:"type:decimal(12,2);not null;default:0"`
+	Earnings    float64         `json:"earnings" gorm:"type:decimal(12,2);not null;default:0"`
+	Items       []DriverEarning `json:"items,omitempty" gorm:"foreignKey:PayoutStatementID"`
+	CreatedAt   time.Time       `json:"created_at" gorm:"not null"`
+}
+
+// NewDriverEarning splits grossFare into the platform's commission and the
+// driver's earnings. The commission is rounded to cents and the driver gets
+// the rest, so the two always add up to the fare.
+func NewDriverEarning(driverID, rideID string, grossFare, commissionRate float64, earnedAt time.Time) *DriverEarning {
+	commission := math.Round(grossFare*commissionRate*100) / 100
+	return &DriverEarning{
+		ID:             uuid.New().String(),
+		DriverID:       driverID,
+		RideID:         rideID,
+		GrossFare:      grossFare,
+		CommissionRate: commissionRate,
+		Commission:     commission,
+		Earnings:       math.Round((grossFare-commission)*100) / 100,
+		EarnedAt:       earnedAt,
+		CreatedAt:      time.Now(),
+	}
+}
+
+// EarningsTotals adds up a set of driver earnings
+type EarningsTotals struct {
+	RideCount  int     `json:"ride_count"`
+	GrossFares float64 `json:"gross_fares"`
+	Commission float64 `json:"commission"`
+	Earnings   float64 `json:"earnings"`
+}
+
+func NewPayoutStatement(driverID string, periodStart, periodEnd time.Time) *PayoutStatement {
+	return &PayoutStatement{
+		ID:          uuid.New().String(),
+		DriverID:    driverID,
+		PeriodStart: periodStart,
+		PeriodEnd:   periodEnd,
+		CreatedAt:   time.Now(),
+	}
+}
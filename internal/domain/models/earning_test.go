@@ -0,0 +1,31 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDriverEarning(t *testing.T) {
+	tests := []struct {
+		name           string
+		grossFare      float64
+		rate           float64
+		wantCommission float64
+		wantEarnings   float64
+	}{
+		{name: "Even split", grossFare: 280, rate: 0.2, wantCommission: 56, wantEarnings: 224},
+		{name: "Commission rounded to cents", grossFare: 147.33, rate: 0.15, wantCommission: 22.1, wantEarnings: 125.23},
+		{name: "No commission", grossFare: 100, rate: 0, wantCommission: 0, wantEarnings: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			earning := NewDriverEarning("driver-1", "ride-1", tt.grossFare, tt.rate, time.Now())
+
+			assert.Equal(t, tt.wantCommission, earning.Commission)
+			assert.Equal(t, tt.wantEarnings, earning.Earnings)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type LedgerAccountType string
+
+const (
+	// Asset accounts grow with debits, the others with credits
+	LedgerAccountTypeAsset     LedgerAccountType = "asset"
+	LedgerAccountTypeLiability LedgerAccountType = "liability"
+	LedgerAccountTypeRevenue   LedgerAccountType = "revenue"
+)
+
+// Accounts of the platform itself
+const (
+	// LedgerAccountCash holds the money collected through the payment gateway
+	LedgerAccountCash = "platform:cash"
+	// LedgerAccountRideRevenue receives the fares of rides paid from wallets
+	LedgerAccountRideRevenue = "platform:ride_revenue"
+	// LedgerAccountRideHolds holds wallet money set aside for the fares of
+	// rides under way
+	LedgerAccountRideHolds = "platform:ride_holds"
+)
+
+type JournalEntryKind string
+
+const (
+	JournalEntryKindTopUp       JournalEntryKind = "top_up"
+	JournalEntryKindRideCharge  JournalEntryKind = "ride_charge"
+	JournalEntryKindRideHold    JournalEntryKind = "ride_hold"
+	JournalEntryKindHoldRelease JournalEntryKind = "hold_release"
+)
+
+// LedgerAccount is one account of the double-entry ledger. Its balance is
+// never stored; it is the sum of the account's postings.
+type LedgerAccount struct {
+	ID        string            `json:"id" gorm:"primaryKey;type:uuid"`
+	Code      string            `json:"code" gorm:"size:100;not null;unique"`
+	Type      LedgerAccountType `json:"type" gorm:"size:20;not null"`
+	OwnerID   *string           `json:"owner_id,omitempty" gorm:"type:uuid;index"`
+	Currency  string            `json:"currency" gorm:"size:3;not null"`
+	CreatedAt time.Time         `json:"created_at" gorm:"not null"`
+}
+
+// JournalEntry is one business event, e.g. a top-up, recorded as postings
+// that sum to zero. IdempotencyKey makes recording the same event twice a
+// no-op.
+type JournalEntry struct {
+	ID             string           `json:"id" gorm:"primaryKey;type:uuid"`
+	IdempotencyKey string           `json:"-" gorm:"size:255;not null;unique"`
+	Kind           JournalEntryKind `json:"kind" gorm:"size:20;not null"`
+	Description    string           `json:"description" gorm:"size:255"`
+	RideID         *string          `json:"ride_id,omitempty" gorm:"type:uuid;index"`
+	Postings       []Posting        `json:"postings,omitempty" gorm:"foreignKey:EntryID"`
+	CreatedAt      time.Time        `json:"created_at" gorm:"not null;index"`
+}
+
+// Posting moves Amount into or out of an account. Debits are positive and
+// credits negative.
+type Posting struct {
+	ID        string        `json:"id" gorm:"primaryKey;type:uuid"`
+	EntryID   string        `json:"entry_id" gorm:"type:uuid;not null;index"`
+	Entry     *JournalEntry `json:"entry,omitempty" gorm:"foreignKey:EntryID"`
+	AccountID string        `json:"account_id" gorm:"type:uuid;not null;index:idx_postings_account_time,priority:1"`
+	Amount    float64       `json:"amount" gorm:"type:decimal(12,2);not null"`
+	CreatedAt time.Time     `json:"created_at" gorm:"not null;index:idx_postings_account_time,priority:2"`
+}
+
+func NewLedgerAccount(code string, accountType LedgerAccountType, ownerID *string, currency string) *LedgerAccount {
+	return &LedgerAccount{
+		ID:        uuid.New().String(),
+		Code:      code,
+		Type:      accountType,
+		OwnerID:   ownerID,
+		Currency:  currency,
+		CreatedAt: time.Now(),
+	}
+}
+
+// WalletAccountCode is the code of a rider's wallet account.
+func WalletAccountCode(userID string) string {
+	return "wallet:" + userID
+}
+
+// BalanceOf turns the sum of an account's postings into its balance, which
+// is positive for an account in its normal state.
+func (a *LedgerAccount) BalanceOf(postingSum float64) float64 {
+	if a.Type != LedgerAccountTypeAsset {
+		postingSum = -postingSum
+	}
+	return math.Round(postingSum*100) / 100
+}
+
+func NewJournalEntry(idempotencyKey string, kind JournalEntryKind, description string) *JournalEntry {
+	return &JournalEntry{
+		ID:             uuid.New().String(),
+		IdempotencyKey: idempotencyKey,
+		Kind:           kind,
+		Description:    description,
+		CreatedAt:      time.Now(),
+	}
+}
+
+// AddTransfer debits one account and credits another by amount.
+func (e *JournalEntry) AddTransfer(debit, credit *LedgerAccount, amount float64) {
+	amount = math.Round(amount*100) / 100
+	e.Postings = append(e.Postings,
+		Posting{ID: uuid.New().String(), EntryID: e.ID, AccountID: debit.ID, Amount: amount, CreatedAt: e.CreatedAt},
+		Posting{ID: uuid.New().String(), EntryID: e.ID, AccountID: credit.ID, Amount: -amount, CreatedAt: e.CreatedAt},
+	)
+}
+
+// IsBalanced reports whether the entry has postings and they sum to zero.
+func (e *JournalEntry) IsBalanced() bool {
+	if len(e.Postings) == 0 {
+		return false
+	}
+	var cents int64
+	for _, p := range e.Postings {
+		cents += int64(math.Round(p.Amount * 100))
+	}
+	return cents == 0
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalEntryIsBalanced(t *testing.T) {
+	cash := NewLedgerAccount(LedgerAccountCash, LedgerAccountTypeAsset, nil, "BDT")
+	wallet := NewLedgerAccount(WalletAccountCode("rider-1"), LedgerAccountTypeLiability, nil, "BDT")
+
+	entry := NewJournalEntry("top-up:1", JournalEntryKindTopUp, "Wallet top-up")
+	assert.False(t, entry.IsBalanced(), "an entry without postings is not balanced")
+
+	entry.AddTransfer(cash, wallet, 100.105)
+	assert.True(t, entry.IsBalanced())
+	assert.Equal(t, 100.11, entry.Postings[0].Amount)
+	assert.Equal(t, -100.11, entry.Postings[1].Amount)
+
+	entry.Postings[1].Amount = -100.10
+	assert.False(t, entry.IsBalanced())
+}
+
+func TestLedgerAccountBalanceOf(t *testing.T) {
+	cash := NewLedgerAccount(LedgerAccountCash, LedgerAccountTypeAsset, nil, "BDT")
+	wallet := NewLedgerAccount(WalletAccountCode("rider-1"), LedgerAccountTypeLiability, nil, "BDT")
+
+	// A top-up of 500 followed by a ride of 180.4
+	assert.Equal(t, 500.0, cash.BalanceOf(500))
+	assert.Equal(t, 319.6, wallet.BalanceOf(-500+180.4))
+}
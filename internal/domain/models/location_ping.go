@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LocationPing is one reported position of a driver. RideID is set when the
+// driver was serving a ride at the time.
+type LocationPing struct {
+	ID         string    `json:"id" gorm:"primaryKey;type:uuid"`
+	DriverID   string    `json:"driver_id" gorm:"type:uuid;not null;index:idx_location_pings_driver_time,priority:1"`
+	RideID     *string   `json:"ride_id,omitempty" gorm:"type:uuid;index:idx_location_pings_ride_time,priority:1"`
+	Location   Location  `json:"location" gorm:"embedded"`
+	RecordedAt time.Time `json:"recorded_at" gorm:"not null;index;index:idx_location_pings_driver_time,priority:2;index:idx_location_pings_ride_time,priority:2"`
+}
+
+func NewLocationPing(driverID string, rideID *string, location Location, recordedAt time.Time) *LocationPing {
+	return &LocationPing{
+		ID:         uuid.New().String(),
+		DriverID:   driverID,
+		RideID:     rideID,
+		Location:   location,
+		RecordedAt: recordedAt,
+	}
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentMethod is how the rider pays for a ride
+type PaymentMethod string
+
+const (
+	PaymentMethodCard   PaymentMethod = "card"
+	PaymentMethodWallet PaymentMethod = "wallet"
+)
+
+type PaymentStatus string
+
+const (
+	PaymentStatusAuthorized PaymentStatus = "authorized"
+	PaymentStatusCaptured   PaymentStatus = "captured"
+	PaymentStatusVoided     PaymentStatus = "voided"
+	PaymentStatusRefunded   PaymentStatus = "refunded"
+	// PaymentStatusFailed marks a capture the gateway refused; the
+	// authorization is kept so the capture can be retried
+	PaymentStatusFailed PaymentStatus = "failed"
+)
+
+// Payment is the money movement for one ride: an authorization when a
+// driver accepts, a capture of the final fare when the ride completes and
+// any later refunds.
+type Payment struct {
+	ID               string        `json:"id" gorm:"primaryKey;type:uuid"`
+	RideID           string        `json:"ride_id" gorm:"type:uuid;not null;unique"`
+	RiderID          string        `json:"rider_id" gorm:"type:uuid;not null;index"`
+	Status           PaymentStatus `json:"status" gorm:"size:20;not null;index"`
+	Currency         string        `json:"currency" gorm:"size:3;not null"`
+	AuthorizedAmount float64       `json:"authorized_amount" gorm:"type:decimal(10,2);not null"`
+	CapturedAmount   float64       `json:"captured_amount" gorm:"type:decimal(10,2);not null;default:0"`
+	RefundedAmount   float64       `json:"refunded_amount" gorm:"type:decimal(10,2);not null;default:0"`
+	AuthorizationID  string        `json:"-" gorm:"size:100;not null"`
+	CaptureID        string        `json:"-" gorm:"size:100"`
+	FailureReason    string        `json:"failure_reason,omitempty" gorm:"size:255"`
+	CreatedAt        time.Time     `json:"created_at" gorm:"not null"`
+	UpdatedAt        time.Time     `json:"updated_at" gorm:"not null"`
+}
+
+func NewPayment(rideID, riderID, currency string, authorizedAmount float64, authorizationID string) *Payment {
+	return &Payment{
+		ID:               uuid.New().String(),
+		RideID:           rideID,
+		RiderID:          riderID,
+		Status:           PaymentStatusAuthorized,
+		Currency:         currency,
+		AuthorizedAmount: authorizedAmount,
+		AuthorizationID:  authorizationID,
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
+	}
+}
+
+func (p *Payment) MarkCaptured(amount float64, captureID string) {
+	p.Status = PaymentStatusCaptured
+	p.CapturedAmount = amount
+	p.CaptureID = captureID
+	p.FailureReason = ""
+	p.UpdatedAt = time.Now()
+}
+
+func (p *Payment) MarkCaptureFailed(reason string) {
+	p.Status = PaymentStatusFailed
+	p.FailureReason = reason
+	p.UpdatedAt = time.Now()
+}
+
+func (p *Payment) MarkVoided() {
+	p.Status = PaymentStatusVoided
+	p.UpdatedAt = time.Now()
+}
+
+// AddRefund records a refund; the payment counts as refunded once the
+// whole capture has been returned.
+func (p *Payment) AddRefund(amount float64) {
+	p.RefundedAmount = math.Round((p.RefundedAmount+amount)*100) / 100
+	if p.RefundedAmount >= p.CapturedAmount {
+		p.Status = PaymentStatusRefunded
+	}
+	p.UpdatedAt = time.Now()
+}
+
+// RefundableAmount is what can still be refunded.
+func (p *Payment) RefundableAmount() float64 {
+	if p.Status != PaymentStatusCaptured {
+		return 0
+	}
+	return p.CapturedAmount - p.RefundedAmount
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PoolStatus string
+
+const (
+	PoolStatusOpen   PoolStatus = "open"
+	PoolStatusClosed PoolStatus = "closed"
+)
+
+type PoolStopKind string
+
+const (
+	PoolStopPickup  PoolStopKind = "pickup"
+	PoolStopDropoff PoolStopKind = "dropoff"
+)
+
+// PoolStop is where a pool's driver picks up or drops off the riders of
+// one ride.
+type PoolStop struct {
+	RideID    string       `json:"ride_id"`
+	Kind      PoolStopKind `json:"kind"`
+	Location  Location     `json:"location"`
+	Seats     int          `json:"seats"`
+	VisitedAt *time.Time   `json:"visited_at,omitempty"`
+}
+
+// Pool is a driver's trip shared by pooled rides. Route holds the stops
+// still ahead in the order the driver should serve them and Visited the
+// stops served so far, from which the distance is split between riders.
+// A driver has at most one open pool; it closes once its route is empty.
+// Version guards against concurrent changes to the route.
+type Pool struct {
+	ID        string     `json:"id" gorm:"primaryKey;type:uuid"`
+	DriverID  string     `json:"driver_id" gorm:"type:uuid;not null;uniqueIndex:idx_pools_open_driver,where:status = 'open'"`
+	Status    PoolStatus `json:"status" gorm:"size:20;not null"`
+	Capacity  int        `json:"capacity" gorm:"not null"`
+	Route     []PoolStop `json:"route" gorm:"type:jsonb;serializer:json"`
+	Visited   []PoolStop `json:"visited" gorm:"type:jsonb;serializer:json"`
+	Version   int        `json:"-" gorm:"not null;default:0"`
+	ClosedAt  *time.Time `json:"closed_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at" gorm:"not null"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"not null"`
+}
+
+func NewPool(driverID string, capacity int, now time.Time) *Pool {
+	return &Pool{
+		ID:        uuid.New().String(),
+		DriverID:  driverID,
+		Status:    PoolStatusOpen,
+		Capacity:  capacity,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Visit moves the stop of kind for rideID from the route to the visited
+// stops. It reports false when the stop is not on the route, e.g. because
+// it was visited before.
+func (p *Pool) Visit(rideID string, kind PoolStopKind, at time.Time) bool {
+	for i, stop := range p.Route {
+		if stop.RideID != rideID || stop.Kind != kind {
+			continue
+		}
+		stop.VisitedAt = &at
+		p.Visited = append(p.Visited, stop)
+		p.Route = append(p.Route[:i:i], p.Route[i+1:]...)
+		p.touch(at)
+		return true
+	}
+	return false
+}
+
+// Remove takes the remaining stops of rideID off the route. It reports
+// false when there were none.
+func (p *Pool) Remove(rideID string, at time.Time) bool {
+	route := make([]PoolStop, 0, len(p.Route))
+	for _, stop := range p.Route {
+		if stop.RideID != rideID {
+			route = append(route, stop)
+		}
+	}
+	if len(route) == len(p.Route) {
+		return false
+	}
+	p.Route = route
+	p.touch(at)
+	return true
+}
+
+// touch records a change to the route and closes the pool once nothing is
+// left to do.
+func (p *Pool) touch(at time.Time) {
+	p.UpdatedAt = at
+	if len(p.Route) == 0 {
+		p.Status = PoolStatusClosed
+		p.ClosedAt = &at
+	}
+}
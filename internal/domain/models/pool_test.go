@@ -0,0 +1,57 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolRoute(t *testing.T) {
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	newPool := func() *Pool {
+		pool := NewPool("driver-1", 3, start)
+		pool.Route = []PoolStop{
+			{RideID: "a", Kind: PoolStopPickup, Seats: 1},
+			{RideID: "b", Kind: PoolStopPickup, Seats: 1},
+			{RideID: "a", Kind: PoolStopDropoff, Seats: 1},
+			{RideID: "b", Kind: PoolStopDropoff, Seats: 1},
+		}
+		return pool
+	}
+
+	t.Run("visiting moves the stop off the route", func(t *testing.T) {
+		pool := newPool()
+
+		assert.True(t, pool.Visit("b", PoolStopPickup, start.Add(time.Minute)))
+		assert.False(t, pool.Visit("b", PoolStopPickup, start.Add(2*time.Minute)))
+
+		assert.Len(t, pool.Route, 3)
+		assert.Len(t, pool.Visited, 1)
+		assert.Equal(t, start.Add(time.Minute), *pool.Visited[0].VisitedAt)
+		assert.Equal(t, PoolStatusOpen, pool.Status)
+	})
+
+	t.Run("removing a ride drops its remaining stops", func(t *testing.T) {
+		pool := newPool()
+		pool.Visit("a", PoolStopPickup, start)
+
+		assert.True(t, pool.Remove("a", start))
+		assert.False(t, pool.Remove("a", start))
+
+		assert.Equal(t, []PoolStop{
+			{RideID: "b", Kind: PoolStopPickup, Seats: 1},
+			{RideID: "b", Kind: PoolStopDropoff, Seats: 1},
+		}, pool.Route)
+	})
+
+	t.Run("the pool closes once the route is empty", func(t *testing.T) {
+		pool := newPool()
+		pool.Remove("a", start)
+		pool.Visit("b", PoolStopPickup, start)
+		pool.Visit("b", PoolStopDropoff, start.Add(time.Hour))
+
+		assert.Equal(t, PoolStatusClosed, pool.Status)
+		assert.Equal(t, start.Add(time.Hour), *pool.ClosedAt)
+	})
+}
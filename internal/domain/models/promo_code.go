@@ -0,0 +1,114 @@
+package models
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DiscountType string
+
+const (
+	DiscountTypePercentage DiscountType = "percentage"
+	DiscountTypeFlat       DiscountType = "flat"
+)
+
+// PromoCode is a discount riders can apply to their fare. Zero limits mean
+// "unlimited" and a nil window bound means "open ended".
+type PromoCode struct {
+	ID           string       `json:"id" gorm:"primaryKey;type:uuid"`
+	Code         string       `json:"code" gorm:"size:32;not null;unique"`
+	DiscountType DiscountType `json:"discount_type" gorm:"size:20;not null"`
+	// DiscountValue is a percentage for percentage discounts and an amount
+	// of money for flat ones
+	DiscountValue float64 `json:"discount_value" gorm:"type:decimal(10,2);not null"`
+	// MaxDiscount caps percentage discounts
+	MaxDiscount  float64     `json:"max_discount" gorm:"type:decimal(10,2);not null;default:0"`
+	MaxUses      int         `json:"max_uses" gorm:"not null;default:0"`
+	PerUserLimit int         `json:"per_user_limit" gorm:"not null;default:0"`
+	UsedCount    int         `json:"used_count" gorm:"not null;default:0"`
+	VehicleType  VehicleType `json:"vehicle_type,omitempty" gorm:"size:20"`
+	StartsAt     *time.Time  `json:"starts_at,omitempty"`
+	EndsAt       *time.Time  `json:"ends_at,omitempty"`
+	CreatedAt    time.Time   `json:"created_at" gorm:"not null"`
+	UpdatedAt    time.Time   `json:"updated_at" gorm:"not null"`
+}
+
+// PromoRedemption records one use of a promo code. A ride can redeem at
+// most one code.
+type PromoRedemption struct {
+	ID          string    `json:"id" gorm:"primaryKey;type:uuid"`
+	PromoCodeID string    `json:"promo_code_id" gorm:"type:uuid;not null;index:idx_promo_redemptions_code_user"`
+	UserID      string    `json:"user_id" gorm:"type:uuid;not null;index:idx_promo_redemptions_code_user"`
+	RideID      string    `json:"ride_id" gorm:"type:uuid;not null;unique"`
+	Discount    float64   `json:"discount" gorm:"type:decimal(10,2);not null"`
+	CreatedAt   time.Time `json:"created_at" gorm:"not null"`
+}
+
+func NewPromoCode(code string, discountType DiscountType, discountValue float64) *PromoCode {
+	return &PromoCode{
+		ID:            uuid.New().String(),
+		Code:          NormalizePromoCode(code),
+		DiscountType:  discountType,
+		DiscountValue: discountValue,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+}
+
+func NewPromoRedemption(promoCodeID, userID, rideID string, discount float64, createdAt time.Time) *PromoRedemption {
+	return &PromoRedemption{
+		ID:          uuid.New().String(),
+		PromoCodeID: promoCodeID,
+		UserID:      userID,
+		RideID:      rideID,
+		Discount:    discount,
+		CreatedAt:   createdAt,
+	}
+}
+
+// NormalizePromoCode makes codes case-insensitive
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// IsValidAt reports whether t falls within the code's validity window.
+func (p *PromoCode) IsValidAt(t time.Time) bool {
+	if p.StartsAt != nil && t.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && !t.Before(*p.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// IsExhausted reports whether the code has no uses left.
+func (p *PromoCode) IsExhausted() bool {
+	return p.MaxUses > 0 && p.UsedCount >= p.MaxUses
+}
+
+// AppliesTo reports whether the code may be used on a ride with the given
+// vehicle type.
+func (p *PromoCode) AppliesTo(vehicleType VehicleType) bool {
+	return p.VehicleType == "" || p.VehicleType == vehicleType
+}
+
+// DiscountOn returns the discount the code gives on amount, rounded to
+// cents. It never exceeds amount.
+func (p *PromoCode) DiscountOn(amount float64) float64 {
+	var discount float64
+	switch p.DiscountType {
+	case DiscountTypePercentage:
+		discount = amount * p.DiscountValue / 100
+		if p.MaxDiscount > 0 {
+			discount = math.Min(discount, p.MaxDiscount)
+		}
+	case DiscountTypeFlat:
+		discount = p.DiscountValue
+	}
+	discount = math.Min(math.Max(discount, 0), amount)
+	return math.Round(discount*100) / 100
+}
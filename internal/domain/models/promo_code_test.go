@@ -0,0 +1,52 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromoCodeDiscountOn(t *testing.T) {
+	tests := []struct {
+		name     string
+		promo    PromoCode
+		amount   float64
+		expected float64
+	}{
+		{name: "Percentage", promo: PromoCode{DiscountType: DiscountTypePercentage, DiscountValue: 15}, amount: 280, expected: 42},
+		{name: "Percentage rounded to cents", promo: PromoCode{DiscountType: DiscountTypePercentage, DiscountValue: 10}, amount: 147.33, expected: 14.73},
+		{name: "Percentage capped", promo: PromoCode{DiscountType: DiscountTypePercentage, DiscountValue: 50, MaxDiscount: 100}, amount: 280, expected: 100},
+		{name: "Flat", promo: PromoCode{DiscountType: DiscountTypeFlat, DiscountValue: 50}, amount: 280, expected: 50},
+		{name: "Flat above the fare", promo: PromoCode{DiscountType: DiscountTypeFlat, DiscountValue: 500}, amount: 280, expected: 280},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.promo.DiscountOn(tt.amount))
+		})
+	}
+}
+
+func TestPromoCodeRestrictions(t *testing.T) {
+	start := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	promo := NewPromoCode(" spring24 ", DiscountTypeFlat, 20)
+	promo.StartsAt = &start
+	promo.EndsAt = &end
+	promo.MaxUses = 2
+	promo.VehicleType = VehicleTypeBike
+
+	assert.Equal(t, "SPRING24", promo.Code)
+
+	assert.False(t, promo.IsValidAt(start.Add(-time.Second)))
+	assert.True(t, promo.IsValidAt(start))
+	assert.False(t, promo.IsValidAt(end))
+
+	assert.True(t, promo.AppliesTo(VehicleTypeBike))
+	assert.False(t, promo.AppliesTo(VehicleTypeCar))
+
+	assert.False(t, promo.IsExhausted())
+	promo.UsedCount = 2
+	assert.True(t, promo.IsExhausted())
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RatingRole string
+
+const (
+	// RatingRoleDriver marks a rating a rider gave their driver
+	RatingRoleDriver RatingRole = "driver"
+	// RatingRoleRider marks a rating a driver gave their rider
+	RatingRoleRider RatingRole = "rider"
+)
+
+const (
+	MinRatingStars = 1
+	MaxRatingStars = 5
+	// MaxRatingTags caps how many tags one rating may carry
+	MaxRatingTags   = 5
+	maxRatingTagLen = 32
+)
+
+// Rating is one participant's review of the other after a ride. RaterID
+// and RateeID are user IDs; RateeRole is the role the rated user had on
+// the ride. Each participant rates a ride at most once.
+type Rating struct {
+	ID        string     `json:"id" gorm:"primaryKey;type:uuid"`
+	RideID    string     `json:"ride_id" gorm:"type:uuid;not null;uniqueIndex:idx_ratings_ride_rater,priority:1"`
+	RaterID   string     `json:"rater_id" gorm:"type:uuid;not null;uniqueIndex:idx_ratings_ride_rater,priority:2;index"`
+	RateeID   string     `json:"ratee_id" gorm:"type:uuid;not null;index:idx_ratings_ratee,priority:1"`
+	RateeRole RatingRole `json:"ratee_role" gorm:"size:10;not null;index:idx_ratings_ratee,priority:2"`
+	Stars     int        `json:"stars" gorm:"not null"`
+	Comment   string     `json:"comment,omitempty" gorm:"size:500"`
+	Tags      []string   `json:"tags,omitempty" gorm:"type:jsonb;serializer:json"`
+	CreatedAt time.Time  `json:"created_at" gorm:"not null;index:idx_ratings_ratee,priority:3"`
+}
+
+func NewRating(rideID, raterID, rateeID string, rateeRole RatingRole, stars int, comment string, tags []string) *Rating {
+	return &Rating{
+		ID:        uuid.New().String(),
+		RideID:    rideID,
+		RaterID:   raterID,
+		RateeID:   rateeID,
+		RateeRole: rateeRole,
+		Stars:     stars,
+		Comment:   strings.TrimSpace(comment),
+		Tags:      NormalizeRatingTags(tags),
+		CreatedAt: time.Now(),
+	}
+}
+
+// NormalizeRatingTags lowercases tags and drops blanks and duplicates
+func NormalizeRatingTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// IsValid reports whether the stars are in range and the tags within
+// limits
+func (r *Rating) IsValid() bool {
+	if r.Stars < MinRatingStars || r.Stars > MaxRatingStars || len(r.Tags) > MaxRatingTags {
+		return false
+	}
+	for _, tag := range r.Tags {
+		if len(tag) > maxRatingTagLen {
+			return false
+		}
+	}
+	return true
+}
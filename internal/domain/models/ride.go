@@ -0,0 +1,252 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sayeed1999/share-a-ride/internal/pkg/pricing"
+)
+
+type RideStatus string
+
+const (
+	RideStatusScheduled     RideStatus = "scheduled"
+	RideStatusRequested     RideStatus = "requested"
+	RideStatusAccepted      RideStatus = "accepted"
+	RideStatusDriverArrived RideStatus = "driver_arrived"
+	RideStatusInProgress    RideStatus = "in_progress"
+	RideStatusCompleted     RideStatus = "completed"
+	RideStatusCancelled     RideStatus = "cancelled"
+)
+
+// rideTransitions lists the statuses a ride may move to from each status.
+// Completed and cancelled are terminal.
+var rideTransitions = map[RideStatus][]RideStatus{
+	RideStatusScheduled:     {RideStatusRequested, RideStatusCancelled},
+	RideStatusRequested:     {RideStatusAccepted, RideStatusCancelled},
+	RideStatusAccepted:      {RideStatusDriverArrived, RideStatusCancelled},
+	RideStatusDriverArrived: {RideStatusInProgress, RideStatusCancelled},
+	RideStatusInProgress:    {RideStatusCompleted},
+}
+
+// ActiveRideStatuses are the statuses of a ride that has not finished yet.
+// Scheduled rides are not active until they are released for dispatch.
+var ActiveRideStatuses = []RideStatus{
+	RideStatusRequested,
+	RideStatusAccepted,
+	RideStatusDriverArrived,
+	RideStatusInProgress,
+}
+
+// CanTransitionTo reports whether a ride in status s may move to next.
+func (s RideStatus) CanTransitionTo(next RideStatus) bool {
+	for _, allowed := range rideTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal reports whether no further transitions are possible from s.
+func (s RideStatus) IsTerminal() bool {
+	return len(rideTransitions[s]) == 0
+}
+
+// Waypoint is a stop the rider asked for between pickup and dropoff.
+// AddedInTrip marks a stop added after the trip started.
+type Waypoint struct {
+	ID          string    `json:"id"`
+	Location    Location  `json:"location"`
+	AddedInTrip bool      `json:"added_in_trip"`
+	AddedAt     time.Time `json:"added_at"`
+}
+
+// Ride is a trip from request to completion or cancellation.
+type Ride struct {
+	ID       string  `json:"id" gorm:"primaryKey;type:uuid"`
+	RiderID  string  `json:"rider_id" gorm:"type:uuid;not null;index"`
+	Rider    *User   `json:"rider,omitempty" gorm:"foreignKey:RiderID"`
+	DriverID *string `json:"driver_id,omitempty" gorm:"type:uuid;index"`
+	Driver   *Driver `json:"driver,omitempty" gorm:"foreignKey:DriverID"`
+	// VehicleID is the vehicle the driver accepted the ride with
+	VehicleID       *string  `json:"vehicle_id,omitempty" gorm:"type:uuid"`
+	PickupLocation  Location `json:"pickup_location" gorm:"embedded;embeddedPrefix:pickup_"`
+	DropoffLocation Location `json:"dropoff_location" gorm:"embedded;embeddedPrefix:dropoff_"`
+	// Waypoints are the stops between pickup and dropoff in the order the
+	// driver serves them
+	Waypoints   []Waypoint  `json:"waypoints" gorm:"type:jsonb;serializer:json"`
+	VehicleType VehicleType `json:"vehicle_type,omitempty" gorm:"size:20"`
+	Status      RideStatus  `json:"status" gorm:"size:20;not null;index"`
+	// Fare is what the rider paid after the promo Discount
+	Fare float64 `json:"fare" gorm:"type:decimal(10,2);default:0"`
+	// SurgeMultiplier is the surge quoted at the pickup point when the ride
+	// was requested
+	SurgeMultiplier float64 `json:"surge_multiplier" gorm:"type:decimal(4,2);not null;default:1"`
+	// PromoCodeID is the code the rider booked with, redeemed when the ride
+	// completes
+	PromoCodeID   *string       `json:"promo_code_id,omitempty" gorm:"type:uuid"`
+	Discount      float64       `json:"discount" gorm:"type:decimal(10,2);not null;default:0"`
+	PaymentMethod PaymentMethod `json:"payment_method" gorm:"size:20;not null;default:card"`
+	// DistanceKm is the distance charged for a completed ride and
+	// FareBreakdown itemises its fare for the receipt
+	DistanceKm         float64            `json:"distance_km,omitempty" gorm:"type:decimal(8,2)"`
+	FareBreakdown      *pricing.Breakdown `json:"fare_breakdown,omitempty" gorm:"type:jsonb;serializer:json"`
+	CancellationReason string             `json:"cancellation_reason,omitempty" gorm:"size:255"`
+	CancelledBy        *string            `json:"cancelled_by,omitempty" gorm:"type:uuid"`
+	// ScheduledAt is the pickup time of a ride booked in advance and
+	// ReminderSentAt when its rider was reminded of it
+	ScheduledAt    *time.Time `json:"scheduled_at,omitempty" gorm:"index"`
+	ReminderSentAt *time.Time `json:"reminder_sent_at,omitempty"`
+	// Pooled rides share their driver with other riders going the same way;
+	// Seats is the size of the rider's party and PoolID the shared trip the
+	// ride joined
+	Pooled      bool       `json:"pooled" gorm:"not null;default:false"`
+	Seats       int        `json:"seats" gorm:"not null;default:1"`
+	PoolID      *string    `json:"pool_id,omitempty" gorm:"type:uuid;index"`
+	AcceptedAt  *time.Time `json:"accepted_at,omitempty"`
+	ArrivedAt   *time.Time `json:"arrived_at,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"not null;index"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"not null"`
+}
+
+func NewRide(riderID string, pickup, dropoff Location, at time.Time) *Ride {
+	return &Ride{
+		ID:              uuid.New().String(),
+		RiderID:         riderID,
+		PickupLocation:  pickup,
+		DropoffLocation: dropoff,
+		Status:          RideStatusRequested,
+		SurgeMultiplier: 1,
+		PaymentMethod:   PaymentMethodCard,
+		Seats:           1,
+		CreatedAt:       at,
+		UpdatedAt:       at,
+	}
+}
+
+// Schedule books the ride for pickup at pickupAt instead of dispatching it
+// now.
+func (r *Ride) Schedule(pickupAt, at time.Time) {
+	r.Status = RideStatusScheduled
+	r.ScheduledAt = &pickupAt
+	r.UpdatedAt = at
+}
+
+// IsScheduled reports whether the ride was booked in advance.
+func (r *Ride) IsScheduled() bool {
+	return r.ScheduledAt != nil
+}
+
+// The methods below apply a transition at the given time without checking
+// it; callers are expected to consult CanTransitionTo first.
+
+// Release hands a scheduled ride over to dispatch.
+func (r *Ride) Release(at time.Time) {
+	r.Status = RideStatusRequested
+	r.UpdatedAt = at
+}
+
+func (r *Ride) Accept(driverID string, at time.Time) {
+	r.DriverID = &driverID
+	r.Status = RideStatusAccepted
+	r.AcceptedAt = &at
+	r.UpdatedAt = at
+}
+
+func (r *Ride) MarkDriverArrived(at time.Time) {
+	r.Status = RideStatusDriverArrived
+	r.ArrivedAt = &at
+	r.UpdatedAt = at
+}
+
+func (r *Ride) Start(at time.Time) {
+	r.Status = RideStatusInProgress
+	r.StartedAt = &at
+	r.UpdatedAt = at
+}
+
+// Complete charges fare less discount for the ride.
+func (r *Ride) Complete(fare, discount float64, at time.Time) {
+	r.Status = RideStatusCompleted
+	r.Fare = fare - discount
+	r.Discount = discount
+	r.CompletedAt = &at
+	r.UpdatedAt = at
+}
+
+// Cancel records who cancelled the ride; an empty cancelledBy means the
+// system cancelled it, e.g. because no driver could be found.
+func (r *Ride) Cancel(cancelledBy, reason string, at time.Time) {
+	r.Status = RideStatusCancelled
+	if cancelledBy != "" {
+		r.CancelledBy = &cancelledBy
+	}
+	r.CancellationReason = reason
+	r.CancelledAt = &at
+	r.UpdatedAt = at
+}
+
+// Route returns the pickup, the waypoints in order and the dropoff.
+func (r *Ride) Route() []Location {
+	route := make([]Location, 0, len(r.Waypoints)+2)
+	route = append(route, r.PickupLocation)
+	for _, waypoint := range r.Waypoints {
+		route = append(route, waypoint.Location)
+	}
+	return append(route, r.DropoffLocation)
+}
+
+// AddWaypoint inserts a stop at location before the waypoint at position,
+// or after the last one when position is out of range. Stops added during
+// the trip always go last, as the driver may already have passed the
+// others.
+func (r *Ride) AddWaypoint(location Location, position int, at time.Time) Waypoint {
+	waypoint := Waypoint{
+		ID:          uuid.New().String(),
+		Location:    location,
+		AddedInTrip: r.Status == RideStatusInProgress,
+		AddedAt:     at,
+	}
+	if position < 0 || position > len(r.Waypoints) || waypoint.AddedInTrip {
+		position = len(r.Waypoints)
+	}
+	waypoints := make([]Waypoint, 0, len(r.Waypoints)+1)
+	waypoints = append(waypoints, r.Waypoints[:position]...)
+	waypoints = append(waypoints, waypoint)
+	r.Waypoints = append(waypoints, r.Waypoints[position:]...)
+	r.UpdatedAt = at
+	return waypoint
+}
+
+// RemoveWaypoint drops the stop with id. It reports false when the ride
+// has no such stop.
+func (r *Ride) RemoveWaypoint(id string, at time.Time) bool {
+	for i, waypoint := range r.Waypoints {
+		if waypoint.ID == id {
+			r.Waypoints = append(r.Waypoints[:i:i], r.Waypoints[i+1:]...)
+			r.UpdatedAt = at
+			return true
+		}
+	}
+	return false
+}
+
+// HasTripWaypoint reports whether a stop was added after the trip started.
+func (r *Ride) HasTripWaypoint() bool {
+	for _, waypoint := range r.Waypoints {
+		if waypoint.AddedInTrip {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAssignedTo reports whether driverID is the driver on this ride.
+func (r *Ride) IsAssignedTo(driverID string) bool {
+	return r.DriverID != nil && *r.DriverID == driverID
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RideOfferStatus string
+
+const (
+	RideOfferStatusPending  RideOfferStatus = "pending"
+	RideOfferStatusAccepted RideOfferStatus = "accepted"
+	RideOfferStatusDeclined RideOfferStatus = "declined"
+	RideOfferStatusExpired  RideOfferStatus = "expired"
+)
+
+// RideOffer is a single attempt to hand a requested ride to one driver.
+// Vehicle is the driver's active vehicle when the offer was made.
+type RideOffer struct {
+	ID          string          `json:"id" gorm:"primaryKey;type:uuid"`
+	RideID      string          `json:"ride_id" gorm:"type:uuid;not null;index"`
+	Ride        *Ride           `json:"ride,omitempty" gorm:"foreignKey:RideID"`
+	DriverID    string          `json:"driver_id" gorm:"type:uuid;not null;index"`
+	VehicleID   *string         `json:"vehicle_id,omitempty" gorm:"type:uuid"`
+	Vehicle     *Vehicle        `json:"vehicle,omitempty" gorm:"foreignKey:VehicleID"`
+	Status      RideOfferStatus `json:"status" gorm:"size:20;not null"`
+	DistanceKm  float64         `json:"distance_km" gorm:"type:decimal(8,3)"`
+	ExpiresAt   time.Time       `json:"expires_at" gorm:"not null"`
+	RespondedAt *time.Time      `json:"responded_at,omitempty"`
+	CreatedAt   time.Time       `json:"created_at" gorm:"not null;index"`
+}
+
+func NewRideOffer(rideID string, driver *Driver, distanceKm float64, createdAt, expiresAt time.Time) *RideOffer {
+	offer := &RideOffer{
+		ID:         uuid.New().String(),
+		RideID:     rideID,
+		DriverID:   driver.ID,
+		Status:     RideOfferStatusPending,
+		DistanceKm: distanceKm,
+		ExpiresAt:  expiresAt,
+		CreatedAt:  createdAt,
+	}
+	if driver.ActiveVehicle != nil {
+		offer.VehicleID = &driver.ActiveVehicle.ID
+	}
+	return offer
+}
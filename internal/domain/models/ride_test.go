@@ -0,0 +1,85 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRideStatusCanTransitionTo(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     RideStatus
+		to       RideStatus
+		expected bool
+	}{
+		{name: "scheduled to requested", from: RideStatusScheduled, to: RideStatusRequested, expected: true},
+		{name: "scheduled to cancelled", from: RideStatusScheduled, to: RideStatusCancelled, expected: true},
+		{name: "scheduled to accepted", from: RideStatusScheduled, to: RideStatusAccepted, expected: false},
+		{name: "requested to accepted", from: RideStatusRequested, to: RideStatusAccepted, expected: true},
+		{name: "requested to cancelled", from: RideStatusRequested, to: RideStatusCancelled, expected: true},
+		{name: "requested to in progress", from: RideStatusRequested, to: RideStatusInProgress, expected: false},
+		{name: "accepted to driver arrived", from: RideStatusAccepted, to: RideStatusDriverArrived, expected: true},
+		{name: "accepted to completed", from: RideStatusAccepted, to: RideStatusCompleted, expected: false},
+		{name: "driver arrived to in progress", from: RideStatusDriverArrived, to: RideStatusInProgress, expected: true},
+		{name: "in progress to completed", from: RideStatusInProgress, to: RideStatusCompleted, expected: true},
+		{name: "in progress to cancelled", from: RideStatusInProgress, to: RideStatusCancelled, expected: false},
+		{name: "completed is terminal", from: RideStatusCompleted, to: RideStatusCancelled, expected: false},
+		{name: "cancelled is terminal", from: RideStatusCancelled, to: RideStatusAccepted, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.from.CanTransitionTo(tt.to))
+		})
+	}
+}
+
+func TestRideLifecycle(t *testing.T) {
+	now := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	ride := NewRide("rider-1", Location{Latitude: 23.81, Longitude: 90.41}, Location{Latitude: 23.75, Longitude: 90.39}, now)
+	assert.Equal(t, RideStatusRequested, ride.Status)
+	assert.False(t, ride.IsAssignedTo("driver-1"))
+
+	ride.Accept("driver-1", now)
+	assert.Equal(t, RideStatusAccepted, ride.Status)
+	assert.True(t, ride.IsAssignedTo("driver-1"))
+	assert.NotNil(t, ride.AcceptedAt)
+
+	ride.MarkDriverArrived(now)
+	ride.Start(now)
+	assert.NotNil(t, ride.ArrivedAt)
+	assert.NotNil(t, ride.StartedAt)
+
+	completedAt := now.Add(20 * time.Minute)
+	ride.Complete(12.5, 2.5, completedAt)
+	assert.Equal(t, RideStatusCompleted, ride.Status)
+	assert.Equal(t, 10.0, ride.Fare)
+	assert.Equal(t, 2.5, ride.Discount)
+	assert.Equal(t, &completedAt, ride.CompletedAt)
+	assert.Equal(t, completedAt, ride.UpdatedAt)
+	assert.True(t, ride.Status.IsTerminal())
+}
+
+func TestRideWaypoints(t *testing.T) {
+	pickup, dropoff := Location{Latitude: 23.81, Longitude: 90.41}, Location{Latitude: 23.75, Longitude: 90.39}
+	friend, pharmacy := Location{Latitude: 23.80, Longitude: 90.40}, Location{Latitude: 23.78, Longitude: 90.40}
+	now := time.Now()
+
+	ride := NewRide("rider-1", pickup, dropoff, now)
+	last := ride.AddWaypoint(pharmacy, 0, now)
+	first := ride.AddWaypoint(friend, 0, now)
+	assert.Equal(t, []Location{pickup, friend, pharmacy, dropoff}, ride.Route())
+	assert.False(t, ride.HasTripWaypoint())
+
+	assert.True(t, ride.RemoveWaypoint(first.ID, now))
+	assert.False(t, ride.RemoveWaypoint(first.ID, now))
+	assert.Equal(t, []Location{pickup, pharmacy, dropoff}, ride.Route())
+
+	ride.Status = RideStatusInProgress
+	ride.AddWaypoint(friend, 0, now)
+	assert.Equal(t, last.ID, ride.Waypoints[0].ID)
+	assert.True(t, ride.Waypoints[1].AddedInTrip)
+	assert.True(t, ride.HasTripWaypoint())
+}
@@ -12,17 +12,24 @@ type UserType string
 const (
 	UserTypeRider  UserType = "rider"
 	UserTypeDriver UserType = "driver"
+	// UserTypeAdmin is for operations staff; admins cannot sign up through
+	// the API and are created directly in the database
+	UserTypeAdmin UserType = "admin"
 )
 
+// User is anyone with an account. RatingAverage and RatingCount are the
+// ratings the user received from drivers as a rider.
 type User struct {
-	ID        string    `json:"id" gorm:"primaryKey;type:uuid"`
-	Name      string    `json:"name" gorm:"size:100;not null"`
-	Email     string    `json:"email" gorm:"size:255;not null;unique"`
-	Phone     string    `json:"phone" gorm:"size:20;not null;unique"`
-	Password  string    `json:"-" gorm:"size:255;not null"`
-	UserType  UserType  `json:"user_type" gorm:"size:10;not null"`
-	CreatedAt time.Time `json:"created_at" gorm:"not null"`
-	UpdatedAt time.Time `json:"updated_at" gorm:"not null"`
+	ID            string    `json:"id" gorm:"primaryKey;type:uuid"`
+	Name          string    `json:"name" gorm:"size:100;not null"`
+	Email         string    `json:"email" gorm:"size:255;not null;unique"`
+	Phone         string    `json:"phone" gorm:"size:20;not null;unique"`
+	Password      string    `json:"-" gorm:"size:255;not null"`
+	UserType      UserType  `json:"user_type" gorm:"size:10;not null"`
+	RatingAverage float64   `json:"rating_average" gorm:"type:decimal(3,2);not null;default:0"`
+	RatingCount   int       `json:"rating_count" gorm:"not null;default:0"`
+	CreatedAt     time.Time `json:"created_at" gorm:"not null"`
+	UpdatedAt     time.Time `json:"updated_at" gorm:"not null"`
 }
 
 func NewUser(name, email, phone, password string, userType UserType) (*User, error) {
@@ -55,3 +62,7 @@ func (u *User) IsDriver() bool {
 func (u *User) IsRider() bool {
 	return u.UserType == UserTypeRider
 }
+
+func (u *User) IsAdmin() bool {
+	return u.UserType == UserTypeAdmin
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type VehicleType string
+
+const (
+	VehicleTypeCar  VehicleType = "car"
+	VehicleTypeBike VehicleType = "bike"
+)
+
+// defaultPassengerSeats is assumed for vehicles registered without a seat
+// count
+var defaultPassengerSeats = map[VehicleType]int{
+	VehicleTypeCar:  3,
+	VehicleTypeBike: 1,
+}
+
+// Vehicle is a vehicle a driver registered. Seats is the number of
+// passenger seats, which bounds how many pooled riders it can carry. A
+// driver has at most one active vehicle, the one they drive when online.
+type Vehicle struct {
+	ID          string      `json:"id" gorm:"primaryKey;type:uuid"`
+	DriverID    string      `json:"driver_id" gorm:"type:uuid;not null;index;uniqueIndex:idx_vehicles_active_driver,where:is_active = true"`
+	Type        VehicleType `json:"type" gorm:"size:20;not null"`
+	Model       string      `json:"model" gorm:"size:100;not null"`
+	PlateNumber string      `json:"plate_number" gorm:"size:20;not null;uniqueIndex"`
+	Seats       int         `json:"seats" gorm:"not null;default:0"`
+	Colour      string      `json:"colour,omitempty" gorm:"size:30"`
+	Year        int         `json:"year,omitempty"`
+	IsActive    bool        `json:"is_active" gorm:"not null;default:false"`
+	CreatedAt   time.Time   `json:"created_at" gorm:"not null"`
+	UpdatedAt   time.Time   `json:"updated_at" gorm:"not null"`
+}
+
+// NewVehicle registers a vehicle with the details of v to a driver. The
+// vehicle is not active.
+func NewVehicle(driverID string, v Vehicle) *Vehicle {
+	now := time.Now()
+	v.ID = uuid.New().String()
+	v.DriverID = driverID
+	v.IsActive = false
+	v.CreatedAt = now
+	v.UpdatedAt = now
+	return &v
+}
+
+// PassengerSeats returns Seats, or the usual number for the vehicle type
+// when none was given.
+func (v Vehicle) PassengerSeats() int {
+	if v.Seats > 0 {
+		return v.Seats
+	}
+	return defaultPassengerSeats[v.Type]
+}
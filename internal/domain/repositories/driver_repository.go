@@ -2,10 +2,29 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/sayeed1999/share-a-ride/internal/domain/models"
 )
 
+// NearbyDriverQuery selects available, verified drivers around a point.
+// A zero Limit returns every match; an empty VehicleType matches any.
+type NearbyDriverQuery struct {
+	Latitude    float64
+	Longitude   float64
+	RadiusKm    float64
+	Limit       int
+	VehicleType models.VehicleType
+}
+
+// VerificationQueueFilter selects the drivers in one verification status.
+// Page is 1-based.
+type VerificationQueueFilter struct {
+	Status models.VerificationStatus
+	Page   int
+	Limit  int
+}
+
 type DriverRepository interface {
 	Create(ctx context.Context, driver *models.Driver) error
 	FindByID(ctx context.Context, id string) (*models.Driver, error)
@@ -14,13 +33,64 @@ type DriverRepository interface {
 	Update(ctx context.Context, driver *models.Driver) error
 	Delete(ctx context.Context, id string) error
 
+	// Verification
+	// ListByVerificationStatus returns matching drivers with their user and
+	// documents, longest waiting first, and the total number of matches
+	ListByVerificationStatus(ctx context.Context, filter VerificationQueueFilter) ([]models.Driver, int64, error)
+	// UpdateVerification persists the driver's verification and
+	// availability only if its stored verification status still equals
+	// expected
+	UpdateVerification(ctx context.Context, driver *models.Driver, expected models.VerificationStatus) error
+	// Resubmit replaces the driver's licence, active vehicle and documents and
+	// persists its verification, only if its stored verification status
+	// still equals expected. It returns the documents it removed.
+	Resubmit(ctx context.Context, driver *models.Driver, expected models.VerificationStatus) ([]models.Document, error)
+	// Suspend persists the driver's verification and availability and
+	// records entry, only if its stored verification status still equals
+	// expected
+	Suspend(ctx context.Context, driver *models.Driver, expected models.VerificationStatus, entry *models.AuditEntry) error
+	// FindApprovedWithLapsedDocuments returns approved drivers, with their
+	// documents, who hold a document that expired before day and no
+	// unexpired document of the same type
+	FindApprovedWithLapsedDocuments(ctx context.Context, day time.Time) ([]models.Driver, error)
+
+	// Vehicle related operations
+	AddVehicle(ctx context.Context, vehicle *models.Vehicle) error
+	FindVehicleByPlateNumber(ctx context.Context, plateNumber string) (*models.Vehicle, error)
+	// ActivateVehicle makes the driver's vehicle vehicleID their only
+	// active vehicle, returning ErrVehicleNotFound if the driver has no
+	// such vehicle. If driver is not nil its verification is persisted
+	// too, as in SaveDocument
+	ActivateVehicle(ctx context.Context, driverID string, vehicleID string, at time.Time, driver *models.Driver, expected models.VerificationStatus) error
+
 	// Document related operations
 	AddDocument(ctx context.Context, document *models.Document) error
+	// SaveDocument adds document to its driver in place of the driver's
+	// document replacedID, if not empty. If driver is not nil its
+	// verification is persisted too, only if its stored verification
+	// status still equals expected
+	SaveDocument(ctx context.Context, document *models.Document, replacedID string, driver *models.Driver, expected models.VerificationStatus) error
 	GetDocuments(ctx context.Context, driverID string) ([]models.Document, error)
-	DeleteDocument(ctx context.Context, documentID string) error
+	// DeleteDocument deletes the driver's document documentID, returning
+	// ErrDocumentNotFound if the driver has no such document
+	DeleteDocument(ctx context.Context, driverID string, documentID string) error
+	// FindDocumentsExpiringBetween returns the documents expiring on a day
+	// from from to to, inclusive, of drivers who were not rejected
+	FindDocumentsExpiringBetween(ctx context.Context, from, to time.Time) ([]models.Document, error)
+	// UpdateDocumentReminder records the days before expiry of the last
+	// expiry reminder sent about a document
+	UpdateDocumentReminder(ctx context.Context, documentID string, days int) error
+	// FindStorageKeysInUse returns those of keys that a document still
+	// refers to
+	FindStorageKeysInUse(ctx context.Context, keys []string) ([]string, error)
 
 	// Location and availability
-	UpdateLocation(ctx context.Context, driverID string, lat, lng float64) error
+	UpdateLocation(ctx context.Context, driverID string, lat, lng float64, at time.Time) error
 	UpdateAvailability(ctx context.Context, driverID string, isAvailable bool) error
-	FindAvailableNearby(ctx context.Context, lat, lng float64, radiusKm float64) ([]models.Driver, error)
+	FindAllAvailable(ctx context.Context) ([]models.Driver, error)
+	// MarkStaleUnavailable takes every available driver whose location was
+	// last reported before the given time offline and returns their IDs
+	MarkStaleUnavailable(ctx context.Context, before time.Time) ([]string, error)
+	// FindAvailableNearby returns matching drivers ordered by distance, nearest first
+	FindAvailableNearby(ctx context.Context, query NearbyDriverQuery) ([]models.Driver, error)
 }
@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+)
+
+// EarningsFilter selects a driver's earnings. Nil bounds are open; From is
+// inclusive and To exclusive.
+type EarningsFilter struct {
+	DriverID string
+	From     *time.Time
+	To       *time.Time
+	// Unpaid restricts the filter to earnings not on a payout statement yet
+	Unpaid bool
+}
+
+type EarningsRepository interface {
+	// Create records an earning; a ride that already has one is ignored
+	Create(ctx context.Context, earning *models.DriverEarning) error
+	Sum(ctx context.Context, filter EarningsFilter) (*models.EarningsTotals, error)
+	// CreateStatements puts every unpaid earning before periodEnd on a
+	// statement of its driver for the period starting at periodStart.
+	// Drivers that already have a statement for the period are skipped.
+	CreateStatements(ctx context.Context, periodStart, periodEnd time.Time) ([]models.PayoutStatement, error)
+	// ListStatements returns a driver's statements, newest first, and the
+	// total count
+	ListStatements(ctx context.Context, driverID string, page, limit int) ([]models.PayoutStatement, int64, error)
+	// FindStatement returns a statement with its earnings
+	FindStatement(ctx context.Context, id string) (*models.PayoutStatement, error)
+}
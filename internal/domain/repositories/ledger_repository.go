@@ -0,0 +1,30 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+)
+
+type LedgerRepository interface {
+	// FindOrCreateAccount returns the account with the code of account,
+	// creating it from account if there is none yet
+	FindOrCreateAccount(ctx context.Context, account *models.LedgerAccount) (*models.LedgerAccount, error)
+	FindAccountByCode(ctx context.Context, code string) (*models.LedgerAccount, error)
+	// Post records a balanced entry with its postings in one transaction.
+	// It returns ErrJournalEntryExists if the idempotency key is taken.
+	Post(ctx context.Context, entry *models.JournalEntry) error
+	// PostWithinBalance records entries like Post, all or none, unless
+	// together they would take the balance of account below zero, in which
+	// case it returns ErrInsufficientBalance. The account is locked while
+	// the entries are recorded, so concurrent entries cannot overdraw it
+	// together.
+	PostWithinBalance(ctx context.Context, account *models.LedgerAccount, entries ...*models.JournalEntry) error
+	FindEntryByKey(ctx context.Context, idempotencyKey string) (*models.JournalEntry, error)
+	// SumPostings returns the sum of an account's postings; see
+	// LedgerAccount.BalanceOf
+	SumPostings(ctx context.Context, accountID string) (float64, error)
+	// ListPostings returns an account's postings with their entries, newest
+	// first, and the total count
+	ListPostings(ctx context.Context, accountID string, page, limit int) ([]models.Posting, int64, error)
+}
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+)
+
+type LocationHistoryRepository interface {
+	Create(ctx context.Context, ping *models.LocationPing) error
+	// ListByRide returns the pings recorded during a ride, oldest first
+	ListByRide(ctx context.Context, rideID string) ([]models.LocationPing, error)
+	// ListByDriver returns a driver's pings recorded in [from, to], oldest first
+	ListByDriver(ctx context.Context, driverID string, from, to time.Time) ([]models.LocationPing, error)
+	// DeleteRecordedBefore removes up to limit pings older than before and
+	// returns how many were removed
+	DeleteRecordedBefore(ctx context.Context, before time.Time, limit int) (int64, error)
+}
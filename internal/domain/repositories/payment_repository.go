@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+)
+
+type PaymentRepository interface {
+	Create(ctx context.Context, payment *models.Payment) error
+	FindByRideID(ctx context.Context, rideID string) (*models.Payment, error)
+	Update(ctx context.Context, payment *models.Payment) error
+}
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+)
+
+type PoolRepository interface {
+	// Create fails with ErrPoolConflict when the driver already has an
+	// open pool
+	Create(ctx context.Context, pool *models.Pool) error
+	FindByID(ctx context.Context, id string) (*models.Pool, error)
+	FindOpenByDriverID(ctx context.Context, driverID string) (*models.Pool, error)
+	// Update persists pool only if nobody changed it since it was loaded
+	// and bumps its version; otherwise it fails with ErrPoolConflict
+	Update(ctx context.Context, pool *models.Pool) error
+}
@@ -0,0 +1,24 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+)
+
+type PromoCodeRepository interface {
+	Create(ctx context.Context, promo *models.PromoCode) error
+	FindByID(ctx context.Context, id string) (*models.PromoCode, error)
+	FindByCode(ctx context.Context, code string) (*models.PromoCode, error)
+	List(ctx context.Context) ([]models.PromoCode, error)
+	// CountRedemptions returns how often userID has redeemed the code
+	CountRedemptions(ctx context.Context, promoCodeID string, userID string) (int64, error)
+
+	// Redeem stores redemption and counts it against the code in one
+	// transaction. It fails with ErrPromoCodeExhausted or
+	// ErrPromoCodeUserLimit when the redemption would exceed a limit, also
+	// when several redemptions race for the last use.
+	Redeem(ctx context.Context, redemption *models.PromoRedemption) error
+	// ReleaseRedemption undoes the redemption made for rideID, if any
+	ReleaseRedemption(ctx context.Context, rideID string) error
+}
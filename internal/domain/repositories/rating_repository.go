@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+)
+
+type RatingRepository interface {
+	// Create stores rating and, in the same transaction, refreshes the
+	// ratee's rating count and their average over their latest window
+	// ratings. It fails with ErrRatingExists when the rater already rated
+	// the ride.
+	Create(ctx context.Context, rating *models.Rating, window int) error
+	// FindLowRatedPartners returns the IDs of users who gave userID, or
+	// were given by userID, fewer than stars stars
+	FindLowRatedPartners(ctx context.Context, userID string, stars int) ([]string, error)
+}
@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+)
+
+type RideOfferRepository interface {
+	Create(ctx context.Context, offer *models.RideOffer) error
+	FindByID(ctx context.Context, id string) (*models.RideOffer, error)
+	// FindPendingByDriverID returns the driver's latest pending offer that
+	// is still open at now. Offers left pending past their expiry, e.g. by
+	// a dispatcher that stopped, are ignored.
+	FindPendingByDriverID(ctx context.Context, driverID string, now time.Time) (*models.RideOffer, error)
+
+	// Resolve moves a pending offer to status. It fails with
+	// ErrOfferNotPending when the offer was already resolved.
+	Resolve(ctx context.Context, offerID string, status models.RideOfferStatus, at time.Time) error
+
+	// AcceptanceRates returns, per driver, the share of offers made since
+	// the given time that the driver accepted. Drivers without resolved
+	// offers in that window are absent from the result.
+	AcceptanceRates(ctx context.Context, driverIDs []string, since time.Time) (map[string]float64, error)
+}
@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+)
+
+// RideFilter narrows a ride listing. Zero values mean "no filter"; Page is
+// 1-based.
+type RideFilter struct {
+	RiderID  string
+	DriverID string
+	Statuses []models.RideStatus
+	From     *time.Time
+	To       *time.Time
+	Page     int
+	Limit    int
+}
+
+type RideRepository interface {
+	Create(ctx context.Context, ride *models.Ride) error
+	FindByID(ctx context.Context, id string) (*models.Ride, error)
+	FindActiveByRiderID(ctx context.Context, riderID string) (*models.Ride, error)
+	FindActiveByDriverID(ctx context.Context, driverID string) (*models.Ride, error)
+	// ListActiveByDriverID returns all of a driver's active rides, which
+	// are several when the driver carries a pool
+	ListActiveByDriverID(ctx context.Context, driverID string) ([]models.Ride, error)
+	List(ctx context.Context, filter RideFilter) ([]models.Ride, int64, error)
+	// FindOpenRequests returns rides still waiting for a driver
+	FindOpenRequests(ctx context.Context) ([]models.Ride, error)
+	// FindScheduledBefore returns scheduled rides due for pickup at or
+	// before the given time, earliest first
+	FindScheduledBefore(ctx context.Context, before time.Time) ([]models.Ride, error)
+	// FindUncharged returns completed and cancelled rides last updated
+	// before the given time whose fare or cancellation fee was not
+	// collected: card rides whose payment was not captured, and wallet
+	// rides not charged or whose hold was not released
+	FindUncharged(ctx context.Context, before time.Time) ([]models.Ride, error)
+
+	// UpdateStatus persists ride only if its stored status still equals
+	// expected, so two concurrent transitions cannot both succeed.
+	UpdateStatus(ctx context.Context, ride *models.Ride, expected models.RideStatus) error
+	// UpdateWaypoints persists the ride's waypoints only if its stored
+	// status still equals expected. Status updates leave the waypoints
+	// alone, so they cannot undo a change made in the meantime.
+	UpdateWaypoints(ctx context.Context, ride *models.Ride, expected models.RideStatus) error
+}
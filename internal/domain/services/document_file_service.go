@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+)
+
+// UploadedFile is a stored document file that drivers refer to by FileKey
+// when submitting documents.
+type UploadedFile struct {
+	FileKey      string
+	ContentType  string
+	SizeBytes    int64
+	Checksum     string
+	URL          string
+	URLExpiresAt time.Time
+}
+
+type DocumentFileService interface {
+	// Upload checks that file is a PDF, JPG or PNG within the size limit
+	// and stores it for userID
+	Upload(ctx context.Context, userID string, file io.Reader) (*UploadedFile, error)
+	// Resolve returns the file userID uploaded under fileKey
+	Resolve(ctx context.Context, userID string, fileKey string) (*models.DocumentFile, error)
+	// SignURLs sets the FileURL of stored documents to a signed download
+	// URL
+	SignURLs(ctx context.Context, documents []models.Document) error
+	// Discard deletes the files stored under keys that no document refers
+	// to anymore
+	Discard(ctx context.Context, keys []string) error
+	// SweepUploads deletes uploaded files that no document refers to once
+	// their upload URL has expired, and returns how many were deleted
+	SweepUploads(ctx context.Context) (int, error)
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"time"
 
 	"github.com/sayeed1999/share-a-ride/internal/domain/models"
 )
@@ -12,9 +13,15 @@ type VerifyDriverInput struct {
 	Documents     []DocumentInput
 }
 
+// DocumentInput refers to a file uploaded with DocumentFileService.
+// ExpiresOn is required for document types that expire. VehicleID names
+// the vehicle of a vehicle document; it defaults to the active vehicle.
 type DocumentInput struct {
-	Type    models.DocumentType
-	FileURL string
+	Type      models.DocumentType
+	FileKey   string
+	VehicleID string
+	IssuedOn  *time.Time
+	ExpiresOn *time.Time
 }
 
 type UpdateLocationInput struct {
@@ -22,15 +29,45 @@ type UpdateLocationInput struct {
 	Longitude float64
 }
 
+// DriverLocator finds available, verified drivers around a point
+type DriverLocator interface {
+	// NearbyDrivers returns up to k drivers within radiusKm, nearest first;
+	// a k of zero or less returns all of them
+	NearbyDrivers(ctx context.Context, lat, lng, radiusKm float64, k int) ([]models.Driver, error)
+}
+
 type DriverService interface {
+	DriverLocator
+
 	VerifyDriver(ctx context.Context, userID string, input VerifyDriverInput) (*models.Driver, error)
 	UpdateLocation(ctx context.Context, driverID string, input UpdateLocationInput) error
 	UpdateAvailability(ctx context.Context, driverID string, isAvailable bool) error
+	// ExpireStaleLocations marks drivers whose last location is too old
+	// unavailable and returns how many were affected
+	ExpireStaleLocations(ctx context.Context) (int, error)
 	GetDriverProfile(ctx context.Context, driverID string) (*models.Driver, error)
 	GetDriverByUserID(ctx context.Context, userID string) (*models.Driver, error)
 
 	// Document management
-	AddDocument(ctx context.Context, driverID string, input DocumentInput) error
+	// AddDocument adds a document to the driver. Approved drivers adding a
+	// required document go back in the review queue.
+	AddDocument(ctx context.Context, driverID string, input DocumentInput) (*models.Document, error)
+	// ReplaceDocument replaces one of the driver's documents with a new
+	// file of the same type, ignoring input.Type. Approved drivers
+	// replacing a required document go back in the review queue.
+	ReplaceDocument(ctx context.Context, driverID string, documentID string, input DocumentInput) (*models.Document, error)
 	GetDocuments(ctx context.Context, driverID string) ([]models.Document, error)
+	// DeleteDocument deletes one of the driver's documents. The last
+	// document of a required type cannot be deleted; it can be replaced.
 	DeleteDocument(ctx context.Context, driverID string, documentID string) error
+
+	// Vehicle management
+	AddVehicle(ctx context.Context, driverID string, vehicle models.Vehicle) (*models.Vehicle, error)
+	ListVehicles(ctx context.Context, driverID string) ([]models.Vehicle, error)
+	// ActivateVehicle makes one of the driver's vehicles the one they
+	// drive. Drivers switch vehicles while offline, to a vehicle with a
+	// valid registration and insurance.
+	// An approved driver goes back in the review queue if the vehicle has
+	// documents added since their approval.
+	ActivateVehicle(ctx context.Context, driverID string, vehicleID string) (*models.Vehicle, error)
 }
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+)
+
+// ListPayoutStatementsInput paginates a driver's payout statements. Page is
+// 1-based; zero values fall back to service defaults.
+type ListPayoutStatementsInput struct {
+	Page  int
+	Limit int
+}
+
+// DriverEarnings is a driver's earnings overview. CurrentWeek covers the
+// week in progress and Unpaid everything not on a statement yet.
+type DriverEarnings struct {
+	CommissionRate float64
+	CurrentWeek    models.EarningsTotals
+	Unpaid         models.EarningsTotals
+	Lifetime       models.EarningsTotals
+	Statements     []models.PayoutStatement
+	Total          int64
+	Page           int
+	Limit          int
+	HasMore        bool
+}
+
+type EarningsService interface {
+	// RecordRide splits a completed ride's fare into commission and driver
+	// earnings. Recording a ride twice has no effect.
+	RecordRide(ctx context.Context, ride *models.Ride) error
+	GetDriverEarnings(ctx context.Context, driverID string, input ListPayoutStatementsInput) (*DriverEarnings, error)
+	// GetStatement returns one of the driver's statements with its rides
+	GetStatement(ctx context.Context, driverID string, statementID string) (*models.PayoutStatement, error)
+	// RunPayouts creates the statements of the week containing weekOf,
+	// which must have ended. Running a week again only creates statements
+	// for drivers that had none.
+	RunPayouts(ctx context.Context, weekOf time.Time) ([]models.PayoutStatement, error)
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"context"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+)
+
+// MatchingService finds a driver for a requested ride by offering it to
+// nearby drivers one at a time.
+type MatchingService interface {
+	// Dispatch blocks until a driver accepts ride, every candidate has been
+	// tried or ctx is done.
+	Dispatch(ctx context.Context, ride *models.Ride) (*models.Ride, error)
+
+	// StartDispatch runs Dispatch in the background; StopDispatch aborts it.
+	StartDispatch(ride *models.Ride)
+	StopDispatch(rideID string)
+
+	// ResumeDispatch starts dispatch for every ride still waiting for a
+	// driver that is not already being dispatched, such as rides left
+	// behind by a restart. It returns how many were started.
+	ResumeDispatch(ctx context.Context) (int, error)
+
+	// Driver side of an offer
+	GetPendingOffer(ctx context.Context, driverID string) (*models.RideOffer, error)
+	RespondToOffer(ctx context.Context, driverID string, offerID string, accept bool) error
+}
@@ -0,0 +1,24 @@
+package services
+
+import (
+	"context"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+)
+
+type PaymentService interface {
+	// AuthorizeRide holds the estimated fare of an accepted ride, plus a
+	// buffer, on the rider's payment method
+	AuthorizeRide(ctx context.Context, ride *models.Ride, estimatedFare float64) (*models.Payment, error)
+	// CaptureRide charges the completed ride's fare against its
+	// authorization. A fare above the hold is authorized anew; if that is
+	// declined, the original hold is captured instead. A failed capture is
+	// recorded on the payment and can be retried by calling CaptureRide
+	// again.
+	CaptureRide(ctx context.Context, ride *models.Ride) (*models.Payment, error)
+	// VoidRide releases the funds held for a ride that will not be charged.
+	// Rides without an authorization are ignored.
+	VoidRide(ctx context.Context, rideID string) error
+	RefundRide(ctx context.Context, rideID string, amount float64) (*models.Payment, error)
+	GetRidePayment(ctx context.Context, rideID string) (*models.Payment, error)
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"context"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+)
+
+// PoolService plans the shared trips of pooled rides. A driver's pool
+// orders the pickups and dropoffs of all the pooled rides they carry.
+type PoolService interface {
+	// GetDriverPool returns the driver's open pool with the stops ahead in
+	// the order they should be served
+	GetDriverPool(ctx context.Context, driverID string) (*models.Pool, error)
+	// CanJoin reports whether ride fits the driver's pool, or would open a
+	// new one when the driver is free. shared tells whether the ride would
+	// share the driver with other riders. It fails with ErrRideDoesNotFit
+	// or ErrActiveRideExists when the ride cannot be pooled with the driver.
+	CanJoin(ctx context.Context, ride *models.Ride, driver *models.Driver) (shared bool, err error)
+	// Join adds the ride's pickup and dropoff to the driver's pool, opening
+	// one if needed
+	Join(ctx context.Context, ride *models.Ride, driver *models.Driver) (*models.Pool, error)
+	// Leave takes the remaining stops of a cancelled ride off its pool
+	Leave(ctx context.Context, ride *models.Ride) error
+	// Visit records that the driver served the ride's pickup or dropoff
+	Visit(ctx context.Context, ride *models.Ride, kind models.PoolStopKind) (*models.Pool, error)
+	// SharedDistanceKm returns the straight-line distance driven in the
+	// ride's pool that falls to the ride, splitting each leg between the
+	// riders on board. A dropoff still ahead counts as served now.
+	SharedDistanceKm(ctx context.Context, ride *models.Ride) (float64, error)
+}
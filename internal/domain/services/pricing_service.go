@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/pricing"
+)
+
+type EstimateFareInput struct {
+	Pickup  models.Location
+	Dropoff models.Location
+	// Waypoints are stops between pickup and dropoff, in order
+	Waypoints []models.Location
+	// VehicleType limits the estimate to one vehicle type; empty estimates all
+	VehicleType models.VehicleType
+}
+
+type FareEstimate struct {
+	VehicleType     models.VehicleType `json:"vehicle_type"`
+	Currency        string             `json:"currency"`
+	DistanceKm      float64            `json:"distance_km"`
+	DurationMinutes float64            `json:"duration_minutes"`
+	PromoCode       string             `json:"promo_code,omitempty"`
+	Breakdown       pricing.Breakdown  `json:"breakdown"`
+}
+
+type PricingService interface {
+	// EstimateFare prices a trip between two points before it is booked,
+	// including the current surge at the pickup point
+	EstimateFare(ctx context.Context, input EstimateFareInput) ([]FareEstimate, error)
+	// QuoteTrip prices a trip whose distance and duration are known at the
+	// given surge multiplier
+	QuoteTrip(vehicleType models.VehicleType, distanceKm float64, duration time.Duration, surgeMultiplier float64) (*FareEstimate, error)
+	// EstimateRide prices a booked ride along its pickup, waypoints and
+	// dropoff at the surge the rider was quoted
+	EstimateRide(ride *models.Ride, vehicleType models.VehicleType) (*FareEstimate, error)
+	// QuoteRide prices a finished ride from the driver's recorded path and
+	// the time since the ride started. Rides without a usable path are
+	// charged the estimated distance.
+	QuoteRide(ride *models.Ride, vehicleType models.VehicleType, path []models.LocationPing, endedAt time.Time) (*FareEstimate, error)
+	// QuotePooledRide prices a pooled ride by its share of the straight-line
+	// distance driven in its pool, timed at the vehicle's average speed
+	QuotePooledRide(ride *models.Ride, vehicleType models.VehicleType, sharedDistanceKm float64) (*FareEstimate, error)
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+)
+
+type CreatePromoCodeInput struct {
+	Code          string
+	DiscountType  models.DiscountType
+	DiscountValue float64
+	MaxDiscount   float64
+	MaxUses       int
+	PerUserLimit  int
+	VehicleType   models.VehicleType
+	StartsAt      *time.Time
+	EndsAt        *time.Time
+}
+
+type ApplyPromoCodeInput struct {
+	Code     string
+	Estimate EstimateFareInput
+}
+
+type PromoService interface {
+	CreatePromoCode(ctx context.Context, input CreatePromoCodeInput) (*models.PromoCode, error)
+	ListPromoCodes(ctx context.Context) ([]models.PromoCode, error)
+
+	// ApplyToEstimate prices a trip like EstimateFare with the code's
+	// discount taken off. Without a vehicle type only the vehicle types the
+	// code applies to are priced.
+	ApplyToEstimate(ctx context.Context, userID string, input ApplyPromoCodeInput) ([]FareEstimate, error)
+	// CheckPromoCode returns the code if userID may use it now on a ride
+	// with the given vehicle type
+	CheckPromoCode(ctx context.Context, userID string, code string, vehicleType models.VehicleType) (*models.PromoCode, error)
+
+	// RedeemForRide spends the ride's promo code on fare and returns the
+	// discount. Rides without a code get no discount, and neither do rides
+	// whose code ran out since they were booked or that were served by a
+	// vehicle type the code does not apply to.
+	RedeemForRide(ctx context.Context, ride *models.Ride, vehicleType models.VehicleType, fare float64) (float64, error)
+	// ReleaseRedemption gives back the use redeemed for a ride
+	ReleaseRedemption(ctx context.Context, rideID string) error
+}
@@ -0,0 +1,22 @@
+package services
+
+import (
+	"context"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+)
+
+type RateRideInput struct {
+	Stars   int
+	Comment string
+	Tags    []string
+}
+
+type RatingService interface {
+	// RateDriver records the rider's rating of the driver of a completed
+	// ride
+	RateDriver(ctx context.Context, rideID string, riderID string, input RateRideInput) (*models.Rating, error)
+	// RateRider records the driver's rating of the rider of a completed
+	// ride
+	RateRider(ctx context.Context, rideID string, driverID string, input RateRideInput) (*models.Rating, error)
+}
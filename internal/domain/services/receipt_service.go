@@ -0,0 +1,17 @@
+package services
+
+import (
+	"context"
+
+	"github.com/sayeed1999/share-a-ride/internal/pkg/receipt"
+)
+
+type ReceiptService interface {
+	// GetRideReceipt gathers the receipt of a completed ride
+	GetRideReceipt(ctx context.Context, rideID string) (*receipt.Receipt, error)
+	// SendRideReceipt emails the receipt of a completed ride to its rider
+	SendRideReceipt(ctx context.Context, rideID string) error
+	// QueueRideReceipt sends the receipt in the background; failures are
+	// logged
+	QueueRideReceipt(rideID string)
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+)
+
+type RequestRideInput struct {
+	Pickup  models.Location
+	Dropoff models.Location
+	// VehicleType is the preferred vehicle; empty means any
+	VehicleType models.VehicleType
+	// PromoCode is redeemed when the ride completes; empty means none
+	PromoCode string
+	// PaymentMethod defaults to card
+	PaymentMethod models.PaymentMethod
+	// ScheduledAt books the ride for a later pickup; nil dispatches now
+	ScheduledAt *time.Time
+	// Pooled rides share the driver with other riders; Seats is the size
+	// of the rider's party and defaults to 1
+	Pooled bool
+	Seats  int
+	// Waypoints are stops between pickup and dropoff, in order
+	Waypoints []models.Location
+}
+
+type AddWaypointInput struct {
+	Location models.Location
+	// Position is the index among the ride's waypoints to insert the stop
+	// at; nil adds it after the last one
+	Position *int
+}
+
+// RideRoute is a ride with its fare and trip time estimated over its
+// current stops. ArrivalAt is when a ride under way is expected at its
+// dropoff.
+type RideRoute struct {
+	Ride      *models.Ride  `json:"ride"`
+	Estimate  *FareEstimate `json:"estimate"`
+	ArrivalAt *time.Time    `json:"arrival_at,omitempty"`
+}
+
+type CancelRideInput struct {
+	Reason string
+}
+
+// ListRidesInput filters and paginates a ride history. Page is 1-based;
+// zero values fall back to service defaults.
+type ListRidesInput struct {
+	Statuses []models.RideStatus
+	From     *time.Time
+	To       *time.Time
+	Page     int
+	Limit    int
+}
+
+type RideList struct {
+	Rides   []models.Ride
+	Total   int64
+	Page    int
+	Limit   int
+	HasMore bool
+}
+
+type RideService interface {
+	RequestRide(ctx context.Context, riderID string, input RequestRideInput) (*models.Ride, error)
+	GetRide(ctx context.Context, rideID string) (*models.Ride, error)
+
+	// Rider side of the ride lifecycle
+	GetCurrentRide(ctx context.Context, riderID string) (*models.Ride, error)
+	ListRiderRides(ctx context.Context, riderID string, input ListRidesInput) (*RideList, error)
+	// AddWaypoint adds a stop to the rider's ride. Stops can be added until
+	// the trip starts, and one more after the last stop once it is under
+	// way.
+	AddWaypoint(ctx context.Context, rideID string, riderID string, input AddWaypointInput) (*RideRoute, error)
+	// RemoveWaypoint removes a stop from the rider's ride before the trip
+	// starts
+	RemoveWaypoint(ctx context.Context, rideID string, riderID string, waypointID string) (*RideRoute, error)
+
+	// Driver side of the ride lifecycle
+	AcceptRide(ctx context.Context, rideID string, driverID string) (*models.Ride, error)
+	MarkDriverArrived(ctx context.Context, rideID string, driverID string) (*models.Ride, error)
+	StartRide(ctx context.Context, rideID string, driverID string) (*models.Ride, error)
+	// CompleteRide prices the ride from the driver's recorded path, takes
+	// off the discount of the ride's promo code and charges the rider
+	CompleteRide(ctx context.Context, rideID string, driverID string) (*models.Ride, error)
+	ListDriverRides(ctx context.Context, driverID string, input ListRidesInput) (*RideList, error)
+
+	// CancelRide may be called by the rider or by the assigned driver's user.
+	// Riders cancelling a scheduled ride shortly before pickup pay a fee.
+	CancelRide(ctx context.Context, rideID string, userID string, input CancelRideInput) (*models.Ride, error)
+
+	// RetryCharges charges the fares and cancellation fees that could not
+	// be collected when rides ended before endedBefore. It returns how many
+	// were collected.
+	RetryCharges(ctx context.Context, endedBefore time.Time) (int, error)
+}
@@ -0,0 +1,17 @@
+package services
+
+import (
+	"context"
+)
+
+// SchedulingService looks after rides booked in advance: it reminds riders
+// of upcoming pickups and hands rides over to dispatch shortly before them.
+type SchedulingService interface {
+	// ReleaseDueRides starts dispatch for scheduled rides due within the
+	// dispatch lead time and returns how many were released. Rides whose
+	// rider is already on another ride are cancelled instead.
+	ReleaseDueRides(ctx context.Context) (int, error)
+	// SendReminders emails riders whose ride is due within the reminder
+	// lead time and returns how many were reminded
+	SendReminders(ctx context.Context) (int, error)
+}
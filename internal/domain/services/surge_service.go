@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/sayeed1999/share-a-ride/internal/pkg/geo"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/surge"
+)
+
+// SurgeCell is the supply and demand in one cell of the surge grid
+type SurgeCell struct {
+	surge.Cell
+	Bounds geo.Box `json:"bounds"`
+	// Demand is the number of open ride requests picking up in the cell
+	Demand int `json:"demand"`
+	// Supply is the number of available drivers in the cell
+	Supply     int     `json:"supply"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+// SurgeSnapshot is the result of one surge computation. Cells without
+// demand or supply are left out and have a multiplier of 1.
+type SurgeSnapshot struct {
+	ComputedAt  time.Time   `json:"computed_at"`
+	CellSizeDeg float64     `json:"cell_size_deg"`
+	Cells       []SurgeCell `json:"cells"`
+}
+
+type SurgeService interface {
+	// Recompute counts open requests and available drivers per cell and
+	// replaces the current multipliers
+	Recompute(ctx context.Context) error
+	// MultiplierAt returns the current multiplier for a pickup point; it is
+	// 1 until the first computation
+	MultiplierAt(lat, lng float64) float64
+	// Heatmap returns the latest snapshot, or nil before the first computation
+	Heatmap() *SurgeSnapshot
+}
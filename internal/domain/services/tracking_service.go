@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+)
+
+// DriverPosition is a live location update of the driver on a ride
+type DriverPosition struct {
+	RideID     string          `json:"ride_id"`
+	DriverID   string          `json:"driver_id"`
+	Location   models.Location `json:"location"`
+	RecordedAt time.Time       `json:"recorded_at"`
+}
+
+// RideSubscription streams the positions of the driver on a rider's ride.
+// Positions is closed once Close is called.
+type RideSubscription struct {
+	Ride      *models.Ride
+	Positions <-chan DriverPosition
+	Close     func()
+}
+
+type TrackingService interface {
+	// PublishLocation stores the driver's location, records it in the
+	// driver's history and forwards it to the rider of the driver's active
+	// ride, if any
+	PublishLocation(ctx context.Context, driverID string, input UpdateLocationInput) error
+	// SubscribeToCurrentRide follows the driver of the rider's active ride
+	SubscribeToCurrentRide(ctx context.Context, riderID string) (*RideSubscription, error)
+	// GetRidePath returns the driver's recorded positions during a ride,
+	// oldest first. Only the ride's rider and driver may read it.
+	GetRidePath(ctx context.Context, rideID string, userID string) ([]models.LocationPing, error)
+	// PruneLocationHistory removes history older than the retention period
+	// and returns how many positions were removed
+	PruneLocationHistory(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+)
+
+// ListVerificationsInput selects a page of the verification queue; a zero
+// Status lists pending drivers and zero paging values fall back to service
+// defaults.
+type ListVerificationsInput struct {
+	Status models.VerificationStatus
+	Page   int
+	Limit  int
+}
+
+type DriverList struct {
+	Drivers []models.Driver
+	Total   int64
+	Page    int
+	Limit   int
+	HasMore bool
+}
+
+type ReviewDriverInput struct {
+	Status models.VerificationStatus
+	Reason string
+}
+
+type VerificationService interface {
+	// ListVerifications returns the drivers in a verification status with
+	// their documents, longest waiting first
+	ListVerifications(ctx context.Context, input ListVerificationsInput) (*DriverList, error)
+	// ReviewDriver records reviewerID's decision on a driver's verification
+	// and notifies the driver
+	ReviewDriver(ctx context.Context, driverID string, reviewerID string, input ReviewDriverInput) (*models.Driver, error)
+	// RemindExpiringDocuments emails drivers whose documents expire within
+	// one of the configured reminder thresholds and returns how many
+	// reminders were sent
+	RemindExpiringDocuments(ctx context.Context) (int, error)
+	// SuspendLapsedDrivers takes approved drivers whose documents have
+	// lapsed offline and back to resubmission, and returns how many were
+	// suspended
+	SuspendLapsedDrivers(ctx context.Context) (int, error)
+}
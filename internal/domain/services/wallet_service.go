@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+)
+
+type TopUpInput struct {
+	Amount float64
+	// IdempotencyKey identifies the top-up to the rider's client; repeating
+	// a top-up with the same key returns the first result
+	IdempotencyKey string
+}
+
+// ListWalletTransactionsInput paginates a wallet's transactions. Page is
+// 1-based; zero values fall back to service defaults.
+type ListWalletTransactionsInput struct {
+	Page  int
+	Limit int
+}
+
+type Wallet struct {
+	UserID   string  `json:"user_id"`
+	Balance  float64 `json:"balance"`
+	Currency string  `json:"currency"`
+}
+
+// WalletTransaction is one journal entry as seen from a wallet. Amount is
+// positive for money added to the wallet and negative for money spent.
+type WalletTransaction struct {
+	ID          string                  `json:"id"`
+	Kind        models.JournalEntryKind `json:"kind"`
+	Description string                  `json:"description"`
+	RideID      *string                 `json:"ride_id,omitempty"`
+	Amount      float64                 `json:"amount"`
+	CreatedAt   time.Time               `json:"created_at"`
+}
+
+type WalletTransactionList struct {
+	Transactions []WalletTransaction
+	Total        int64
+	Page         int
+	Limit        int
+	HasMore      bool
+}
+
+type WalletService interface {
+	GetWallet(ctx context.Context, userID string) (*Wallet, error)
+	// TopUp charges the rider's payment method and adds the amount to the
+	// wallet
+	TopUp(ctx context.Context, userID string, input TopUpInput) (*WalletTransaction, error)
+	// HoldRideFare sets amount aside in the rider's wallet for an accepted
+	// ride, or returns ErrInsufficientBalance. A ride is held at most once.
+	HoldRideFare(ctx context.Context, ride *models.Ride, amount float64) (*WalletTransaction, error)
+	// ReleaseRideHold returns the amount held for a ride that will not be
+	// charged to the wallet. It is a no-op if nothing is held.
+	ReleaseRideHold(ctx context.Context, ride *models.Ride) error
+	// ChargeRide pays a ride's fare from the rider's wallet, releasing its
+	// hold in the same step. A ride is charged at most once. The wallet is
+	// never overdrawn: if the fare exceeds the hold and the balance
+	// together, it returns ErrInsufficientBalance and leaves the hold in
+	// place so the charge can be retried after a top-up.
+	ChargeRide(ctx context.Context, ride *models.Ride) (*WalletTransaction, error)
+	ListTransactions(ctx context.Context, userID string, input ListWalletTransactionsInput) (*WalletTransactionList, error)
+}
@@ -0,0 +1,93 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock abstracts time so that timeouts can be driven by tests
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+// New returns a Clock backed by the time package
+func New() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+type fakeTimer struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// Fake is a manually advanced Clock for tests
+type Fake struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFake returns a Fake clock set to now
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	timer := &fakeTimer{deadline: f.now.Add(d), ch: make(chan time.Time, 1)}
+	if d <= 0 {
+		timer.ch <- f.now
+		return timer.ch
+	}
+	f.timers = append(f.timers, timer)
+	f.cond.Broadcast()
+	return timer.ch
+}
+
+// Advance moves the clock forward and fires every timer that is due
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+	pending := f.timers[:0]
+	for _, timer := range f.timers {
+		if !timer.deadline.After(f.now) {
+			timer.ch <- f.now
+			continue
+		}
+		pending = append(pending, timer)
+	}
+	f.timers = pending
+}
+
+// BlockUntil waits until at least n timers are waiting to fire
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.timers) < n {
+		f.cond.Wait()
+	}
+}
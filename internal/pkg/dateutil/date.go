@@ -36,3 +36,9 @@ func StartOfDay(t time.Time) time.Time {
 func EndOfDay(t time.Time) time.Time {
 	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 999999999, t.Location())
 }
+
+// StartOfWeek returns the start of the Monday of the week containing t
+func StartOfWeek(t time.Time) time.Time {
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	return StartOfDay(t).AddDate(0, 0, -daysSinceMonday)
+}
@@ -0,0 +1,69 @@
+package geo
+
+import "math"
+
+// EarthRadiusKm is the mean radius of the earth used for distance calculations
+const EarthRadiusKm = 6371.0
+
+// kmPerDegreeLat is the length of one degree of latitude
+const kmPerDegreeLat = EarthRadiusKm * math.Pi / 180
+
+// boxPaddingDeg widens bounding boxes by about 10cm so that points exactly
+// on the circle survive floating point rounding
+const boxPaddingDeg = 1e-6
+
+// Box is a latitude/longitude rectangle
+type Box struct {
+	MinLat float64 `json:"min_lat"`
+	MaxLat float64 `json:"max_lat"`
+	MinLng float64 `json:"min_lng"`
+	MaxLng float64 `json:"max_lng"`
+}
+
+// CoversAllLongitudes reports whether the box spans every longitude, which
+// happens near the poles or when the box would cross the antimeridian. Such
+// a box can only be used to filter on latitude.
+func (b Box) CoversAllLongitudes() bool {
+	return b.MinLng <= -180 && b.MaxLng >= 180
+}
+
+// BoundingBox returns the smallest box containing every point within
+// radiusKm of the given point
+func BoundingBox(lat, lng, radiusKm float64) Box {
+	dLat := radiusKm/kmPerDegreeLat + boxPaddingDeg
+	box := Box{
+		MinLat: math.Max(lat-dLat, -90),
+		MaxLat: math.Min(lat+dLat, 90),
+		MinLng: -180,
+		MaxLng: 180,
+	}
+
+	// The widest point of the circle is at the latitude closest to a pole
+	maxAbsLat := math.Max(math.Abs(box.MinLat), math.Abs(box.MaxLat))
+	if maxAbsLat >= 90 {
+		return box
+	}
+	dLng := radiusKm/(kmPerDegreeLat*math.Cos(toRadians(maxAbsLat))) + boxPaddingDeg
+	if lng-dLng < -180 || lng+dLng > 180 {
+		return box
+	}
+
+	box.MinLng = lng - dLng
+	box.MaxLng = lng + dLng
+	return box
+}
+
+// HaversineKm returns the great-circle distance in kilometres between two points
+func HaversineKm(lat1, lng1, lat2, lng2 float64) float64 {
+	dLat := toRadians(lat2 - lat1)
+	dLng := toRadians(lng2 - lng1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
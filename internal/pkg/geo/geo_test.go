@@ -0,0 +1,65 @@
+package geo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHaversineKm(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat1     float64
+		lng1     float64
+		lat2     float64
+		lng2     float64
+		expected float64
+	}{
+		{name: "Same point", lat1: 23.8103, lng1: 90.4125, lat2: 23.8103, lng2: 90.4125, expected: 0},
+		{name: "Dhaka to Chattogram", lat1: 23.8103, lng1: 90.4125, lat2: 22.3569, lng2: 91.7832, expected: 214.5},
+		{name: "One degree of latitude", lat1: 0, lng1: 0, lat2: 1, lng2: 0, expected: 111.19},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.expected, HaversineKm(tt.lat1, tt.lng1, tt.lat2, tt.lng2), 1)
+		})
+	}
+}
+
+func TestBoundingBox(t *testing.T) {
+	t.Run("Contains every point on the circle", func(t *testing.T) {
+		lat, lng, radius := 23.8103, 90.4125, 5.0
+		box := BoundingBox(lat, lng, radius)
+
+		assert.False(t, box.CoversAllLongitudes())
+		for bearing := 0.0; bearing < 360; bearing += 15 {
+			pLat, pLng := destination(lat, lng, radius, bearing)
+			assert.True(t, pLat >= box.MinLat && pLat <= box.MaxLat, "latitude at bearing %v", bearing)
+			assert.True(t, pLng >= box.MinLng && pLng <= box.MaxLng, "longitude at bearing %v", bearing)
+		}
+	})
+
+	t.Run("Near the antimeridian", func(t *testing.T) {
+		box := BoundingBox(0, 179.99, 5)
+		assert.True(t, box.CoversAllLongitudes())
+	})
+
+	t.Run("Near a pole", func(t *testing.T) {
+		box := BoundingBox(89.99, 0, 5)
+		assert.True(t, box.CoversAllLongitudes())
+		assert.Equal(t, 90.0, box.MaxLat)
+	})
+}
+
+// destination returns the point distanceKm away from lat/lng along bearing
+func destination(lat, lng, distanceKm, bearing float64) (float64, float64) {
+	d := distanceKm / EarthRadiusKm
+	b := toRadians(bearing)
+	lat1, lng1 := toRadians(lat), toRadians(lng)
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(d) + math.Cos(lat1)*math.Sin(d)*math.Cos(b))
+	lng2 := lng1 + math.Atan2(math.Sin(b)*math.Sin(d)*math.Cos(lat1), math.Cos(d)-math.Sin(lat1)*math.Sin(lat2))
+	return lat2 * 180 / math.Pi, lng2 * 180 / math.Pi
+}
@@ -0,0 +1,179 @@
+package geoindex
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/sayeed1999/share-a-ride/internal/pkg/geo"
+)
+
+// DefaultCellSizeDeg buckets points into cells of roughly 1km
+const DefaultCellSizeDeg = 0.01
+
+type cell struct {
+	lat int
+	lng int
+}
+
+type entry[T any] struct {
+	id    string
+	lat   float64
+	lng   float64
+	value T
+	cell  cell
+}
+
+// Neighbor is a point found by Nearby together with its distance from the query
+type Neighbor[T any] struct {
+	ID         string
+	Latitude   float64
+	Longitude  float64
+	DistanceKm float64
+	Value      T
+}
+
+// Index is a grid-bucketed in-memory index of points keyed by ID. It is
+// safe for concurrent use by multiple readers and writers.
+type Index[T any] struct {
+	mu       sync.RWMutex
+	cellSize float64
+	entries  map[string]*entry[T]
+	cells    map[cell]map[string]*entry[T]
+}
+
+// New returns an empty index that buckets points into square cells of
+// cellSizeDeg degrees
+func New[T any](cellSizeDeg float64) *Index[T] {
+	return &Index[T]{
+		cellSize: cellSizeDeg,
+		entries:  make(map[string]*entry[T]),
+		cells:    make(map[cell]map[string]*entry[T]),
+	}
+}
+
+// Upsert adds the point or moves it to its new position
+func (idx *Index[T]) Upsert(id string, lat, lng float64, value T) {
+	e := &entry[T]{id: id, lat: lat, lng: lng, value: value, cell: idx.cellOf(lat, lng)}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(id)
+	idx.entries[id] = e
+	bucket, ok := idx.cells[e.cell]
+	if !ok {
+		bucket = make(map[string]*entry[T])
+		idx.cells[e.cell] = bucket
+	}
+	bucket[id] = e
+}
+
+// Remove deletes the point if present
+func (idx *Index[T]) Remove(id string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(id)
+}
+
+// Len returns the number of indexed points
+func (idx *Index[T]) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.entries)
+}
+
+// Nearby returns up to k points within radiusKm of lat/lng, nearest first.
+// A k of zero or less returns every point in range.
+func (idx *Index[T]) Nearby(lat, lng, radiusKm float64, k int) []Neighbor[T] {
+	box := geo.BoundingBox(lat, lng, radiusKm)
+	minCell := idx.cellOf(box.MinLat, box.MinLng)
+	maxCell := idx.cellOf(box.MaxLat, box.MaxLng)
+
+	// With a k, only the k nearest so far are kept in a max-heap
+	found := &neighborHeap[T]{}
+	visit := func(e *entry[T]) {
+		d := geo.HaversineKm(lat, lng, e.lat, e.lng)
+		if d > radiusKm {
+			return
+		}
+		if k > 0 && found.Len() == k {
+			if d >= (*found)[0].DistanceKm {
+				return
+			}
+			heap.Pop(found)
+		}
+		heap.Push(found, Neighbor[T]{ID: e.id, Latitude: e.lat, Longitude: e.lng, DistanceKm: d, Value: e.value})
+	}
+
+	idx.mu.RLock()
+	boxCells := (maxCell.lat - minCell.lat + 1) * (maxCell.lng - minCell.lng + 1)
+	if box.CoversAllLongitudes() || boxCells > len(idx.cells) {
+		// Cheaper to walk the occupied cells than every cell of the box
+		for c, bucket := range idx.cells {
+			if c.lat < minCell.lat || c.lat > maxCell.lat {
+				continue
+			}
+			for _, e := range bucket {
+				visit(e)
+			}
+		}
+	} else {
+		for cLat := minCell.lat; cLat <= maxCell.lat; cLat++ {
+			for cLng := minCell.lng; cLng <= maxCell.lng; cLng++ {
+				for _, e := range idx.cells[cell{lat: cLat, lng: cLng}] {
+					visit(e)
+				}
+			}
+		}
+	}
+	idx.mu.RUnlock()
+
+	result := []Neighbor[T](*found)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].DistanceKm < result[j].DistanceKm
+	})
+	return result
+}
+
+// remove must be called with the write lock held
+func (idx *Index[T]) remove(id string) {
+	e, ok := idx.entries[id]
+	if !ok {
+		return
+	}
+	delete(idx.entries, id)
+	if bucket := idx.cells[e.cell]; bucket != nil {
+		delete(bucket, id)
+		if len(bucket) == 0 {
+			delete(idx.cells, e.cell)
+		}
+	}
+}
+
+func (idx *Index[T]) cellOf(lat, lng float64) cell {
+	return cell{
+		lat: int(math.Floor(lat / idx.cellSize)),
+		lng: int(math.Floor(lng / idx.cellSize)),
+	}
+}
+
+// neighborHeap is a max-heap on distance
+type neighborHeap[T any] []Neighbor[T]
+
+func (h neighborHeap[T]) Len() int           { return len(h) }
+func (h neighborHeap[T]) Less(i, j int) bool { return h[i].DistanceKm > h[j].DistanceKm }
+func (h neighborHeap[T]) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *neighborHeap[T]) Push(x any) {
+	*h = append(*h, x.(Neighbor[T]))
+}
+
+func (h *neighborHeap[T]) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
@@ -0,0 +1,134 @@
+package geoindex
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sayeed1999/share-a-ride/internal/pkg/geo"
+)
+
+// Dhaka city centre, used as the middle of generated points
+const (
+	centerLat = 23.8103
+	centerLng = 90.4125
+)
+
+func randomIndex(rng *rand.Rand, n int) (*Index[int], map[string][2]float64) {
+	idx := New[int](DefaultCellSizeDeg)
+	points := make(map[string][2]float64, n)
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("driver-%d", i)
+		lat := centerLat + (rng.Float64()-0.5)*0.5
+		lng := centerLng + (rng.Float64()-0.5)*0.5
+		idx.Upsert(id, lat, lng, i)
+		points[id] = [2]float64{lat, lng}
+	}
+	return idx, points
+}
+
+func TestNearbyMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	idx, points := randomIndex(rng, 2000)
+
+	for _, radius := range []float64{0.5, 2, 5, 20} {
+		t.Run(fmt.Sprintf("radius %vkm", radius), func(t *testing.T) {
+			var expected []string
+			for id, p := range points {
+				if geo.HaversineKm(centerLat, centerLng, p[0], p[1]) <= radius {
+					expected = append(expected, id)
+				}
+			}
+
+			var actual []string
+			found := idx.Nearby(centerLat, centerLng, radius, 0)
+			for i, n := range found {
+				actual = append(actual, n.ID)
+				if i > 0 {
+					assert.LessOrEqual(t, found[i-1].DistanceKm, n.DistanceKm)
+				}
+			}
+
+			sort.Strings(expected)
+			sort.Strings(actual)
+			assert.Equal(t, expected, actual)
+
+			// A k keeps exactly the k nearest
+			nearest := idx.Nearby(centerLat, centerLng, radius, 5)
+			if len(found) > 5 {
+				found = found[:5]
+			}
+			assert.Equal(t, found, nearest)
+		})
+	}
+}
+
+func TestUpsertMovesAndRemoveDeletes(t *testing.T) {
+	idx := New[string](DefaultCellSizeDeg)
+	idx.Upsert("driver-1", centerLat, centerLng, "car")
+	idx.Upsert("driver-2", centerLat+0.005, centerLng, "bike")
+
+	found := idx.Nearby(centerLat, centerLng, 1, 1)
+	assert.Len(t, found, 1)
+	assert.Equal(t, "driver-1", found[0].ID)
+	assert.Equal(t, "car", found[0].Value)
+
+	// Moving far away takes the driver out of range
+	idx.Upsert("driver-1", centerLat+1, centerLng, "car")
+	found = idx.Nearby(centerLat, centerLng, 1, 0)
+	assert.Len(t, found, 1)
+	assert.Equal(t, "driver-2", found[0].ID)
+
+	idx.Remove("driver-2")
+	idx.Remove("unknown")
+	assert.Empty(t, idx.Nearby(centerLat, centerLng, 1, 0))
+	assert.Equal(t, 1, idx.Len())
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	idx := New[int](DefaultCellSizeDeg)
+	var wg sync.WaitGroup
+
+	for w := 0; w < 4; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(w)))
+			for i := 0; i < 500; i++ {
+				id := fmt.Sprintf("driver-%d", rng.Intn(50))
+				if i%10 == 0 {
+					idx.Remove(id)
+					continue
+				}
+				idx.Upsert(id, centerLat+rng.Float64()*0.05, centerLng+rng.Float64()*0.05, i)
+			}
+		}(w)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				idx.Nearby(centerLat, centerLng, 3, 10)
+			}
+		}()
+	}
+
+	wg.Wait()
+	assert.LessOrEqual(t, idx.Len(), 50)
+}
+
+// BenchmarkNearby is the in-memory counterpart of BenchmarkFindAvailableNearby
+// in the repository integration tests, using the same data shape.
+func BenchmarkNearby(b *testing.B) {
+	for _, n := range []int{1000, 10000, 100000} {
+		b.Run(fmt.Sprintf("%d drivers", n), func(b *testing.B) {
+			idx, _ := randomIndex(rand.New(rand.NewSource(1)), n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				idx.Nearby(centerLat, centerLng, 3, 20)
+			}
+		})
+	}
+}
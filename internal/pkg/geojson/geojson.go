@@ -0,0 +1,34 @@
+// Package geojson builds the subset of GeoJSON (RFC 7946) objects the API
+// returns.
+package geojson
+
+// Position is a [longitude, latitude] pair; note the order.
+type Position [2]float64
+
+type Geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+type Feature struct {
+	Type       string                 `json:"type"`
+	Geometry   *Geometry              `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// NewLineString returns a LineString through positions, or nil when there
+// are fewer than the two positions a LineString requires.
+func NewLineString(positions []Position) *Geometry {
+	if len(positions) < 2 {
+		return nil
+	}
+	return &Geometry{Type: "LineString", Coordinates: positions}
+}
+
+// NewFeature wraps geometry, which may be nil, in a Feature.
+func NewFeature(geometry *Geometry, properties map[string]interface{}) Feature {
+	if properties == nil {
+		properties = map[string]interface{}{}
+	}
+	return Feature{Type: "Feature", Geometry: geometry, Properties: properties}
+}
@@ -0,0 +1,28 @@
+package geojson
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineStringFeature(t *testing.T) {
+	line := NewLineString([]Position{{90.4125, 23.8103}, {90.3935, 23.7509}})
+	data, err := json.Marshal(NewFeature(line, map[string]interface{}{"ride_id": "ride-1"}))
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "Feature",
+		"geometry": {"type": "LineString", "coordinates": [[90.4125, 23.8103], [90.3935, 23.7509]]},
+		"properties": {"ride_id": "ride-1"}
+	}`, string(data))
+}
+
+func TestLineStringNeedsTwoPositions(t *testing.T) {
+	assert.Nil(t, NewLineString([]Position{{90.4125, 23.8103}}))
+
+	data, err := json.Marshal(NewFeature(nil, nil))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type": "Feature", "geometry": null, "properties": {}}`, string(data))
+}
@@ -0,0 +1,48 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentBytes(t *testing.T) {
+	doc := New()
+	doc.Text(50, 60, HelveticaBold, 18, "Receipt (copy)")
+	doc.Line(50, 70, 545, 70)
+	doc.AddPage()
+	doc.Text(50, 60, Helvetica, 10, `C:\path`)
+
+	out := doc.Bytes()
+
+	assert.True(t, bytes.HasPrefix(out, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(out, []byte("%%EOF\n")))
+	assert.Contains(t, string(out), "/Count 2")
+	assert.Contains(t, string(out), `(Receipt \(copy\)) Tj`)
+	assert.Contains(t, string(out), `(C:\\path) Tj`)
+
+	// Every cross-reference entry points at the object it numbers
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	require.NotNil(t, startxref)
+	xref, err := strconv.Atoi(string(startxref[1]))
+	require.NoError(t, err)
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out[xref:], -1)
+	require.Len(t, entries, 8)
+	for i, entry := range entries {
+		offset, err := strconv.Atoi(string(entry[1]))
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(out[offset:], []byte(fmt.Sprintf("%d 0 obj", i+1))))
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	assert.InDelta(t, 5.56, TextWidth(Helvetica, 10, "0"), 1e-9)
+	assert.InDelta(t, 11.11, TextWidth(HelveticaBold, 10, "Mi"), 1e-9)
+	// Characters outside WinAnsiEncoding are drawn as question marks
+	assert.Equal(t, TextWidth(Helvetica, 10, "?"), TextWidth(Helvetica, 10, "৳"))
+}
@@ -0,0 +1,178 @@
+// Package pool plans the route of a vehicle shared by several riders and
+// splits the distance driven between them.
+//
+// A route is the ordered list of stops still ahead of the driver. Riders
+// whose pickup is no longer on the route are on board. Distances are
+// straight-line kilometres.
+package pool
+
+import (
+	"math"
+
+	"github.com/sayeed1999/share-a-ride/internal/pkg/geo"
+)
+
+// Kind tells pickups from dropoffs.
+type Kind string
+
+const (
+	Pickup  Kind = "pickup"
+	Dropoff Kind = "dropoff"
+)
+
+// Point is a position on the map.
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// Stop is where the driver picks up or drops off the Seats riders booked
+// under RideID.
+type Stop struct {
+	RideID string
+	Kind   Kind
+	Point  Point
+	Seats  int
+}
+
+// Limits bound what a route may ask of the vehicle and its riders.
+type Limits struct {
+	// Capacity is the number of passenger seats in the vehicle
+	Capacity int
+	// MaxDetourFactor caps each rider's distance on the route relative to
+	// driving them straight to their dropoff
+	MaxDetourFactor float64
+	// MaxPickupKm caps the distance driven before each pickup, so riders
+	// are not kept waiting while others are taken elsewhere; zero means no
+	// limit
+	MaxPickupKm float64
+}
+
+// Insert adds the pickup and dropoff of a new ride to route where they
+// lengthen it the least, keeping the order of the existing stops. It
+// reports false when no placement respects limits.
+func Insert(start Point, route []Stop, pickup, dropoff Stop, limits Limits) ([]Stop, bool) {
+	var best []Stop
+	bestKm := math.Inf(1)
+
+	for i := 0; i <= len(route); i++ {
+		for j := i; j <= len(route); j++ {
+			candidate := make([]Stop, 0, len(route)+2)
+			candidate = append(candidate, route[:i]...)
+			candidate = append(candidate, pickup)
+			candidate = append(candidate, route[i:j]...)
+			candidate = append(candidate, dropoff)
+			candidate = append(candidate, route[j:]...)
+
+			if !Feasible(start, candidate, limits) {
+				continue
+			}
+			if km := Length(start, candidate); km < bestKm {
+				best, bestKm = candidate, km
+			}
+		}
+	}
+	return best, best != nil
+}
+
+// Feasible reports whether driving route from start keeps within the
+// seats of the vehicle and the pickup and detour limits of every rider.
+// Riders already on board are measured from start.
+func Feasible(start Point, route []Stop, limits Limits) bool {
+	seats := Onboard(route)
+	if seats > limits.Capacity {
+		return false
+	}
+
+	// Where each ride boards, and how far along the route
+	from := make(map[string]Point)
+	boardedKm := make(map[string]float64)
+	position, km := start, 0.0
+	for _, stop := range route {
+		km += distanceKm(position, stop.Point)
+		position = stop.Point
+
+		switch stop.Kind {
+		case Pickup:
+			seats += stop.Seats
+			if seats > limits.Capacity {
+				return false
+			}
+			if limits.MaxPickupKm > 0 && km > limits.MaxPickupKm+1e-9 {
+				return false
+			}
+			from[stop.RideID] = stop.Point
+			boardedKm[stop.RideID] = km
+		case Dropoff:
+			seats -= stop.Seats
+			origin, picked := from[stop.RideID]
+			if !picked {
+				origin = start
+			}
+			direct := distanceKm(origin, stop.Point)
+			if km-boardedKm[stop.RideID] > direct*limits.MaxDetourFactor+1e-9 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Onboard returns the seats taken by riders whose pickup is no longer on
+// route.
+func Onboard(route []Stop) int {
+	picked := make(map[string]bool)
+	seats := 0
+	for _, stop := range route {
+		switch stop.Kind {
+		case Pickup:
+			picked[stop.RideID] = true
+		case Dropoff:
+			if !picked[stop.RideID] {
+				seats += stop.Seats
+			}
+		}
+	}
+	return seats
+}
+
+// Length returns the distance of driving route from start.
+func Length(start Point, route []Stop) float64 {
+	km := 0.0
+	position := start
+	for _, stop := range route {
+		km += distanceKm(position, stop.Point)
+		position = stop.Point
+	}
+	return km
+}
+
+// SharedDistance returns the part of the distance driven through visited,
+// the stops served so far in order, that falls to rideID. Each leg is
+// split between the riders on board in proportion to their seats, so the
+// shares of all rides add up to the distance driven with riders on board.
+func SharedDistance(visited []Stop, rideID string) float64 {
+	aboard := make(map[string]int)
+	total := 0
+	km := 0.0
+	for i, stop := range visited {
+		if i > 0 && aboard[rideID] > 0 {
+			leg := distanceKm(visited[i-1].Point, stop.Point)
+			km += leg * float64(aboard[rideID]) / float64(total)
+		}
+
+		switch stop.Kind {
+		case Pickup:
+			aboard[stop.RideID] += stop.Seats
+			total += stop.Seats
+		case Dropoff:
+			total -= aboard[stop.RideID]
+			delete(aboard, stop.RideID)
+		}
+	}
+	return km
+}
+
+func distanceKm(a, b Point) float64 {
+	return geo.HaversineKm(a.Lat, a.Lng, b.Lat, b.Lng)
+}
@@ -0,0 +1,88 @@
+package pool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// at returns points along a line of latitude, km kilometres apart
+func at(km float64) Point {
+	return Point{Lat: 23.8, Lng: 90.4 + km*0.00983}
+}
+
+func stops(rideID string, seats int, from, to float64) (Stop, Stop) {
+	return Stop{RideID: rideID, Kind: Pickup, Point: at(from), Seats: seats},
+		Stop{RideID: rideID, Kind: Dropoff, Point: at(to), Seats: seats}
+}
+
+func rideIDs(route []Stop) []string {
+	ids := make([]string, len(route))
+	for i, stop := range route {
+		ids[i] = string(stop.Kind) + ":" + stop.RideID
+	}
+	return ids
+}
+
+func TestInsert(t *testing.T) {
+	limits := Limits{Capacity: 3, MaxDetourFactor: 1.5, MaxPickupKm: 5.5}
+
+	t.Run("orders pickups and dropoffs along the way", func(t *testing.T) {
+		aPickup, aDropoff := stops("a", 1, 1, 10)
+		bPickup, bDropoff := stops("b", 1, 3, 8)
+
+		route, ok := Insert(at(0), []Stop{aPickup, aDropoff}, bPickup, bDropoff, limits)
+
+		assert.True(t, ok)
+		assert.Equal(t, []string{"pickup:a", "pickup:b", "dropoff:b", "dropoff:a"}, rideIDs(route))
+	})
+
+	t.Run("rejects a ride that goes the other way", func(t *testing.T) {
+		aPickup, aDropoff := stops("a", 1, 1, 10)
+		bPickup, bDropoff := stops("b", 1, 9, 0)
+
+		_, ok := Insert(at(0), []Stop{aPickup, aDropoff}, bPickup, bDropoff, limits)
+
+		assert.False(t, ok)
+	})
+
+	t.Run("rejects a ride that needs more seats than are free", func(t *testing.T) {
+		_, aDropoff := stops("a", 2, 0, 10)
+		bPickup, bDropoff := stops("b", 2, 2, 8)
+
+		_, ok := Insert(at(1), []Stop{aDropoff}, bPickup, bDropoff, limits)
+
+		assert.False(t, ok)
+	})
+
+	t.Run("fits a ride after a rider gets off", func(t *testing.T) {
+		_, aDropoff := stops("a", 2, 0, 4)
+		bPickup, bDropoff := stops("b", 2, 5, 9)
+
+		route, ok := Insert(at(1), []Stop{aDropoff}, bPickup, bDropoff, limits)
+
+		assert.True(t, ok)
+		assert.Equal(t, []string{"dropoff:a", "pickup:b", "dropoff:b"}, rideIDs(route))
+	})
+}
+
+func TestOnboard(t *testing.T) {
+	aPickup, aDropoff := stops("a", 1, 1, 10)
+	_, bDropoff := stops("b", 2, 0, 8)
+
+	assert.Equal(t, 2, Onboard([]Stop{aPickup, bDropoff, aDropoff}))
+}
+
+func TestSharedDistance(t *testing.T) {
+	aPickup, aDropoff := stops("a", 1, 0, 6)
+	bPickup, bDropoff := stops("b", 1, 2, 10)
+	visited := []Stop{aPickup, bPickup, aDropoff, bDropoff}
+
+	a := SharedDistance(visited, "a")
+	b := SharedDistance(visited, "b")
+
+	// a rides 2 km alone and shares 4; b shares 4 and rides 4 alone
+	assert.InDelta(t, 4, a, 0.01)
+	assert.InDelta(t, 6, b, 0.01)
+	assert.InDelta(t, Length(at(0), visited), a+b, 1e-9)
+}
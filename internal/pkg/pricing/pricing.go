@@ -0,0 +1,91 @@
+// Package pricing turns trip distance and duration into a fare.
+//
+// Amounts are worked out in whole cents so that the line items of a
+// breakdown always add up to its total.
+package pricing
+
+import (
+	"math"
+	"time"
+)
+
+// Tariff holds the rates of one vehicle type, in the main currency unit.
+type Tariff struct {
+	BaseFare  float64
+	PerKm     float64
+	PerMinute float64
+	// MinimumFare is the least a trip costs before the booking fee
+	MinimumFare float64
+	BookingFee  float64
+}
+
+// Breakdown itemises a fare. Total is the sum of the other fields.
+type Breakdown struct {
+	BaseFare     float64 `json:"base_fare"`
+	DistanceFare float64 `json:"distance_fare"`
+	TimeFare     float64 `json:"time_fare"`
+	// MinimumFareAdjustment tops short trips up to the minimum fare
+	MinimumFareAdjustment float64 `json:"minimum_fare_adjustment"`
+	SurgeMultiplier       float64 `json:"surge_multiplier"`
+	// SurgeAmount is what the surge multiplier adds to the trip fare
+	SurgeAmount float64 `json:"surge_amount"`
+	BookingFee  float64 `json:"booking_fee"`
+	// Discount is what a promotion takes off the total
+	Discount float64 `json:"discount"`
+	Total    float64 `json:"total"`
+}
+
+// Quote prices a trip of distanceKm lasting duration. The surge
+// multiplier applies to the trip fare but not to the booking fee; values
+// below 1 are treated as 1.
+func (t Tariff) Quote(distanceKm float64, duration time.Duration, surgeMultiplier float64) Breakdown {
+	base := toCents(t.BaseFare)
+	distance := toCents(t.PerKm * distanceKm)
+	timed := toCents(t.PerMinute * duration.Minutes())
+	booking := toCents(t.BookingFee)
+
+	var adjustment int64
+	if minimum := toCents(t.MinimumFare); base+distance+timed < minimum {
+		adjustment = minimum - (base + distance + timed)
+	}
+
+	surgeMultiplier = math.Max(surgeMultiplier, 1)
+	trip := base + distance + timed + adjustment
+	surge := int64(math.Round(float64(trip) * (surgeMultiplier - 1)))
+
+	return Breakdown{
+		BaseFare:              fromCents(base),
+		DistanceFare:          fromCents(distance),
+		TimeFare:              fromCents(timed),
+		MinimumFareAdjustment: fromCents(adjustment),
+		SurgeMultiplier:       surgeMultiplier,
+		SurgeAmount:           fromCents(surge),
+		BookingFee:            fromCents(booking),
+		Total:                 fromCents(trip + surge + booking),
+	}
+}
+
+// WithDiscount returns b with amount taken off the total. The total never
+// drops below zero.
+func (b Breakdown) WithDiscount(amount float64) Breakdown {
+	total := toCents(b.Total)
+	discount := toCents(amount)
+	if discount > total {
+		discount = total
+	}
+	if discount < 0 {
+		discount = 0
+	}
+
+	b.Discount = fromCents(discount)
+	b.Total = fromCents(total - discount)
+	return b
+}
+
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func fromCents(cents int64) float64 {
+	return float64(cents) / 100
+}
@@ -0,0 +1,71 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuote(t *testing.T) {
+	tariff := Tariff{BaseFare: 50, PerKm: 20, PerMinute: 2, MinimumFare: 100, BookingFee: 10}
+
+	tests := []struct {
+		name       string
+		distanceKm float64
+		duration   time.Duration
+		surge      float64
+		expected   Breakdown
+	}{
+		{
+			name:       "Regular trip",
+			distanceKm: 8.5,
+			duration:   25 * time.Minute,
+			surge:      1,
+			expected:   Breakdown{BaseFare: 50, DistanceFare: 170, TimeFare: 50, SurgeMultiplier: 1, BookingFee: 10, Total: 280},
+		},
+		{
+			name:       "Short trip is topped up to the minimum",
+			distanceKm: 1,
+			duration:   3 * time.Minute,
+			surge:      1,
+			expected:   Breakdown{BaseFare: 50, DistanceFare: 20, TimeFare: 6, MinimumFareAdjustment: 24, SurgeMultiplier: 1, BookingFee: 10, Total: 110},
+		},
+		{
+			name:       "Amounts are rounded to cents",
+			distanceKm: 3.333,
+			duration:   10*time.Minute + 20*time.Second,
+			surge:      1,
+			expected:   Breakdown{BaseFare: 50, DistanceFare: 66.66, TimeFare: 20.67, SurgeMultiplier: 1, BookingFee: 10, Total: 147.33},
+		},
+		{
+			name:       "Surge applies to the trip but not the booking fee",
+			distanceKm: 8.5,
+			duration:   25 * time.Minute,
+			surge:      1.5,
+			expected: Breakdown{BaseFare: 50, DistanceFare: 170, TimeFare: 50, SurgeMultiplier: 1.5, SurgeAmount: 135,
+				BookingFee: 10, Total: 415},
+		},
+		{
+			name:       "Multipliers below one are ignored",
+			distanceKm: 8.5,
+			duration:   25 * time.Minute,
+			surge:      0,
+			expected:   Breakdown{BaseFare: 50, DistanceFare: 170, TimeFare: 50, SurgeMultiplier: 1, BookingFee: 10, Total: 280},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tariff.Quote(tt.distanceKm, tt.duration, tt.surge))
+		})
+	}
+}
+
+func TestWithDiscount(t *testing.T) {
+	b := Breakdown{Total: 280}
+
+	assert.Equal(t, Breakdown{Discount: 30.5, Total: 249.5}, b.WithDiscount(30.5))
+	assert.Equal(t, Breakdown{Discount: 280, Total: 0}, b.WithDiscount(500))
+	assert.Equal(t, b, b.WithDiscount(-10))
+}
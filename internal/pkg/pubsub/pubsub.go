@@ -0,0 +1,128 @@
+// Package pubsub fans messages out to in-process subscribers by topic.
+//
+// Every subscription has its own bounded buffer so that one slow reader
+// never holds up a publisher or the other subscribers. When a buffer is
+// full the oldest message is dropped to make room, which suits streams
+// where only the latest value matters, such as positions.
+package pubsub
+
+import (
+	"sync"
+)
+
+// Hub routes published messages to the subscriptions of a topic.
+type Hub[T any] struct {
+	mu     sync.RWMutex
+	topics map[string]map[*Subscription[T]]struct{}
+}
+
+func New[T any]() *Hub[T] {
+	return &Hub[T]{topics: make(map[string]map[*Subscription[T]]struct{})}
+}
+
+// Subscription receives the messages published to one topic.
+type Subscription[T any] struct {
+	hub   *Hub[T]
+	topic string
+	ch    chan T
+
+	mu      sync.Mutex
+	closed  bool
+	dropped int
+}
+
+// Subscribe registers a subscription to topic that buffers up to buffer
+// messages. Close must be called once the subscription is no longer read.
+func (h *Hub[T]) Subscribe(topic string, buffer int) *Subscription[T] {
+	if buffer < 1 {
+		buffer = 1
+	}
+	sub := &Subscription[T]{hub: h, topic: topic, ch: make(chan T, buffer)}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subs, ok := h.topics[topic]
+	if !ok {
+		subs = make(map[*Subscription[T]]struct{})
+		h.topics[topic] = subs
+	}
+	subs[sub] = struct{}{}
+	return sub
+}
+
+// Publish delivers msg to every subscription of topic without blocking and
+// returns the number of subscriptions it reached.
+func (h *Hub[T]) Publish(topic string, msg T) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sub := range h.topics[topic] {
+		sub.deliver(msg)
+	}
+	return len(h.topics[topic])
+}
+
+// Subscribers returns the number of subscriptions to topic.
+func (h *Hub[T]) Subscribers(topic string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.topics[topic])
+}
+
+// C returns the channel messages are delivered on. It is closed by Close.
+func (s *Subscription[T]) C() <-chan T {
+	return s.ch
+}
+
+// Dropped returns how many messages were discarded because the
+// subscription fell behind.
+func (s *Subscription[T]) Dropped() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// Close unregisters the subscription and closes its channel. It is safe to
+// call more than once.
+func (s *Subscription[T]) Close() {
+	s.hub.mu.Lock()
+	if subs, ok := s.hub.topics[s.topic]; ok {
+		delete(subs, s)
+		if len(subs) == 0 {
+			delete(s.hub.topics, s.topic)
+		}
+	}
+	s.hub.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+func (s *Subscription[T]) deliver(msg T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+
+	for {
+		select {
+		case s.ch <- msg:
+			return
+		default:
+		}
+
+		// Make room by discarding the oldest message; the reader may have
+		// drained the buffer in the meantime, in which case nothing is lost
+		select {
+		case <-s.ch:
+			s.dropped++
+		default:
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package pubsub
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishReachesTopicSubscribersOnly(t *testing.T) {
+	hub := New[int]()
+	a := hub.Subscribe("ride-1", 4)
+	b := hub.Subscribe("ride-1", 4)
+	other := hub.Subscribe("ride-2", 4)
+	defer a.Close()
+	defer b.Close()
+	defer other.Close()
+
+	assert.Equal(t, 2, hub.Publish("ride-1", 7))
+
+	assert.Equal(t, 7, <-a.C())
+	assert.Equal(t, 7, <-b.C())
+	assert.Empty(t, other.C())
+}
+
+func TestSlowSubscriberKeepsLatest(t *testing.T) {
+	hub := New[int]()
+	sub := hub.Subscribe("ride-1", 2)
+	defer sub.Close()
+
+	for i := 1; i <= 5; i++ {
+		hub.Publish("ride-1", i)
+	}
+
+	assert.Equal(t, 4, <-sub.C())
+	assert.Equal(t, 5, <-sub.C())
+	assert.Equal(t, 3, sub.Dropped())
+}
+
+func TestCloseUnsubscribes(t *testing.T) {
+	hub := New[int]()
+	sub := hub.Subscribe("ride-1", 1)
+
+	sub.Close()
+	sub.Close()
+
+	assert.Equal(t, 0, hub.Subscribers("ride-1"))
+	assert.Equal(t, 0, hub.Publish("ride-1", 1))
+	_, open := <-sub.C()
+	assert.False(t, open)
+}
+
+func TestConcurrentPublishAndClose(t *testing.T) {
+	hub := New[int]()
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		sub := hub.Subscribe("ride-1", 1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				hub.Publish("ride-1", j)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range sub.C() {
+				sub.Close()
+			}
+		}()
+	}
+
+	wg.Wait()
+	assert.Equal(t, 0, hub.Subscribers("ride-1"))
+}
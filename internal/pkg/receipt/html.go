@@ -0,0 +1,47 @@
+package receipt
+
+import (
+	"bytes"
+	"html/template"
+)
+
+var htmlTemplate = template.Must(template.New("receipt").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Your ride receipt</title>
+</head>
+<body style="font-family: Helvetica, Arial, sans-serif; color: #222; max-width: 560px; margin: 0 auto;">
+<h1 style="font-size: 22px;">Thanks for riding, {{.RiderName}}</h1>
+<p style="color: #666;">Ride {{.RideID}} on {{.CompletedAt.Format "2 Jan 2006"}}</p>
+
+<table style="width: 100%; border-collapse: collapse;">
+<tr><td style="padding: 4px 0;">Pickup</td><td style="text-align: right;">{{.Pickup}} at {{.StartedAt.Format "15:04"}}</td></tr>
+<tr><td style="padding: 4px 0;">Dropoff</td><td style="text-align: right;">{{.Dropoff}} at {{.CompletedAt.Format "15:04"}}</td></tr>
+<tr><td style="padding: 4px 0;">Distance</td><td style="text-align: right;">{{printf "%.2f" .DistanceKm}} km</td></tr>
+<tr><td style="padding: 4px 0;">Duration</td><td style="text-align: right;">{{.FormatDuration}}</td></tr>
+</table>
+
+<h2 style="font-size: 16px; margin-top: 24px;">Fare</h2>
+<table style="width: 100%; border-collapse: collapse;">
+{{- range .Lines}}
+<tr><td style="padding: 4px 0;">{{.Label}}</td><td style="text-align: right;">{{$.FormatAmount .Amount}}</td></tr>
+{{- end}}
+<tr style="font-weight: bold; border-top: 1px solid #ccc;"><td style="padding: 8px 0;">Total</td><td style="text-align: right;">{{.FormatAmount .Fare.Total}}</td></tr>
+</table>
+<p style="color: #666;">Paid by {{.PaymentMethod}}</p>
+
+<h2 style="font-size: 16px; margin-top: 24px;">Your driver</h2>
+<p>{{.Driver.Name}}<br>{{.Driver.VehicleModel}} ({{.Driver.VehicleType}}), {{.Driver.PlateNumber}}</p>
+</body>
+</html>
+`))
+
+// HTML renders the receipt as an HTML email body
+func HTML(r *Receipt) (string, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, r); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
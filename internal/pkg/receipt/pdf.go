@@ -0,0 +1,61 @@
+package receipt
+
+import (
+	"fmt"
+
+	"github.com/sayeed1999/share-a-ride/internal/pkg/pdf"
+)
+
+const (
+	marginLeft  = 56.0
+	marginRight = pdf.PageWidth - 56
+	lineHeight  = 18.0
+)
+
+// PDF renders the receipt as a one page PDF document
+func PDF(r *Receipt) []byte {
+	doc := pdf.New()
+	y := 72.0
+
+	doc.Text(marginLeft, y, pdf.HelveticaBold, 20, "Ride receipt")
+	y += 22
+	doc.Text(marginLeft, y, pdf.Helvetica, 10, fmt.Sprintf("Ride %s on %s", r.RideID, r.CompletedAt.Format("2 Jan 2006")))
+	y += 14
+	doc.Text(marginLeft, y, pdf.Helvetica, 10, "Rider: "+r.RiderName)
+	y += 30
+
+	row := func(font pdf.Font, label, value string) {
+		doc.Text(marginLeft, y, font, 11, label)
+		doc.TextRight(marginRight, y, font, 11, value)
+		y += lineHeight
+	}
+	heading := func(title string) {
+		y += 12
+		doc.Text(marginLeft, y, pdf.HelveticaBold, 13, title)
+		y += 8
+		doc.Line(marginLeft, y, marginRight, y)
+		y += lineHeight
+	}
+
+	heading("Trip")
+	row(pdf.Helvetica, "Pickup", fmt.Sprintf("%s at %s", r.Pickup, r.StartedAt.Format("15:04")))
+	row(pdf.Helvetica, "Dropoff", fmt.Sprintf("%s at %s", r.Dropoff, r.CompletedAt.Format("15:04")))
+	row(pdf.Helvetica, "Distance", fmt.Sprintf("%.2f km", r.DistanceKm))
+	row(pdf.Helvetica, "Duration", r.FormatDuration())
+
+	heading("Fare")
+	for _, line := range r.Lines() {
+		row(pdf.Helvetica, line.Label, r.FormatAmount(line.Amount))
+	}
+	doc.Line(marginLeft, y-lineHeight+6, marginRight, y-lineHeight+6)
+	y += 4
+	row(pdf.HelveticaBold, "Total", r.FormatAmount(r.Fare.Total))
+	row(pdf.Helvetica, "Paid by", r.PaymentMethod)
+
+	heading("Driver")
+	row(pdf.Helvetica, "Name", r.Driver.Name)
+	row(pdf.Helvetica, "Vehicle", fmt.Sprintf("%s (%s)", r.Driver.VehicleModel, r.Driver.VehicleType))
+	row(pdf.Helvetica, "Plate number", r.Driver.PlateNumber)
+
+	return doc.Bytes()
+}
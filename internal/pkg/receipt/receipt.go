@@ -0,0 +1,95 @@
+// Package receipt renders ride receipts as HTML and PDF.
+package receipt
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sayeed1999/share-a-ride/internal/pkg/pricing"
+)
+
+// Receipt is what a rider was charged for a completed ride
+type Receipt struct {
+	RideID        string
+	RiderName     string
+	Pickup        Place
+	Dropoff       Place
+	StartedAt     time.Time
+	CompletedAt   time.Time
+	DistanceKm    float64
+	Currency      string
+	Fare          pricing.Breakdown
+	PaymentMethod string
+	Driver        Driver
+}
+
+// Place is a pickup or dropoff point
+type Place struct {
+	Latitude  float64
+	Longitude float64
+}
+
+func (p Place) String() string {
+	return fmt.Sprintf("%.5f, %.5f", p.Latitude, p.Longitude)
+}
+
+// Driver describes who drove the ride and in what
+type Driver struct {
+	Name         string
+	VehicleType  string
+	VehicleModel string
+	PlateNumber  string
+}
+
+// Line is one item of the fare breakdown
+type Line struct {
+	Label  string
+	Amount float64
+}
+
+// Duration is how long the trip took, to the minute
+func (r *Receipt) Duration() time.Duration {
+	return r.CompletedAt.Sub(r.StartedAt).Round(time.Minute)
+}
+
+// FormatDuration prints the trip duration as hours and minutes
+func (r *Receipt) FormatDuration() string {
+	d := r.Duration()
+	if d < time.Hour {
+		return fmt.Sprintf("%d min", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%d h %d min", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// FormatAmount prints amount in the receipt's currency
+func (r *Receipt) FormatAmount(amount float64) string {
+	return fmt.Sprintf("%s %.2f", r.Currency, amount)
+}
+
+// Lines itemises the fare, leaving out items that did not apply. The
+// discount is negative so that the lines add up to the total.
+func (r *Receipt) Lines() []Line {
+	fare := r.Fare
+	items := []Line{
+		{"Base fare", fare.BaseFare},
+		{fmt.Sprintf("Distance (%.2f km)", r.DistanceKm), fare.DistanceFare},
+		{fmt.Sprintf("Time (%s)", r.FormatDuration()), fare.TimeFare},
+		{"Minimum fare adjustment", fare.MinimumFareAdjustment},
+		{fmt.Sprintf("Surge (x%.2f)", fare.SurgeMultiplier), fare.SurgeAmount},
+		{"Booking fee", fare.BookingFee},
+		{"Promo discount", -fare.Discount},
+	}
+
+	lines := make([]Line, 0, len(items))
+	for _, item := range items {
+		if item.Amount != 0 {
+			lines = append(lines, item)
+		}
+	}
+	return lines
+}
+
+// Filename is the name the PDF copy is attached under
+func (r *Receipt) Filename() string {
+	return fmt.Sprintf("receipt-%s.pdf", r.RideID)
+}
@@ -0,0 +1,74 @@
+package receipt
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sayeed1999/share-a-ride/internal/pkg/pricing"
+)
+
+func newTestReceipt() *Receipt {
+	startedAt := time.Date(2024, 5, 6, 9, 30, 0, 0, time.UTC)
+	return &Receipt{
+		RideID:      "ride-1",
+		RiderName:   "Jane <Rider>",
+		Pickup:      Place{Latitude: 23.8103, Longitude: 90.4125},
+		Dropoff:     Place{Latitude: 23.7509, Longitude: 90.3935},
+		StartedAt:   startedAt,
+		CompletedAt: startedAt.Add(25*time.Minute + 10*time.Second),
+		DistanceKm:  8.5,
+		Currency:    "BDT",
+		Fare: pricing.Breakdown{BaseFare: 50, DistanceFare: 170, TimeFare: 50, SurgeMultiplier: 1,
+			BookingFee: 10, Discount: 30, Total: 250},
+		PaymentMethod: "card",
+		Driver:        Driver{Name: "John Driver", VehicleType: "car", VehicleModel: "Toyota Axio", PlateNumber: "DHA-1234"},
+	}
+}
+
+func TestLines(t *testing.T) {
+	r := newTestReceipt()
+
+	assert.Equal(t, []Line{
+		{"Base fare", 50},
+		{"Distance (8.50 km)", 170},
+		{"Time (25 min)", 50},
+		{"Booking fee", 10},
+		{"Promo discount", -30},
+	}, r.Lines())
+
+	var sum float64
+	for _, line := range r.Lines() {
+		sum += line.Amount
+	}
+	assert.Equal(t, r.Fare.Total, sum)
+}
+
+func TestFormatDuration(t *testing.T) {
+	r := newTestReceipt()
+	r.CompletedAt = r.StartedAt.Add(65 * time.Minute)
+
+	assert.Equal(t, "1 h 5 min", r.FormatDuration())
+}
+
+func TestHTML(t *testing.T) {
+	html, err := HTML(newTestReceipt())
+
+	assert.NoError(t, err)
+	assert.Contains(t, html, "Jane &lt;Rider&gt;")
+	assert.Contains(t, html, "23.81030, 90.41250 at 09:30")
+	assert.Contains(t, html, "Promo discount")
+	assert.Contains(t, html, "BDT 250.00")
+	assert.Contains(t, html, "Toyota Axio (car), DHA-1234")
+}
+
+func TestPDF(t *testing.T) {
+	doc := PDF(newTestReceipt())
+
+	assert.True(t, bytes.HasPrefix(doc, []byte("%PDF-")))
+	assert.True(t, bytes.HasSuffix(doc, []byte("%%EOF\n")))
+	assert.Contains(t, string(doc), "(BDT 250.00) Tj")
+	assert.Contains(t, string(doc), "(DHA-1234) Tj")
+}
@@ -0,0 +1,76 @@
+// Package surge works out price multipliers from local supply and demand.
+//
+// The map is divided into square cells of a fixed size in degrees; each
+// cell gets its own multiplier from the riders waiting and the drivers
+// available inside it.
+package surge
+
+import (
+	"math"
+
+	"github.com/sayeed1999/share-a-ride/internal/pkg/geo"
+)
+
+// Cell identifies one square of the grid.
+type Cell struct {
+	Row int `json:"row"`
+	Col int `json:"col"`
+}
+
+// CellOf returns the cell of size cellSizeDeg containing the point.
+func CellOf(lat, lng, cellSizeDeg float64) Cell {
+	return Cell{
+		Row: int(math.Floor(lat / cellSizeDeg)),
+		Col: int(math.Floor(lng / cellSizeDeg)),
+	}
+}
+
+// Bounds returns the area covered by c.
+func (c Cell) Bounds(cellSizeDeg float64) geo.Box {
+	return geo.Box{
+		MinLat: float64(c.Row) * cellSizeDeg,
+		MaxLat: float64(c.Row+1) * cellSizeDeg,
+		MinLng: float64(c.Col) * cellSizeDeg,
+		MaxLng: float64(c.Col+1) * cellSizeDeg,
+	}
+}
+
+// Params shape the multiplier curve.
+type Params struct {
+	// Sensitivity is how much the multiplier rises per waiting rider in
+	// excess of each available driver
+	Sensitivity float64
+	// MaxMultiplier caps the multiplier
+	MaxMultiplier float64
+	// Step rounds multipliers down to a multiple of itself, e.g. 0.1
+	Step float64
+}
+
+// Multiplier returns the surge multiplier for demand waiting riders and
+// supply available drivers. It is 1 while there are at least as many
+// drivers as riders and grows linearly with the excess demand after that.
+func Multiplier(demand, supply int, p Params) float64 {
+	if demand == 0 {
+		return 1
+	}
+
+	// With no drivers at all every waiting rider counts as excess demand
+	ratio := float64(demand) + 1
+	if supply > 0 {
+		ratio = float64(demand) / float64(supply)
+	}
+	if ratio <= 1 {
+		return 1
+	}
+
+	m := 1 + (ratio-1)*p.Sensitivity
+	if p.Step > 0 {
+		// Small epsilon so that exact multiples are not rounded down
+		m = math.Floor(m/p.Step+1e-9) * p.Step
+		m = math.Round(m*100) / 100
+	}
+	if p.MaxMultiplier >= 1 && m > p.MaxMultiplier {
+		m = p.MaxMultiplier
+	}
+	return math.Max(m, 1)
+}
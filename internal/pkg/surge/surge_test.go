@@ -0,0 +1,41 @@
+package surge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiplier(t *testing.T) {
+	params := Params{Sensitivity: 0.5, MaxMultiplier: 2.5, Step: 0.1}
+
+	tests := []struct {
+		name     string
+		demand   int
+		supply   int
+		expected float64
+	}{
+		{name: "No demand", demand: 0, supply: 0, expected: 1},
+		{name: "Enough drivers", demand: 3, supply: 5, expected: 1},
+		{name: "Balanced", demand: 4, supply: 4, expected: 1},
+		{name: "Twice the riders", demand: 8, supply: 4, expected: 1.5},
+		{name: "Rounded down to step", demand: 5, supply: 3, expected: 1.3},
+		{name: "No drivers", demand: 1, supply: 0, expected: 1.5},
+		{name: "Capped", demand: 40, supply: 2, expected: 2.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Multiplier(tt.demand, tt.supply, params))
+		})
+	}
+}
+
+func TestCellBoundsContainPoint(t *testing.T) {
+	const size = 0.02
+	for _, p := range [][2]float64{{23.8103, 90.4125}, {-33.8688, 151.2093}, {0, 0}, {-0.001, -0.001}} {
+		box := CellOf(p[0], p[1], size).Bounds(size)
+		assert.True(t, p[0] >= box.MinLat && p[0] < box.MaxLat, "latitude %v in %+v", p[0], box)
+		assert.True(t, p[1] >= box.MinLng && p[1] < box.MaxLng, "longitude %v in %+v", p[1], box)
+	}
+}
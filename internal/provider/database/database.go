@@ -2,6 +2,8 @@ package database
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/sayeed1999/share-a-ride/internal/domain/models"
 	"gorm.io/driver/postgres"
@@ -44,9 +46,137 @@ func (p *provider) Close() error {
 }
 
 func (p *provider) AutoMigrate(ctx context.Context) error {
-	return p.db.WithContext(ctx).AutoMigrate(
+	db := p.db.WithContext(ctx)
+
+	if err := db.AutoMigrate(
 		&models.User{},
 		&models.Driver{},
+		&models.Vehicle{},
 		&models.Document{},
-	)
+		&models.Ride{},
+		&models.RideOffer{},
+		&models.LocationPing{},
+		&models.PromoCode{},
+		&models.PromoRedemption{},
+		&models.Payment{},
+		&models.LedgerAccount{},
+		&models.JournalEntry{},
+		&models.Posting{},
+		&models.DriverEarning{},
+		&models.PayoutStatement{},
+		&models.Rating{},
+		&models.Pool{},
+		&models.AuditEntry{},
+	); err != nil {
+		return err
+	}
+
+	if err := migrateDriverLocation(db); err != nil {
+		return err
+	}
+	if err := migrateDriverVerification(db); err != nil {
+		return err
+	}
+	if err := migrateDriverVehicles(db); err != nil {
+		return err
+	}
+
+	// Uploaded documents have no stored URL
+	if err := db.Exec(`ALTER TABLE documents ALTER COLUMN file_url DROP NOT NULL`).Error; err != nil {
+		return err
+	}
+
+	// Partial index backing the bounding box prefilter of nearby driver searches
+	return db.Exec(`CREATE INDEX IF NOT EXISTS idx_drivers_available_verified_location
+		ON drivers (current_latitude, current_longitude)
+		WHERE is_available = true AND verification_status = 'approved'`).Error
+}
+
+// migrateDriverLocation copies the location of drivers from the columns
+// it was stored in before it was prefixed with current_ and drops them.
+func migrateDriverLocation(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&models.Driver{}, "latitude") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`UPDATE drivers SET current_latitude = latitude, current_longitude = longitude`).Error; err != nil {
+			return err
+		}
+		for _, column := range []string{"latitude", "longitude"} {
+			if err := tx.Migrator().DropColumn(&models.Driver{}, column); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// migrateDriverVerification carries the verified flag of drivers over to
+// their verification status and drops it, along with the index built on it.
+func migrateDriverVerification(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&models.Driver{}, "is_verified") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`UPDATE drivers SET verification_status = 'approved' WHERE is_verified = true`).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`UPDATE drivers SET submitted_at = created_at WHERE submitted_at IS NULL`).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`DROP INDEX IF EXISTS idx_drivers_available_location`).Error; err != nil {
+			return err
+		}
+		return tx.Migrator().DropColumn(&models.Driver{}, "is_verified")
+	})
+}
+
+// migrateDriverVehicles moves the vehicle embedded in each driver to the
+// vehicles table as the driver's active vehicle, hands it the driver's
+// registration and insurance documents and drops the driver columns.
+// Drivers registered before seat counts existed get the default seats.
+func migrateDriverVehicles(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasColumn(&models.Driver{}, "plate_number") {
+		return nil
+	}
+
+	// Plate numbers are unique per vehicle but were never checked on drivers
+	var duplicates []string
+	if err := db.Raw(`SELECT plate_number FROM drivers GROUP BY plate_number HAVING COUNT(*) > 1 ORDER BY plate_number`).
+		Scan(&duplicates).Error; err != nil {
+		return err
+	}
+	if len(duplicates) > 0 {
+		return fmt.Errorf("cannot migrate driver vehicles: plate numbers registered to more than one driver: %s",
+			strings.Join(duplicates, ", "))
+	}
+
+	seats := "0"
+	if migrator.HasColumn(&models.Driver{}, "seats") {
+		seats = "seats"
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`INSERT INTO vehicles (id, driver_id, type, model, plate_number, seats, is_active, created_at, updated_at)
+			SELECT gen_random_uuid(), id, type, model, plate_number, ` + seats + `, true, created_at, updated_at FROM drivers`).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`UPDATE documents SET vehicle_id = vehicles.id FROM vehicles
+			WHERE vehicles.driver_id = documents.driver_id AND documents.vehicle_id IS NULL
+			AND documents.type IN ('registration', 'insurance')`).Error; err != nil {
+			return err
+		}
+		for _, column := range []string{"type", "model", "plate_number", "seats"} {
+			if !tx.Migrator().HasColumn(&models.Driver{}, column) {
+				continue
+			}
+			if err := tx.Migrator().DropColumn(&models.Driver{}, column); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
@@ -1,15 +1,46 @@
 package email
 
 import (
+	"bytes"
+	"encoding/base64"
 	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
 	"net/smtp"
+	"net/textproto"
+	"time"
 
 	"github.com/sayeed1999/share-a-ride/internal/config"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/receipt"
+)
+
+const (
+	contentTypeText = "text/plain; charset=UTF-8"
+	contentTypeHTML = "text/html; charset=UTF-8"
 )
 
 type EmailServiceInterface interface {
 	SendVerificationEmail(to, token string) error
 	SendPasswordResetEmail(to, token string) error
+	// SendRideReceipt emails the receipt as HTML with a PDF copy attached
+	SendRideReceipt(to string, r *receipt.Receipt) error
+	// SendRideReminder reminds a rider of a ride scheduled for pickup at
+	// scheduledAt
+	SendRideReminder(to string, scheduledAt time.Time) error
+	// SendDriverVerificationDecision tells a driver the outcome of the
+	// review of their verification and the reviewer's reason
+	SendDriverVerificationDecision(to, status, reason string) error
+	// SendDocumentExpiryReminder warns a driver that one of their
+	// documents expires soon
+	SendDocumentExpiryReminder(to, documentType string, expiresOn time.Time) error
+}
+
+// Attachment is a file sent along with an email
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
 }
 
 type EmailService struct {
@@ -27,7 +58,7 @@ func (s *EmailService) SendVerificationEmail(to, token string) error {
 	verifyLink := fmt.Sprintf("%s/verify-email?token=%s", s.config.App.BaseURL, token)
 	body := fmt.Sprintf("Please click the link below to verify your email:\n%s", verifyLink)
 
-	return s.sendEmail(to, subject, body)
+	return s.sendEmail(to, subject, contentTypeText, body)
 }
 
 func (s *EmailService) SendPasswordResetEmail(to, token string) error {
@@ -35,10 +66,60 @@ func (s *EmailService) SendPasswordResetEmail(to, token string) error {
 	resetLink := fmt.Sprintf("%s/reset-password?token=%s", s.config.App.BaseURL, token)
 	body := fmt.Sprintf("Please click the link below to reset your password:\n%s\nThis link will expire in 1 hour.", resetLink)
 
-	return s.sendEmail(to, subject, body)
+	return s.sendEmail(to, subject, contentTypeText, body)
+}
+
+func (s *EmailService) SendRideReceipt(to string, r *receipt.Receipt) error {
+	subject := "Your ride receipt"
+	body, err := receipt.HTML(r)
+	if err != nil {
+		return err
+	}
+
+	return s.sendEmail(to, subject, contentTypeHTML, body, Attachment{
+		Filename:    r.Filename(),
+		ContentType: "application/pdf",
+		Data:        receipt.PDF(r),
+	})
+}
+
+func (s *EmailService) SendRideReminder(to string, scheduledAt time.Time) error {
+	subject := "Upcoming ride reminder"
+	body := fmt.Sprintf("Your ride is scheduled for pickup at %s.\nWe will start looking for a driver shortly before then.",
+		scheduledAt.UTC().Format("Mon, 02 Jan 2006 15:04 MST"))
+
+	return s.sendEmail(to, subject, contentTypeText, body)
+}
+
+// verificationDecisionMessages lead the email for each decision a reviewer
+// can make
+var verificationDecisionMessages = map[string]string{
+	"approved":              "Your driver account has been approved. You can now go online and accept rides.",
+	"rejected":              "Your driver account application has been rejected.",
+	"resubmission_required": "We need you to correct your driver details and submit them again.",
+}
+
+func (s *EmailService) SendDriverVerificationDecision(to, status, reason string) error {
+	subject := "Update on your driver verification"
+	message, ok := verificationDecisionMessages[status]
+	if !ok {
+		message = fmt.Sprintf("Your driver verification status is now %s.", status)
+	}
+	body := fmt.Sprintf("%s\n\nReason: %s", message, reason)
+
+	return s.sendEmail(to, subject, contentTypeText, body)
+}
+
+func (s *EmailService) SendDocumentExpiryReminder(to, documentType string, expiresOn time.Time) error {
+	subject := fmt.Sprintf("Your %s expires on %s", documentType, expiresOn.Format("2 January 2006"))
+	body := fmt.Sprintf("Your %s on file expires on %s. Upload a renewed %s before then; "+
+		"once it lapses you will be taken offline until the new one is reviewed.",
+		documentType, expiresOn.Format("Monday, 2 January 2006"), documentType)
+
+	return s.sendEmail(to, subject, contentTypeText, body)
 }
 
-func (s *EmailService) sendEmail(to, subject, body string) error {
+func (s *EmailService) sendEmail(to, subject, contentType, body string, attachments ...Attachment) error {
 	auth := smtp.PlainAuth(
 		"",
 		s.config.Email.Username,
@@ -46,17 +127,74 @@ func (s *EmailService) sendEmail(to, subject, body string) error {
 		s.config.Email.Host,
 	)
 
-	msg := fmt.Sprintf("From: %s\r\n"+
-		"To: %s\r\n"+
-		"Subject: %s\r\n"+
-		"\r\n"+
-		"%s\r\n", s.config.Email.From, to, subject, body)
+	msg, err := buildMessage(s.config.Email.From, to, subject, contentType, body, attachments)
+	if err != nil {
+		return err
+	}
 
 	return smtp.SendMail(
 		fmt.Sprintf("%s:%d", s.config.Email.Host, s.config.Email.Port),
 		auth,
 		s.config.Email.From,
 		[]string{to},
-		[]byte(msg),
+		msg,
 	)
 }
+
+// buildMessage writes a MIME message. A message with attachments is sent
+// as multipart/mixed with the body as its first part.
+func buildMessage(from, to, subject, contentType, body string, attachments []Attachment) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n"+
+		"To: %s\r\n"+
+		"Subject: %s\r\n"+
+		"MIME-Version: 1.0\r\n", from, to, mime.QEncoding.Encode("utf-8", subject))
+
+	if len(attachments) == 0 {
+		fmt.Fprintf(&buf, "Content-Type: %s\r\n\r\n%s\r\n", contentType, body)
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", mw.Boundary())
+
+	part, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {contentType}})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fmt.Fprintf(part, "%s\r\n", body); err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range attachments {
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(attachment.ContentType, map[string]string{"name": attachment.Filename})},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64(part, attachment.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeBase64 encodes data in lines of 76 characters, the most MIME allows
+func writeBase64(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := io.WriteString(w, encoded[:76]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := io.WriteString(w, encoded+"\r\n")
+	return err
+}
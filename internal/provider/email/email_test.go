@@ -1,10 +1,17 @@
 package email
 
 import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
 	"testing"
 
 	"github.com/sayeed1999/share-a-ride/internal/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSendVerificationEmail(t *testing.T) {
@@ -106,3 +113,52 @@ func TestSendPasswordResetEmail(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildMessage(t *testing.T) {
+	t.Run("Plain message", func(t *testing.T) {
+		msg, err := buildMessage("noreply@example.com", "user@example.com", "Hello", contentTypeText, "Hi there", nil)
+		require.NoError(t, err)
+
+		parsed, err := mail.ReadMessage(bytes.NewReader(msg))
+		require.NoError(t, err)
+		assert.Equal(t, "Hello", parsed.Header.Get("Subject"))
+		assert.Equal(t, contentTypeText, parsed.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(parsed.Body)
+		assert.Equal(t, "Hi there\r\n", string(body))
+	})
+
+	t.Run("Message with attachment", func(t *testing.T) {
+		data := bytes.Repeat([]byte("%PDF-1.4 "), 20)
+		msg, err := buildMessage("noreply@example.com", "user@example.com", "Your ride receipt", contentTypeHTML,
+			"<p>Thanks</p>", []Attachment{{Filename: "receipt-1.pdf", ContentType: "application/pdf", Data: data}})
+		require.NoError(t, err)
+
+		parsed, err := mail.ReadMessage(bytes.NewReader(msg))
+		require.NoError(t, err)
+		mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+		require.NoError(t, err)
+		assert.Equal(t, "multipart/mixed", mediaType)
+
+		reader := multipart.NewReader(parsed.Body, params["boundary"])
+		body, err := reader.NextPart()
+		require.NoError(t, err)
+		assert.Equal(t, contentTypeHTML, body.Header.Get("Content-Type"))
+
+		// The multipart reader decodes quoted-printable but not base64, so the
+		// raw part is checked for the encoding and line length instead
+		attachment, err := reader.NextPart()
+		require.NoError(t, err)
+		assert.Equal(t, "receipt-1.pdf", attachment.FileName())
+		assert.Equal(t, "base64", attachment.Header.Get("Content-Transfer-Encoding"))
+		encoded, _ := io.ReadAll(attachment)
+		for _, line := range bytes.Split(bytes.TrimSpace(encoded), []byte("\r\n")) {
+			assert.LessOrEqual(t, len(line), 76)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(string(bytes.ReplaceAll(encoded, []byte("\r\n"), nil)))
+		require.NoError(t, err)
+		assert.Equal(t, data, decoded)
+
+		_, err = reader.NextPart()
+		assert.Equal(t, io.EOF, err)
+	})
+}
@@ -0,0 +1,142 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+type fakeStatus int
+
+const (
+	fakeAuthorized fakeStatus = iota
+	fakeCaptured
+	fakeVoided
+)
+
+type fakeTransaction struct {
+	status   fakeStatus
+	amount   int64
+	refunded int64
+	// authorizationID links a capture to its authorization
+	authorizationID string
+}
+
+// Fake is an in-process Gateway for tests and local development. It
+// approves everything except customers marked with DeclineCustomer and
+// numbers its transactions sequentially, so runs are reproducible.
+type Fake struct {
+	mu           sync.Mutex
+	next         int
+	transactions map[string]*fakeTransaction
+	references   map[string]string
+	declined     map[string]bool
+}
+
+func NewFake() *Fake {
+	return &Fake{
+		transactions: make(map[string]*fakeTransaction),
+		references:   make(map[string]string),
+		declined:     make(map[string]bool),
+	}
+}
+
+// DeclineCustomer makes every later authorization for customerID fail.
+func (f *Fake) DeclineCustomer(customerID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.declined[customerID] = true
+}
+
+func (f *Fake) Authorize(ctx context.Context, req AuthorizeRequest) (*Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if id, ok := f.references[req.Reference]; ok {
+		return &Transaction{ID: id, Amount: f.transactions[id].amount}, nil
+	}
+	if f.declined[req.CustomerID] || req.Amount <= 0 {
+		return nil, ErrDeclined
+	}
+
+	id := f.newID("auth")
+	f.transactions[id] = &fakeTransaction{status: fakeAuthorized, amount: req.Amount}
+	if req.Reference != "" {
+		f.references[req.Reference] = id
+	}
+	return &Transaction{ID: id, Amount: req.Amount}, nil
+}
+
+func (f *Fake) Capture(ctx context.Context, authorizationID string, amount int64) (*Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	auth, ok := f.transactions[authorizationID]
+	if !ok {
+		return nil, ErrUnknownTransaction
+	}
+	if auth.status != fakeAuthorized {
+		return nil, ErrInvalidState
+	}
+	if amount > auth.amount {
+		return nil, ErrAmountTooLarge
+	}
+
+	auth.status = fakeCaptured
+	id := f.newID("capture")
+	f.transactions[id] = &fakeTransaction{status: fakeCaptured, amount: amount, authorizationID: authorizationID}
+	return &Transaction{ID: id, Amount: amount}, nil
+}
+
+func (f *Fake) Refund(ctx context.Context, captureID string, amount int64) (*Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	capture, ok := f.transactions[captureID]
+	if !ok {
+		return nil, ErrUnknownTransaction
+	}
+	if capture.status != fakeCaptured || capture.authorizationID == "" {
+		return nil, ErrInvalidState
+	}
+	if capture.refunded+amount > capture.amount {
+		return nil, ErrAmountTooLarge
+	}
+
+	capture.refunded += amount
+	id := f.newID("refund")
+	f.transactions[id] = &fakeTransaction{status: fakeCaptured, amount: amount}
+	return &Transaction{ID: id, Amount: amount}, nil
+}
+
+func (f *Fake) FindCapture(ctx context.Context, authorizationID string) (*Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for id, transaction := range f.transactions {
+		if transaction.authorizationID == authorizationID {
+			return &Transaction{ID: id, Amount: transaction.amount}, nil
+		}
+	}
+	return nil, ErrUnknownTransaction
+}
+
+func (f *Fake) Void(ctx context.Context, authorizationID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	auth, ok := f.transactions[authorizationID]
+	if !ok {
+		return ErrUnknownTransaction
+	}
+	if auth.status != fakeAuthorized {
+		return ErrInvalidState
+	}
+	auth.status = fakeVoided
+	return nil
+}
+
+func (f *Fake) newID(kind string) string {
+	f.next++
+	return fmt.Sprintf("fake_%s_%d", kind, f.next)
+}
@@ -0,0 +1,61 @@
+package payment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeLifecycle(t *testing.T) {
+	ctx := context.Background()
+	gateway := NewFake()
+
+	auth, err := gateway.Authorize(ctx, AuthorizeRequest{Reference: "ride-1", CustomerID: "rider-1", Amount: 35000, Currency: "BDT"})
+	require.NoError(t, err)
+	assert.Equal(t, "fake_auth_1", auth.ID)
+
+	// Authorizing the same reference again is idempotent
+	again, err := gateway.Authorize(ctx, AuthorizeRequest{Reference: "ride-1", CustomerID: "rider-1", Amount: 35000, Currency: "BDT"})
+	require.NoError(t, err)
+	assert.Equal(t, auth.ID, again.ID)
+
+	_, err = gateway.Capture(ctx, auth.ID, 40000)
+	assert.Equal(t, ErrAmountTooLarge, err)
+
+	capture, err := gateway.Capture(ctx, auth.ID, 28000)
+	require.NoError(t, err)
+	assert.Equal(t, int64(28000), capture.Amount)
+
+	assert.Equal(t, ErrInvalidState, gateway.Void(ctx, auth.ID))
+	_, err = gateway.Capture(ctx, auth.ID, 28000)
+	assert.Equal(t, ErrInvalidState, err)
+	found, err := gateway.FindCapture(ctx, auth.ID)
+	require.NoError(t, err)
+	assert.Equal(t, capture, found)
+
+	_, err = gateway.Refund(ctx, capture.ID, 20000)
+	require.NoError(t, err)
+	_, err = gateway.Refund(ctx, capture.ID, 10000)
+	assert.Equal(t, ErrAmountTooLarge, err)
+}
+
+func TestFakeDeclineAndVoid(t *testing.T) {
+	ctx := context.Background()
+	gateway := NewFake()
+	gateway.DeclineCustomer("rider-2")
+
+	_, err := gateway.Authorize(ctx, AuthorizeRequest{Reference: "ride-2", CustomerID: "rider-2", Amount: 1000})
+	assert.Equal(t, ErrDeclined, err)
+
+	auth, err := gateway.Authorize(ctx, AuthorizeRequest{Reference: "ride-3", CustomerID: "rider-3", Amount: 1000})
+	require.NoError(t, err)
+	assert.NoError(t, gateway.Void(ctx, auth.ID))
+	_, err = gateway.Capture(ctx, auth.ID, 1000)
+	assert.Equal(t, ErrInvalidState, err)
+
+	assert.Equal(t, ErrUnknownTransaction, gateway.Void(ctx, "missing"))
+	_, err = gateway.FindCapture(ctx, auth.ID)
+	assert.Equal(t, ErrUnknownTransaction, err)
+}
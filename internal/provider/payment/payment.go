@@ -0,0 +1,52 @@
+// Package payment talks to payment gateways. Amounts are in minor units of
+// the currency, e.g. cents or poisha, so that no rounding happens at the
+// gateway boundary.
+package payment
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrDeclined means the payment method was refused
+	ErrDeclined = errors.New("payment declined")
+	// ErrUnknownTransaction means the gateway has no record of the transaction
+	ErrUnknownTransaction = errors.New("unknown payment transaction")
+	// ErrInvalidState means the transaction cannot make the requested move,
+	// e.g. capturing a voided authorization
+	ErrInvalidState = errors.New("payment transaction in invalid state")
+	// ErrAmountTooLarge means a capture exceeds its authorization or a
+	// refund exceeds its capture
+	ErrAmountTooLarge = errors.New("amount exceeds the available amount")
+)
+
+type AuthorizeRequest struct {
+	// Reference identifies the purchase; authorizing the same reference
+	// twice returns the first authorization
+	Reference  string
+	CustomerID string
+	Amount     int64
+	Currency   string
+}
+
+// Transaction is the gateway's record of one operation.
+type Transaction struct {
+	ID     string
+	Amount int64
+}
+
+// Gateway moves money through a payment provider. Funds are first held
+// with Authorize and later charged with Capture or released with Void;
+// captured funds can be returned with Refund.
+type Gateway interface {
+	Authorize(ctx context.Context, req AuthorizeRequest) (*Transaction, error)
+	// Capture charges amount of an authorization; it may be less than the
+	// authorized amount, in which case the rest is released
+	Capture(ctx context.Context, authorizationID string, amount int64) (*Transaction, error)
+	Refund(ctx context.Context, captureID string, amount int64) (*Transaction, error)
+	// FindCapture returns the capture of an authorization, or
+	// ErrUnknownTransaction if it was not captured
+	FindCapture(ctx context.Context, authorizationID string) (*Transaction, error)
+	Void(ctx context.Context, authorizationID string) error
+}
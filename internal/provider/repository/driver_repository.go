@@ -2,11 +2,14 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
 	"github.com/sayeed1999/share-a-ride/internal/domain/models"
 	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"github.com/sayeed1999/share-a-ride/internal/pkg/geo"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type driverRepository struct {
@@ -17,13 +20,27 @@ func NewDriverRepository(db *gorm.DB) repositories.DriverRepository {
 	return &driverRepository{db: db}
 }
 
+// Create stores the driver with their vehicles and documents. The active
+// vehicle is one of Vehicles and is not stored twice.
 func (r *driverRepository) Create(ctx context.Context, driver *models.Driver) error {
-	return r.db.WithContext(ctx).Create(driver).Error
+	return r.db.WithContext(ctx).Omit("ActiveVehicle").Create(driver).Error
+}
+
+// preloadActiveVehicle loads the one vehicle a driver has marked active
+func preloadActiveVehicle(db *gorm.DB) *gorm.DB {
+	return db.Preload("ActiveVehicle", "is_active = ?", true)
+}
+
+// preloadVehicles loads the driver's vehicles and documents
+func preloadVehicles(db *gorm.DB) *gorm.DB {
+	return preloadActiveVehicle(db).
+		Preload("Vehicles", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Documents")
 }
 
 func (r *driverRepository) FindByID(ctx context.Context, id string) (*models.Driver, error) {
 	var driver models.Driver
-	if err := r.db.WithContext(ctx).Preload("Documents").First(&driver, "id = ?", id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(preloadVehicles).First(&driver, "id = ?", id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, errors.ErrDriverNotFound
 		}
@@ -34,7 +51,7 @@ func (r *driverRepository) FindByID(ctx context.Context, id string) (*models.Dri
 
 func (r *driverRepository) FindByUserID(ctx context.Context, userID string) (*models.Driver, error) {
 	var driver models.Driver
-	if err := r.db.WithContext(ctx).Preload("Documents").First(&driver, "user_id = ?", userID).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(preloadVehicles).First(&driver, "user_id = ?", userID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, errors.ErrDriverNotFound
 		}
@@ -62,6 +79,117 @@ func (r *driverRepository) Delete(ctx context.Context, id string) error {
 	return r.db.WithContext(ctx).Delete(&models.Driver{}, "id = ?", id).Error
 }
 
+func (r *driverRepository) ListByVerificationStatus(ctx context.Context, filter repositories.VerificationQueueFilter) ([]models.Driver, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Driver{}).
+		Where("verification_status = ?", filter.Status)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var drivers []models.Driver
+	if err := query.
+		Preload("User").
+		Scopes(preloadVehicles).
+		Order("submitted_at ASC").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&drivers).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return drivers, total, nil
+}
+
+func (r *driverRepository) UpdateVerification(ctx context.Context, driver *models.Driver, expected models.VerificationStatus) error {
+	result := r.db.WithContext(ctx).Model(&models.Driver{}).
+		Where("id = ? AND verification_status = ?", driver.ID, expected).
+		Select("verification_status", "reviewed_by", "reviewed_at", "review_reason", "is_available", "updated_at").
+		Updates(driver)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrVerificationConflict
+	}
+	return nil
+}
+
+func (r *driverRepository) Suspend(ctx context.Context, driver *models.Driver, expected models.VerificationStatus, entry *models.AuditEntry) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Driver{}).
+			Where("id = ? AND verification_status = ?", driver.ID, expected).
+			Select("verification_status", "reviewed_by", "reviewed_at", "review_reason", "is_available", "updated_at").
+			Updates(driver)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.ErrVerificationConflict
+		}
+		return tx.Create(entry).Error
+	})
+}
+
+func (r *driverRepository) FindApprovedWithLapsedDocuments(ctx context.Context, day time.Time) ([]models.Driver, error) {
+	var drivers []models.Driver
+	if err := r.db.WithContext(ctx).
+		Scopes(preloadVehicles).
+		Where("verification_status = ?", models.VerificationStatusApproved).
+		Where(`EXISTS (
+			SELECT 1 FROM documents lapsed
+			LEFT JOIN vehicles ON vehicles.id = lapsed.vehicle_id
+			WHERE lapsed.driver_id = drivers.id AND lapsed.expires_on < ?
+			AND (lapsed.vehicle_id IS NULL OR vehicles.is_active)
+			AND NOT EXISTS (
+				SELECT 1 FROM documents valid
+				WHERE valid.driver_id = lapsed.driver_id AND valid.type = lapsed.type
+				AND valid.vehicle_id IS NOT DISTINCT FROM lapsed.vehicle_id
+				AND (valid.expires_on IS NULL OR valid.expires_on >= ?)
+			)
+		)`, day, day).
+		Find(&drivers).Error; err != nil {
+		return nil, err
+	}
+	return drivers, nil
+}
+
+func (r *driverRepository) Resubmit(ctx context.Context, driver *models.Driver, expected models.VerificationStatus) ([]models.Document, error) {
+	var removed []models.Document
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Driver{}).
+			Where("id = ? AND verification_status = ?", driver.ID, expected).
+			Select("license_number", "verification_status", "submitted_at", "updated_at").
+			Updates(driver)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.ErrVerificationConflict
+		}
+
+		if err := tx.Save(driver.ActiveVehicle).Error; err != nil {
+			return err
+		}
+
+		// Documents of the driver's other vehicles stay
+		if err := tx.Clauses(clause.Returning{}).
+			Where("driver_id = ? AND (vehicle_id IS NULL OR vehicle_id = ?)", driver.ID, driver.ActiveVehicle.ID).
+			Delete(&removed).Error; err != nil {
+			return err
+		}
+		if len(driver.Documents) == 0 {
+			return nil
+		}
+		return tx.Create(&driver.Documents).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
 func (r *driverRepository) AddDocument(ctx context.Context, document *models.Document) error {
 	return r.db.WithContext(ctx).Create(document).Error
 }
@@ -74,20 +202,116 @@ func (r *driverRepository) GetDocuments(ctx context.Context, driverID string) ([
 	return documents, nil
 }
 
-func (r *driverRepository) DeleteDocument(ctx context.Context, documentID string) error {
-	return r.db.WithContext(ctx).Delete(&models.Document{}, "id = ?", documentID).Error
+func (r *driverRepository) SaveDocument(ctx context.Context, document *models.Document, replacedID string, driver *models.Driver, expected models.VerificationStatus) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if replacedID != "" {
+			if err := deleteDocument(tx, document.DriverID, replacedID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(document).Error; err != nil {
+			return err
+		}
+		if driver == nil {
+			return nil
+		}
+		return reverifyDriver(tx, driver, expected)
+	})
+}
+
+// reverifyDriver persists the review queue entry of driver, guarded by the
+// verification status it had
+func reverifyDriver(tx *gorm.DB, driver *models.Driver, expected models.VerificationStatus) error {
+	result := tx.Model(&models.Driver{}).
+		Where("id = ? AND verification_status = ?", driver.ID, expected).
+		Select("verification_status", "submitted_at", "is_available", "updated_at").
+		Updates(driver)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrVerificationConflict
+	}
+	return nil
+}
+
+func (r *driverRepository) DeleteDocument(ctx context.Context, driverID string, documentID string) error {
+	return deleteDocument(r.db.WithContext(ctx), driverID, documentID)
+}
+
+func deleteDocument(db *gorm.DB, driverID string, documentID string) error {
+	result := db.Delete(&models.Document{}, "id = ? AND driver_id = ?", documentID, driverID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrDocumentNotFound
+	}
+	return nil
 }
 
-func (r *driverRepository) UpdateLocation(ctx context.Context, driverID string, lat, lng float64) error {
+func (r *driverRepository) FindDocumentsExpiringBetween(ctx context.Context, from, to time.Time) ([]models.Document, error) {
+	var documents []models.Document
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN drivers ON drivers.id = documents.driver_id").
+		Where("drivers.verification_status <> ?", models.VerificationStatusRejected).
+		Where("documents.expires_on BETWEEN ? AND ?", from, to).
+		Order("documents.expires_on ASC").
+		Find(&documents).Error; err != nil {
+		return nil, err
+	}
+	return documents, nil
+}
+
+func (r *driverRepository) UpdateDocumentReminder(ctx context.Context, documentID string, days int) error {
+	return r.db.WithContext(ctx).Model(&models.Document{}).
+		Where("id = ?", documentID).
+		Update("expiry_reminder_days", days).Error
+}
+
+func (r *driverRepository) FindStorageKeysInUse(ctx context.Context, keys []string) ([]string, error) {
+	var inUse []string
+	if err := r.db.WithContext(ctx).Model(&models.Document{}).
+		Where("storage_key IN ?", keys).
+		Distinct().
+		Pluck("storage_key", &inUse).Error; err != nil {
+		return nil, err
+	}
+	return inUse, nil
+}
+
+func (r *driverRepository) UpdateLocation(ctx context.Context, driverID string, lat, lng float64, at time.Time) error {
 	return r.db.WithContext(ctx).Model(&models.Driver{}).
 		Where("id = ?", driverID).
 		Updates(map[string]interface{}{
-			"current_latitude":  lat,
-			"current_longitude": lng,
-			"updated_at":        gorm.Expr("NOW()"),
+			"current_latitude":    lat,
+			"current_longitude":   lng,
+			"location_updated_at": at,
+			"updated_at":          gorm.Expr("NOW()"),
 		}).Error
 }
 
+func (r *driverRepository) MarkStaleUnavailable(ctx context.Context, before time.Time) ([]string, error) {
+	var drivers []models.Driver
+	err := r.db.WithContext(ctx).Model(&drivers).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+		Where("is_available = ?", true).
+		Where("location_updated_at IS NULL OR location_updated_at < ?", before).
+		Updates(map[string]interface{}{
+			"is_available": false,
+			"updated_at":   gorm.Expr("NOW()"),
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(drivers))
+	for i, driver := range drivers {
+		ids[i] = driver.ID
+	}
+	return ids, nil
+}
+
 func (r *driverRepository) UpdateAvailability(ctx context.Context, driverID string, isAvailable bool) error {
 	return r.db.WithContext(ctx).Model(&models.Driver{}).
 		Where("id = ?", driverID).
@@ -97,28 +321,99 @@ func (r *driverRepository) UpdateAvailability(ctx context.Context, driverID stri
 		}).Error
 }
 
-func (r *driverRepository) FindAvailableNearby(ctx context.Context, lat, lng float64, radiusKm float64) ([]models.Driver, error) {
+func (r *driverRepository) FindAllAvailable(ctx context.Context) ([]models.Driver, error) {
 	var drivers []models.Driver
+	if err := r.db.WithContext(ctx).
+		Scopes(preloadActiveVehicle).
+		Where("is_available = ? AND verification_status = ?", true, models.VerificationStatusApproved).
+		Find(&drivers).Error; err != nil {
+		return nil, err
+	}
+	return drivers, nil
+}
+
+// haversineDistanceSQL computes the distance in km from a point to the
+// driver's current location. Its arguments are the earth radius, the point's
+// latitude twice and its longitude. LEAST guards asin against rounding above 1.
+const haversineDistanceSQL = `2 * ? * asin(LEAST(1, sqrt(
+	power(sin(radians(current_latitude - ?) / 2), 2) +
+	cos(radians(?)) * cos(radians(current_latitude)) *
+	power(sin(radians(current_longitude - ?) / 2), 2)
+)))`
+
+func (r *driverRepository) FindAvailableNearby(ctx context.Context, query repositories.NearbyDriverQuery) ([]models.Driver, error) {
+	var drivers []models.Driver
+
+	// Cheap bounding box prefilter on the indexed coordinates first, so the
+	// exact distance is only computed for drivers that can be in range
+	box := geo.BoundingBox(query.Latitude, query.Longitude, query.RadiusKm)
+	candidates := r.db.Model(&models.Driver{}).
+		Select("drivers.*, "+haversineDistanceSQL+" AS distance",
+			geo.EarthRadiusKm, query.Latitude, query.Latitude, query.Longitude).
+		Where("is_available = ? AND verification_status = ?", true, models.VerificationStatusApproved).
+		Where("current_latitude BETWEEN ? AND ?", box.MinLat, box.MaxLat)
+	if !box.CoversAllLongitudes() {
+		candidates = candidates.Where("current_longitude BETWEEN ? AND ?", box.MinLng, box.MaxLng)
+	}
+	if query.VehicleType != "" {
+		candidates = candidates.Where(`EXISTS (
+			SELECT 1 FROM vehicles
+			WHERE vehicles.driver_id = drivers.id AND vehicles.is_active AND vehicles.type = ?
+		)`, query.VehicleType)
+	}
+
+	// The distance alias is only visible outside the subquery
+	nearby := r.db.WithContext(ctx).
+		Table("(?) AS nearby", candidates).
+		Where("distance <= ?", query.RadiusKm).
+		Order("distance")
+	if query.Limit > 0 {
+		nearby = nearby.Limit(query.Limit)
+	}
 
-	// Using the Haversine formula to calculate distance
-	query := `
-		SELECT *,
-		(6371 * acos(cos(radians(?)) * 
-		cos(radians(current_latitude)) * 
-		cos(radians(current_longitude) - 
-		radians(?)) + 
-		sin(radians(?)) * 
-		sin(radians(current_latitude)))) AS distance 
-		FROM drivers 
-		WHERE is_available = true 
-		AND is_verified = true
-		HAVING distance <= ? 
-		ORDER BY distance
-	`
-
-	if err := r.db.WithContext(ctx).Raw(query, lat, lng, lat, radiusKm).Scan(&drivers).Error; err != nil {
+	if err := nearby.Scopes(preloadActiveVehicle).Find(&drivers).Error; err != nil {
 		return nil, err
 	}
 
 	return drivers, nil
 }
+
+func (r *driverRepository) AddVehicle(ctx context.Context, vehicle *models.Vehicle) error {
+	return r.db.WithContext(ctx).Create(vehicle).Error
+}
+
+func (r *driverRepository) FindVehicleByPlateNumber(ctx context.Context, plateNumber string) (*models.Vehicle, error) {
+	var vehicle models.Vehicle
+	if err := r.db.WithContext(ctx).First(&vehicle, "plate_number = ?", plateNumber).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrVehicleNotFound
+		}
+		return nil, err
+	}
+	return &vehicle, nil
+}
+
+func (r *driverRepository) ActivateVehicle(ctx context.Context, driverID string, vehicleID string, at time.Time, driver *models.Driver, expected models.VerificationStatus) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Deactivate first, the active vehicle of a driver is unique
+		if err := tx.Model(&models.Vehicle{}).
+			Where("driver_id = ? AND is_active = ? AND id <> ?", driverID, true, vehicleID).
+			Updates(map[string]interface{}{"is_active": false, "updated_at": at}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.Vehicle{}).
+			Where("id = ? AND driver_id = ?", vehicleID, driverID).
+			Updates(map[string]interface{}{"is_active": true, "updated_at": at})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.ErrVehicleNotFound
+		}
+		if driver == nil {
+			return nil
+		}
+		return reverifyDriver(tx, driver, expected)
+	})
+}
@@ -0,0 +1,384 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"github.com/sayeed1999/share-a-ride/internal/provider/database"
+)
+
+// newTestDB returns a connection to a fresh, migrated schema in the
+// Postgres pointed at by TEST_DATABASE_DSN (see `make test-integration`).
+// The schema is dropped when the test finishes.
+func newTestDB(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set")
+	}
+
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	require.NoError(t, admin.Exec("CREATE SCHEMA "+schema).Error)
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	provider, err := database.New(database.Config{DSN: dsn + " search_path=" + schema})
+	require.NoError(t, err)
+	t.Cleanup(func() { provider.Close() })
+	require.NoError(t, provider.AutoMigrate(context.Background()))
+
+	return provider.DB()
+}
+
+func createTestDriver(t *testing.T, db *gorm.DB, vehicleType models.VehicleType, lat, lng float64, available, verified bool) *models.Driver {
+	t.Helper()
+
+	id := uuid.New().String()
+	user := &models.User{
+		ID:        id,
+		Name:      "Driver " + id[:8],
+		Email:     id + "@example.com",
+		Phone:     id[:20],
+		Password:  "hashed",
+		UserType:  models.UserTypeDriver,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	require.NoError(t, db.Create(user).Error)
+
+	driver := models.NewDriver(user.ID, "LIC-"+id[:8], models.Vehicle{Type: vehicleType, Model: "Test", PlateNumber: id[:8]})
+	driver.UpdateLocation(lat, lng, time.Now())
+	driver.IsAvailable = available
+	if verified {
+		driver.VerificationStatus = models.VerificationStatusApproved
+	}
+	require.NoError(t, db.Omit("ActiveVehicle").Create(driver).Error)
+	return driver
+}
+
+func TestFindAvailableNearby(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewDriverRepository(db)
+	ctx := context.Background()
+
+	// Pickup in central Dhaka; offsets of 0.009 degrees latitude are ~1km
+	lat, lng := 23.8103, 90.4125
+	near := createTestDriver(t, db, models.VehicleTypeCar, lat+0.009, lng, true, true)
+	mid := createTestDriver(t, db, models.VehicleTypeBike, lat-0.027, lng, true, true)
+	far := createTestDriver(t, db, models.VehicleTypeCar, lat+0.09, lng, true, true)
+	createTestDriver(t, db, models.VehicleTypeCar, lat, lng+0.001, false, true)
+	createTestDriver(t, db, models.VehicleTypeCar, lat, lng-0.001, true, false)
+
+	ids := func(drivers []models.Driver) []string {
+		result := make([]string, len(drivers))
+		for i, d := range drivers {
+			result[i] = d.ID
+		}
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		query    repositories.NearbyDriverQuery
+		expected []string
+	}{
+		{
+			name:     "Only available verified drivers in range, nearest first",
+			query:    repositories.NearbyDriverQuery{Latitude: lat, Longitude: lng, RadiusKm: 5},
+			expected: []string{near.ID, mid.ID},
+		},
+		{
+			name:     "Wider radius",
+			query:    repositories.NearbyDriverQuery{Latitude: lat, Longitude: lng, RadiusKm: 15},
+			expected: []string{near.ID, mid.ID, far.ID},
+		},
+		{
+			name:     "Limit",
+			query:    repositories.NearbyDriverQuery{Latitude: lat, Longitude: lng, RadiusKm: 15, Limit: 1},
+			expected: []string{near.ID},
+		},
+		{
+			name:     "Vehicle type filter",
+			query:    repositories.NearbyDriverQuery{Latitude: lat, Longitude: lng, RadiusKm: 15, VehicleType: models.VehicleTypeBike},
+			expected: []string{mid.ID},
+		},
+		{
+			name:     "Nobody in range",
+			query:    repositories.NearbyDriverQuery{Latitude: lat, Longitude: lng, RadiusKm: 0.5},
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drivers, err := repo.FindAvailableNearby(ctx, tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, ids(drivers))
+		})
+	}
+}
+
+func TestUpdateLocationIsFoundNearby(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewDriverRepository(db)
+	ctx := context.Background()
+
+	driver := createTestDriver(t, db, models.VehicleTypeCar, 0, 0, true, true)
+	require.NoError(t, repo.UpdateLocation(ctx, driver.ID, 23.8103, 90.4125, time.Now()))
+
+	drivers, err := repo.FindAvailableNearby(ctx, repositories.NearbyDriverQuery{Latitude: 23.8103, Longitude: 90.4125, RadiusKm: 1})
+	assert.NoError(t, err)
+	assert.Len(t, drivers, 1)
+	assert.InDelta(t, 23.8103, drivers[0].CurrentLocation.Latitude, 1e-6)
+}
+
+func TestMarkStaleUnavailable(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewDriverRepository(db)
+	ctx := context.Background()
+
+	stale := createTestDriver(t, db, models.VehicleTypeCar, 23.8103, 90.4125, true, true)
+	fresh := createTestDriver(t, db, models.VehicleTypeCar, 23.8103, 90.4125, true, true)
+	offline := createTestDriver(t, db, models.VehicleTypeCar, 23.8103, 90.4125, false, true)
+	require.NoError(t, repo.UpdateLocation(ctx, stale.ID, 23.8103, 90.4125, time.Now().Add(-time.Hour)))
+	require.NoError(t, repo.UpdateLocation(ctx, offline.ID, 23.8103, 90.4125, time.Now().Add(-time.Hour)))
+
+	expired, err := repo.MarkStaleUnavailable(ctx, time.Now().Add(-10*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{stale.ID}, expired)
+
+	drivers, err := repo.FindAllAvailable(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, drivers, 1) {
+		assert.Equal(t, fresh.ID, drivers[0].ID)
+	}
+}
+
+func TestVerificationQueue(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewDriverRepository(db)
+	ctx := context.Background()
+
+	older := createTestDriver(t, db, models.VehicleTypeCar, 0, 0, false, false)
+	newer := createTestDriver(t, db, models.VehicleTypeCar, 0, 0, false, false)
+	createTestDriver(t, db, models.VehicleTypeCar, 0, 0, false, true)
+	require.NoError(t, db.Model(older).Update("submitted_at", time.Now().Add(-time.Hour)).Error)
+
+	drivers, total, err := repo.ListByVerificationStatus(ctx, repositories.VerificationQueueFilter{
+		Status: models.VerificationStatusPending, Page: 1, Limit: 10,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	if assert.Len(t, drivers, 2) {
+		assert.Equal(t, older.ID, drivers[0].ID)
+		assert.Equal(t, newer.ID, drivers[1].ID)
+		assert.NotNil(t, drivers[0].User)
+	}
+
+	newer.Review(models.VerificationStatusApproved, older.UserID, "ok", time.Now())
+	assert.NoError(t, repo.UpdateVerification(ctx, newer, models.VerificationStatusPending))
+	// A second reviewer working from the stale status loses
+	assert.Equal(t, errors.ErrVerificationConflict, repo.UpdateVerification(ctx, newer, models.VerificationStatusPending))
+
+	stored, err := repo.FindByID(ctx, newer.ID)
+	assert.NoError(t, err)
+	assert.True(t, stored.IsVerified())
+	assert.Equal(t, "ok", stored.ReviewReason)
+}
+
+func TestDocumentExpiry(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewDriverRepository(db)
+	ctx := context.Background()
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	addDocument := func(driver *models.Driver, docType models.DocumentType, expiresInDays int) *models.Document {
+		expiresOn := today.AddDate(0, 0, expiresInDays)
+		document := models.NewDocument(driver.ID, docType, models.DocumentFile{})
+		document.ExpiresOn = &expiresOn
+		require.NoError(t, repo.AddDocument(ctx, document))
+		return document
+	}
+
+	lapsed := createTestDriver(t, db, models.VehicleTypeCar, 0, 0, true, true)
+	addDocument(lapsed, models.DocumentTypeLicense, -1)
+	renewed := createTestDriver(t, db, models.VehicleTypeCar, 0, 0, true, true)
+	addDocument(renewed, models.DocumentTypeLicense, -1)
+	renewal := addDocument(renewed, models.DocumentTypeLicense, 7)
+	unverified := createTestDriver(t, db, models.VehicleTypeCar, 0, 0, false, false)
+	addDocument(unverified, models.DocumentTypeLicense, -1)
+
+	drivers, err := repo.FindApprovedWithLapsedDocuments(ctx, today)
+	assert.NoError(t, err)
+	if assert.Len(t, drivers, 1) {
+		assert.Equal(t, lapsed.ID, drivers[0].ID)
+		assert.Len(t, drivers[0].Documents, 1)
+	}
+
+	expiring, err := repo.FindDocumentsExpiringBetween(ctx, today, today.AddDate(0, 0, 30))
+	assert.NoError(t, err)
+	if assert.Len(t, expiring, 1) {
+		assert.Equal(t, renewal.ID, expiring[0].ID)
+	}
+	assert.NoError(t, repo.UpdateDocumentReminder(ctx, renewal.ID, 7))
+
+	lapsed.Suspend("license expired", time.Now())
+	entry := models.NewAuditEntry(models.AuditActionDriverSuspended, "driver", lapsed.ID, nil, "license expired", time.Now())
+	assert.NoError(t, repo.Suspend(ctx, lapsed, models.VerificationStatusApproved, entry))
+	// An admin who reviewed the driver in the meantime wins
+	again := models.NewAuditEntry(models.AuditActionDriverSuspended, "driver", lapsed.ID, nil, "license expired", time.Now())
+	assert.Equal(t, errors.ErrVerificationConflict, repo.Suspend(ctx, lapsed, models.VerificationStatusApproved, again))
+
+	stored, err := repo.FindByID(ctx, lapsed.ID)
+	assert.NoError(t, err)
+	assert.False(t, stored.IsAvailable)
+	assert.Equal(t, models.VerificationStatusResubmissionRequired, stored.VerificationStatus)
+	var entries int64
+	require.NoError(t, db.Model(&models.AuditEntry{}).Where("subject_id = ?", lapsed.ID).Count(&entries).Error)
+	assert.Equal(t, int64(1), entries)
+}
+
+func TestActivateVehicle(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewDriverRepository(db)
+	ctx := context.Background()
+
+	driver := createTestDriver(t, db, models.VehicleTypeCar, 23.8103, 90.4125, false, true)
+	other := createTestDriver(t, db, models.VehicleTypeCar, 0, 0, false, true)
+	bike := models.NewVehicle(driver.ID, models.Vehicle{Type: models.VehicleTypeBike, Model: "Test", PlateNumber: "BIKE-" + driver.ID[:8]})
+	require.NoError(t, repo.AddVehicle(ctx, bike))
+
+	found, err := repo.FindVehicleByPlateNumber(ctx, bike.PlateNumber)
+	assert.NoError(t, err)
+	assert.Equal(t, bike.ID, found.ID)
+	_, err = repo.FindVehicleByPlateNumber(ctx, "missing")
+	assert.Equal(t, errors.ErrVehicleNotFound, err)
+
+	// Vehicles are only reachable through the driver who registered them
+	assert.Equal(t, errors.ErrVehicleNotFound, repo.ActivateVehicle(ctx, other.ID, bike.ID, time.Now(), nil, ""))
+
+	require.NoError(t, repo.ActivateVehicle(ctx, driver.ID, bike.ID, time.Now(), nil, ""))
+	stored, err := repo.FindByID(ctx, driver.ID)
+	require.NoError(t, err)
+	if assert.NotNil(t, stored.ActiveVehicle) {
+		assert.Equal(t, bike.ID, stored.ActiveVehicle.ID)
+	}
+	if assert.Len(t, stored.Vehicles, 2) {
+		assert.False(t, stored.Vehicles[0].IsActive)
+		assert.True(t, stored.Vehicles[1].IsActive)
+	}
+
+	// Nearby drivers are matched on the vehicle they drive
+	require.NoError(t, repo.UpdateAvailability(ctx, driver.ID, true))
+	query := repositories.NearbyDriverQuery{Latitude: 23.8103, Longitude: 90.4125, RadiusKm: 1, VehicleType: models.VehicleTypeCar}
+	drivers, err := repo.FindAvailableNearby(ctx, query)
+	assert.NoError(t, err)
+	assert.Empty(t, drivers)
+	query.VehicleType = models.VehicleTypeBike
+	drivers, err = repo.FindAvailableNearby(ctx, query)
+	assert.NoError(t, err)
+	if assert.Len(t, drivers, 1) {
+		assert.Equal(t, bike.ID, drivers[0].ActiveVehicle.ID)
+	}
+
+	// Switching back can put the driver in review in the same write
+	driver.Reverify(time.Now())
+	require.NoError(t, repo.ActivateVehicle(ctx, driver.ID, driver.ActiveVehicle.ID, time.Now(), driver, models.VerificationStatusApproved))
+	stored, err = repo.FindByID(ctx, driver.ID)
+	require.NoError(t, err)
+	assert.Equal(t, driver.ActiveVehicle.ID, stored.ActiveVehicle.ID)
+	assert.Equal(t, models.VerificationStatusPending, stored.VerificationStatus)
+	assert.False(t, stored.IsAvailable)
+}
+
+func TestSaveAndDeleteDocument(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewDriverRepository(db)
+	ctx := context.Background()
+
+	driver := createTestDriver(t, db, models.VehicleTypeCar, 0, 0, true, true)
+	other := createTestDriver(t, db, models.VehicleTypeCar, 0, 0, true, true)
+	license := models.NewDocument(driver.ID, models.DocumentTypeLicense, models.DocumentFile{})
+	require.NoError(t, repo.AddDocument(ctx, license))
+
+	// Documents are only reachable through the driver who holds them
+	assert.Equal(t, errors.ErrDocumentNotFound, repo.DeleteDocument(ctx, other.ID, license.ID))
+	stolen := models.NewDocument(other.ID, models.DocumentTypeLicense, models.DocumentFile{})
+	assert.Equal(t, errors.ErrDocumentNotFound, repo.SaveDocument(ctx, stolen, license.ID, nil, ""))
+
+	renewed := models.NewDocument(driver.ID, models.DocumentTypeLicense, models.DocumentFile{})
+	driver.Reverify(time.Now())
+	assert.NoError(t, repo.SaveDocument(ctx, renewed, license.ID, driver, models.VerificationStatusApproved))
+
+	stored, err := repo.FindByID(ctx, driver.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.VerificationStatusPending, stored.VerificationStatus)
+	assert.False(t, stored.IsAvailable)
+	if assert.Len(t, stored.Documents, 1) {
+		assert.Equal(t, renewed.ID, stored.Documents[0].ID)
+	}
+
+	// The verification is guarded, and a lost race keeps the old document
+	again := models.NewDocument(driver.ID, models.DocumentTypeLicense, models.DocumentFile{})
+	assert.Equal(t, errors.ErrVerificationConflict, repo.SaveDocument(ctx, again, renewed.ID, driver, models.VerificationStatusApproved))
+	assert.NoError(t, repo.DeleteDocument(ctx, driver.ID, renewed.ID))
+}
+
+// BenchmarkFindAvailableNearby measures the SQL path that the in-memory
+// driver index (see geoindex.BenchmarkNearby) replaces during matching.
+// Drivers are spread over roughly 55km around Dhaka.
+func BenchmarkFindAvailableNearby(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		b.Run(fmt.Sprintf("%d drivers", n), func(b *testing.B) {
+			db := newTestDB(b)
+			repo := NewDriverRepository(db)
+			ctx := context.Background()
+
+			rng := rand.New(rand.NewSource(1))
+			users := make([]models.User, n)
+			drivers := make([]models.Driver, n)
+			for i := range drivers {
+				id := uuid.New().String()
+				users[i] = models.User{ID: id, Name: "Driver", Email: id + "@example.com", Phone: id[:20],
+					Password: "hashed", UserType: models.UserTypeDriver, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+				driver := models.NewDriver(id, "LIC-"+id[:8], models.Vehicle{Type: models.VehicleTypeCar, Model: "Test", PlateNumber: id[:8]})
+				driver.UpdateLocation(23.8103+(rng.Float64()-0.5)*0.5, 90.4125+(rng.Float64()-0.5)*0.5, time.Now())
+				driver.IsAvailable = true
+				driver.VerificationStatus = models.VerificationStatusApproved
+				drivers[i] = *driver
+			}
+			require.NoError(b, db.CreateInBatches(users, 500).Error)
+			require.NoError(b, db.Omit("ActiveVehicle").CreateInBatches(drivers, 500).Error)
+
+			query := repositories.NearbyDriverQuery{Latitude: 23.8103, Longitude: 90.4125, RadiusKm: 3, Limit: 20}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := repo.FindAvailableNearby(ctx, query); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
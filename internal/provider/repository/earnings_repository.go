@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type earningsRepository struct {
+	db *gorm.DB
+}
+
+func NewEarningsRepository(db *gorm.DB) repositories.EarningsRepository {
+	return &earningsRepository{db: db}
+}
+
+func (r *earningsRepository) Create(ctx context.Context, earning *models.DriverEarning) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "ride_id"}}, DoNothing: true}).
+		Create(earning).Error
+}
+
+func (r *earningsRepository) Sum(ctx context.Context, filter repositories.EarningsFilter) (*models.EarningsTotals, error) {
+	query := r.db.WithContext(ctx).Model(&models.DriverEarning{}).Where("driver_id = ?", filter.DriverID)
+	if filter.From != nil {
+		query = query.Where("earned_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("earned_at < ?", *filter.To)
+	}
+	if filter.Unpaid {
+		query = query.Where("payout_statement_id IS NULL")
+	}
+	return sumEarnings(query)
+}
+
+func sumEarnings(query *gorm.DB) (*models.EarningsTotals, error) {
+	var totals models.EarningsTotals
+	err := query.Select(`COUNT(*) AS ride_count,
+		COALESCE(SUM(gross_fare), 0) AS gross_fares,
+		COALESCE(SUM(commission), 0) AS commission,
+		COALESCE(SUM(earnings), 0) AS earnings`).
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	return &totals, nil
+}
+
+func (r *earningsRepository) CreateStatements(ctx context.Context, periodStart, periodEnd time.Time) ([]models.PayoutStatement, error) {
+	var driverIDs []string
+	if err := r.db.WithContext(ctx).Model(&models.DriverEarning{}).
+		Where("payout_statement_id IS NULL AND earned_at < ?", periodEnd).
+		Distinct().
+		Pluck("driver_id", &driverIDs).Error; err != nil {
+		return nil, err
+	}
+
+	statements := make([]models.PayoutStatement, 0, len(driverIDs))
+	for _, driverID := range driverIDs {
+		statement := models.NewPayoutStatement(driverID, periodStart, periodEnd)
+		created, err := r.createStatement(ctx, statement)
+		if err != nil {
+			return statements, err
+		}
+		if created {
+			statements = append(statements, *statement)
+		}
+	}
+	return statements, nil
+}
+
+// createStatement claims the driver's unpaid earnings for statement and
+// fills in its totals from exactly the claimed rows, so earnings recorded
+// meanwhile are left for the next statement
+func (r *earningsRepository) createStatement(ctx context.Context, statement *models.PayoutStatement) (bool, error) {
+	created := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The unique period index makes concurrent or repeated runs skip
+		// drivers that already have a statement
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Omit("Items").Create(statement)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := tx.Model(&models.DriverEarning{}).
+			Where("driver_id = ? AND payout_statement_id IS NULL AND earned_at < ?", statement.DriverID, statement.PeriodEnd).
+			Update("payout_statement_id", statement.ID).Error; err != nil {
+			return err
+		}
+
+		totals, err := sumEarnings(tx.Model(&models.DriverEarning{}).Where("payout_statement_id = ?", statement.ID))
+		if err != nil {
+			return err
+		}
+		statement.RideCount = totals.RideCount
+		statement.GrossFares = totals.GrossFares
+		statement.Commission = totals.Commission
+		statement.Earnings = totals.Earnings
+		if err := tx.Model(statement).Select("ride_count", "gross_fares", "commission", "earnings").Updates(statement).Error; err != nil {
+			return err
+		}
+
+		created = true
+		return nil
+	})
+	return created, err
+}
+
+func (r *earningsRepository) ListStatements(ctx context.Context, driverID string, page, limit int) ([]models.PayoutStatement, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.PayoutStatement{}).Where("driver_id = ?", driverID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var statements []models.PayoutStatement
+	if err := query.
+		Order("period_start DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&statements).Error; err != nil {
+		return nil, 0, err
+	}
+	return statements, total, nil
+}
+
+func (r *earningsRepository) FindStatement(ctx context.Context, id string) (*models.PayoutStatement, error) {
+	var statement models.PayoutStatement
+	if err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("earned_at") }).
+		First(&statement, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrPayoutStatementNotFound
+		}
+		return nil, err
+	}
+	return &statement, nil
+}
@@ -0,0 +1,68 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+)
+
+func TestEarningsCreateStatements(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewEarningsRepository(db)
+	ctx := context.Background()
+
+	monday := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	nextMonday := monday.AddDate(0, 0, 7)
+	driverID := uuid.New().String()
+
+	record := func(fare float64, earnedAt time.Time) *models.DriverEarning {
+		earning := models.NewDriverEarning(driverID, uuid.New().String(), fare, 0.2, earnedAt)
+		require.NoError(t, repo.Create(ctx, earning))
+		return earning
+	}
+	// A ride of the previous week that was never paid out is carried over
+	record(100, monday.Add(-time.Hour))
+	record(280, monday.Add(2*24*time.Hour))
+	inNextWeek := record(50, nextMonday.Add(time.Hour))
+
+	// Recording a ride twice keeps the first earning
+	duplicate := models.NewDriverEarning(driverID, inNextWeek.RideID, 999, 0.2, nextMonday)
+	require.NoError(t, repo.Create(ctx, duplicate))
+
+	statements, err := repo.CreateStatements(ctx, monday, nextMonday)
+	require.NoError(t, err)
+	if assert.Len(t, statements, 1) {
+		assert.Equal(t, 2, statements[0].RideCount)
+		assert.Equal(t, 380.0, statements[0].GrossFares)
+		assert.Equal(t, 76.0, statements[0].Commission)
+		assert.Equal(t, 304.0, statements[0].Earnings)
+	}
+
+	// A second run finds nothing left to pay for the week
+	again, err := repo.CreateStatements(ctx, monday, nextMonday)
+	require.NoError(t, err)
+	assert.Empty(t, again)
+
+	unpaid, err := repo.Sum(ctx, repositories.EarningsFilter{DriverID: driverID, Unpaid: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, unpaid.RideCount)
+	assert.Equal(t, 40.0, unpaid.Earnings)
+
+	found, err := repo.FindStatement(ctx, statements[0].ID)
+	require.NoError(t, err)
+	assert.Len(t, found.Items, 2)
+
+	list, total, err := repo.ListStatements(ctx, driverID, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, list, 1)
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ledgerRepository struct {
+	db *gorm.DB
+}
+
+func NewLedgerRepository(db *gorm.DB) repositories.LedgerRepository {
+	return &ledgerRepository{db: db}
+}
+
+func (r *ledgerRepository) FindOrCreateAccount(ctx context.Context, account *models.LedgerAccount) (*models.LedgerAccount, error) {
+	// Concurrent first uses of an account race on the insert; the loser
+	// reads the winner's row
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "code"}}, DoNothing: true}).
+		Create(account).Error; err != nil {
+		return nil, err
+	}
+	return r.FindAccountByCode(ctx, account.Code)
+}
+
+func (r *ledgerRepository) FindAccountByCode(ctx context.Context, code string) (*models.LedgerAccount, error) {
+	var account models.LedgerAccount
+	if err := r.db.WithContext(ctx).First(&account, "code = ?", code).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrLedgerAccountNotFound
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *ledgerRepository) Post(ctx context.Context, entry *models.JournalEntry) error {
+	if !entry.IsBalanced() {
+		return errors.ErrUnbalancedEntry
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := createEntry(tx, entry); err != nil {
+			return err
+		}
+		return tx.Create(&entry.Postings).Error
+	})
+}
+
+func (r *ledgerRepository) PostWithinBalance(ctx context.Context, account *models.LedgerAccount, entries ...*models.JournalEntry) error {
+	var postings []models.Posting
+	for _, entry := range entries {
+		if !entry.IsBalanced() {
+			return errors.ErrUnbalancedEntry
+		}
+		postings = append(postings, entry.Postings...)
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&models.LedgerAccount{}, "id = ?", account.ID).Error; err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := createEntry(tx, entry); err != nil {
+				return err
+			}
+		}
+
+		var sum float64
+		if err := tx.Model(&models.Posting{}).
+			Where("account_id = ?", account.ID).
+			Select("COALESCE(SUM(amount), 0)").
+			Scan(&sum).Error; err != nil {
+			return err
+		}
+		for _, posting := range postings {
+			if posting.AccountID == account.ID {
+				sum += posting.Amount
+			}
+		}
+		if account.BalanceOf(sum) < 0 {
+			return errors.ErrInsufficientBalance
+		}
+
+		return tx.Create(&postings).Error
+	})
+}
+
+// createEntry inserts entry without its postings. The unique idempotency
+// key settles concurrent attempts to record the same event.
+func createEntry(tx *gorm.DB, entry *models.JournalEntry) error {
+	result := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "idempotency_key"}}, DoNothing: true}).
+		Omit("Postings").
+		Create(entry)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrJournalEntryExists
+	}
+	return nil
+}
+
+func (r *ledgerRepository) FindEntryByKey(ctx context.Context, idempotencyKey string) (*models.JournalEntry, error) {
+	var entry models.JournalEntry
+	if err := r.db.WithContext(ctx).
+		Preload("Postings").
+		First(&entry, "idempotency_key = ?", idempotencyKey).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrJournalEntryNotFound
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *ledgerRepository) SumPostings(ctx context.Context, accountID string) (float64, error) {
+	var sum float64
+	err := r.db.WithContext(ctx).Model(&models.Posting{}).
+		Where("account_id = ?", accountID).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&sum).Error
+	return sum, err
+}
+
+func (r *ledgerRepository) ListPostings(ctx context.Context, accountID string, page, limit int) ([]models.Posting, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Posting{}).Where("account_id = ?", accountID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var postings []models.Posting
+	if err := query.
+		Preload("Entry").
+		Order("created_at DESC, id").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&postings).Error; err != nil {
+		return nil, 0, err
+	}
+	return postings, total, nil
+}
@@ -0,0 +1,71 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+)
+
+func TestLedgerPostAndBalance(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLedgerRepository(db)
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	cash, err := repo.FindOrCreateAccount(ctx, models.NewLedgerAccount(models.LedgerAccountCash, models.LedgerAccountTypeAsset, nil, "BDT"))
+	require.NoError(t, err)
+	wallet, err := repo.FindOrCreateAccount(ctx, models.NewLedgerAccount(models.WalletAccountCode(userID), models.LedgerAccountTypeLiability, &userID, "BDT"))
+	require.NoError(t, err)
+
+	// A second creation of the same account returns the first one
+	again, err := repo.FindOrCreateAccount(ctx, models.NewLedgerAccount(models.WalletAccountCode(userID), models.LedgerAccountTypeLiability, &userID, "BDT"))
+	require.NoError(t, err)
+	assert.Equal(t, wallet.ID, again.ID)
+
+	for i, amount := range []float64{500, 250.5} {
+		entry := models.NewJournalEntry(uuid.New().String(), models.JournalEntryKindTopUp, "Wallet top-up")
+		entry.CreatedAt = time.Now().Add(time.Duration(i) * time.Second)
+		entry.AddTransfer(cash, wallet, amount)
+		require.NoError(t, repo.Post(ctx, entry))
+
+		// Posting the same event again is refused
+		duplicate := models.NewJournalEntry(entry.IdempotencyKey, models.JournalEntryKindTopUp, "Wallet top-up")
+		duplicate.AddTransfer(cash, wallet, amount)
+		assert.Equal(t, errors.ErrJournalEntryExists, repo.Post(ctx, duplicate))
+	}
+
+	unbalanced := models.NewJournalEntry(uuid.New().String(), models.JournalEntryKindTopUp, "Wallet top-up")
+	unbalanced.AddTransfer(cash, wallet, 10)
+	unbalanced.Postings = unbalanced.Postings[:1]
+	assert.Equal(t, errors.ErrUnbalancedEntry, repo.Post(ctx, unbalanced))
+
+	// Charges are refused once they would overdraw the wallet
+	revenue, err := repo.FindOrCreateAccount(ctx, models.NewLedgerAccount(models.LedgerAccountRideRevenue, models.LedgerAccountTypeRevenue, nil, "BDT"))
+	require.NoError(t, err)
+	charge := models.NewJournalEntry(uuid.New().String(), models.JournalEntryKindRideCharge, "Ride fare")
+	charge.AddTransfer(wallet, revenue, 800)
+	assert.Equal(t, errors.ErrInsufficientBalance, repo.PostWithinBalance(ctx, wallet, charge))
+	_, err = repo.FindEntryByKey(ctx, charge.IdempotencyKey)
+	assert.Equal(t, errors.ErrJournalEntryNotFound, err)
+
+	sum, err := repo.SumPostings(ctx, wallet.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 750.5, wallet.BalanceOf(sum))
+
+	postings, total, err := repo.ListPostings(ctx, wallet.ID, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	if assert.Len(t, postings, 1) {
+		assert.Equal(t, -250.5, postings[0].Amount)
+		assert.Equal(t, models.JournalEntryKindTopUp, postings[0].Entry.Kind)
+	}
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+type locationHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewLocationHistoryRepository(db *gorm.DB) repositories.LocationHistoryRepository {
+	return &locationHistoryRepository{db: db}
+}
+
+func (r *locationHistoryRepository) Create(ctx context.Context, ping *models.LocationPing) error {
+	return r.db.WithContext(ctx).Create(ping).Error
+}
+
+func (r *locationHistoryRepository) ListByRide(ctx context.Context, rideID string) ([]models.LocationPing, error) {
+	var pings []models.LocationPing
+	if err := r.db.WithContext(ctx).
+		Where("ride_id = ?", rideID).
+		Order("recorded_at ASC").
+		Find(&pings).Error; err != nil {
+		return nil, err
+	}
+	return pings, nil
+}
+
+func (r *locationHistoryRepository) ListByDriver(ctx context.Context, driverID string, from, to time.Time) ([]models.LocationPing, error) {
+	var pings []models.LocationPing
+	if err := r.db.WithContext(ctx).
+		Where("driver_id = ? AND recorded_at BETWEEN ? AND ?", driverID, from, to).
+		Order("recorded_at ASC").
+		Find(&pings).Error; err != nil {
+		return nil, err
+	}
+	return pings, nil
+}
+
+func (r *locationHistoryRepository) DeleteRecordedBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	// Delete in bounded batches so pruning never holds long locks
+	batch := r.db.Model(&models.LocationPing{}).
+		Select("id").
+		Where("recorded_at < ?", before).
+		Limit(limit)
+
+	result := r.db.WithContext(ctx).
+		Where("id IN (?)", batch).
+		Delete(&models.LocationPing{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,50 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+)
+
+func TestLocationHistory(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLocationHistoryRepository(db)
+	ctx := context.Background()
+
+	driver := createTestDriver(t, db, models.VehicleTypeCar, 23.8103, 90.4125, true, true)
+	rideID := "6f1c2a3e-0000-4000-8000-000000000001"
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	old := models.NewLocationPing(driver.ID, nil, models.Location{Latitude: 23.80, Longitude: 90.41}, start.Add(-40*24*time.Hour))
+	first := models.NewLocationPing(driver.ID, &rideID, models.Location{Latitude: 23.81, Longitude: 90.41}, start)
+	second := models.NewLocationPing(driver.ID, &rideID, models.Location{Latitude: 23.82, Longitude: 90.41}, start.Add(time.Minute))
+	for _, ping := range []*models.LocationPing{second, old, first} {
+		require.NoError(t, repo.Create(ctx, ping))
+	}
+
+	path, err := repo.ListByRide(ctx, rideID)
+	assert.NoError(t, err)
+	if assert.Len(t, path, 2) {
+		assert.Equal(t, first.ID, path[0].ID)
+		assert.Equal(t, second.ID, path[1].ID)
+	}
+
+	trail, err := repo.ListByDriver(ctx, driver.ID, start.Add(-50*24*time.Hour), start.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, trail, 3)
+
+	deleted, err := repo.DeleteRecordedBefore(ctx, start.Add(-30*24*time.Hour), 100)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	trail, err = repo.ListByDriver(ctx, driver.ID, start.Add(-50*24*time.Hour), start.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, trail, 2)
+}
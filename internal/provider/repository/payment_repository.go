@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+type paymentRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepository(db *gorm.DB) repositories.PaymentRepository {
+	return &paymentRepository{db: db}
+}
+
+func (r *paymentRepository) Create(ctx context.Context, payment *models.Payment) error {
+	return r.db.WithContext(ctx).Create(payment).Error
+}
+
+func (r *paymentRepository) FindByRideID(ctx context.Context, rideID string) (*models.Payment, error) {
+	var payment models.Payment
+	if err := r.db.WithContext(ctx).First(&payment, "ride_id = ?", rideID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrPaymentNotFound
+		}
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *paymentRepository) Update(ctx context.Context, payment *models.Payment) error {
+	return r.db.WithContext(ctx).Save(payment).Error
+}
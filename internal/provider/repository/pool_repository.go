@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type poolRepository struct {
+	db *gorm.DB
+}
+
+func NewPoolRepository(db *gorm.DB) repositories.PoolRepository {
+	return &poolRepository{db: db}
+}
+
+func (r *poolRepository) Create(ctx context.Context, pool *models.Pool) error {
+	// The partial unique index allows one open pool per driver
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(pool)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrPoolConflict
+	}
+	return nil
+}
+
+func (r *poolRepository) FindByID(ctx context.Context, id string) (*models.Pool, error) {
+	return r.find(ctx, "id = ?", id)
+}
+
+func (r *poolRepository) FindOpenByDriverID(ctx context.Context, driverID string) (*models.Pool, error) {
+	return r.find(ctx, "driver_id = ? AND status = ?", driverID, models.PoolStatusOpen)
+}
+
+func (r *poolRepository) find(ctx context.Context, query string, args ...interface{}) (*models.Pool, error) {
+	var pool models.Pool
+	if err := r.db.WithContext(ctx).Where(query, args...).First(&pool).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrPoolNotFound
+		}
+		return nil, err
+	}
+	return &pool, nil
+}
+
+func (r *poolRepository) Update(ctx context.Context, pool *models.Pool) error {
+	expected := pool.Version
+	pool.Version++
+	result := r.db.WithContext(ctx).Model(&models.Pool{}).
+		Where("id = ? AND version = ?", pool.ID, expected).
+		Select("*").
+		Omit("id", "created_at").
+		Updates(pool)
+	if result.Error != nil {
+		pool.Version = expected
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		pool.Version = expected
+		return errors.ErrPoolConflict
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type promoCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewPromoCodeRepository(db *gorm.DB) repositories.PromoCodeRepository {
+	return &promoCodeRepository{db: db}
+}
+
+func (r *promoCodeRepository) Create(ctx context.Context, promo *models.PromoCode) error {
+	return r.db.WithContext(ctx).Create(promo).Error
+}
+
+func (r *promoCodeRepository) FindByID(ctx context.Context, id string) (*models.PromoCode, error) {
+	return r.findOne(ctx, "id = ?", id)
+}
+
+func (r *promoCodeRepository) FindByCode(ctx context.Context, code string) (*models.PromoCode, error) {
+	return r.findOne(ctx, "code = ?", models.NormalizePromoCode(code))
+}
+
+func (r *promoCodeRepository) findOne(ctx context.Context, query string, arg string) (*models.PromoCode, error) {
+	var promo models.PromoCode
+	if err := r.db.WithContext(ctx).First(&promo, query, arg).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrPromoCodeNotFound
+		}
+		return nil, err
+	}
+	return &promo, nil
+}
+
+func (r *promoCodeRepository) List(ctx context.Context) ([]models.PromoCode, error) {
+	var promos []models.PromoCode
+	if err := r.db.WithContext(ctx).Order("created_at DESC").Find(&promos).Error; err != nil {
+		return nil, err
+	}
+	return promos, nil
+}
+
+func (r *promoCodeRepository) CountRedemptions(ctx context.Context, promoCodeID string, userID string) (int64, error) {
+	return countRedemptions(r.db.WithContext(ctx), promoCodeID, userID)
+}
+
+func countRedemptions(db *gorm.DB, promoCodeID string, userID string) (int64, error) {
+	var count int64
+	err := db.Model(&models.PromoRedemption{}).
+		Where("promo_code_id = ? AND user_id = ?", promoCodeID, userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *promoCodeRepository) Redeem(ctx context.Context, redemption *models.PromoRedemption) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The guarded increment both enforces max_uses and row-locks the code
+		// until commit, so concurrent redemptions of one code run one at a
+		// time and the per-user count below cannot go stale
+		result := tx.Model(&models.PromoCode{}).
+			Where("id = ? AND (max_uses = 0 OR used_count < max_uses)", redemption.PromoCodeID).
+			Updates(map[string]interface{}{
+				"used_count": gorm.Expr("used_count + 1"),
+				"updated_at": redemption.CreatedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.ErrPromoCodeExhausted
+		}
+
+		var promo models.PromoCode
+		if err := tx.First(&promo, "id = ?", redemption.PromoCodeID).Error; err != nil {
+			return err
+		}
+		if promo.PerUserLimit > 0 {
+			count, err := countRedemptions(tx, promo.ID, redemption.UserID)
+			if err != nil {
+				return err
+			}
+			if count >= int64(promo.PerUserLimit) {
+				return errors.ErrPromoCodeUserLimit
+			}
+		}
+
+		return tx.Create(redemption).Error
+	})
+}
+
+func (r *promoCodeRepository) ReleaseRedemption(ctx context.Context, rideID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var released []models.PromoRedemption
+		if err := tx.Clauses(clause.Returning{}).
+			Where("ride_id = ?", rideID).
+			Delete(&released).Error; err != nil {
+			return err
+		}
+
+		for _, redemption := range released {
+			if err := tx.Model(&models.PromoCode{}).
+				Where("id = ?", redemption.PromoCodeID).
+				Update("used_count", gorm.Expr("used_count - 1")).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,91 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+)
+
+func TestPromoCodeConcurrentRedemption(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewPromoCodeRepository(db)
+	ctx := context.Background()
+
+	promo := models.NewPromoCode("launch50", models.DiscountTypeFlat, 50)
+	promo.MaxUses = 3
+	require.NoError(t, repo.Create(ctx, promo))
+
+	found, err := repo.FindByCode(ctx, "Launch50")
+	require.NoError(t, err)
+	assert.Equal(t, promo.ID, found.ID)
+
+	// Ten riders race for three uses
+	var wg sync.WaitGroup
+	results := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			redemption := models.NewPromoRedemption(promo.ID, uuid.New().String(), uuid.New().String(), 50, time.Now())
+			results <- repo.Redeem(ctx, redemption)
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	var redeemed, exhausted int
+	for err := range results {
+		switch err {
+		case nil:
+			redeemed++
+		case errors.ErrPromoCodeExhausted:
+			exhausted++
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	assert.Equal(t, 3, redeemed)
+	assert.Equal(t, 7, exhausted)
+
+	found, err = repo.FindByID(ctx, promo.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, found.UsedCount)
+}
+
+func TestPromoCodePerUserLimit(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewPromoCodeRepository(db)
+	ctx := context.Background()
+
+	promo := models.NewPromoCode("WELCOME", models.DiscountTypePercentage, 20)
+	promo.PerUserLimit = 1
+	require.NoError(t, repo.Create(ctx, promo))
+
+	userID := uuid.New().String()
+	first := models.NewPromoRedemption(promo.ID, userID, uuid.New().String(), 20, time.Now())
+	require.NoError(t, repo.Redeem(ctx, first))
+
+	second := models.NewPromoRedemption(promo.ID, userID, uuid.New().String(), 20, time.Now())
+	assert.Equal(t, errors.ErrPromoCodeUserLimit, repo.Redeem(ctx, second))
+
+	// Releasing the first use frees it up again
+	require.NoError(t, repo.ReleaseRedemption(ctx, first.RideID))
+	count, err := repo.CountRedemptions(ctx, promo.ID, userID)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	assert.NoError(t, repo.Redeem(ctx, second))
+
+	found, err := repo.FindByID(ctx, promo.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, found.UsedCount)
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"math"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ratingRepository struct {
+	db *gorm.DB
+}
+
+func NewRatingRepository(db *gorm.DB) repositories.RatingRepository {
+	return &ratingRepository{db: db}
+}
+
+func (r *ratingRepository) Create(ctx context.Context, rating *models.Rating, window int) error {
+	// Drivers keep their rating on the driver profile, riders on the user
+	var profile interface{} = &models.User{}
+	key, notFound := "id", errors.ErrUserNotFound
+	if rating.RateeRole == models.RatingRoleDriver {
+		profile = &models.Driver{}
+		key, notFound = "user_id", errors.ErrDriverNotFound
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the profile so concurrent ratings of one user refresh the
+		// average one at a time
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where(key+" = ?", rating.RateeID).
+			First(profile).Error
+		if err == gorm.ErrRecordNotFound {
+			return notFound
+		}
+		if err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(rating)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.ErrRatingExists
+		}
+
+		var stats struct {
+			Average float64
+			Count   int
+		}
+		err = tx.Raw(`SELECT
+				(SELECT COALESCE(AVG(stars), 0) FROM (
+					SELECT stars FROM ratings WHERE ratee_id = @ratee AND ratee_role = @role
+					ORDER BY created_at DESC LIMIT @window
+				) AS recent) AS average,
+				(SELECT COUNT(*) FROM ratings WHERE ratee_id = @ratee AND ratee_role = @role) AS count`,
+			map[string]interface{}{"ratee": rating.RateeID, "role": rating.RateeRole, "window": window}).
+			Scan(&stats).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(profile).Updates(map[string]interface{}{
+			"rating_average": math.Round(stats.Average*100) / 100,
+			"rating_count":   stats.Count,
+		}).Error
+	})
+}
+
+func (r *ratingRepository) FindLowRatedPartners(ctx context.Context, userID string, stars int) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT ratee_id FROM ratings WHERE rater_id = @user AND stars < @stars
+		UNION
+		SELECT rater_id FROM ratings WHERE ratee_id = @user AND stars < @stars`,
+		map[string]interface{}{"user": userID, "stars": stars}).
+		Scan(&ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,53 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+)
+
+func TestRatingCreateUpdatesAverage(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewRatingRepository(db)
+	ctx := context.Background()
+
+	driver := createTestDriver(t, db, models.VehicleTypeCar, 23.8103, 90.4125, true, true)
+	riderID := uuid.New().String()
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+
+	rate := func(stars int, at time.Time) *models.Rating {
+		rating := models.NewRating(uuid.New().String(), riderID, driver.UserID, models.RatingRoleDriver, stars, "", nil)
+		rating.CreatedAt = at
+		require.NoError(t, repo.Create(ctx, rating, 2))
+		return rating
+	}
+	rate(1, start)
+	rate(4, start.Add(time.Hour))
+	last := rate(5, start.Add(2*time.Hour))
+
+	// Only the two latest ratings count towards the average
+	var found models.Driver
+	require.NoError(t, db.First(&found, "id = ?", driver.ID).Error)
+	assert.Equal(t, 4.5, found.RatingAverage)
+	assert.Equal(t, 3, found.RatingCount)
+
+	again := models.NewRating(last.RideID, riderID, driver.UserID, models.RatingRoleDriver, 1, "", nil)
+	assert.Equal(t, errors.ErrRatingExists, repo.Create(ctx, again, 2))
+
+	partners, err := repo.FindLowRatedPartners(ctx, driver.UserID, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{riderID}, partners)
+
+	partners, err = repo.FindLowRatedPartners(ctx, riderID, 1)
+	require.NoError(t, err)
+	assert.Empty(t, partners)
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+type rideOfferRepository struct {
+	db *gorm.DB
+}
+
+func NewRideOfferRepository(db *gorm.DB) repositories.RideOfferRepository {
+	return &rideOfferRepository{db: db}
+}
+
+func (r *rideOfferRepository) Create(ctx context.Context, offer *models.RideOffer) error {
+	return r.db.WithContext(ctx).Create(offer).Error
+}
+
+func (r *rideOfferRepository) FindByID(ctx context.Context, id string) (*models.RideOffer, error) {
+	var offer models.RideOffer
+	if err := r.db.WithContext(ctx).First(&offer, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrOfferNotFound
+		}
+		return nil, err
+	}
+	return &offer, nil
+}
+
+func (r *rideOfferRepository) FindPendingByDriverID(ctx context.Context, driverID string, now time.Time) (*models.RideOffer, error) {
+	var offer models.RideOffer
+	err := r.db.WithContext(ctx).
+		Preload("Ride").
+		Preload("Vehicle").
+		Where("driver_id = ? AND status = ? AND expires_at > ?", driverID, models.RideOfferStatusPending, now).
+		Order("created_at DESC").
+		First(&offer).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrOfferNotFound
+		}
+		return nil, err
+	}
+	return &offer, nil
+}
+
+func (r *rideOfferRepository) Resolve(ctx context.Context, offerID string, status models.RideOfferStatus, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&models.RideOffer{}).
+		Where("id = ? AND status = ?", offerID, models.RideOfferStatusPending).
+		Updates(map[string]interface{}{
+			"status":       status,
+			"responded_at": at,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrOfferNotPending
+	}
+	return nil
+}
+
+func (r *rideOfferRepository) AcceptanceRates(ctx context.Context, driverIDs []string, since time.Time) (map[string]float64, error) {
+	var rows []struct {
+		DriverID string
+		Rate     float64
+	}
+
+	if len(driverIDs) > 0 {
+		err := r.db.WithContext(ctx).Model(&models.RideOffer{}).
+			Select("driver_id, AVG(CASE WHEN status = ? THEN 1.0 ELSE 0.0 END) AS rate", models.RideOfferStatusAccepted).
+			Where("driver_id IN ? AND status <> ? AND created_at >= ?", driverIDs, models.RideOfferStatusPending, since).
+			Group("driver_id").
+			Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rates := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		rates[row.DriverID] = row.Rate
+	}
+	return rates, nil
+}
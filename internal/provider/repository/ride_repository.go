@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/sayeed1999/share-a-ride/internal/domain/errors"
+	"github.com/sayeed1999/share-a-ride/internal/domain/models"
+	"github.com/sayeed1999/share-a-ride/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+type rideRepository struct {
+	db *gorm.DB
+}
+
+func NewRideRepository(db *gorm.DB) repositories.RideRepository {
+	return &rideRepository{db: db}
+}
+
+func (r *rideRepository) Create(ctx context.Context, ride *models.Ride) error {
+	return r.db.WithContext(ctx).Create(ride).Error
+}
+
+func (r *rideRepository) FindByID(ctx context.Context, id string) (*models.Ride, error) {
+	var ride models.Ride
+	if err := r.db.WithContext(ctx).First(&ride, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrRideNotFound
+		}
+		return nil, err
+	}
+	return &ride, nil
+}
+
+func (r *rideRepository) FindActiveByRiderID(ctx context.Context, riderID string) (*models.Ride, error) {
+	return r.findActive(ctx, "rider_id = ?", riderID)
+}
+
+func (r *rideRepository) FindActiveByDriverID(ctx context.Context, driverID string) (*models.Ride, error) {
+	return r.findActive(ctx, "driver_id = ?", driverID)
+}
+
+func (r *rideRepository) ListActiveByDriverID(ctx context.Context, driverID string) ([]models.Ride, error) {
+	var rides []models.Ride
+	if err := r.db.WithContext(ctx).
+		Where("driver_id = ? AND status IN ?", driverID, models.ActiveRideStatuses).
+		Order("created_at ASC").
+		Find(&rides).Error; err != nil {
+		return nil, err
+	}
+	return rides, nil
+}
+
+func (r *rideRepository) findActive(ctx context.Context, query string, arg string) (*models.Ride, error) {
+	var ride models.Ride
+	err := r.db.WithContext(ctx).
+		Where(query, arg).
+		Where("status IN ?", models.ActiveRideStatuses).
+		Order("created_at DESC").
+		First(&ride).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrRideNotFound
+		}
+		return nil, err
+	}
+	return &ride, nil
+}
+
+func (r *rideRepository) FindOpenRequests(ctx context.Context) ([]models.Ride, error) {
+	var rides []models.Ride
+	if err := r.db.WithContext(ctx).
+		Where("status = ?", models.RideStatusRequested).
+		Find(&rides).Error; err != nil {
+		return nil, err
+	}
+	return rides, nil
+}
+
+func (r *rideRepository) FindUncharged(ctx context.Context, before time.Time) ([]models.Ride, error) {
+	const hasEntry = "EXISTS (SELECT 1 FROM journal_entries WHERE journal_entries.ride_id = rides.id AND journal_entries.kind = ?)"
+	db := r.db.WithContext(ctx)
+	card := db.Where("payment_method = ? AND fare > 0 AND EXISTS (SELECT 1 FROM payments WHERE payments.ride_id = rides.id AND payments.status IN ?)",
+		models.PaymentMethodCard, []models.PaymentStatus{models.PaymentStatusAuthorized, models.PaymentStatusFailed})
+	wallet := db.Where("payment_method = ?", models.PaymentMethodWallet).
+		Where(db.Where("fare > 0 AND NOT "+hasEntry, models.JournalEntryKindRideCharge).
+			Or(hasEntry+" AND NOT "+hasEntry, models.JournalEntryKindRideHold, models.JournalEntryKindHoldRelease))
+
+	var rides []models.Ride
+	if err := db.
+		Where("status IN ? AND updated_at < ?",
+			[]models.RideStatus{models.RideStatusCompleted, models.RideStatusCancelled}, before).
+		Where(card.Or(wallet)).
+		Order("updated_at ASC").
+		Find(&rides).Error; err != nil {
+		return nil, err
+	}
+	return rides, nil
+}
+
+func (r *rideRepository) FindScheduledBefore(ctx context.Context, before time.Time) ([]models.Ride, error) {
+	var rides []models.Ride
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND scheduled_at <= ?", models.RideStatusScheduled, before).
+		Order("scheduled_at ASC").
+		Find(&rides).Error; err != nil {
+		return nil, err
+	}
+	return rides, nil
+}
+
+func (r *rideRepository) List(ctx context.Context, filter repositories.RideFilter) ([]models.Ride, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Ride{})
+	if filter.RiderID != "" {
+		query = query.Where("rider_id = ?", filter.RiderID)
+	}
+	if filter.DriverID != "" {
+		query = query.Where("driver_id = ?", filter.DriverID)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at <= ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rides []models.Ride
+	if err := query.
+		Preload("Rider").
+		Preload("Driver").
+		Order("created_at DESC").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&rides).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return rides, total, nil
+}
+
+func (r *rideRepository) UpdateStatus(ctx context.Context, ride *models.Ride, expected models.RideStatus) error {
+	result := r.db.WithContext(ctx).Model(&models.Ride{}).
+		Where("id = ? AND status = ?", ride.ID, expected).
+		Select("*").
+		Omit("id", "created_at", "waypoints", "Rider", "Driver").
+		Updates(ride)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrRideStatusConflict
+	}
+	return nil
+}
+
+func (r *rideRepository) UpdateWaypoints(ctx context.Context, ride *models.Ride, expected models.RideStatus) error {
+	result := r.db.WithContext(ctx).Model(&models.Ride{}).
+		Where("id = ? AND status = ?", ride.ID, expected).
+		Select("waypoints", "updated_at").
+		Updates(ride)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrRideStatusConflict
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sayeed1999/share-a-ride/internal/pkg/clock"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC))
+	store, err := NewLocal(t.TempDir(), "http://localhost:8080/files", []byte("secret"), clk)
+	require.NoError(t, err)
+
+	key := "documents/user-1/abc.pdf"
+	require.NoError(t, store.Put(ctx, key, strings.NewReader("%PDF-1.4"), 8, "application/pdf"))
+
+	info, err := store.Stat(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), info.Size)
+	assert.Equal(t, "application/pdf", info.ContentType)
+
+	_, err = store.Stat(ctx, "documents/user-1/missing.pdf")
+	assert.Equal(t, ErrNotFound, err)
+
+	assert.Error(t, store.Put(ctx, "../escape.pdf", strings.NewReader("x"), 1, "application/pdf"))
+
+	t.Run("signed urls download until they expire", func(t *testing.T) {
+		signed, err := store.SignedURL(ctx, key, time.Minute)
+		require.NoError(t, err)
+		u, err := url.Parse(signed)
+		require.NoError(t, err)
+		assert.Equal(t, "/files/"+key, u.Path)
+
+		download := func(rawQuery string) *httptest.ResponseRecorder {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/"+key+"?"+rawQuery, nil)
+			store.Handler().ServeHTTP(rec, req)
+			return rec
+		}
+
+		rec := download(u.RawQuery)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "%PDF-1.4", rec.Body.String())
+
+		query := u.Query()
+		query.Set("expires", "9999999999")
+		assert.Equal(t, http.StatusForbidden, download(query.Encode()).Code)
+
+		clk.Advance(time.Minute)
+		assert.Equal(t, http.StatusForbidden, download(u.RawQuery).Code)
+	})
+
+	t.Run("lists objects under a prefix", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "documents/user-2/def.pdf", strings.NewReader("%PDF-1.4"), 8, "application/pdf"))
+
+		objects, err := store.List(ctx, "documents/user-1/")
+
+		require.NoError(t, err)
+		if assert.Len(t, objects, 1) {
+			assert.Equal(t, key, objects[0].Key)
+			assert.Equal(t, int64(8), objects[0].Size)
+			assert.True(t, objects[0].ModifiedAt.Equal(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)))
+		}
+	})
+
+	t.Run("delete removes the object", func(t *testing.T) {
+		require.NoError(t, store.Delete(ctx, key))
+		require.NoError(t, store.Delete(ctx, key))
+
+		_, _, err := store.Get(ctx, key)
+		assert.Equal(t, ErrNotFound, err)
+	})
+}
+
+func TestLocalPutChecksSize(t *testing.T) {
+	store, err := NewLocal(t.TempDir(), "http://localhost:8080/files", []byte("secret"), clock.New())
+	require.NoError(t, err)
+
+	err = store.Put(context.Background(), "a.png", io.LimitReader(strings.NewReader("short"), 5), 10, "image/png")
+
+	assert.Error(t, err)
+	_, err = store.Stat(context.Background(), "a.png")
+	assert.Equal(t, ErrNotFound, err)
+}
@@ -0,0 +1,50 @@
+// Package storage keeps uploaded files in an object store and hands out
+// signed, expiring URLs to download them, so that files are never served
+// to anyone who was not given a link.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	// ErrNotFound means no object is stored under the key
+	ErrNotFound = errors.New("object not found")
+	// ErrInvalidSignature means a signed URL was altered or not issued by
+	// the store
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrURLExpired means a signed URL is past its expiry
+	ErrURLExpired = errors.New("signed url expired")
+)
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModifiedAt  time.Time
+}
+
+// ObjectStore keeps objects under slash separated keys such as
+// "documents/<user>/<file>".
+type ObjectStore interface {
+	// Put stores size bytes read from body under key, replacing any object
+	// already there
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Stat returns the object under key without reading it
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// Get opens the object under key; the caller closes it
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	// Delete removes the object under key; deleting a missing object is
+	// not an error
+	Delete(ctx context.Context, key string) error
+	// List returns the objects whose keys start with prefix. Content types
+	// are not listed by every store and may be empty.
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	// SignedURL returns a URL that downloads the object under key until
+	// expiry has passed
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}